@@ -20,26 +20,33 @@
 package api
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"github.com/nuts-foundation/nuts-registry/logging"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	core "github.com/nuts-foundation/nuts-go-core"
 
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
 
 	"io/ioutil"
 
 	"github.com/labstack/echo/v4"
 	"github.com/nuts-foundation/nuts-registry/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
 )
 
 // String converts an identifier to string
@@ -47,6 +54,9 @@ func (i Identifier) String() string {
 	return string(i)
 }
 
+// totalCountHeader is the response header containing the total number of results of a paginated query.
+const totalCountHeader = "X-Total-Count"
+
 // ApiWrapper is needed to connect the implementation to the echo ServiceWrapper
 type ApiWrapper struct {
 	R pkg.RegistryClient
@@ -86,6 +96,70 @@ func (apiResource ApiWrapper) Verify(ctx echo.Context, params VerifyParams) erro
 	return ctx.JSON(http.StatusOK, altVerifyResponse{Events: resultingEvents, Fix: needsFixing})
 }
 
+// StreamEvents is the Api implementation for streaming the applied events as Server-Sent Events.
+func (apiResource ApiWrapper) StreamEvents(ctx echo.Context, params StreamEventsParams) error {
+	var from events.Ref
+	fromParam := params.From
+	if fromParam == nil {
+		fromParam = params.LastEventID
+	}
+	if fromParam != nil && *fromParam != "" {
+		ref, err := events.ParseRef(*fromParam)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, fmt.Sprintf("invalid ref: %s", *fromParam))
+		}
+		from = ref
+	}
+	stream, err := apiResource.R.Subscribe(ctx.Request().Context(), from)
+	if errors.Is(err, events.ErrUnknownEvent) {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+	for event := range stream {
+		if err := writeServerSentEvent(response, event); err != nil {
+			return err
+		}
+		response.Flush()
+	}
+	return nil
+}
+
+// writeServerSentEvent writes the event in SSE format: its ref as ID, its type as event name and its JSON as data.
+func writeServerSentEvent(writer io.Writer, event events.Event) error {
+	data := bytes.Buffer{}
+	if err := json.Compact(&data, event.Marshal()); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.Ref(), event.Type(), data.String())
+	return err
+}
+
+// ListEventSchemas is the Api implementation for listing the JSON schemas of the event payloads.
+func (apiResource ApiWrapper) ListEventSchemas(ctx echo.Context) error {
+	result := make([]EventSchema, 0)
+	for _, schema := range domain.Schemas() {
+		result = append(result, EventSchema{Type: string(schema.EventType), Version: int(schema.Version)})
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// GetEventSchema is the Api implementation for getting the JSON schema which applies to events of the given type and version.
+func (apiResource ApiWrapper) GetEventSchema(ctx echo.Context, eventType string, version int) error {
+	schema := domain.GetSchema(events.EventType(eventType), events.Version(version))
+	if schema == nil {
+		return ctx.String(http.StatusNotFound, "unknown schema")
+	}
+	return ctx.Blob(http.StatusOK, "application/schema+json", schema.Data)
+}
+
 // DeprecatedVendorClaim is deprecated, use VendorClaim.
 func (apiResource ApiWrapper) DeprecatedVendorClaim(ctx echo.Context, _ string) error {
 	return apiResource.VendorClaim(ctx)
@@ -124,11 +198,8 @@ func (apiResource ApiWrapper) RegisterEndpoint(ctx echo.Context, id string) erro
 	if err = ep.validate(); err != nil {
 		return ctx.String(http.StatusBadRequest, err.Error())
 	}
-	event, err := apiResource.R.RegisterEndpoint(organizationID, ep.Identifier.String(), ep.URL, ep.EndpointType, ep.Status, fromEndpointProperties(ep.Properties))
-	if err != nil {
-		return ctx.String(http.StatusInternalServerError, err.Error())
-	}
-	return ctx.JSON(http.StatusOK, event)
+	event, err := apiResource.R.RegisterEndpoint(organizationID, ep.Identifier.String(), ep.URL, ep.EndpointType, ep.Status, ep.NotBefore, ep.NotAfter, fromEndpointProperties(ep.Properties))
+	return respondWithEvent(ctx, event, err)
 }
 
 // VendorClaim is the Api implementation for registering a vendor claim.
@@ -154,10 +225,7 @@ func (apiResource ApiWrapper) VendorClaim(ctx echo.Context) error {
 		return nil
 	}
 	event, err := apiResource.R.VendorClaim(organizationID, org.Name, keys)
-	if err != nil {
-		return ctx.String(http.StatusInternalServerError, err.Error())
-	}
-	return ctx.JSON(http.StatusOK, event)
+	return respondWithEvent(ctx, event, err)
 }
 
 // RegisterVendor is the Api implementation for registering a vendor.
@@ -170,20 +238,83 @@ func (apiResource ApiWrapper) RegisterVendor(ctx echo.Context) error {
 		return ctx.String(http.StatusBadRequest, err.Error())
 	} else {
 		event, err := apiResource.R.RegisterVendor(certificate)
-		if err != nil {
-			return ctx.String(http.StatusInternalServerError, err.Error())
-		}
-		return ctx.JSON(http.StatusOK, event)
+		return respondWithEvent(ctx, event, err)
+	}
+}
+
+// DeregisterEndpoint is the Api implementation for deregistering an endpoint of an organization.
+func (apiResource ApiWrapper) DeregisterEndpoint(ctx echo.Context, id string, endpointId string) error {
+	organizationID := tryParsePartyID(id, ctx)
+	if organizationID.IsZero() {
+		return nil
+	}
+	endpointID, err := url.PathUnescape(endpointId)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	event, err := apiResource.R.DeregisterEndpoint(organizationID, types.EndpointID(endpointID))
+	return respondWithDeregistrationEvent(ctx, event, err)
+}
+
+// EndVendorClaim is the Api implementation for ending the current vendor's claim on an organization.
+func (apiResource ApiWrapper) EndVendorClaim(ctx echo.Context, id string) error {
+	organizationID := tryParsePartyID(id, ctx)
+	if organizationID.IsZero() {
+		return nil
+	}
+	event, err := apiResource.R.EndVendorClaim(organizationID)
+	return respondWithDeregistrationEvent(ctx, event, err)
+}
+
+// RetireVendor is the Api implementation for retiring a vendor.
+func (apiResource ApiWrapper) RetireVendor(ctx echo.Context, id string) error {
+	vendorID := tryParsePartyID(id, ctx)
+	if vendorID.IsZero() {
+		return nil
+	}
+	event, err := apiResource.R.RetireVendor(vendorID)
+	return respondWithDeregistrationEvent(ctx, event, err)
+}
+
+func respondWithDeregistrationEvent(ctx echo.Context, event events.Event, err error) error {
+	if errors.Is(err, pkg.ErrOrganizationNotFound) || errors.Is(err, pkg.ErrEndpointNotFound) || errors.Is(err, pkg.ErrVendorNotFound) {
+		return ctx.String(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, pkg.ErrNotManaged) {
+		return ctx.String(http.StatusBadRequest, err.Error())
 	}
+	return respondWithEvent(ctx, event, err)
+}
+
+// respondWithEvent responds with the published event or, if it couldn't be published, the error. When the payload of
+// the event is invalid, the error lists the offending fields.
+func respondWithEvent(ctx echo.Context, event events.Event, err error) error {
+	if errors.As(err, &domain.PayloadValidationError{}) {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, event)
 }
 
 // OrganizationById is the Api implementation for getting an organization based on its Id.
-func (apiResource ApiWrapper) OrganizationById(ctx echo.Context, id string) error {
+func (apiResource ApiWrapper) OrganizationById(ctx echo.Context, id string, params OrganizationByIdParams) error {
 	organizationID := tryParsePartyID(id, ctx)
 	if organizationID.IsZero() {
 		return nil
 	}
-	result, err := apiResource.R.OrganizationById(organizationID)
+	asOf, ok := tryParseAsOf(params.AsOf, ctx)
+	if !ok {
+		return nil
+	}
+	var result *db.Organization
+	var err error
+	if asOf != nil {
+		result, err = apiResource.R.OrganizationByIdAsOf(organizationID, *asOf)
+	} else {
+		result, err = apiResource.R.OrganizationById(organizationID)
+	}
 	if err != nil {
 		logging.Log().Errorf("Error getting organization %s: %v", organizationID, err)
 	}
@@ -194,12 +325,22 @@ func (apiResource ApiWrapper) OrganizationById(ctx echo.Context, id string) erro
 }
 
 // VendorById is the Api implementation for getting a vendor based on its Id.
-func (apiResource ApiWrapper) VendorById(ctx echo.Context, id string) error {
+func (apiResource ApiWrapper) VendorById(ctx echo.Context, id string, params VendorByIdParams) error {
 	vendorID := tryParsePartyID(id, ctx)
 	if vendorID.IsZero() {
 		return nil
 	}
-	result, err := apiResource.R.VendorById(vendorID)
+	asOf, ok := tryParseAsOf(params.AsOf, ctx)
+	if !ok {
+		return nil
+	}
+	var result *db.Vendor
+	var err error
+	if asOf != nil {
+		result, err = apiResource.R.VendorByIdAsOf(vendorID, *asOf)
+	} else {
+		result, err = apiResource.R.VendorById(vendorID)
+	}
 	if err != nil && !errors.Is(err, pkg.ErrVendorNotFound) {
 		logging.Log().Errorf("Error getting vendor %s: %v", vendorID, err)
 		return ctx.String(http.StatusInternalServerError, "an internal server error occurred")
@@ -210,8 +351,296 @@ func (apiResource ApiWrapper) VendorById(ctx echo.Context, id string) error {
 	return ctx.JSON(http.StatusOK, Vendor{}.fromDb(*result))
 }
 
+// altEventHistoryEntry is alternative, unmarshallable version of EventHistoryEntry in generated.go (see altVerifyResponse).
+type altEventHistoryEntry struct {
+	Event             json.RawMessage `json:"event"`
+	SignerCertificate *string         `json:"signerCertificate,omitempty"`
+}
+
+// VendorHistory is the Api implementation for getting the event history of a vendor.
+func (apiResource ApiWrapper) VendorHistory(ctx echo.Context, id string) error {
+	vendorID := tryParsePartyID(id, ctx)
+	if vendorID.IsZero() {
+		return nil
+	}
+	history, err := apiResource.R.VendorHistory(vendorID)
+	return respondWithHistory(ctx, history, err, pkg.ErrVendorNotFound)
+}
+
+// OrganizationHistory is the Api implementation for getting the event history of an organization.
+func (apiResource ApiWrapper) OrganizationHistory(ctx echo.Context, id string) error {
+	organizationID := tryParsePartyID(id, ctx)
+	if organizationID.IsZero() {
+		return nil
+	}
+	history, err := apiResource.R.OrganizationHistory(organizationID)
+	return respondWithHistory(ctx, history, err, pkg.ErrOrganizationNotFound)
+}
+
+// EndpointHistory is the Api implementation for getting the event history of an endpoint of an organization.
+func (apiResource ApiWrapper) EndpointHistory(ctx echo.Context, id string, endpointId string) error {
+	organizationID := tryParsePartyID(id, ctx)
+	if organizationID.IsZero() {
+		return nil
+	}
+	endpointID, err := url.PathUnescape(endpointId)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	history, err := apiResource.R.EndpointHistory(organizationID, types.EndpointID(endpointID))
+	return respondWithHistory(ctx, history, err, pkg.ErrEndpointNotFound)
+}
+
+func respondWithHistory(ctx echo.Context, history []events.Event, err error, notFoundErr error) error {
+	if errors.Is(err, notFoundErr) {
+		return ctx.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		logging.Log().Errorf("Error getting event history: %v", err)
+		return ctx.String(http.StatusInternalServerError, "an internal server error occurred")
+	}
+	result := make([]altEventHistoryEntry, len(history))
+	for i, event := range history {
+		result[i] = altEventHistoryEntry{Event: event.Marshal()}
+		if certificate := event.SignatureDetails().Certificate; certificate != nil {
+			pem := certificateToPEM(certificate)
+			result[i].SignerCertificate = &pem
+		}
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// altParkedEvent is alternative, unmarshallable version of ParkedEvent in generated.go (see altVerifyResponse).
+type altParkedEvent struct {
+	Event       json.RawMessage `json:"event"`
+	State       string          `json:"state"`
+	Attempts    int             `json:"attempts"`
+	LastError   *string         `json:"lastError,omitempty"`
+	ParkedAt    time.Time       `json:"parkedAt"`
+	LastAttempt *time.Time      `json:"lastAttempt,omitempty"`
+}
+
+func (p altParkedEvent) fromParkedEvent(parked events.ParkedEvent) altParkedEvent {
+	p.Event = parked.Event.Marshal()
+	p.State = string(parked.State)
+	p.Attempts = parked.Attempts
+	p.ParkedAt = parked.ParkedAt
+	if parked.LastError != "" {
+		p.LastError = &parked.LastError
+	}
+	if !parked.LastAttempt.IsZero() {
+		p.LastAttempt = &parked.LastAttempt
+	}
+	return p
+}
+
+func (p altParkedEvent) toParkedEvent() (*events.ParkedEvent, error) {
+	event, err := events.EventFromJSON(p.Event)
+	if err != nil {
+		return nil, err
+	}
+	result := events.ParkedEvent{
+		Event:    event,
+		State:    events.ParkedEventState(p.State),
+		Attempts: p.Attempts,
+		ParkedAt: p.ParkedAt,
+	}
+	if p.LastError != nil {
+		result.LastError = *p.LastError
+	}
+	if p.LastAttempt != nil {
+		result.LastAttempt = *p.LastAttempt
+	}
+	return &result, nil
+}
+
+// ListParkedEvents is the Api implementation for listing the events in the retry queue.
+func (apiResource ApiWrapper) ListParkedEvents(ctx echo.Context) error {
+	parkedEvents, err := apiResource.R.ParkedEvents()
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	result := make([]altParkedEvent, len(parkedEvents))
+	for i, parked := range parkedEvents {
+		result[i] = altParkedEvent{}.fromParkedEvent(parked)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// GetParkedEvent is the Api implementation for getting an event from the retry queue.
+func (apiResource ApiWrapper) GetParkedEvent(ctx echo.Context, ref string) error {
+	parsedRef, err := parseEventRef(ctx, ref)
+	if parsedRef == nil {
+		return err
+	}
+	parked, err := apiResource.R.ParkedEvent(parsedRef)
+	if err != nil {
+		return respondWithParkedEventError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, altParkedEvent{}.fromParkedEvent(*parked))
+}
+
+// RetryParkedEvent is the Api implementation for retrying an event from the retry queue.
+func (apiResource ApiWrapper) RetryParkedEvent(ctx echo.Context, ref string) error {
+	parsedRef, err := parseEventRef(ctx, ref)
+	if parsedRef == nil {
+		return err
+	}
+	if err := apiResource.R.RetryParkedEvent(parsedRef); err != nil {
+		if errors.Is(err, events.ErrUnknownEvent) {
+			return ctx.String(http.StatusNotFound, err.Error())
+		}
+		return ctx.String(http.StatusConflict, err.Error())
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// DiscardParkedEvent is the Api implementation for discarding an event from the retry queue.
+func (apiResource ApiWrapper) DiscardParkedEvent(ctx echo.Context, ref string) error {
+	parsedRef, err := parseEventRef(ctx, ref)
+	if parsedRef == nil {
+		return err
+	}
+	if err := apiResource.R.DiscardParkedEvent(parsedRef); err != nil {
+		return respondWithParkedEventError(ctx, err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// parseEventRef parses the ref. If it's invalid, a 400 response is written and a nil ref is returned.
+func parseEventRef(ctx echo.Context, ref string) (events.Ref, error) {
+	parsedRef, err := events.ParseRef(ref)
+	if err != nil || parsedRef.IsZero() {
+		return nil, ctx.String(http.StatusBadRequest, fmt.Sprintf("invalid ref: %s", ref))
+	}
+	return parsedRef, nil
+}
+
+func respondWithParkedEventError(ctx echo.Context, err error) error {
+	if errors.Is(err, events.ErrUnknownEvent) {
+		return ctx.String(http.StatusNotFound, err.Error())
+	}
+	return ctx.String(http.StatusInternalServerError, err.Error())
+}
+
+// altFork is alternative, unmarshallable version of Fork in generated.go (see altVerifyResponse).
+type altFork struct {
+	Parent   events.Ref      `json:"parent"`
+	Branches []altForkBranch `json:"branches"`
+	Merged   bool            `json:"merged"`
+}
+
+type altForkBranch struct {
+	First     events.Ref `json:"first"`
+	Last      events.Ref `json:"last"`
+	Canonical bool       `json:"canonical"`
+}
+
+func (f altFork) fromFork(fork events.Fork) altFork {
+	f.Parent = fork.Parent
+	f.Merged = fork.Merged
+	f.Branches = make([]altForkBranch, len(fork.Branches))
+	for i, branch := range fork.Branches {
+		f.Branches[i] = altForkBranch{First: branch.First, Last: branch.Last, Canonical: branch.Canonical}
+	}
+	return f
+}
+
+func (f altFork) toFork() events.Fork {
+	result := events.Fork{Parent: f.Parent, Merged: f.Merged, Branches: make([]events.ForkBranch, len(f.Branches))}
+	for i, branch := range f.Branches {
+		result.Branches[i] = events.ForkBranch{First: branch.First, Last: branch.Last, Canonical: branch.Canonical}
+	}
+	return result
+}
+
+// ListForks is the Api implementation for listing the event paths which forked.
+func (apiResource ApiWrapper) ListForks(ctx echo.Context) error {
+	forks, err := apiResource.R.Forks()
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	result := make([]altFork, len(forks))
+	for i, fork := range forks {
+		result[i] = altFork{}.fromFork(fork)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// MergeFork is the Api implementation for merging a fork.
+func (apiResource ApiWrapper) MergeFork(ctx echo.Context, ref string) error {
+	parent, err := parseEventRef(ctx, ref)
+	if parent == nil {
+		return err
+	}
+	request := MergeForkRequest{}
+	body, err := ioutil.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &request); err != nil {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+	}
+	var keep events.Ref
+	if request.Keep != nil {
+		if keep, err = events.ParseRef(*request.Keep); err != nil {
+			return ctx.String(http.StatusBadRequest, fmt.Sprintf("invalid ref: %s", *request.Keep))
+		}
+	}
+	event, err := apiResource.R.MergeFork(parent, keep)
+	if errors.Is(err, pkg.ErrForkNotFound) {
+		return ctx.String(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, pkg.ErrForkNotMergeable) {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, event)
+}
+
+// GetStateDigest is the Api implementation for calculating the state digest over the refs of all applied events.
+func (apiResource ApiWrapper) GetStateDigest(ctx echo.Context, params GetStateDigestParams) error {
+	result, err := apiResource.R.Digest(params.Refs != nil && *params.Refs)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// ExportEvents is the Api implementation for exporting all events as tar.gz bundle.
+func (apiResource ApiWrapper) ExportEvents(ctx echo.Context) error {
+	// Buffer the bundle, so an error can still be reported with the appropriate status code
+	buf := new(bytes.Buffer)
+	if err := apiResource.R.Export(buf); err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s.tar.gz", bundle.RootDir))
+	return ctx.Blob(http.StatusOK, "application/gzip", buf.Bytes())
+}
+
+// ImportEvents is the Api implementation for validating and (optionally) importing a tar.gz bundle of events.
+func (apiResource ApiWrapper) ImportEvents(ctx echo.Context, params ImportEventsParams) error {
+	apply := params.Apply != nil && *params.Apply
+	report, err := apiResource.R.Import(ctx.Request().Body, apply)
+	if errors.Is(err, pkg.ErrInvalidBundle) {
+		return ctx.String(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
 // EndpointsByOrganisationId is the Api implementation for getting all or certain types of endpoints for an organization
 func (apiResource ApiWrapper) EndpointsByOrganisationId(ctx echo.Context, params EndpointsByOrganisationIdParams) error {
+	asOf, ok := tryParseAsOf(params.AsOf, ctx)
+	if !ok {
+		return nil
+	}
 	foundEPs := []Endpoint{}
 	strict := params.Strict
 	for _, id := range params.OrgIds {
@@ -219,7 +648,13 @@ func (apiResource ApiWrapper) EndpointsByOrganisationId(ctx echo.Context, params
 		if organizationID.IsZero() {
 			return nil
 		}
-		dbEndpoints, err := apiResource.R.EndpointsByOrganizationAndType(organizationID, params.Type)
+		var dbEndpoints []db.Endpoint
+		var err error
+		if asOf != nil {
+			dbEndpoints, err = apiResource.R.EndpointsByOrganizationAndTypeAsOf(organizationID, params.Type, *asOf)
+		} else {
+			dbEndpoints, err = apiResource.R.EndpointsByOrganizationAndType(organizationID, params.Type)
+		}
 
 		if err != nil {
 			logging.Log().Warning(err.Error())
@@ -257,6 +692,7 @@ func (apiResource ApiWrapper) SearchOrganizations(ctx echo.Context, params Searc
 
 	var (
 		searchResult []db.Organization
+		total        int
 		org          *db.Organization
 		err          error
 	)
@@ -267,8 +703,17 @@ func (apiResource ApiWrapper) SearchOrganizations(ctx echo.Context, params Searc
 		if org != nil {
 			searchResult = append(searchResult, *org)
 		}
+		total = len(searchResult)
 	} else {
-		searchResult, err = apiResource.R.SearchOrganizations(params.Query)
+		options, ok := tryParseSearchOptions(params.Offset, params.Limit, ctx)
+		if !ok {
+			return nil
+		}
+		var page *db.SearchResult
+		if page, err = apiResource.R.SearchOrganizations(params.Query, options); page != nil {
+			searchResult = page.Organizations
+			total = page.Total
+		}
 	}
 
 	if errors.Is(err, db.ErrOrganizationNotFound) {
@@ -284,6 +729,56 @@ func (apiResource ApiWrapper) SearchOrganizations(ctx echo.Context, params Searc
 		result[i] = Organization{}.fromDb(o)
 	}
 
+	ctx.Response().Header().Set(totalCountHeader, strconv.Itoa(total))
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// SearchEndpoints is the Api implementation for finding endpoints across all organizations
+func (apiResource ApiWrapper) SearchEndpoints(ctx echo.Context, params SearchEndpointsParams) error {
+	options, ok := tryParseSearchOptions(params.Offset, params.Limit, ctx)
+	if !ok {
+		return nil
+	}
+	query := db.EndpointQuery{}
+	if params.Type != nil {
+		query.EndpointType = *params.Type
+	}
+	if params.Status != nil {
+		query.Status = *params.Status
+	}
+	if params.IncludeUnavailable != nil {
+		query.IncludeUnavailable = *params.IncludeUnavailable
+	}
+	if params.Vendor != nil {
+		if query.Vendor = tryParsePartyID(*params.Vendor, ctx); query.Vendor.IsZero() {
+			return nil
+		}
+	}
+	if params.Domain != nil {
+		query.Domain = string(*params.Domain)
+	}
+	if params.Property != nil {
+		query.Properties = make(map[string]string, len(*params.Property))
+		for _, property := range *params.Property {
+			parts := strings.SplitN(property, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return ctx.String(http.StatusBadRequest, fmt.Sprintf("invalid property (expected name=value): %s", property))
+			}
+			query.Properties[parts[0]] = parts[1]
+		}
+	}
+
+	page, err := apiResource.R.SearchEndpoints(query, options)
+	if err != nil {
+		return err
+	}
+
+	result := make([]OrganizationEndpoint, len(page.Endpoints))
+	for i, e := range page.Endpoints {
+		result[i] = OrganizationEndpoint{}.fromDb(e)
+	}
+
+	ctx.Response().Header().Set(totalCountHeader, strconv.Itoa(page.Total))
 	return ctx.JSON(http.StatusOK, result)
 }
 
@@ -380,6 +875,38 @@ func (apiResource ApiWrapper) MTLSCertificates(ctx echo.Context) error {
 	return err
 }
 
+// tryParseAsOf parses the (optional) asOf query parameter. If it's invalid, a 400 response is written and false is returned.
+func tryParseAsOf(asOf *string, ctx echo.Context) (*time.Time, bool) {
+	if asOf == nil {
+		return nil, true
+	}
+	moment, err := time.Parse(time.RFC3339, *asOf)
+	if err != nil {
+		_ = ctx.String(http.StatusBadRequest, fmt.Sprintf("invalid asOf (expected RFC3339 timestamp): %s", *asOf))
+		return nil, false
+	}
+	return &moment, true
+}
+
+func tryParseSearchOptions(offset *int, limit *int, ctx echo.Context) (db.SearchOptions, bool) {
+	options := db.SearchOptions{}
+	if offset != nil {
+		if *offset < 0 {
+			_ = ctx.String(http.StatusBadRequest, "offset must not be negative")
+			return options, false
+		}
+		options.Offset = *offset
+	}
+	if limit != nil {
+		if *limit < 1 {
+			_ = ctx.String(http.StatusBadRequest, "limit must be greater than 0")
+			return options, false
+		}
+		options.Limit = *limit
+	}
+	return options, true
+}
+
 func tryParsePartyID(id string, ctx echo.Context) core.PartyID {
 	unescapedID, err := url.PathUnescape(id)
 	if err != nil {
@@ -24,10 +24,12 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"strings"
 	"time"
@@ -38,9 +40,13 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws"
 	"github.com/nuts-foundation/nuts-registry/mock"
 	"github.com/nuts-foundation/nuts-registry/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
 	"github.com/stretchr/testify/assert"
@@ -86,6 +92,14 @@ func (mdb *MockDb) RegisterEventHandlers(fn events.EventRegistrar) {
 
 }
 
+func (mdb *MockDb) Snapshot() ([]byte, error) {
+	panic("implement me")
+}
+
+func (mdb *MockDb) Restore(_ []byte) error {
+	panic("implement me")
+}
+
 func (mdb *MockDb) FindEndpointsByOrganizationAndType(organizationIdentifier core.PartyID, endpointType *string) ([]db.Endpoint, error) {
 	if mdb.endpointsError != nil {
 		return nil, mdb.endpointsError
@@ -102,8 +116,12 @@ func (mdb *MockDb) FindEndpointsByOrganizationAndType(organizationIdentifier cor
 	return eps, nil
 }
 
-func (mdb *MockDb) SearchOrganizations(query string) []db.Organization {
-	return mdb.organizations
+func (mdb *MockDb) SearchOrganizations(query string, options db.SearchOptions) db.SearchResult {
+	return db.SearchResult{Organizations: mdb.organizations, Total: len(mdb.organizations)}
+}
+
+func (mdb *MockDb) SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) db.EndpointSearchResult {
+	panic("implement me")
 }
 
 func (mdb *MockDb) ReverseLookup(name string) (*db.Organization, error) {
@@ -504,8 +522,173 @@ func TestApiResource_SearchOrganizations(t *testing.T) {
 
 			assert.Nil(t, err)
 			assert.Equal(t, 0, len(result))
+			assert.Equal(t, "0", rec.Header().Get("X-Total-Count"))
+		}
+	})
+
+	t.Run("200 - paginated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		registryClient := mock.NewMockRegistryClient(ctrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().
+			SearchOrganizations("org", db.SearchOptions{Offset: 1, Limit: 1}).
+			Return(&db.SearchResult{Organizations: organizations[1:], Total: 2}, nil)
+
+		req := httptest.NewRequest(echo.GET, "/?query=org&offset=1&limit=1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/organizations")
+
+		err := wrapper.SearchOrganizations(c)
+
+		if assert.Nil(t, err) && assert.Equal(t, http.StatusOK, rec.Code) {
+			result, err := deserializeOrganizations(rec.Body)
+			assert.Nil(t, err)
+			assert.Len(t, result, 1)
+			assert.Equal(t, "2", rec.Header().Get("X-Total-Count"))
+		}
+	})
+
+	t.Run("400 - negative offset", func(t *testing.T) {
+		e, wrapper := initEcho(&MockDb{})
+
+		req := httptest.NewRequest(echo.GET, "/?query=org&offset=-1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/organizations")
+
+		err := wrapper.SearchOrganizations(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("400 - zero limit", func(t *testing.T) {
+		e, wrapper := initEcho(&MockDb{})
+
+		req := httptest.NewRequest(echo.GET, "/?query=org&limit=0", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/organizations")
+
+		err := wrapper.SearchOrganizations(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestApiResource_SearchEndpoints(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		registryClient := mock.NewMockRegistryClient(ctrl)
+		e, wrapper := initMockEcho(registryClient)
+		query := db.EndpointQuery{
+			EndpointType:       "fhir",
+			Status:             db.StatusActive,
+			Vendor:             test.VendorID("v1"),
+			Domain:             types.HealthcareDomain,
+			Properties:         map[string]string{"version": "r4", "auth": "a=b"},
+			IncludeUnavailable: true,
+		}
+		registryClient.EXPECT().
+			SearchEndpoints(query, db.SearchOptions{Offset: 1, Limit: 1}).
+			Return(&db.EndpointSearchResult{Endpoints: []db.OrganizationEndpoint{{Endpoint: endpoints[0], Organization: organizations[0]}}, Total: 2}, nil)
+
+		q := make(url.Values)
+		q.Set("type", "fhir")
+		q.Set("status", db.StatusActive)
+		q.Set("vendor", test.VendorID("v1").String())
+		q.Set("domain", types.HealthcareDomain)
+		q.Add("property", "version=r4")
+		q.Add("property", "auth=a=b")
+		q.Set("includeUnavailable", "true")
+		q.Set("offset", "1")
+		q.Set("limit", "1")
+		req := httptest.NewRequest(echo.GET, "/?"+q.Encode(), nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/search")
+
+		err := wrapper.SearchEndpoints(c)
+
+		if assert.Nil(t, err) && assert.Equal(t, http.StatusOK, rec.Code) {
+			var result []OrganizationEndpoint
+			if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result)) || !assert.Len(t, result, 1) {
+				return
+			}
+			assert.Equal(t, string(endpoints[0].Identifier), result[0].Endpoint.Identifier.String())
+			assert.Equal(t, "test", result[0].Organization.Name)
+			assert.Equal(t, "2", rec.Header().Get("X-Total-Count"))
+		}
+	})
+
+	t.Run("200 - no criteria", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		registryClient := mock.NewMockRegistryClient(ctrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().
+			SearchEndpoints(db.EndpointQuery{}, db.SearchOptions{}).
+			Return(&db.EndpointSearchResult{Endpoints: []db.OrganizationEndpoint{}}, nil)
+
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/search")
+
+		err := wrapper.SearchEndpoints(c)
+
+		if assert.Nil(t, err) && assert.Equal(t, http.StatusOK, rec.Code) {
+			assert.Equal(t, "[]", strings.TrimSpace(rec.Body.String()))
+			assert.Equal(t, "0", rec.Header().Get("X-Total-Count"))
 		}
 	})
+
+	t.Run("400 - invalid property", func(t *testing.T) {
+		e, wrapper := initEcho(&MockDb{})
+
+		req := httptest.NewRequest(echo.GET, "/?property=version", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/search")
+
+		err := wrapper.SearchEndpoints(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid property (expected name=value): version", rec.Body.String())
+	})
+
+	t.Run("400 - invalid vendor", func(t *testing.T) {
+		e, wrapper := initEcho(&MockDb{})
+
+		req := httptest.NewRequest(echo.GET, "/?vendor=foo", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/search")
+
+		err := wrapper.SearchEndpoints(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("400 - negative offset", func(t *testing.T) {
+		e, wrapper := initEcho(&MockDb{})
+
+		req := httptest.NewRequest(echo.GET, "/?offset=-1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/search")
+
+		err := wrapper.SearchEndpoints(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
 }
 
 func TestApiResource_ReverseLookup(t *testing.T) {
@@ -678,6 +861,98 @@ func TestApiResource_VendorById(t *testing.T) {
 	})
 }
 
+func TestApiResource_AsOf(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	moment := time.Date(2020, 10, 20, 20, 30, 0, 0, time.UTC)
+	query := "/?asOf=" + url.QueryEscape(moment.Format(time.RFC3339))
+
+	t.Run("organization", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().OrganizationByIdAsOf(test.OrganizationID("org"), moment).Return(&db.Organization{Identifier: test.OrganizationID("org"), Name: "old name"}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, query, nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.OrganizationID("org").String())
+
+		err := wrapper.OrganizationById(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		result, _ := deserializeOrganization(rec.Body)
+		if assert.NotNil(t, result) {
+			assert.Equal(t, "old name", result.Name)
+		}
+	})
+	t.Run("organization - not registered at that moment", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().OrganizationByIdAsOf(test.OrganizationID("org"), moment).Return(nil, db.ErrOrganizationNotFound)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, query, nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.OrganizationID("org").String())
+
+		_ = wrapper.OrganizationById(c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("400 invalid asOf", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/?asOf=yesterday", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.OrganizationID("org").String())
+
+		_ = wrapper.OrganizationById(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid asOf (expected RFC3339 timestamp): yesterday", rec.Body.String())
+	})
+	t.Run("vendor", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().VendorByIdAsOf(test.VendorID("vendor"), moment).Return(&db.Vendor{Identifier: test.VendorID("vendor"), Name: "old name"}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, query, nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.VendorID("vendor").String())
+
+		err := wrapper.VendorById(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		result, _ := deserializeVendor(rec.Body)
+		if assert.NotNil(t, result) {
+			assert.Equal(t, "old name", result.Name)
+		}
+	})
+	t.Run("endpoints", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().EndpointsByOrganizationAndTypeAsOf(test.OrganizationID("org"), nil, moment).Return([]db.Endpoint{{Organization: test.OrganizationID("org"), URL: "http://old", Identifier: "endpoint"}}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, query+"&orgIds="+url.QueryEscape(test.OrganizationID("org").String()), nil), rec)
+
+		err := wrapper.EndpointsByOrganisationId(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		result, _ := deserializeEndpoints(rec.Body)
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, "http://old", result[0].URL)
+		}
+	})
+}
+
 func TestApiResource_Verify(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -744,6 +1019,34 @@ func TestApiResource_RegisterVendor(t *testing.T) {
 			}
 		})
 
+		t.Run("200 - status and availability window", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			orgID := test.OrganizationID("1234")
+			notBefore := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+			notAfter := notBefore.AddDate(0, 1, 0)
+			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", db.StatusDeprecated, &notBefore, &notAfter, map[string]string{})
+
+			b, _ := json.Marshal(Endpoint{
+				URL:          "foo:bar",
+				EndpointType: "fhir",
+				Status:       db.StatusDeprecated,
+				NotBefore:    &notBefore,
+				NotAfter:     &notAfter,
+			})
+
+			req := httptest.NewRequest(echo.POST, "/", bytes.NewReader(b))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/api/organization/:id/endpoints")
+			c.SetParamNames("id")
+			c.SetParamValues(orgID.String())
+
+			err := wrapper.RegisterEndpoint(c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
 		t.Run("400 - Invalid JSON", func(t *testing.T) {
 			var registryClient = mock.NewMockRegistryClient(mockCtrl)
 			e, wrapper := initMockEcho(registryClient)
@@ -834,6 +1137,34 @@ func TestApiResource_VendorClaim(t *testing.T) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 		})
 
+		t.Run("200 - status and availability window", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			orgID := test.OrganizationID("1234")
+			notBefore := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+			notAfter := notBefore.AddDate(0, 1, 0)
+			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", db.StatusDeprecated, &notBefore, &notAfter, map[string]string{})
+
+			b, _ := json.Marshal(Endpoint{
+				URL:          "foo:bar",
+				EndpointType: "fhir",
+				Status:       db.StatusDeprecated,
+				NotBefore:    &notBefore,
+				NotAfter:     &notAfter,
+			})
+
+			req := httptest.NewRequest(echo.POST, "/", bytes.NewReader(b))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/api/organization/:id/endpoints")
+			c.SetParamNames("id")
+			c.SetParamValues(orgID.String())
+
+			err := wrapper.RegisterEndpoint(c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
 		t.Run("400 - Invalid JSON", func(t *testing.T) {
 			var registryClient = mock.NewMockRegistryClient(mockCtrl)
 			e, wrapper := initMockEcho(registryClient)
@@ -946,7 +1277,7 @@ func TestApiResource_RegisterEndpoint(t *testing.T) {
 			var registryClient = mock.NewMockRegistryClient(mockCtrl)
 			e, wrapper := initMockEcho(registryClient)
 			orgID := test.OrganizationID("1234")
-			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", "", map[string]string{"key": "value"})
+			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", "", nil, nil, map[string]string{"key": "value"})
 
 			props := EndpointProperties{}
 			props["key"] = "value"
@@ -969,6 +1300,55 @@ func TestApiResource_RegisterEndpoint(t *testing.T) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 		})
 
+		t.Run("200 - status and availability window", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			orgID := test.OrganizationID("1234")
+			notBefore := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+			notAfter := notBefore.AddDate(0, 1, 0)
+			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", db.StatusDeprecated, &notBefore, &notAfter, map[string]string{})
+
+			b, _ := json.Marshal(Endpoint{
+				URL:          "foo:bar",
+				EndpointType: "fhir",
+				Status:       db.StatusDeprecated,
+				NotBefore:    &notBefore,
+				NotAfter:     &notAfter,
+			})
+
+			req := httptest.NewRequest(echo.POST, "/", bytes.NewReader(b))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/api/organization/:id/endpoints")
+			c.SetParamNames("id")
+			c.SetParamValues(orgID.String())
+
+			err := wrapper.RegisterEndpoint(c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("400 - invalid payload", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			orgID := test.OrganizationID("1234")
+			registryClient.EXPECT().RegisterEndpoint(orgID, "", "foo:bar", "fhir", "", nil, nil, map[string]string{}).
+				Return(nil, domain.PayloadValidationError{EventType: domain.RegisterEndpoint, Violations: []string{"/status: value is not one of the allowed values"}})
+
+			b, _ := json.Marshal(Endpoint{URL: "foo:bar", EndpointType: "fhir"})
+			req := httptest.NewRequest(echo.POST, "/", bytes.NewReader(b))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/api/organization/:id/endpoints")
+			c.SetParamNames("id")
+			c.SetParamValues(orgID.String())
+
+			err := wrapper.RegisterEndpoint(c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "payload of RegisterEndpointEvent doesn't conform to its schema: /status: value is not one of the allowed values", rec.Body.String())
+		})
+
 		t.Run("400 - Invalid JSON", func(t *testing.T) {
 			var registryClient = mock.NewMockRegistryClient(mockCtrl)
 			e, wrapper := initMockEcho(registryClient)
@@ -1098,3 +1478,731 @@ func Test_listOfEvents(t *testing.T) {
 		assert.Empty(t, list)
 	})
 }
+
+func TestApiResource_StreamEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	event := events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{OrgName: "org"}, nil)
+	stream := func(evts ...events.Event) <-chan events.Event {
+		ch := make(chan events.Event, len(evts))
+		for _, e := range evts {
+			ch <- e
+		}
+		close(ch)
+		return ch
+	}
+
+	t.Run("ok - events are written as SSE", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Subscribe(gomock.Any(), events.Ref(nil)).Return(stream(event), nil)
+
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := wrapper.StreamEvents(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		lines := strings.Split(rec.Body.String(), "\n")
+		if !assert.Len(t, lines, 5) {
+			return
+		}
+		assert.Equal(t, "id: "+event.Ref().String(), lines[0])
+		assert.Equal(t, "event: VendorClaimEvent", lines[1])
+		received, err := events.EventFromJSON([]byte(strings.TrimPrefix(lines[2], "data: ")))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), received.Ref())
+	})
+	t.Run("ok - resume using Last-Event-ID", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Subscribe(gomock.Any(), events.Ref{1, 2, 3}).Return(stream(), nil)
+
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Last-Event-ID", "010203")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := wrapper.StreamEvents(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("error - invalid ref", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+
+		req := httptest.NewRequest(echo.GET, "/?from=foo", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		_ = wrapper.StreamEvents(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid ref: foo", rec.Body.String())
+	})
+	t.Run("error - unknown event", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Subscribe(gomock.Any(), events.Ref{1, 2, 3}).Return(nil, events.ErrUnknownEvent)
+
+		req := httptest.NewRequest(echo.GET, "/?from=010203", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		_ = wrapper.StreamEvents(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestApiResource_History(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	certificate, _ := x509.ParseCertificate(test.GenerateCertificateEx(time.Now(), 1, privateKey))
+	signedEvent := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Name: "vendor"}, nil)
+	_ = signedEvent.Sign(func(payload []byte) ([]byte, error) {
+		headers := jws.NewHeaders()
+		_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+		return jws.Sign(payload, jwa.RS256, privateKey, jws.WithHeaders(headers))
+	})
+	unsignedEvent := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	parseResponse := func(t *testing.T, rec *httptest.ResponseRecorder) []altEventHistoryEntry {
+		var result []altEventHistoryEntry
+		if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result)) {
+			t.FailNow()
+		}
+		return result
+	}
+
+	t.Run("ok - vendor", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().VendorHistory(test.VendorID("vendor")).Return([]events.Event{signedEvent}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.VendorID("vendor").String())
+
+		err := wrapper.VendorHistory(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		result := parseResponse(t, rec)
+		if !assert.Len(t, result, 1) {
+			return
+		}
+		event, _ := events.EventFromJSON(result[0].Event)
+		assert.Equal(t, signedEvent.Ref(), event.Ref())
+		if assert.NotNil(t, result[0].SignerCertificate) {
+			assert.Equal(t, certificateToPEM(certificate), *result[0].SignerCertificate)
+		}
+	})
+	t.Run("ok - organization", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().OrganizationHistory(test.OrganizationID("org")).Return([]events.Event{unsignedEvent}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.OrganizationID("org").String())
+
+		err := wrapper.OrganizationHistory(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		result := parseResponse(t, rec)
+		if !assert.Len(t, result, 1) {
+			return
+		}
+		assert.Nil(t, result[0].SignerCertificate)
+	})
+	t.Run("ok - endpoint", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().EndpointHistory(test.OrganizationID("org"), types.EndpointID("end/point")).Return([]events.Event{unsignedEvent, unsignedEvent}, nil)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id", "endpointId")
+		c.SetParamValues(test.OrganizationID("org").String(), "end%2Fpoint")
+
+		err := wrapper.EndpointHistory(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, parseResponse(t, rec), 2)
+	})
+	t.Run("404 when not found", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().EndpointHistory(test.OrganizationID("org"), types.EndpointID("unknown")).Return(nil, pkg.ErrEndpointNotFound)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id", "endpointId")
+		c.SetParamValues(test.OrganizationID("org").String(), "unknown")
+
+		_ = wrapper.EndpointHistory(c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("400 invalid PartyID", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues("https%3A//system%23value")
+
+		_ = wrapper.OrganizationHistory(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("500", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().VendorHistory(test.VendorID("vendor")).Return(nil, errors.New("multiple event paths match"))
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(test.VendorID("vendor").String())
+
+		_ = wrapper.VendorHistory(c)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestApiResource_RetryQueue(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	parked := events.ParkedEvent{
+		Event:       event,
+		State:       events.ParkedEventDeadLetter,
+		Attempts:    10,
+		LastError:   "failed",
+		ParkedAt:    time.Now(),
+		LastAttempt: time.Now(),
+	}
+	// newContext creates an echo context with the ref path parameter set
+	newContext := func(e *echo.Echo, ref string) (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("ref")
+		c.SetParamValues(ref)
+		return c, rec
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().ParkedEvents().Return([]events.ParkedEvent{parked}, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		err := wrapper.ListParkedEvents(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var result []altParkedEvent
+		if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result)) || !assert.Len(t, result, 1) {
+			return
+		}
+		actual, err := result[0].toParkedEvent()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), actual.Event.Ref())
+		assert.Equal(t, events.ParkedEventDeadLetter, actual.State)
+		assert.Equal(t, 10, actual.Attempts)
+		assert.Equal(t, "failed", actual.LastError)
+	})
+	t.Run("get", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().ParkedEvent(event.Ref()).Return(&parked, nil)
+			c, rec := newContext(e, event.Ref().String())
+
+			err := wrapper.GetParkedEvent(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"state":"dead-letter"`)
+		})
+		t.Run("not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().ParkedEvent(event.Ref()).Return(nil, events.ErrUnknownEvent)
+			c, rec := newContext(e, event.Ref().String())
+
+			_ = wrapper.GetParkedEvent(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+		t.Run("invalid ref", func(t *testing.T) {
+			e, wrapper := initMockEcho(mock.NewMockRegistryClient(mockCtrl))
+			c, rec := newContext(e, "not-hex")
+
+			_ = wrapper.GetParkedEvent(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "invalid ref: not-hex", rec.Body.String())
+		})
+	})
+	t.Run("retry", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetryParkedEvent(event.Ref()).Return(nil)
+			c, rec := newContext(e, event.Ref().String())
+
+			err := wrapper.RetryParkedEvent(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		})
+		t.Run("still failing", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetryParkedEvent(event.Ref()).Return(errors.New("failed"))
+			c, rec := newContext(e, event.Ref().String())
+
+			_ = wrapper.RetryParkedEvent(c)
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Equal(t, "failed", rec.Body.String())
+		})
+		t.Run("not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetryParkedEvent(event.Ref()).Return(events.ErrUnknownEvent)
+			c, rec := newContext(e, event.Ref().String())
+
+			_ = wrapper.RetryParkedEvent(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	})
+	t.Run("discard", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().DiscardParkedEvent(event.Ref()).Return(nil)
+			c, rec := newContext(e, event.Ref().String())
+
+			err := wrapper.DiscardParkedEvent(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		})
+		t.Run("not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().DiscardParkedEvent(event.Ref()).Return(events.ErrUnknownEvent)
+			c, rec := newContext(e, event.Ref().String())
+
+			_ = wrapper.DiscardParkedEvent(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	})
+}
+
+func TestApiResource_Forks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	parent := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	branch1 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "1"}, parent.Ref())
+	branch2 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "2"}, parent.Ref())
+	fork := events.Fork{Parent: parent.Ref(), Branches: []events.ForkBranch{
+		{First: branch1.Ref(), Last: branch1.Ref(), Canonical: true},
+		{First: branch2.Ref(), Last: branch2.Ref()},
+	}}
+	// newContext creates an echo context with the ref path parameter set and the given body
+	newContext := func(e *echo.Echo, ref string, body string) (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, rec)
+		c.SetParamNames("ref")
+		c.SetParamValues(ref)
+		return c, rec
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Forks().Return([]events.Fork{fork}, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		err := wrapper.ListForks(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var result []altFork
+		if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result)) || !assert.Len(t, result, 1) {
+			return
+		}
+		assert.Equal(t, fork, result[0].toFork())
+	})
+	t.Run("merge", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().MergeFork(parent.Ref(), events.Ref(nil)).Return(branch1, nil)
+			c, rec := newContext(e, parent.Ref().String(), "")
+
+			err := wrapper.MergeFork(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+		t.Run("ok - keep", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().MergeFork(parent.Ref(), branch2.Ref()).Return(branch1, nil)
+			c, rec := newContext(e, parent.Ref().String(), `{"keep": "`+branch2.Ref().String()+`"}`)
+
+			err := wrapper.MergeFork(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+		t.Run("not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().MergeFork(parent.Ref(), events.Ref(nil)).Return(nil, pkg.ErrForkNotFound)
+			c, rec := newContext(e, parent.Ref().String(), "")
+
+			_ = wrapper.MergeFork(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+		t.Run("not mergeable", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().MergeFork(parent.Ref(), events.Ref(nil)).Return(nil, fmt.Errorf("%w: reason", pkg.ErrForkNotMergeable))
+			c, rec := newContext(e, parent.Ref().String(), "")
+
+			_ = wrapper.MergeFork(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "fork can't be merged: reason", rec.Body.String())
+		})
+		t.Run("invalid keep", func(t *testing.T) {
+			e, wrapper := initMockEcho(mock.NewMockRegistryClient(mockCtrl))
+			c, rec := newContext(e, parent.Ref().String(), `{"keep": "not-hex"}`)
+
+			_ = wrapper.MergeFork(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "invalid ref: not-hex", rec.Body.String())
+		})
+		t.Run("invalid ref", func(t *testing.T) {
+			e, wrapper := initMockEcho(mock.NewMockRegistryClient(mockCtrl))
+			c, rec := newContext(e, "not-hex", "")
+
+			_ = wrapper.MergeFork(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	})
+}
+
+func TestApiResource_Deregistration(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	orgID := test.OrganizationID("org")
+	vendorID := test.VendorID("vendor")
+	event := events.CreateEvent(domain.DeregisterEndpoint, domain.DeregisterEndpointEvent{Organization: orgID, Identifier: "endpoint"}, nil)
+	// newContext creates an echo context with the given path parameters set
+	newContext := func(e *echo.Echo, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.DELETE, "/", nil), rec)
+		names := []string{"id", "endpointId"}
+		c.SetParamNames(names[:len(params)]...)
+		c.SetParamValues(params...)
+		return c, rec
+	}
+
+	t.Run("deregister endpoint", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().DeregisterEndpoint(orgID, types.EndpointID("endpoint")).Return(event, nil)
+			c, rec := newContext(e, orgID.String(), "endpoint")
+
+			err := wrapper.DeregisterEndpoint(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+		t.Run("endpoint not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().DeregisterEndpoint(orgID, types.EndpointID("endpoint")).Return(nil, pkg.ErrEndpointNotFound)
+			c, rec := newContext(e, orgID.String(), "endpoint")
+
+			_ = wrapper.DeregisterEndpoint(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "endpoint not found", rec.Body.String())
+		})
+		t.Run("invalid organization ID", func(t *testing.T) {
+			e, wrapper := initMockEcho(mock.NewMockRegistryClient(mockCtrl))
+			c, rec := newContext(e, "invalid", "endpoint")
+
+			_ = wrapper.DeregisterEndpoint(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	})
+	t.Run("end vendor claim", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().EndVendorClaim(orgID).Return(event, nil)
+			c, rec := newContext(e, orgID.String())
+
+			err := wrapper.EndVendorClaim(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+		t.Run("not managed", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().EndVendorClaim(orgID).Return(nil, fmt.Errorf("%w: reason", pkg.ErrNotManaged))
+			c, rec := newContext(e, orgID.String())
+
+			_ = wrapper.EndVendorClaim(c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "not managed by this node: reason", rec.Body.String())
+		})
+	})
+	t.Run("retire vendor", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetireVendor(vendorID).Return(event, nil)
+			c, rec := newContext(e, vendorID.String())
+
+			err := wrapper.RetireVendor(c)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+		t.Run("not found", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetireVendor(vendorID).Return(nil, pkg.ErrVendorNotFound)
+			c, rec := newContext(e, vendorID.String())
+
+			_ = wrapper.RetireVendor(c)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+		t.Run("error", func(t *testing.T) {
+			var registryClient = mock.NewMockRegistryClient(mockCtrl)
+			e, wrapper := initMockEcho(registryClient)
+			registryClient.EXPECT().RetireVendor(vendorID).Return(nil, errors.New("failed"))
+			c, rec := newContext(e, vendorID.String())
+
+			_ = wrapper.RetireVendor(c)
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		})
+	})
+}
+
+func TestApiResource_ExportEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("ok", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Export(gomock.Any()).DoAndReturn(func(writer io.Writer) error {
+			_, err := writer.Write([]byte("bundle"))
+			return err
+		})
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		err := wrapper.ExportEvents(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/gzip", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "attachment; filename=registry-export.tar.gz", rec.Header().Get(echo.HeaderContentDisposition))
+		assert.Equal(t, "bundle", rec.Body.String())
+	})
+	t.Run("error", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Export(gomock.Any()).DoAndReturn(func(writer io.Writer) error {
+			_, _ = writer.Write([]byte("partial"))
+			return errors.New("failed")
+		})
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		_ = wrapper.ExportEvents(c)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "failed", rec.Body.String())
+	})
+}
+
+func TestApiResource_GetStateDigest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("ok", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Digest(true).Return(&digest.Digest{Root: "root", EventCount: 1, Vendors: []digest.VendorDigest{
+			{Vendor: test.VendorID("vendor"), Digest: "vendor", EventCount: 1, Refs: []events.Ref{{1}}},
+		}}, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/?refs=true", nil), rec)
+
+		err := wrapper.GetStateDigest(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"root":"root","eventCount":1,"vendors":[{"vendor":"urn:oid:1.3.6.1.4.1.54851.4:vendor","digest":"vendor","eventCount":1,"refs":["01"]}]}`, rec.Body.String())
+	})
+	t.Run("ok - refs not specified", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Digest(false).Return(&digest.Digest{}, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		err := wrapper.GetStateDigest(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("error", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Digest(false).Return(nil, errors.New("failed"))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		_ = wrapper.GetStateDigest(c)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "failed", rec.Body.String())
+	})
+}
+
+func TestApiResource_ImportEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newContext := func(e *echo.Echo, query string) (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		return e.NewContext(httptest.NewRequest(echo.POST, "/"+query, bytes.NewReader([]byte("bundle"))), rec), rec
+	}
+
+	t.Run("ok - dry run", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		report := &bundle.ImportReport{Events: []bundle.ImportReportEntry{{File: "events/1.json", Ref: []byte{1}, Outcome: bundle.ImportOutcomeApplied}}}
+		registryClient.EXPECT().Import(gomock.Any(), false).Return(report, nil)
+		c, rec := newContext(e, "")
+
+		err := wrapper.ImportEvents(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"applied":false,"events":[{"file":"events/1.json","ref":"01","outcome":"applied"}]}`, rec.Body.String())
+	})
+	t.Run("ok - apply", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Import(gomock.Any(), true).Return(&bundle.ImportReport{Applied: true}, nil)
+		c, rec := newContext(e, "?apply=true")
+
+		err := wrapper.ImportEvents(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("invalid bundle", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Import(gomock.Any(), false).Return(nil, fmt.Errorf("%w: reason", pkg.ErrInvalidBundle))
+		c, rec := newContext(e, "")
+
+		_ = wrapper.ImportEvents(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid bundle: reason", rec.Body.String())
+	})
+	t.Run("error", func(t *testing.T) {
+		var registryClient = mock.NewMockRegistryClient(mockCtrl)
+		e, wrapper := initMockEcho(registryClient)
+		registryClient.EXPECT().Import(gomock.Any(), false).Return(nil, errors.New("failed"))
+		c, rec := newContext(e, "")
+
+		_ = wrapper.ImportEvents(c)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestApiResource_ListEventSchemas(t *testing.T) {
+	e, wrapper := initMockEcho(nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+	err := wrapper.ListEventSchemas(c)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var result []EventSchema
+	_ = json.Unmarshal(rec.Body.Bytes(), &result)
+	assert.Len(t, result, len(domain.Schemas()))
+	assert.Contains(t, result, EventSchema{Type: string(domain.RegisterEndpoint), Version: 0})
+}
+
+func TestApiResource_GetEventSchema(t *testing.T) {
+	newContext := func(e *echo.Echo, eventType string, version string) (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetParamNames("type", "version")
+		c.SetParamValues(eventType, version)
+		return c, rec
+	}
+	t.Run("ok", func(t *testing.T) {
+		e, wrapper := initMockEcho(nil)
+		c, rec := newContext(e, string(domain.RegisterEndpoint), "2")
+
+		err := wrapper.GetEventSchema(c)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/schema+json", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, string(domain.GetSchema(domain.RegisterEndpoint, 0).Data), rec.Body.String())
+	})
+	t.Run("unknown schema", func(t *testing.T) {
+		e, wrapper := initMockEcho(nil)
+		c, rec := newContext(e, string(domain.RetireVendor), "1")
+
+		_ = wrapper.GetEventSchema(c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
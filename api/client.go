@@ -20,14 +20,17 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/nuts-foundation/nuts-registry/logging"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,7 +40,11 @@ import (
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 
 	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
 )
 
 // HttpClient holds the server address and other basic settings for the http client
@@ -95,12 +102,23 @@ func (hb HttpClient) RefreshOrganizationCertificate(organizationID core.PartyID)
 
 // EndpointsByOrganization is the client Api implementation for getting all or certain types of endpoints for an organization
 func (hb HttpClient) EndpointsByOrganizationAndType(organizationID core.PartyID, endpointType *string) ([]db.Endpoint, error) {
+	return hb.endpointsByOrganizationAndType(organizationID, endpointType, nil)
+}
+
+// EndpointsByOrganizationAndTypeAsOf is the client Api implementation for getting the endpoints of an organization as
+// they were registered at the given moment.
+func (hb HttpClient) EndpointsByOrganizationAndTypeAsOf(organizationID core.PartyID, endpointType *string, moment time.Time) ([]db.Endpoint, error) {
+	return hb.endpointsByOrganizationAndType(organizationID, endpointType, formatAsOf(moment))
+}
+
+func (hb HttpClient) endpointsByOrganizationAndType(organizationID core.PartyID, endpointType *string, asOf *string) ([]db.Endpoint, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
 	defer cancel()
 
 	params := &EndpointsByOrganisationIdParams{
 		OrgIds: []string{organizationID.String()},
 		Type:   endpointType,
+		AsOf:   asOf,
 	}
 	res, err := hb.client().EndpointsByOrganisationId(ctx, params)
 	if err != nil {
@@ -128,10 +146,95 @@ func (hb HttpClient) EndpointsByOrganizationAndType(organizationID core.PartyID,
 }
 
 // SearchOrganizations is the client Api implementation for finding organizations by (partial) query
-func (hb HttpClient) SearchOrganizations(query string) ([]db.Organization, error) {
+func (hb HttpClient) SearchOrganizations(query string, options db.SearchOptions) (*db.SearchResult, error) {
 	params := SearchOrganizationsParams{Query: query}
+	if options.Offset > 0 {
+		params.Offset = &options.Offset
+	}
+	if options.Limit > 0 {
+		params.Limit = &options.Limit
+	}
 
-	return hb.searchOrganization(params)
+	orgs, header, err := hb.searchOrganization(params)
+	if err != nil {
+		return nil, err
+	}
+	result := db.SearchResult{Organizations: orgs, Total: len(orgs)}
+	if totalCount := header.Get(totalCountHeader); totalCount != "" {
+		if result.Total, err = strconv.Atoi(totalCount); err != nil {
+			return nil, fmt.Errorf("invalid %s header: %s", totalCountHeader, totalCount)
+		}
+	}
+	return &result, nil
+}
+
+// SearchEndpoints is the client Api implementation for finding endpoints across all organizations
+func (hb HttpClient) SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) (*db.EndpointSearchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+
+	params := &SearchEndpointsParams{}
+	if query.EndpointType != "" {
+		params.Type = &query.EndpointType
+	}
+	if query.Status != "" {
+		params.Status = &query.Status
+	}
+	if query.IncludeUnavailable {
+		params.IncludeUnavailable = &query.IncludeUnavailable
+	}
+	if !query.Vendor.IsZero() {
+		vendorID := query.Vendor.String()
+		params.Vendor = &vendorID
+	}
+	if query.Domain != "" {
+		domain := Domain(query.Domain)
+		params.Domain = &domain
+	}
+	if len(query.Properties) > 0 {
+		properties := make([]string, 0, len(query.Properties))
+		for name, value := range query.Properties {
+			properties = append(properties, name+"="+value)
+		}
+		sort.Strings(properties)
+		params.Property = &properties
+	}
+	if options.Offset > 0 {
+		params.Offset = &options.Offset
+	}
+	if options.Limit > 0 {
+		params.Limit = &options.Limit
+	}
+	res, err := hb.client().SearchEndpoints(ctx, params)
+	if err != nil {
+		logging.Log().Error("error while searching for endpoints", err)
+		return nil, core.Wrap(err)
+	}
+	parsed, err := ParseSearchEndpointsResponse(res)
+	if err != nil {
+		logging.Log().Error("error while reading response body", err)
+		return nil, err
+	}
+	if err := testResponseCode(http.StatusOK, res); err != nil {
+		return nil, err
+	}
+
+	var endpoints []OrganizationEndpoint
+	if err := json.Unmarshal(parsed.Body, &endpoints); err != nil {
+		logging.Log().Error("could not unmarshal response body")
+		return nil, err
+	}
+	result := db.EndpointSearchResult{Endpoints: make([]db.OrganizationEndpoint, len(endpoints))}
+	for i, e := range endpoints {
+		result.Endpoints[i] = e.toDb()
+	}
+	result.Total = len(result.Endpoints)
+	if totalCount := res.Header.Get(totalCountHeader); totalCount != "" {
+		if result.Total, err = strconv.Atoi(totalCount); err != nil {
+			return nil, fmt.Errorf("invalid %s header: %s", totalCountHeader, totalCount)
+		}
+	}
+	return &result, nil
 }
 
 // ErrOrganizationNotFound is returned by the reverseLookup when the organization is not found
@@ -142,7 +245,7 @@ func (hb HttpClient) ReverseLookup(name string) (*db.Organization, error) {
 	t := true
 	params := SearchOrganizationsParams{Query: name, Exact: &t}
 
-	orgs, err := hb.searchOrganization(params)
+	orgs, _, err := hb.searchOrganization(params)
 	if err != nil {
 		return nil, err
 	}
@@ -156,34 +259,34 @@ func (hb HttpClient) ReverseLookup(name string) (*db.Organization, error) {
 	return &orgs[0], nil
 }
 
-func (hb HttpClient) searchOrganization(params SearchOrganizationsParams) ([]db.Organization, error) {
+func (hb HttpClient) searchOrganization(params SearchOrganizationsParams) ([]db.Organization, http.Header, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
 	defer cancel()
 
 	res, err := hb.client().SearchOrganizations(ctx, &params)
 	if err != nil {
 		logging.Log().Error("error while searching for organizations", err)
-		return nil, core.Wrap(err)
+		return nil, nil, core.Wrap(err)
 	}
 
 	parsed, err := ParseSearchOrganizationsResponse(res)
 	if err != nil {
 		logging.Log().Error("error while reading response body", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	if parsed.StatusCode() == http.StatusNotFound {
-		return nil, ErrOrganizationNotFound
+		return nil, nil, ErrOrganizationNotFound
 	}
 	if err := testResponseCode(http.StatusOK, res); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var organizations []Organization
 
 	if err := json.Unmarshal(parsed.Body, &organizations); err != nil {
 		logging.Log().Error("could not unmarshal response body")
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, org := range organizations {
@@ -194,11 +297,11 @@ func (hb HttpClient) searchOrganization(params SearchOrganizationsParams) ([]db.
 		}
 	}
 
-	return organizationsToDb(organizations), nil
+	return organizationsToDb(organizations), res.Header, nil
 }
 
 // RegisterEndpoint is the client Api implementation for registering an endpoint for an organisation.
-func (hb HttpClient) RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, properties map[string]string) (events.Event, error) {
+func (hb HttpClient) RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, notBefore *time.Time, notAfter *time.Time, properties map[string]string) (events.Event, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
 	defer cancel()
 	res, err := hb.client().RegisterEndpoint(ctx, organizationID.String(), RegisterEndpointJSONRequestBody{
@@ -206,6 +309,8 @@ func (hb HttpClient) RegisterEndpoint(organizationID core.PartyID, id string, ur
 		EndpointType: endpointType,
 		Identifier:   Identifier(id),
 		Status:       status,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
 		Properties:   toEndpointProperties(properties),
 	})
 	if err != nil {
@@ -247,12 +352,76 @@ func (hb HttpClient) RegisterVendor(certificate *x509.Certificate) (events.Event
 	return testAndParseEventResponse(res)
 }
 
+// DeregisterEndpoint is the client Api implementation for deregistering an endpoint of an organization.
+func (hb HttpClient) DeregisterEndpoint(organizationID core.PartyID, endpointID types.EndpointID) (events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().DeregisterEndpoint(ctx, organizationID.String(), string(endpointID))
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseDeregistrationResponse(response, pkg.ErrOrganizationNotFound, pkg.ErrEndpointNotFound)
+}
+
+// EndVendorClaim is the client Api implementation for ending the current vendor's claim on an organization.
+func (hb HttpClient) EndVendorClaim(organizationID core.PartyID) (events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().EndVendorClaim(ctx, organizationID.String())
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseDeregistrationResponse(response, pkg.ErrOrganizationNotFound)
+}
+
+// RetireVendor is the client Api implementation for retiring a vendor.
+func (hb HttpClient) RetireVendor(vendorID core.PartyID) (events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().RetireVendor(ctx, vendorID.String())
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseDeregistrationResponse(response, pkg.ErrVendorNotFound)
+}
+
+// testAndParseDeregistrationResponse parses the event in the response, reconstructing the errors (so they can be tested
+// with errors.Is) when not successful. The first of notFoundErrs is returned when the response body doesn't specify which applies.
+func testAndParseDeregistrationResponse(response *http.Response, notFoundErrs ...error) (events.Event, error) {
+	if response.StatusCode == http.StatusNotFound {
+		body, _ := ioutil.ReadAll(response.Body)
+		for _, notFoundErr := range notFoundErrs {
+			if string(body) == notFoundErr.Error() {
+				return nil, notFoundErr
+			}
+		}
+		return nil, notFoundErrs[0]
+	}
+	if response.StatusCode == http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(response.Body)
+		if strings.HasPrefix(string(body), pkg.ErrNotManaged.Error()) {
+			return nil, fmt.Errorf("%w%s", pkg.ErrNotManaged, strings.TrimPrefix(string(body), pkg.ErrNotManaged.Error()))
+		}
+		return nil, errors.New(string(body))
+	}
+	return testAndParseEventResponse(response)
+}
+
 // OrganizationById is the client Api implementation for getting an organization based on its Id.
 func (hb HttpClient) OrganizationById(id core.PartyID) (*db.Organization, error) {
+	return hb.organizationById(id, nil)
+}
+
+// OrganizationByIdAsOf is the client Api implementation for getting an organization as it was registered at the given moment.
+func (hb HttpClient) OrganizationByIdAsOf(id core.PartyID, moment time.Time) (*db.Organization, error) {
+	return hb.organizationById(id, formatAsOf(moment))
+}
+
+func (hb HttpClient) organizationById(id core.PartyID, asOf *string) (*db.Organization, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
 	defer cancel()
 
-	res, err := hb.client().OrganizationById(ctx, id.String())
+	res, err := hb.client().OrganizationById(ctx, id.String(), &OrganizationByIdParams{AsOf: asOf})
 	if err != nil {
 		logging.Log().Error("error while getting organization by id", err)
 		return nil, core.Wrap(err)
@@ -284,10 +453,19 @@ func (hb HttpClient) OrganizationById(id core.PartyID) (*db.Organization, error)
 
 // VendorById is the client Api implementation for getting a vendor based on its Id.
 func (hb HttpClient) VendorById(id core.PartyID) (*db.Vendor, error) {
+	return hb.vendorById(id, nil)
+}
+
+// VendorByIdAsOf is the client Api implementation for getting a vendor as it was registered at the given moment.
+func (hb HttpClient) VendorByIdAsOf(id core.PartyID, moment time.Time) (*db.Vendor, error) {
+	return hb.vendorById(id, formatAsOf(moment))
+}
+
+func (hb HttpClient) vendorById(id core.PartyID, asOf *string) (*db.Vendor, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
 	defer cancel()
 
-	res, err := hb.client().VendorById(ctx, id.String())
+	res, err := hb.client().VendorById(ctx, id.String(), &VendorByIdParams{AsOf: asOf})
 	if err != nil {
 		logging.Log().Error("error while getting vendor by id", err)
 		return nil, core.Wrap(err)
@@ -312,11 +490,300 @@ func (hb HttpClient) VendorById(id core.PartyID) (*db.Vendor, error) {
 	return &o, nil
 }
 
+// VendorHistory returns the event history of the vendor. When not found it returns an ErrVendorNotFound error.
+func (hb HttpClient) VendorHistory(vendorID core.PartyID) ([]events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().VendorHistory(ctx, vendorID.String())
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseHistoryResponse(response, pkg.ErrVendorNotFound)
+}
+
+// OrganizationHistory returns the event history of the organization. When not found it returns an ErrOrganizationNotFound error.
+func (hb HttpClient) OrganizationHistory(organizationID core.PartyID) ([]events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().OrganizationHistory(ctx, organizationID.String())
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseHistoryResponse(response, pkg.ErrOrganizationNotFound)
+}
+
+// EndpointHistory returns the event history of the endpoint. When not found it returns an ErrEndpointNotFound error.
+func (hb HttpClient) EndpointHistory(organizationID core.PartyID, endpointID types.EndpointID) ([]events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().EndpointHistory(ctx, organizationID.String(), string(endpointID))
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	return testAndParseHistoryResponse(response, pkg.ErrEndpointNotFound)
+}
+
+// ParkedEvents returns the events which couldn't be applied (yet) and are parked in the retry queue.
+func (hb HttpClient) ParkedEvents() ([]events.ParkedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().ListParkedEvents(ctx)
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var entries []altParkedEvent
+	if err := json.Unmarshal(responseData, &entries); err != nil {
+		return nil, err
+	}
+	result := make([]events.ParkedEvent, len(entries))
+	for i, entry := range entries {
+		parked, err := entry.toParkedEvent()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = *parked
+	}
+	return result, nil
+}
+
+// ParkedEvent returns the parked event with the given ref. When not found it returns an events.ErrUnknownEvent error.
+func (hb HttpClient) ParkedEvent(ref events.Ref) (*events.ParkedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().GetParkedEvent(ctx, ref.String())
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, events.ErrUnknownEvent
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	entry := altParkedEvent{}
+	if err := json.Unmarshal(responseData, &entry); err != nil {
+		return nil, err
+	}
+	return entry.toParkedEvent()
+}
+
+// RetryParkedEvent tries to apply the parked event with the given ref. If it still can't be applied the reason is
+// returned as error. When not found it returns an events.ErrUnknownEvent error.
+func (hb HttpClient) RetryParkedEvent(ref events.Ref) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().RetryParkedEvent(ctx, ref.String())
+	if err != nil {
+		return core.Wrap(err)
+	}
+	if response.StatusCode == http.StatusConflict {
+		reason, _ := ioutil.ReadAll(response.Body)
+		return errors.New(string(reason))
+	}
+	return testParkedEventResponse(response)
+}
+
+// DiscardParkedEvent removes the parked event with the given ref from the retry queue. When not found it returns an
+// events.ErrUnknownEvent error.
+func (hb HttpClient) DiscardParkedEvent(ref events.Ref) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().DiscardParkedEvent(ctx, ref.String())
+	if err != nil {
+		return core.Wrap(err)
+	}
+	return testParkedEventResponse(response)
+}
+
+// Forks is the client Api implementation for listing the event paths which forked.
+func (hb HttpClient) Forks() ([]events.Fork, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().ListForks(ctx)
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var entries []altFork
+	if err := json.Unmarshal(responseData, &entries); err != nil {
+		return nil, err
+	}
+	result := make([]events.Fork, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.toFork()
+	}
+	return result, nil
+}
+
+// MergeFork is the client Api implementation for merging a fork.
+func (hb HttpClient) MergeFork(parent events.Ref, keep events.Ref) (events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	request := MergeForkJSONRequestBody{}
+	if !keep.IsZero() {
+		keepStr := keep.String()
+		request.Keep = &keepStr
+	}
+	response, err := hb.client().MergeFork(ctx, parent.String(), request)
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, pkg.ErrForkNotFound
+	}
+	if response.StatusCode == http.StatusBadRequest {
+		// Reconstruct the error, so it can be tested with errors.Is
+		body, _ := ioutil.ReadAll(response.Body)
+		return nil, fmt.Errorf("%w%s", pkg.ErrForkNotMergeable, strings.TrimPrefix(string(body), pkg.ErrForkNotMergeable.Error()))
+	}
+	return testAndParseEventResponse(response)
+}
+
+// Digest is the client Api implementation for calculating the state digest over the refs of all applied events.
+func (hb HttpClient) Digest(includeRefs bool) (*digest.Digest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().GetStateDigest(ctx, &GetStateDigestParams{Refs: &includeRefs})
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := digest.Digest{}
+	if err := json.Unmarshal(responseData, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Export is the client Api implementation for exporting all events as tar.gz bundle.
+func (hb HttpClient) Export(writer io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().ExportEvents(ctx)
+	if err != nil {
+		return core.Wrap(err)
+	}
+	defer response.Body.Close()
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, response.Body)
+	return err
+}
+
+// Import is the client Api implementation for validating and (optionally) importing a tar.gz bundle of events.
+func (hb HttpClient) Import(reader io.Reader, apply bool) (*bundle.ImportReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.Timeout)
+	defer cancel()
+	response, err := hb.client().ImportEventsWithBody(ctx, &ImportEventsParams{Apply: &apply}, "application/gzip", reader)
+	if err != nil {
+		return nil, core.Wrap(err)
+	}
+	if response.StatusCode == http.StatusBadRequest {
+		// Reconstruct the error, so it can be tested with errors.Is
+		body, _ := ioutil.ReadAll(response.Body)
+		return nil, fmt.Errorf("%w%s", pkg.ErrInvalidBundle, strings.TrimPrefix(string(body), pkg.ErrInvalidBundle.Error()))
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	report := bundle.ImportReport{}
+	if err := json.Unmarshal(responseData, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func testParkedEventResponse(response *http.Response) error {
+	if response.StatusCode == http.StatusNotFound {
+		return events.ErrUnknownEvent
+	}
+	return testResponseCode(http.StatusNoContent, response)
+}
+
 // VendorCAs on the client is not implemented
 func (hb HttpClient) VendorCAs() [][]*x509.Certificate {
 	return [][]*x509.Certificate{}
 }
 
+// maxServerSentEventSize specifies the maximum size of a line in the event stream.
+const maxServerSentEventSize = 1024 * 1024
+
+// Subscribe is the client Api implementation for streaming the events applied by the registry.
+func (hb HttpClient) Subscribe(ctx context.Context, from events.Ref) (<-chan events.Event, error) {
+	params := &StreamEventsParams{}
+	if !from.IsZero() {
+		fromStr := from.String()
+		params.From = &fromStr
+	}
+	response, err := hb.client().StreamEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	result := make(chan events.Event)
+	go func() {
+		defer close(result)
+		defer response.Body.Close()
+		scanner := bufio.NewScanner(response.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxServerSentEventSize)
+		var data []byte
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "data:") {
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+				continue
+			}
+			if line != "" || len(data) == 0 {
+				// Other fields (id, event) and comments aren't needed, since the data contains the complete event
+				continue
+			}
+			event, err := events.EventFromJSON(data)
+			data = nil
+			if err != nil {
+				logging.Log().WithError(err).Error("Unable to parse event from event stream, ending subscription.")
+				return
+			}
+			select {
+			case result <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
 func testResponseCode(expectedStatusCode int, response *http.Response) error {
 	if response.StatusCode != expectedStatusCode {
 		responseData, _ := ioutil.ReadAll(response.Body)
@@ -336,3 +803,33 @@ func testAndParseEventResponse(response *http.Response) (events.Event, error) {
 	}
 	return events.EventFromJSON(responseData)
 }
+
+func testAndParseHistoryResponse(response *http.Response, notFoundErr error) ([]events.Event, error) {
+	if response.StatusCode == http.StatusNotFound {
+		return nil, notFoundErr
+	}
+	if err := testResponseCode(http.StatusOK, response); err != nil {
+		return nil, err
+	}
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var entries []altEventHistoryEntry
+	if err := json.Unmarshal(responseData, &entries); err != nil {
+		return nil, err
+	}
+	result := make([]events.Event, len(entries))
+	for i, entry := range entries {
+		if result[i], err = events.EventFromJSON(entry.Event); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// formatAsOf formats the given moment as asOf query parameter.
+func formatAsOf(moment time.Time) *string {
+	result := moment.Format(time.RFC3339Nano)
+	return &result
+}
@@ -20,6 +20,8 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -27,11 +29,17 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/nuts-foundation/nuts-registry/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
 	"github.com/nuts-foundation/nuts-registry/test"
 
 	"github.com/stretchr/testify/assert"
@@ -108,18 +116,171 @@ func TestHttpClient_VendorById(t *testing.T) {
 	})
 }
 
+func TestHttpClient_AsOf(t *testing.T) {
+	moment := time.Date(2020, 10, 20, 20, 30, 0, 0, time.UTC)
+	var asOf string
+	// receiver records the asOf query parameter and responds with the given data
+	receiver := func(data interface{}) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			asOf = req.URL.Query().Get("asOf")
+			responseData, _ := json.Marshal(data)
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write(responseData)
+		}))
+	}
+	t.Run("organization", func(t *testing.T) {
+		s := receiver(organizations[0])
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		res, err := c.OrganizationByIdAsOf(test.OrganizationID("id"), moment)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, organizations[0].Identifier, res.Identifier)
+		assert.Equal(t, "2020-10-20T20:30:00Z", asOf)
+	})
+	t.Run("vendor", func(t *testing.T) {
+		s := receiver(vendors[0])
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		res, err := c.VendorByIdAsOf(test.VendorID("id"), moment)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, vendors[0].Identifier, res.Identifier)
+		assert.Equal(t, "2020-10-20T20:30:00Z", asOf)
+	})
+	t.Run("endpoints", func(t *testing.T) {
+		s := receiver(endpointsFromDb(endpoints))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		res, err := c.EndpointsByOrganizationAndTypeAsOf(test.OrganizationID("value"), nil, moment)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, res, 1)
+		assert.Equal(t, "2020-10-20T20:30:00Z", asOf)
+	})
+}
+
 func TestHttpClient_SearchOrganizations(t *testing.T) {
 	t.Run("200", func(t *testing.T) {
 		org, _ := json.Marshal(organizations)
 		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: org})
 		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
 
-		res, err := c.SearchOrganizations("query")
+		res, err := c.SearchOrganizations("query", db.SearchOptions{})
+
+		if assert.Nil(t, err) {
+			assert.Equal(t, 2, len(res.Organizations))
+			assert.Equal(t, 2, res.Total)
+		}
+	})
+	t.Run("200 - paginated", func(t *testing.T) {
+		org, _ := json.Marshal(organizations[1:])
+		var query url.Values
+		s := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			query = req.URL.Query()
+			writer.Header().Set("X-Total-Count", "2")
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write(org)
+		}))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		res, err := c.SearchOrganizations("query", db.SearchOptions{Offset: 1, Limit: 1})
+
+		if assert.Nil(t, err) {
+			assert.Len(t, res.Organizations, 1)
+			assert.Equal(t, 2, res.Total)
+			assert.Equal(t, "1", query.Get("offset"))
+			assert.Equal(t, "1", query.Get("limit"))
+		}
+	})
+	t.Run("error - invalid total count", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			writer.Header().Set("X-Total-Count", "many")
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write([]byte("[]"))
+		}))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		_, err := c.SearchOrganizations("query", db.SearchOptions{})
+
+		assert.EqualError(t, err, "invalid X-Total-Count header: many")
+	})
+}
+
+func TestHttpClient_SearchEndpoints(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		data, _ := json.Marshal([]OrganizationEndpoint{
+			OrganizationEndpoint{}.fromDb(db.OrganizationEndpoint{Endpoint: endpoints[0], Organization: organizations[0]}),
+		})
+		var query url.Values
+		s := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			query = req.URL.Query()
+			writer.Header().Set("X-Total-Count", "2")
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write(data)
+		}))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		res, err := c.SearchEndpoints(db.EndpointQuery{
+			EndpointType:       "fhir",
+			Status:             db.StatusActive,
+			Vendor:             test.VendorID("v1"),
+			Domain:             types.HealthcareDomain,
+			Properties:         map[string]string{"version": "r4", "auth": "oauth"},
+			IncludeUnavailable: true,
+		}, db.SearchOptions{Offset: 1, Limit: 1})
+
+		if assert.Nil(t, err) && assert.Len(t, res.Endpoints, 1) {
+			assert.Equal(t, endpoints[0].Identifier, res.Endpoints[0].Endpoint.Identifier)
+			assert.Equal(t, organizations[0].Identifier, res.Endpoints[0].Organization.Identifier)
+			assert.Equal(t, 2, res.Total)
+			assert.Equal(t, "fhir", query.Get("type"))
+			assert.Equal(t, db.StatusActive, query.Get("status"))
+			assert.Equal(t, test.VendorID("v1").String(), query.Get("vendor"))
+			assert.Equal(t, types.HealthcareDomain, query.Get("domain"))
+			assert.Equal(t, []string{"auth=oauth", "version=r4"}, query["property"])
+			assert.Equal(t, "true", query.Get("includeUnavailable"))
+			assert.Equal(t, "1", query.Get("offset"))
+			assert.Equal(t, "1", query.Get("limit"))
+		}
+	})
+	t.Run("200 - no criteria", func(t *testing.T) {
+		var query url.Values
+		s := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			query = req.URL.Query()
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write([]byte("[]"))
+		}))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		res, err := c.SearchEndpoints(db.EndpointQuery{}, db.SearchOptions{})
 
 		if assert.Nil(t, err) {
-			assert.Equal(t, 2, len(res))
+			assert.Empty(t, res.Endpoints)
+			assert.Equal(t, 0, res.Total)
+			assert.Empty(t, query)
 		}
 	})
+	t.Run("error - 400", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusBadRequest, responseData: []byte("invalid property")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		_, err := c.SearchEndpoints(db.EndpointQuery{}, db.SearchOptions{})
+
+		assert.Error(t, err)
+	})
+	t.Run("error - invalid total count", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			writer.Header().Set("X-Total-Count", "many")
+			writer.WriteHeader(http.StatusOK)
+			_, _ = writer.Write([]byte("[]"))
+		}))
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		_, err := c.SearchEndpoints(db.EndpointQuery{}, db.SearchOptions{})
+
+		assert.EqualError(t, err, "invalid X-Total-Count header: many")
+	})
 }
 
 func TestHttpClient_ReverseLookup(t *testing.T) {
@@ -261,7 +422,7 @@ func TestHttpClient_RegisterEndpoint(t *testing.T) {
 		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: event.Marshal()})
 		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
 
-		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", map[string]string{"foo": "bar"})
+		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", nil, nil, map[string]string{"foo": "bar"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -271,13 +432,13 @@ func TestHttpClient_RegisterEndpoint(t *testing.T) {
 		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: []byte{}})
 		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
 
-		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", nil)
+		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", nil, nil, nil)
 		assert.EqualError(t, err, "registry returned HTTP 500 (expected: 200), response: ", "error")
 		assert.Nil(t, event)
 	})
 	t.Run("http execution error", func(t *testing.T) {
 		c := HttpClient{ServerAddress: "localhost:9876", Timeout: time.Second}
-		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", map[string]string{"foo": "bar"})
+		event, err := c.RegisterEndpoint(test.OrganizationID("orgId"), "id", "url", "type", "status", nil, nil, map[string]string{"foo": "bar"})
 		assert.Contains(t, err.Error(), "connection refused")
 		assert.Nil(t, event)
 	})
@@ -341,3 +502,304 @@ func TestHttpClient_EndpointsByOrganizationAndType(t *testing.T) {
 		assert.Nil(t, event)
 	})
 }
+
+func TestHttpClient_Subscribe(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		event1 := events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{OrgName: "org1"}, nil)
+		event2 := events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{OrgName: "org2"}, nil)
+		responseData := new(bytes.Buffer)
+		_ = writeServerSentEvent(responseData, event1)
+		responseData.WriteString(": comment\n\n")
+		_ = writeServerSentEvent(responseData, event2)
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData.Bytes()})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		stream, err := c.Subscribe(context.Background(), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var received []events.Event
+		for event := range stream {
+			received = append(received, event)
+		}
+		if !assert.Len(t, received, 2) {
+			return
+		}
+		assert.Equal(t, event1.Ref(), received[0].Ref())
+		assert.Equal(t, event2.Ref(), received[1].Ref())
+	})
+	t.Run("error - http status 400", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusBadRequest, responseData: []byte("unknown event")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+
+		stream, err := c.Subscribe(context.Background(), events.Ref{1, 2, 3})
+		assert.EqualError(t, err, "registry returned HTTP 400 (expected: 200), response: unknown event")
+		assert.Nil(t, stream)
+	})
+}
+
+func TestHttpClient_History(t *testing.T) {
+	event1 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "a"}, nil)
+	event2 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "b"}, event1.Ref())
+	responseData, _ := json.Marshal([]altEventHistoryEntry{{Event: event1.Marshal()}, {Event: event2.Marshal()}})
+
+	t.Run("ok", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		for _, fn := range []func() ([]events.Event, error){
+			func() ([]events.Event, error) { return c.VendorHistory(test.VendorID("vendor")) },
+			func() ([]events.Event, error) { return c.OrganizationHistory(test.OrganizationID("org")) },
+			func() ([]events.Event, error) { return c.EndpointHistory(test.OrganizationID("org"), "endpoint") },
+		} {
+			history, err := fn()
+			if !assert.NoError(t, err) {
+				return
+			}
+			if !assert.Len(t, history, 2) {
+				return
+			}
+			assert.Equal(t, event1.Ref(), history[0].Ref())
+			assert.Equal(t, event2.Ref(), history[1].Ref())
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.VendorHistory(test.VendorID("vendor"))
+		assert.Equal(t, pkg.ErrVendorNotFound, err)
+		_, err = c.OrganizationHistory(test.OrganizationID("org"))
+		assert.Equal(t, pkg.ErrOrganizationNotFound, err)
+		_, err = c.EndpointHistory(test.OrganizationID("org"), "endpoint")
+		assert.Equal(t, pkg.ErrEndpointNotFound, err)
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.VendorHistory(test.VendorID("vendor"))
+		assert.EqualError(t, err, "registry returned HTTP 500 (expected: 200), response: error reason")
+	})
+}
+
+func TestHttpClient_RetryQueue(t *testing.T) {
+	event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "a"}, nil)
+	lastError := "failed"
+	entry := altParkedEvent{Event: event.Marshal(), State: "pending", Attempts: 1, LastError: &lastError, ParkedAt: time.Now()}
+
+	t.Run("list", func(t *testing.T) {
+		responseData, _ := json.Marshal([]altParkedEvent{entry})
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		parked, err := c.ParkedEvents()
+		if !assert.NoError(t, err) || !assert.Len(t, parked, 1) {
+			return
+		}
+		assert.Equal(t, event.Ref(), parked[0].Event.Ref())
+		assert.Equal(t, events.ParkedEventPending, parked[0].State)
+		assert.Equal(t, "failed", parked[0].LastError)
+		assert.True(t, parked[0].LastAttempt.IsZero())
+	})
+	t.Run("get", func(t *testing.T) {
+		responseData, _ := json.Marshal(entry)
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		parked, err := c.ParkedEvent(event.Ref())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), parked.Event.Ref())
+		assert.Equal(t, 1, parked.Attempts)
+	})
+	t.Run("retry and discard", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNoContent})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		assert.NoError(t, c.RetryParkedEvent(event.Ref()))
+		assert.NoError(t, c.DiscardParkedEvent(event.Ref()))
+	})
+	t.Run("retry - still failing", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusConflict, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		assert.EqualError(t, c.RetryParkedEvent(event.Ref()), "error reason")
+	})
+	t.Run("not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.ParkedEvent(event.Ref())
+		assert.Equal(t, events.ErrUnknownEvent, err)
+		assert.Equal(t, events.ErrUnknownEvent, c.RetryParkedEvent(event.Ref()))
+		assert.Equal(t, events.ErrUnknownEvent, c.DiscardParkedEvent(event.Ref()))
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.ParkedEvents()
+		assert.EqualError(t, err, "registry returned HTTP 500 (expected: 200), response: error reason")
+		assert.EqualError(t, c.DiscardParkedEvent(event.Ref()), "registry returned HTTP 500 (expected: 204), response: error reason")
+	})
+}
+
+func TestHttpClient_Forks(t *testing.T) {
+	parent := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	branch1 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "1"}, parent.Ref())
+	branch2 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "2"}, parent.Ref())
+	fork := events.Fork{Parent: parent.Ref(), Branches: []events.ForkBranch{
+		{First: branch1.Ref(), Last: branch1.Ref(), Canonical: true},
+		{First: branch2.Ref(), Last: branch2.Ref()},
+	}}
+
+	t.Run("list", func(t *testing.T) {
+		responseData, _ := json.Marshal([]altFork{altFork{}.fromFork(fork)})
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		forks, err := c.Forks()
+		if !assert.NoError(t, err) || !assert.Len(t, forks, 1) {
+			return
+		}
+		assert.Equal(t, fork, forks[0])
+	})
+	t.Run("merge", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: branch1.Marshal()})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		event, err := c.MergeFork(parent.Ref(), branch2.Ref())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, branch1.Ref(), event.Ref())
+	})
+	t.Run("merge - not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.MergeFork(parent.Ref(), nil)
+		assert.Equal(t, pkg.ErrForkNotFound, err)
+	})
+	t.Run("merge - not mergeable", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusBadRequest, responseData: []byte("fork can't be merged: reason")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.MergeFork(parent.Ref(), nil)
+		assert.True(t, errors.Is(err, pkg.ErrForkNotMergeable))
+		assert.EqualError(t, err, "fork can't be merged: reason")
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.Forks()
+		assert.EqualError(t, err, "registry returned HTTP 500 (expected: 200), response: error reason")
+	})
+}
+
+func TestHttpClient_Deregistration(t *testing.T) {
+	orgID := test.OrganizationID("org")
+	event := events.CreateEvent(domain.DeregisterEndpoint, domain.DeregisterEndpointEvent{Organization: orgID, Identifier: "endpoint"}, nil)
+
+	t.Run("deregister endpoint", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: event.Marshal()})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		result, err := c.DeregisterEndpoint(orgID, "endpoint")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), result.Ref())
+	})
+	t.Run("deregister endpoint - endpoint not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: []byte(pkg.ErrEndpointNotFound.Error())})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.DeregisterEndpoint(orgID, "endpoint")
+		assert.Equal(t, pkg.ErrEndpointNotFound, err)
+	})
+	t.Run("deregister endpoint - organization not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: []byte(pkg.ErrOrganizationNotFound.Error())})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.DeregisterEndpoint(orgID, "endpoint")
+		assert.Equal(t, pkg.ErrOrganizationNotFound, err)
+	})
+	t.Run("end vendor claim - not managed", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusBadRequest, responseData: []byte("not managed by this node: reason")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.EndVendorClaim(orgID)
+		assert.True(t, errors.Is(err, pkg.ErrNotManaged))
+		assert.EqualError(t, err, "not managed by this node: reason")
+	})
+	t.Run("retire vendor - not found", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusNotFound, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.RetireVendor(test.VendorID("vendor"))
+		assert.Equal(t, pkg.ErrVendorNotFound, err)
+	})
+	t.Run("retire vendor - error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.RetireVendor(test.VendorID("vendor"))
+		assert.EqualError(t, err, "registry returned HTTP 500 (expected: 200), response: error reason")
+	})
+}
+
+func TestHttpClient_Export(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: []byte("bundle")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		buf := new(bytes.Buffer)
+		err := c.Export(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "bundle", buf.String())
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		buf := new(bytes.Buffer)
+		err := c.Export(buf)
+		assert.Contains(t, err.Error(), "registry returned HTTP 500")
+		assert.Empty(t, buf.Bytes())
+	})
+}
+
+func TestHttpClient_Digest(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		expected := digest.Digest{Root: "root", EventCount: 1, Vendors: []digest.VendorDigest{
+			{Vendor: test.VendorID("vendor"), Digest: "vendor", EventCount: 1, Refs: []events.Ref{{1}}},
+		}}
+		responseData, _ := json.Marshal(expected)
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		actual, err := c.Digest(true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, expected, *actual)
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		actual, err := c.Digest(false)
+		assert.Contains(t, err.Error(), "registry returned HTTP 500")
+		assert.Nil(t, actual)
+	})
+}
+
+func TestHttpClient_Import(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		responseData, _ := json.Marshal(bundle.ImportReport{Applied: true, Events: []bundle.ImportReportEntry{{File: "events/1.json", Ref: []byte{1}, Outcome: bundle.ImportOutcomeApplied}}})
+		s := httptest.NewServer(handler{statusCode: http.StatusOK, responseData: responseData})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		report, err := c.Import(bytes.NewReader([]byte("bundle")), true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, report.Applied)
+		assert.Len(t, report.Events, 1)
+		assert.Equal(t, events.Ref{1}, report.Events[0].Ref)
+	})
+	t.Run("invalid bundle", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusBadRequest, responseData: []byte("invalid bundle: reason")})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.Import(bytes.NewReader([]byte("bundle")), false)
+		assert.True(t, errors.Is(err, pkg.ErrInvalidBundle))
+		assert.EqualError(t, err, "invalid bundle: reason")
+	})
+	t.Run("error", func(t *testing.T) {
+		s := httptest.NewServer(handler{statusCode: http.StatusInternalServerError, responseData: genericError})
+		c := HttpClient{ServerAddress: s.URL, Timeout: time.Second}
+		_, err := c.Import(bytes.NewReader([]byte("bundle")), false)
+		assert.Contains(t, err.Error(), "registry returned HTTP 500")
+	})
+}
@@ -34,6 +34,8 @@ func (e Endpoint) fromDb(db db.Endpoint) Endpoint {
 	e.EndpointType = db.EndpointType
 	e.Identifier = Identifier(db.Identifier)
 	e.Status = db.Status
+	e.NotBefore = db.NotBefore
+	e.NotAfter = db.NotAfter
 	e.Properties = toEndpointProperties(db.Properties)
 	return e
 }
@@ -87,6 +89,19 @@ func (o Organization) toDb() db.Organization {
 	return org
 }
 
+func (e OrganizationEndpoint) fromDb(db db.OrganizationEndpoint) OrganizationEndpoint {
+	e.Endpoint = Endpoint{}.fromDb(db.Endpoint)
+	e.Organization = Organization{}.fromDb(db.Organization)
+	return e
+}
+
+func (e OrganizationEndpoint) toDb() db.OrganizationEndpoint {
+	return db.OrganizationEndpoint{
+		Endpoint:     e.Endpoint.toDb(),
+		Organization: e.Organization.toDb(),
+	}
+}
+
 func (v Vendor) fromDb(db db.Vendor) Vendor {
 	id := Identifier(db.Identifier.String())
 	v.Identifier = &id
@@ -133,6 +148,8 @@ func (e Endpoint) toDb() db.Endpoint {
 		Identifier:   types.EndpointID(e.Identifier),
 		Organization: organizationID,
 		Status:       e.Status,
+		NotBefore:    e.NotBefore,
+		NotAfter:     e.NotAfter,
 		Properties:   fromEndpointProperties(e.Properties),
 	}
 }
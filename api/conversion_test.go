@@ -21,6 +21,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,16 @@ func TestVendorConversion(t *testing.T) {
 		assert.Equal(t, "EC", o.Keys[0].(JWK)["kty"].(string))
 	})
 }
+
+func TestEndpointConversion(t *testing.T) {
+	notBefore := time.Now()
+	notAfter := notBefore.Add(time.Hour)
+	t.Run("status and availability window are converted from and to DB", func(t *testing.T) {
+		e := Endpoint{}.fromDb(db.Endpoint{Status: db.StatusDeprecated, NotBefore: &notBefore, NotAfter: &notAfter})
+
+		assert.Equal(t, db.StatusDeprecated, e.Status)
+		assert.Equal(t, &notBefore, e.NotBefore)
+		assert.Equal(t, &notAfter, e.NotAfter)
+		assert.Equal(t, db.Endpoint{Status: db.StatusDeprecated, NotBefore: &notBefore, NotAfter: &notAfter, Properties: map[string]string{}}, e.toDb())
+	})
+}
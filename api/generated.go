@@ -51,13 +51,19 @@ type Endpoint struct {
 	// Generic identifier used for representing BSN, agbcode, etc. It's always constructed as an URN followed by a double colon (:) and then the identifying value of the given URN
 	Identifier Identifier `json:"identifier"`
 
+	// moment from which the endpoint is no longer available, stays available when absent.
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+
+	// moment from which the endpoint is available, available right away when absent.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+
 	// Generic identifier used for representing BSN, agbcode, etc. It's always constructed as an URN followed by a double colon (:) and then the identifying value of the given URN
 	Organization Identifier `json:"organization"`
 
 	// A property bag, containing extra properties for endpoints
 	Properties *EndpointProperties `json:"properties,omitempty"`
 
-	// status of the endpoint
+	// status of the endpoint: active endpoints are in use, endpoints in maintenance are temporarily out of use, deprecated endpoints can still be used but are going to be replaced and disabled endpoints are out of use.
 	Status string `json:"status"`
 }
 
@@ -80,12 +86,118 @@ type Event struct {
 	Type *string `json:"type,omitempty"`
 }
 
+// EventHistoryEntry defines model for EventHistoryEntry.
+type EventHistoryEntry struct {
+	Event Event `json:"event"`
+
+	// PEM encoded X.509 certificate the event was signed with, absent when the event isn't signed.
+	SignerCertificate *string `json:"signerCertificate,omitempty"`
+}
+
+// EventSchema defines model for EventSchema.
+type EventSchema struct {
+
+	// type of the event the schema applies to.
+	Type string `json:"type"`
+
+	// lowest event version the schema applies to.
+	Version int `json:"version"`
+}
+
+// ExportManifest defines model for ExportManifest.
+type ExportManifest struct {
+	CreatedAt time.Time             `json:"createdAt"`
+	Events    []ExportManifestEntry `json:"events"`
+
+	// Generic identifier used for representing BSN, agbcode, etc. It's always constructed as an URN followed by a double colon (:) and then the identifying value of the given URN
+	Vendor Identifier `json:"vendor"`
+}
+
+// ExportManifestEntry defines model for ExportManifestEntry.
+type ExportManifestEntry struct {
+	File string `json:"file"`
+	Ref  string `json:"ref"`
+
+	// hex-encoded SHA-256 hash of the event file.
+	Sha256 string `json:"sha256"`
+}
+
+// Fork defines model for Fork.
+type Fork struct {
+
+	// branches of the fork, the canonical branch first.
+	Branches []ForkBranch `json:"branches"`
+
+	// whether the canonical branch has been extended by an event issued after all events on the other branches.
+	Merged bool `json:"merged"`
+
+	// ref of the event the branches refer to.
+	Parent string `json:"parent"`
+}
+
+// ForkBranch defines model for ForkBranch.
+type ForkBranch struct {
+
+	// whether the branch is used.
+	Canonical bool `json:"canonical"`
+
+	// ref of the first event on the branch.
+	First string `json:"first"`
+
+	// ref of the last event on the branch.
+	Last string `json:"last"`
+}
+
 // Identifier defines model for Identifier.
 type Identifier string
 
+// ImportReport defines model for ImportReport.
+type ImportReport struct {
+
+	// whether the events have been imported, false for a dry run or when importing stopped at an error.
+	Applied bool `json:"applied"`
+
+	// the error at which importing stopped, only the events marked as imported have been imported.
+	Error *string `json:"error,omitempty"`
+
+	// outcome per event, in the order they're imported.
+	Events   []ImportReportEntry `json:"events"`
+	Manifest *ExportManifest     `json:"manifest,omitempty"`
+}
+
+// ImportReportEntry defines model for ImportReportEntry.
+type ImportReportEntry struct {
+
+	// path of the event file in the bundle.
+	File string `json:"file"`
+
+	// whether the event has actually been imported, only when applying the import.
+	Imported *bool `json:"imported,omitempty"`
+
+	// applied: the event is applied, duplicate: the event has already been applied or occurs earlier in the bundle,
+	// parked: the event refers to an event which is missing, rejected: the event is invalid.
+	Outcome string `json:"outcome"`
+
+	// why the event is parked or rejected.
+	Reason *string `json:"reason,omitempty"`
+
+	// ref of the event, absent when the file couldn't be parsed.
+	Ref *string `json:"ref,omitempty"`
+
+	// type of the event, absent when the file couldn't be parsed.
+	Type *string `json:"type,omitempty"`
+}
+
 // JWK defines model for JWK.
 type JWK map[string]interface{}
 
+// MergeForkRequest defines model for MergeForkRequest.
+type MergeForkRequest struct {
+
+	// ref of the last event of the branch whose data should be kept, defaults to the canonical branch.
+	Keep *string `json:"keep,omitempty"`
+}
+
 // Organization defines model for Organization.
 type Organization struct {
 	Endpoints *[]Endpoint `json:"endpoints,omitempty"`
@@ -101,6 +213,32 @@ type Organization struct {
 	PublicKey *string `json:"publicKey,omitempty"`
 }
 
+// OrganizationEndpoint defines model for OrganizationEndpoint.
+type OrganizationEndpoint struct {
+	Endpoint     Endpoint     `json:"endpoint"`
+	Organization Organization `json:"organization"`
+}
+
+// ParkedEvent defines model for ParkedEvent.
+type ParkedEvent struct {
+
+	// number of times applying the event failed.
+	Attempts int   `json:"attempts"`
+	Event    Event `json:"event"`
+
+	// moment applying the event failed the last time, absent when it hasn't been tried.
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+
+	// reason why the event couldn't be applied the last time it was tried.
+	LastError *string `json:"lastError,omitempty"`
+
+	// moment the event was parked.
+	ParkedAt time.Time `json:"parkedAt"`
+
+	// pending events are retried automatically, dead-lettered events only on request.
+	State string `json:"state"`
+}
+
 // RegisterEndpointEvent defines model for RegisterEndpointEvent.
 type RegisterEndpointEvent struct {
 
@@ -113,13 +251,19 @@ type RegisterEndpointEvent struct {
 	// Generic identifier used for representing BSN, agbcode, etc. It's always constructed as an URN followed by a double colon (:) and then the identifying value of the given URN
 	Identifier Identifier `json:"identifier"`
 
+	// moment from which the endpoint is no longer available, stays available when absent.
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+
+	// moment from which the endpoint is available, available right away when absent.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+
 	// Generic identifier used for representing BSN, agbcode, etc. It's always constructed as an URN followed by a double colon (:) and then the identifying value of the given URN
 	Organization Identifier `json:"organization"`
 
 	// A property bag, containing extra properties for endpoints
 	Properties *EndpointProperties `json:"properties,omitempty"`
 
-	// status of the endpoint
+	// status of the endpoint: active endpoints are in use, endpoints in maintenance are temporarily out of use, deprecated endpoints can still be used but are going to be replaced and disabled endpoints are out of use.
 	Status string `json:"status"`
 }
 
@@ -137,6 +281,19 @@ type RegisterVendorEvent struct {
 	OrgKeys *[]JWK `json:"orgKeys,omitempty"`
 }
 
+// StateDigest defines model for StateDigest.
+type StateDigest struct {
+
+	// number of events the digest has been calculated over.
+	EventCount int `json:"eventCount"`
+
+	// hex-encoded Merkle root over the vendor digests.
+	Root string `json:"root"`
+
+	// digests per vendor, ordered by vendor ID.
+	Vendors []VendorStateDigest `json:"vendors"`
+}
+
 // Vendor defines model for Vendor.
 type Vendor struct {
 
@@ -165,6 +322,39 @@ type VendorClaimEvent struct {
 	VendorIdentifier Identifier `json:"vendorIdentifier"`
 }
 
+// VendorStateDigest defines model for VendorStateDigest.
+type VendorStateDigest struct {
+
+	// hex-encoded Merkle root over the refs of the vendor's events.
+	Digest string `json:"digest"`
+
+	// number of events of the vendor.
+	EventCount int `json:"eventCount"`
+
+	// refs of the vendor's events (ordered), only present when requested.
+	Refs *[]string `json:"refs,omitempty"`
+
+	// ID of the vendor, empty for events which can't be attributed to a vendor.
+	Vendor string `json:"vendor"`
+}
+
+// GetStateDigestParams defines parameters for GetStateDigest.
+type GetStateDigestParams struct {
+
+	// Whether to include the refs of the events per vendor, which are required to list the events that differ.
+	Refs *bool `json:"refs,omitempty"`
+}
+
+// MergeForkJSONBody defines parameters for MergeFork.
+type MergeForkJSONBody MergeForkRequest
+
+// ImportEventsParams defines parameters for ImportEvents.
+type ImportEventsParams struct {
+
+	// Whether to import the events, otherwise only the report is returned (dry run).
+	Apply *bool `json:"apply,omitempty"`
+}
+
 // VerifyParams defines parameters for Verify.
 type VerifyParams struct {
 
@@ -183,11 +373,59 @@ type EndpointsByOrganisationIdParams struct {
 
 	// only return successfull result if each given organisation has an endpoint of the requested type, otherwise 400
 	Strict *bool `json:"strict,omitempty"`
+
+	// Returns the endpoints as registered at the given moment, rebuilt from the events issued at or before that moment (RFC3339). Rebuilding is expensive: only one rebuild runs at a time and the last rebuilds are cached until an event is applied, so querying moments which are close to each other is cheaper.
+	AsOf *string `json:"asOf,omitempty"`
+}
+
+// SearchEndpointsParams defines parameters for SearchEndpoints.
+type SearchEndpointsParams struct {
+
+	// The type of the endpoints, eg Nuts or FHIR
+	Type *string `json:"type,omitempty"`
+
+	// The status of the endpoints, only active and deprecated endpoints are returned when not specified.
+	Status *string `json:"status,omitempty"`
+
+	// Also return endpoints outside their availability window (notBefore and notAfter), e.g. to find endpoints which become available in the future. Only endpoints available at the moment are returned when not specified.
+	IncludeUnavailable *bool `json:"includeUnavailable,omitempty"`
+
+	// Identifier of the vendor which claimed the organizations of the endpoints.
+	Vendor *string `json:"vendor,omitempty"`
+
+	// Domain the vendor which claimed the organizations of the endpoints operates in.
+	Domain *Domain `json:"domain,omitempty"`
+
+	// Property the endpoints must have, specified as name=value. Can be specified multiple times.
+	Property *[]string `json:"property,omitempty"`
+
+	// Number of results to skip, for paging through the results.
+	Offset *int `json:"offset,omitempty"`
+
+	// Maximum number of results to return, all results when not specified.
+	Limit *int `json:"limit,omitempty"`
+}
+
+// StreamEventsParams defines parameters for StreamEvents.
+type StreamEventsParams struct {
+
+	// Ref of the last received event, only events applied after it are streamed.
+	From *string `json:"from,omitempty"`
+
+	// Ref of the last received event, as sent by SSE clients when reconnecting. Ignored when from is specified.
+	LastEventID *string `json:"Last-Event-ID,omitempty"`
 }
 
 // VendorClaimJSONBody defines parameters for VendorClaim.
 type VendorClaimJSONBody Organization
 
+// OrganizationByIdParams defines parameters for OrganizationById.
+type OrganizationByIdParams struct {
+
+	// Returns the organization as registered at the given moment, rebuilt from the events issued at or before that moment (RFC3339). Rebuilding is expensive: only one rebuild runs at a time and the last rebuilds are cached until an event is applied, so querying moments which are close to each other is cheaper.
+	AsOf *string `json:"asOf,omitempty"`
+}
+
 // RegisterEndpointJSONBody defines parameters for RegisterEndpoint.
 type RegisterEndpointJSONBody Endpoint
 
@@ -199,11 +437,27 @@ type SearchOrganizationsParams struct {
 
 	// Only return exact matches, for reverse lookup
 	Exact *bool `json:"exact,omitempty"`
+
+	// Number of (ranked) results to skip, for paging through the results.
+	Offset *int `json:"offset,omitempty"`
+
+	// Maximum number of results to return, all results when not specified.
+	Limit *int `json:"limit,omitempty"`
+}
+
+// VendorByIdParams defines parameters for VendorById.
+type VendorByIdParams struct {
+
+	// Returns the vendor as registered at the given moment, rebuilt from the events issued at or before that moment (RFC3339). Rebuilding is expensive: only one rebuild runs at a time and the last rebuilds are cached until an event is applied, so querying moments which are close to each other is cheaper.
+	AsOf *string `json:"asOf,omitempty"`
 }
 
 // DeprecatedVendorClaimJSONBody defines parameters for DeprecatedVendorClaim.
 type DeprecatedVendorClaimJSONBody Organization
 
+// MergeForkRequestBody defines body for MergeFork for application/json ContentType.
+type MergeForkJSONRequestBody MergeForkJSONBody
+
 // VendorClaimRequestBody defines body for VendorClaim for application/json ContentType.
 type VendorClaimJSONRequestBody VendorClaimJSONBody
 
@@ -286,12 +540,53 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// GetStateDigest request
+	GetStateDigest(ctx context.Context, params *GetStateDigestParams) (*http.Response, error)
+
+	// ExportEvents request
+	ExportEvents(ctx context.Context) (*http.Response, error)
+
+	// ListForks request
+	ListForks(ctx context.Context) (*http.Response, error)
+
+	// MergeFork request  with any body
+	MergeForkWithBody(ctx context.Context, ref string, contentType string, body io.Reader) (*http.Response, error)
+
+	MergeFork(ctx context.Context, ref string, body MergeForkJSONRequestBody) (*http.Response, error)
+
+	// ImportEvents request  with any body
+	ImportEventsWithBody(ctx context.Context, params *ImportEventsParams, contentType string, body io.Reader) (*http.Response, error)
+
+	// ListParkedEvents request
+	ListParkedEvents(ctx context.Context) (*http.Response, error)
+
+	// DiscardParkedEvent request
+	DiscardParkedEvent(ctx context.Context, ref string) (*http.Response, error)
+
+	// GetParkedEvent request
+	GetParkedEvent(ctx context.Context, ref string) (*http.Response, error)
+
+	// RetryParkedEvent request
+	RetryParkedEvent(ctx context.Context, ref string) (*http.Response, error)
+
 	// Verify request
 	Verify(ctx context.Context, params *VerifyParams) (*http.Response, error)
 
 	// EndpointsByOrganisationId request
 	EndpointsByOrganisationId(ctx context.Context, params *EndpointsByOrganisationIdParams) (*http.Response, error)
 
+	// SearchEndpoints request
+	SearchEndpoints(ctx context.Context, params *SearchEndpointsParams) (*http.Response, error)
+
+	// ListEventSchemas request
+	ListEventSchemas(ctx context.Context) (*http.Response, error)
+
+	// GetEventSchema request
+	GetEventSchema(ctx context.Context, pType string, version int) (*http.Response, error)
+
+	// StreamEvents request
+	StreamEvents(ctx context.Context, params *StreamEventsParams) (*http.Response, error)
+
 	// MTLSCAs request
 	MTLSCAs(ctx context.Context) (*http.Response, error)
 
@@ -303,34 +598,52 @@ type ClientInterface interface {
 
 	VendorClaim(ctx context.Context, body VendorClaimJSONRequestBody) (*http.Response, error)
 
+	// EndVendorClaim request
+	EndVendorClaim(ctx context.Context, id string) (*http.Response, error)
+
 	// OrganizationById request
-	OrganizationById(ctx context.Context, id string) (*http.Response, error)
+	OrganizationById(ctx context.Context, id string, params *OrganizationByIdParams) (*http.Response, error)
 
 	// RegisterEndpoint request  with any body
 	RegisterEndpointWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error)
 
 	RegisterEndpoint(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*http.Response, error)
 
+	// DeregisterEndpoint request
+	DeregisterEndpoint(ctx context.Context, id string, endpointId string) (*http.Response, error)
+
+	// EndpointHistory request
+	EndpointHistory(ctx context.Context, id string, endpointId string) (*http.Response, error)
+
+	// OrganizationHistory request
+	OrganizationHistory(ctx context.Context, id string) (*http.Response, error)
+
 	// RefreshOrganizationCertificate request
 	RefreshOrganizationCertificate(ctx context.Context, id string) (*http.Response, error)
 
 	// SearchOrganizations request
 	SearchOrganizations(ctx context.Context, params *SearchOrganizationsParams) (*http.Response, error)
 
+	// RetireVendor request
+	RetireVendor(ctx context.Context, id string) (*http.Response, error)
+
 	// VendorById request
-	VendorById(ctx context.Context, id string) (*http.Response, error)
+	VendorById(ctx context.Context, id string, params *VendorByIdParams) (*http.Response, error)
 
 	// DeprecatedVendorClaim request  with any body
 	DeprecatedVendorClaimWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error)
 
 	DeprecatedVendorClaim(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*http.Response, error)
 
+	// VendorHistory request
+	VendorHistory(ctx context.Context, id string) (*http.Response, error)
+
 	// RegisterVendor request  with any body
 	RegisterVendorWithBody(ctx context.Context, contentType string, body io.Reader) (*http.Response, error)
 }
 
-func (c *Client) Verify(ctx context.Context, params *VerifyParams) (*http.Response, error) {
-	req, err := NewVerifyRequest(c.Server, params)
+func (c *Client) GetStateDigest(ctx context.Context, params *GetStateDigestParams) (*http.Response, error) {
+	req, err := NewGetStateDigestRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -344,8 +657,8 @@ func (c *Client) Verify(ctx context.Context, params *VerifyParams) (*http.Respon
 	return c.Client.Do(req)
 }
 
-func (c *Client) EndpointsByOrganisationId(ctx context.Context, params *EndpointsByOrganisationIdParams) (*http.Response, error) {
-	req, err := NewEndpointsByOrganisationIdRequest(c.Server, params)
+func (c *Client) ExportEvents(ctx context.Context) (*http.Response, error) {
+	req, err := NewExportEventsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -359,8 +672,8 @@ func (c *Client) EndpointsByOrganisationId(ctx context.Context, params *Endpoint
 	return c.Client.Do(req)
 }
 
-func (c *Client) MTLSCAs(ctx context.Context) (*http.Response, error) {
-	req, err := NewMTLSCAsRequest(c.Server)
+func (c *Client) ListForks(ctx context.Context) (*http.Response, error) {
+	req, err := NewListForksRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -374,8 +687,8 @@ func (c *Client) MTLSCAs(ctx context.Context) (*http.Response, error) {
 	return c.Client.Do(req)
 }
 
-func (c *Client) MTLSCertificates(ctx context.Context) (*http.Response, error) {
-	req, err := NewMTLSCertificatesRequest(c.Server)
+func (c *Client) MergeForkWithBody(ctx context.Context, ref string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewMergeForkRequestWithBody(c.Server, ref, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -389,8 +702,8 @@ func (c *Client) MTLSCertificates(ctx context.Context) (*http.Response, error) {
 	return c.Client.Do(req)
 }
 
-func (c *Client) VendorClaimWithBody(ctx context.Context, contentType string, body io.Reader) (*http.Response, error) {
-	req, err := NewVendorClaimRequestWithBody(c.Server, contentType, body)
+func (c *Client) MergeFork(ctx context.Context, ref string, body MergeForkJSONRequestBody) (*http.Response, error) {
+	req, err := NewMergeForkRequest(c.Server, ref, body)
 	if err != nil {
 		return nil, err
 	}
@@ -404,8 +717,8 @@ func (c *Client) VendorClaimWithBody(ctx context.Context, contentType string, bo
 	return c.Client.Do(req)
 }
 
-func (c *Client) VendorClaim(ctx context.Context, body VendorClaimJSONRequestBody) (*http.Response, error) {
-	req, err := NewVendorClaimRequest(c.Server, body)
+func (c *Client) ImportEventsWithBody(ctx context.Context, params *ImportEventsParams, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewImportEventsRequestWithBody(c.Server, params, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -419,8 +732,8 @@ func (c *Client) VendorClaim(ctx context.Context, body VendorClaimJSONRequestBod
 	return c.Client.Do(req)
 }
 
-func (c *Client) OrganizationById(ctx context.Context, id string) (*http.Response, error) {
-	req, err := NewOrganizationByIdRequest(c.Server, id)
+func (c *Client) ListParkedEvents(ctx context.Context) (*http.Response, error) {
+	req, err := NewListParkedEventsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -434,8 +747,8 @@ func (c *Client) OrganizationById(ctx context.Context, id string) (*http.Respons
 	return c.Client.Do(req)
 }
 
-func (c *Client) RegisterEndpointWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error) {
-	req, err := NewRegisterEndpointRequestWithBody(c.Server, id, contentType, body)
+func (c *Client) DiscardParkedEvent(ctx context.Context, ref string) (*http.Response, error) {
+	req, err := NewDiscardParkedEventRequest(c.Server, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -449,8 +762,8 @@ func (c *Client) RegisterEndpointWithBody(ctx context.Context, id string, conten
 	return c.Client.Do(req)
 }
 
-func (c *Client) RegisterEndpoint(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*http.Response, error) {
-	req, err := NewRegisterEndpointRequest(c.Server, id, body)
+func (c *Client) GetParkedEvent(ctx context.Context, ref string) (*http.Response, error) {
+	req, err := NewGetParkedEventRequest(c.Server, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -464,8 +777,8 @@ func (c *Client) RegisterEndpoint(ctx context.Context, id string, body RegisterE
 	return c.Client.Do(req)
 }
 
-func (c *Client) RefreshOrganizationCertificate(ctx context.Context, id string) (*http.Response, error) {
-	req, err := NewRefreshOrganizationCertificateRequest(c.Server, id)
+func (c *Client) RetryParkedEvent(ctx context.Context, ref string) (*http.Response, error) {
+	req, err := NewRetryParkedEventRequest(c.Server, ref)
 	if err != nil {
 		return nil, err
 	}
@@ -479,8 +792,8 @@ func (c *Client) RefreshOrganizationCertificate(ctx context.Context, id string)
 	return c.Client.Do(req)
 }
 
-func (c *Client) SearchOrganizations(ctx context.Context, params *SearchOrganizationsParams) (*http.Response, error) {
-	req, err := NewSearchOrganizationsRequest(c.Server, params)
+func (c *Client) Verify(ctx context.Context, params *VerifyParams) (*http.Response, error) {
+	req, err := NewVerifyRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -494,8 +807,8 @@ func (c *Client) SearchOrganizations(ctx context.Context, params *SearchOrganiza
 	return c.Client.Do(req)
 }
 
-func (c *Client) VendorById(ctx context.Context, id string) (*http.Response, error) {
-	req, err := NewVendorByIdRequest(c.Server, id)
+func (c *Client) EndpointsByOrganisationId(ctx context.Context, params *EndpointsByOrganisationIdParams) (*http.Response, error) {
+	req, err := NewEndpointsByOrganisationIdRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -509,8 +822,8 @@ func (c *Client) VendorById(ctx context.Context, id string) (*http.Response, err
 	return c.Client.Do(req)
 }
 
-func (c *Client) DeprecatedVendorClaimWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error) {
-	req, err := NewDeprecatedVendorClaimRequestWithBody(c.Server, id, contentType, body)
+func (c *Client) SearchEndpoints(ctx context.Context, params *SearchEndpointsParams) (*http.Response, error) {
+	req, err := NewSearchEndpointsRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -524,8 +837,8 @@ func (c *Client) DeprecatedVendorClaimWithBody(ctx context.Context, id string, c
 	return c.Client.Do(req)
 }
 
-func (c *Client) DeprecatedVendorClaim(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*http.Response, error) {
-	req, err := NewDeprecatedVendorClaimRequest(c.Server, id, body)
+func (c *Client) ListEventSchemas(ctx context.Context) (*http.Response, error) {
+	req, err := NewListEventSchemasRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -539,8 +852,8 @@ func (c *Client) DeprecatedVendorClaim(ctx context.Context, id string, body Depr
 	return c.Client.Do(req)
 }
 
-func (c *Client) RegisterVendorWithBody(ctx context.Context, contentType string, body io.Reader) (*http.Response, error) {
-	req, err := NewRegisterVendorRequestWithBody(c.Server, contentType, body)
+func (c *Client) GetEventSchema(ctx context.Context, pType string, version int) (*http.Response, error) {
+	req, err := NewGetEventSchemaRequest(c.Server, pType, version)
 	if err != nil {
 		return nil, err
 	}
@@ -554,157 +867,308 @@ func (c *Client) RegisterVendorWithBody(ctx context.Context, contentType string,
 	return c.Client.Do(req)
 }
 
-// NewVerifyRequest generates requests for Verify
-func NewVerifyRequest(server string, params *VerifyParams) (*http.Request, error) {
-	var err error
-
-	queryUrl, err := url.Parse(server)
+func (c *Client) StreamEvents(ctx context.Context, params *StreamEventsParams) (*http.Response, error) {
+	req, err := NewStreamEventsRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	basePath := fmt.Sprintf("/api/admin/verify")
-	if basePath[0] == '/' {
-		basePath = basePath[1:]
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return c.Client.Do(req)
+}
 
-	queryUrl, err = queryUrl.Parse(basePath)
+func (c *Client) MTLSCAs(ctx context.Context) (*http.Response, error) {
+	req, err := NewMTLSCAsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	queryValues := queryUrl.Query()
-
-	if params.Fix != nil {
-
-		if queryFrag, err := runtime.StyleParam("form", true, "fix", *params.Fix); err != nil {
-			return nil, err
-		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
 			return nil, err
-		} else {
-			for k, v := range parsed {
-				for _, v2 := range v {
-					queryValues.Add(k, v2)
-				}
-			}
 		}
-
 	}
+	return c.Client.Do(req)
+}
 
-	queryUrl.RawQuery = queryValues.Encode()
-
-	req, err := http.NewRequest("POST", queryUrl.String(), nil)
+func (c *Client) MTLSCertificates(ctx context.Context) (*http.Response, error) {
+	req, err := NewMTLSCertificatesRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
 }
 
-// NewEndpointsByOrganisationIdRequest generates requests for EndpointsByOrganisationId
-func NewEndpointsByOrganisationIdRequest(server string, params *EndpointsByOrganisationIdParams) (*http.Request, error) {
-	var err error
-
-	queryUrl, err := url.Parse(server)
+func (c *Client) VendorClaimWithBody(ctx context.Context, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewVendorClaimRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-
-	basePath := fmt.Sprintf("/api/endpoints")
-	if basePath[0] == '/' {
-		basePath = basePath[1:]
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return c.Client.Do(req)
+}
 
-	queryUrl, err = queryUrl.Parse(basePath)
+func (c *Client) VendorClaim(ctx context.Context, body VendorClaimJSONRequestBody) (*http.Response, error) {
+	req, err := NewVendorClaimRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	queryValues := queryUrl.Query()
-
-	if queryFrag, err := runtime.StyleParam("form", true, "orgIds", params.OrgIds); err != nil {
-		return nil, err
-	} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+func (c *Client) EndVendorClaim(ctx context.Context, id string) (*http.Response, error) {
+	req, err := NewEndVendorClaimRequest(c.Server, id)
+	if err != nil {
 		return nil, err
-	} else {
-		for k, v := range parsed {
-			for _, v2 := range v {
-				queryValues.Add(k, v2)
-			}
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
 		}
 	}
+	return c.Client.Do(req)
+}
 
-	if params.Type != nil {
-
-		if queryFrag, err := runtime.StyleParam("form", true, "type", *params.Type); err != nil {
-			return nil, err
-		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+func (c *Client) OrganizationById(ctx context.Context, id string, params *OrganizationByIdParams) (*http.Response, error) {
+	req, err := NewOrganizationByIdRequest(c.Server, id, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
 			return nil, err
-		} else {
-			for k, v := range parsed {
-				for _, v2 := range v {
-					queryValues.Add(k, v2)
-				}
-			}
 		}
-
 	}
+	return c.Client.Do(req)
+}
 
-	if params.Strict != nil {
+func (c *Client) RegisterEndpointWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewRegisterEndpointRequestWithBody(c.Server, id, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-		if queryFrag, err := runtime.StyleParam("form", true, "strict", *params.Strict); err != nil {
+func (c *Client) RegisterEndpoint(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*http.Response, error) {
+	req, err := NewRegisterEndpointRequest(c.Server, id, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
 			return nil, err
-		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+		}
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeregisterEndpoint(ctx context.Context, id string, endpointId string) (*http.Response, error) {
+	req, err := NewDeregisterEndpointRequest(c.Server, id, endpointId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
 			return nil, err
-		} else {
-			for k, v := range parsed {
-				for _, v2 := range v {
-					queryValues.Add(k, v2)
-				}
-			}
 		}
+	}
+	return c.Client.Do(req)
+}
 
+func (c *Client) EndpointHistory(ctx context.Context, id string, endpointId string) (*http.Response, error) {
+	req, err := NewEndpointHistoryRequest(c.Server, id, endpointId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return c.Client.Do(req)
+}
 
-	queryUrl.RawQuery = queryValues.Encode()
+func (c *Client) OrganizationHistory(ctx context.Context, id string) (*http.Response, error) {
+	req, err := NewOrganizationHistoryRequest(c.Server, id)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+func (c *Client) RefreshOrganizationCertificate(ctx context.Context, id string) (*http.Response, error) {
+	req, err := NewRefreshOrganizationCertificateRequest(c.Server, id)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	return req, nil
+func (c *Client) SearchOrganizations(ctx context.Context, params *SearchOrganizationsParams) (*http.Response, error) {
+	req, err := NewSearchOrganizationsRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
 }
 
-// NewMTLSCAsRequest generates requests for MTLSCAs
-func NewMTLSCAsRequest(server string) (*http.Request, error) {
-	var err error
+func (c *Client) RetireVendor(ctx context.Context, id string) (*http.Response, error) {
+	req, err := NewRetireVendorRequest(c.Server, id)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	queryUrl, err := url.Parse(server)
+func (c *Client) VendorById(ctx context.Context, id string, params *VendorByIdParams) (*http.Response, error) {
+	req, err := NewVendorByIdRequest(c.Server, id, params)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	basePath := fmt.Sprintf("/api/mtls/cas")
-	if basePath[0] == '/' {
-		basePath = basePath[1:]
+func (c *Client) DeprecatedVendorClaimWithBody(ctx context.Context, id string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewDeprecatedVendorClaimRequestWithBody(c.Server, id, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return c.Client.Do(req)
+}
 
-	queryUrl, err = queryUrl.Parse(basePath)
+func (c *Client) DeprecatedVendorClaim(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*http.Response, error) {
+	req, err := NewDeprecatedVendorClaimRequest(c.Server, id, body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+func (c *Client) VendorHistory(ctx context.Context, id string) (*http.Response, error) {
+	req, err := NewVendorHistoryRequest(c.Server, id)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
+}
 
-	return req, nil
+func (c *Client) RegisterVendorWithBody(ctx context.Context, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := NewRegisterVendorRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.RequestEditor != nil {
+		err = c.RequestEditor(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.Do(req)
 }
 
-// NewMTLSCertificatesRequest generates requests for MTLSCertificates
-func NewMTLSCertificatesRequest(server string) (*http.Request, error) {
+// NewGetStateDigestRequest generates requests for GetStateDigest
+func NewGetStateDigestRequest(server string, params *GetStateDigestParams) (*http.Request, error) {
 	var err error
 
 	queryUrl, err := url.Parse(server)
@@ -712,7 +1176,7 @@ func NewMTLSCertificatesRequest(server string) (*http.Request, error) {
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/mtls/certificates")
+	basePath := fmt.Sprintf("/api/admin/digest")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -722,27 +1186,36 @@ func NewMTLSCertificatesRequest(server string) (*http.Request, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", queryUrl.String(), nil)
-	if err != nil {
-		return nil, err
+	queryValues := queryUrl.Query()
+
+	if params.Refs != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "refs", *params.Refs); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
 	}
 
-	return req, nil
-}
+	queryUrl.RawQuery = queryValues.Encode()
 
-// NewVendorClaimRequest calls the generic VendorClaim builder with application/json body
-func NewVendorClaimRequest(server string, body VendorClaimJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	bodyReader = bytes.NewReader(buf)
-	return NewVendorClaimRequestWithBody(server, "application/json", bodyReader)
+
+	return req, nil
 }
 
-// NewVendorClaimRequestWithBody generates requests for VendorClaim with any type of body
-func NewVendorClaimRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+// NewExportEventsRequest generates requests for ExportEvents
+func NewExportEventsRequest(server string) (*http.Request, error) {
 	var err error
 
 	queryUrl, err := url.Parse(server)
@@ -750,7 +1223,7 @@ func NewVendorClaimRequestWithBody(server string, contentType string, body io.Re
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/organization")
+	basePath := fmt.Sprintf("/api/admin/export")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -760,32 +1233,24 @@ func NewVendorClaimRequestWithBody(server string, contentType string, body io.Re
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", contentType)
 	return req, nil
 }
 
-// NewOrganizationByIdRequest generates requests for OrganizationById
-func NewOrganizationByIdRequest(server string, id string) (*http.Request, error) {
+// NewListForksRequest generates requests for ListForks
+func NewListForksRequest(server string) (*http.Request, error) {
 	var err error
 
-	var pathParam0 string
-
-	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
-	if err != nil {
-		return nil, err
-	}
-
 	queryUrl, err := url.Parse(server)
 	if err != nil {
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/organization/%s", pathParam0)
+	basePath := fmt.Sprintf("/api/admin/forks")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -803,24 +1268,24 @@ func NewOrganizationByIdRequest(server string, id string) (*http.Request, error)
 	return req, nil
 }
 
-// NewRegisterEndpointRequest calls the generic RegisterEndpoint builder with application/json body
-func NewRegisterEndpointRequest(server string, id string, body RegisterEndpointJSONRequestBody) (*http.Request, error) {
+// NewMergeForkRequest calls the generic MergeFork builder with application/json body
+func NewMergeForkRequest(server string, ref string, body MergeForkJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
 	buf, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 	bodyReader = bytes.NewReader(buf)
-	return NewRegisterEndpointRequestWithBody(server, id, "application/json", bodyReader)
+	return NewMergeForkRequestWithBody(server, ref, "application/json", bodyReader)
 }
 
-// NewRegisterEndpointRequestWithBody generates requests for RegisterEndpoint with any type of body
-func NewRegisterEndpointRequestWithBody(server string, id string, contentType string, body io.Reader) (*http.Request, error) {
+// NewMergeForkRequestWithBody generates requests for MergeFork with any type of body
+func NewMergeForkRequestWithBody(server string, ref string, contentType string, body io.Reader) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
 
-	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	pathParam0, err = runtime.StyleParam("simple", false, "ref", ref)
 	if err != nil {
 		return nil, err
 	}
@@ -830,7 +1295,7 @@ func NewRegisterEndpointRequestWithBody(server string, id string, contentType st
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/organization/%s/endpoints", pathParam0)
+	basePath := fmt.Sprintf("/api/admin/forks/%s/merge", pathParam0)
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -849,23 +1314,16 @@ func NewRegisterEndpointRequestWithBody(server string, id string, contentType st
 	return req, nil
 }
 
-// NewRefreshOrganizationCertificateRequest generates requests for RefreshOrganizationCertificate
-func NewRefreshOrganizationCertificateRequest(server string, id string) (*http.Request, error) {
+// NewImportEventsRequestWithBody generates requests for ImportEvents with any type of body
+func NewImportEventsRequestWithBody(server string, params *ImportEventsParams, contentType string, body io.Reader) (*http.Request, error) {
 	var err error
 
-	var pathParam0 string
-
-	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
-	if err != nil {
-		return nil, err
-	}
-
 	queryUrl, err := url.Parse(server)
 	if err != nil {
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/organization/%s/refresh-cert", pathParam0)
+	basePath := fmt.Sprintf("/api/admin/import")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -875,16 +1333,37 @@ func NewRefreshOrganizationCertificateRequest(server string, id string) (*http.R
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryUrl.String(), nil)
+	queryValues := queryUrl.Query()
+
+	if params.Apply != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "apply", *params.Apply); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("POST", queryUrl.String(), body)
 	if err != nil {
 		return nil, err
 	}
 
+	req.Header.Add("Content-Type", contentType)
 	return req, nil
 }
 
-// NewSearchOrganizationsRequest generates requests for SearchOrganizations
-func NewSearchOrganizationsRequest(server string, params *SearchOrganizationsParams) (*http.Request, error) {
+// NewListParkedEventsRequest generates requests for ListParkedEvents
+func NewListParkedEventsRequest(server string) (*http.Request, error) {
 	var err error
 
 	queryUrl, err := url.Parse(server)
@@ -892,7 +1371,7 @@ func NewSearchOrganizationsRequest(server string, params *SearchOrganizationsPar
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/organizations")
+	basePath := fmt.Sprintf("/api/admin/retry-queue")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -902,39 +1381,41 @@ func NewSearchOrganizationsRequest(server string, params *SearchOrganizationsPar
 		return nil, err
 	}
 
-	queryValues := queryUrl.Query()
-
-	if queryFrag, err := runtime.StyleParam("form", true, "query", params.Query); err != nil {
-		return nil, err
-	} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
 		return nil, err
-	} else {
-		for k, v := range parsed {
-			for _, v2 := range v {
-				queryValues.Add(k, v2)
-			}
-		}
 	}
 
-	if params.Exact != nil {
+	return req, nil
+}
 
-		if queryFrag, err := runtime.StyleParam("form", true, "exact", *params.Exact); err != nil {
-			return nil, err
-		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
-			return nil, err
-		} else {
-			for k, v := range parsed {
-				for _, v2 := range v {
-					queryValues.Add(k, v2)
-				}
-			}
-		}
+// NewDiscardParkedEventRequest generates requests for DiscardParkedEvent
+func NewDiscardParkedEventRequest(server string, ref string) (*http.Request, error) {
+	var err error
 
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "ref", ref)
+	if err != nil {
+		return nil, err
 	}
 
-	queryUrl.RawQuery = queryValues.Encode()
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	basePath := fmt.Sprintf("/api/admin/retry-queue/%s", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryUrl.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -942,13 +1423,13 @@ func NewSearchOrganizationsRequest(server string, params *SearchOrganizationsPar
 	return req, nil
 }
 
-// NewVendorByIdRequest generates requests for VendorById
-func NewVendorByIdRequest(server string, id string) (*http.Request, error) {
+// NewGetParkedEventRequest generates requests for GetParkedEvent
+func NewGetParkedEventRequest(server string, ref string) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
 
-	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	pathParam0, err = runtime.StyleParam("simple", false, "ref", ref)
 	if err != nil {
 		return nil, err
 	}
@@ -958,7 +1439,7 @@ func NewVendorByIdRequest(server string, id string) (*http.Request, error) {
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/vendor/%s", pathParam0)
+	basePath := fmt.Sprintf("/api/admin/retry-queue/%s", pathParam0)
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -976,24 +1457,13 @@ func NewVendorByIdRequest(server string, id string) (*http.Request, error) {
 	return req, nil
 }
 
-// NewDeprecatedVendorClaimRequest calls the generic DeprecatedVendorClaim builder with application/json body
-func NewDeprecatedVendorClaimRequest(server string, id string, body DeprecatedVendorClaimJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
-	}
-	bodyReader = bytes.NewReader(buf)
-	return NewDeprecatedVendorClaimRequestWithBody(server, id, "application/json", bodyReader)
-}
-
-// NewDeprecatedVendorClaimRequestWithBody generates requests for DeprecatedVendorClaim with any type of body
-func NewDeprecatedVendorClaimRequestWithBody(server string, id string, contentType string, body io.Reader) (*http.Request, error) {
+// NewRetryParkedEventRequest generates requests for RetryParkedEvent
+func NewRetryParkedEventRequest(server string, ref string) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
 
-	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	pathParam0, err = runtime.StyleParam("simple", false, "ref", ref)
 	if err != nil {
 		return nil, err
 	}
@@ -1003,7 +1473,7 @@ func NewDeprecatedVendorClaimRequestWithBody(server string, id string, contentTy
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/vendor/%s/claim", pathParam0)
+	basePath := fmt.Sprintf("/api/admin/retry-queue/%s/retry", pathParam0)
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -1013,17 +1483,16 @@ func NewDeprecatedVendorClaimRequestWithBody(server string, id string, contentTy
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	req, err := http.NewRequest("POST", queryUrl.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", contentType)
 	return req, nil
 }
 
-// NewRegisterVendorRequestWithBody generates requests for RegisterVendor with any type of body
-func NewRegisterVendorRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+// NewVerifyRequest generates requests for Verify
+func NewVerifyRequest(server string, params *VerifyParams) (*http.Request, error) {
 	var err error
 
 	queryUrl, err := url.Parse(server)
@@ -1031,7 +1500,7 @@ func NewRegisterVendorRequestWithBody(server string, contentType string, body io
 		return nil, err
 	}
 
-	basePath := fmt.Sprintf("/api/vendors")
+	basePath := fmt.Sprintf("/api/admin/verify")
 	if basePath[0] == '/' {
 		basePath = basePath[1:]
 	}
@@ -1041,511 +1510,2627 @@ func NewRegisterVendorRequestWithBody(server string, contentType string, body io
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	queryValues := queryUrl.Query()
+
+	if params.Fix != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "fix", *params.Fix); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("POST", queryUrl.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", contentType)
 	return req, nil
 }
 
-// ClientWithResponses builds on ClientInterface to offer response payloads
-type ClientWithResponses struct {
-	ClientInterface
-}
+// NewEndpointsByOrganisationIdRequest generates requests for EndpointsByOrganisationId
+func NewEndpointsByOrganisationIdRequest(server string, params *EndpointsByOrganisationIdParams) (*http.Request, error) {
+	var err error
 
-// NewClientWithResponses creates a new ClientWithResponses, which wraps
-// Client with return type handling
-func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
-	client, err := NewClient(server, opts...)
+	queryUrl, err := url.Parse(server)
 	if err != nil {
 		return nil, err
 	}
-	return &ClientWithResponses{client}, nil
-}
 
-// WithBaseURL overrides the baseURL.
-func WithBaseURL(baseURL string) ClientOption {
-	return func(c *Client) error {
-		newBaseURL, err := url.Parse(baseURL)
-		if err != nil {
-			return err
-		}
-		c.Server = newBaseURL.String()
-		return nil
+	basePath := fmt.Sprintf("/api/endpoints")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
 	}
-}
 
-// ClientWithResponsesInterface is the interface specification for the client with responses above.
-type ClientWithResponsesInterface interface {
-	// Verify request
-	VerifyWithResponse(ctx context.Context, params *VerifyParams) (*VerifyResponse, error)
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
 
-	// EndpointsByOrganisationId request
-	EndpointsByOrganisationIdWithResponse(ctx context.Context, params *EndpointsByOrganisationIdParams) (*EndpointsByOrganisationIdResponse, error)
+	queryValues := queryUrl.Query()
 
-	// MTLSCAs request
-	MTLSCAsWithResponse(ctx context.Context) (*MTLSCAsResponse, error)
+	if queryFrag, err := runtime.StyleParam("form", true, "orgIds", params.OrgIds); err != nil {
+		return nil, err
+	} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+		return nil, err
+	} else {
+		for k, v := range parsed {
+			for _, v2 := range v {
+				queryValues.Add(k, v2)
+			}
+		}
+	}
 
-	// MTLSCertificates request
-	MTLSCertificatesWithResponse(ctx context.Context) (*MTLSCertificatesResponse, error)
+	if params.Type != nil {
 
-	// VendorClaim request  with any body
-	VendorClaimWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*VendorClaimResponse, error)
+		if queryFrag, err := runtime.StyleParam("form", true, "type", *params.Type); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
 
-	VendorClaimWithResponse(ctx context.Context, body VendorClaimJSONRequestBody) (*VendorClaimResponse, error)
+	}
 
-	// OrganizationById request
-	OrganizationByIdWithResponse(ctx context.Context, id string) (*OrganizationByIdResponse, error)
+	if params.Strict != nil {
 
-	// RegisterEndpoint request  with any body
-	RegisterEndpointWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*RegisterEndpointResponse, error)
+		if queryFrag, err := runtime.StyleParam("form", true, "strict", *params.Strict); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
 
-	RegisterEndpointWithResponse(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*RegisterEndpointResponse, error)
+	}
 
-	// RefreshOrganizationCertificate request
-	RefreshOrganizationCertificateWithResponse(ctx context.Context, id string) (*RefreshOrganizationCertificateResponse, error)
+	if params.AsOf != nil {
 
-	// SearchOrganizations request
-	SearchOrganizationsWithResponse(ctx context.Context, params *SearchOrganizationsParams) (*SearchOrganizationsResponse, error)
+		if queryFrag, err := runtime.StyleParam("form", true, "asOf", *params.AsOf); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
 
-	// VendorById request
-	VendorByIdWithResponse(ctx context.Context, id string) (*VendorByIdResponse, error)
+	}
 
-	// DeprecatedVendorClaim request  with any body
-	DeprecatedVendorClaimWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*DeprecatedVendorClaimResponse, error)
+	queryUrl.RawQuery = queryValues.Encode()
 
-	DeprecatedVendorClaimWithResponse(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*DeprecatedVendorClaimResponse, error)
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	// RegisterVendor request  with any body
-	RegisterVendorWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*RegisterVendorResponse, error)
+	return req, nil
 }
 
-type VerifyResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *struct {
-
-		// list of events that resulted from fixing the data, list may be empty
-		Events *[]Event `json:"events,omitempty"`
+// NewSearchEndpointsRequest generates requests for SearchEndpoints
+func NewSearchEndpointsRequest(server string, params *SearchEndpointsParams) (*http.Request, error) {
+	var err error
 
-		// if true, the data in the registry needs fixing/upgrading.
-		Fix *bool `json:"fix,omitempty"`
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
 	}
-}
 
-// Status returns HTTPResponse.Status
-func (r VerifyResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+	basePath := fmt.Sprintf("/api/endpoints/search")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
 	}
-	return http.StatusText(0)
-}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryUrl.Query()
+
+	if params.Type != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "type", *params.Type); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Status != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "status", *params.Status); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.IncludeUnavailable != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "includeUnavailable", *params.IncludeUnavailable); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Vendor != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "vendor", *params.Vendor); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Domain != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "domain", *params.Domain); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Property != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "property", *params.Property); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Offset != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "offset", *params.Offset); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Limit != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "limit", *params.Limit); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListEventSchemasRequest generates requests for ListEventSchemas
+func NewListEventSchemasRequest(server string) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/events/schemas")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetEventSchemaRequest generates requests for GetEventSchema
+func NewGetEventSchemaRequest(server string, pType string, version int) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "type", pType)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParam("simple", false, "version", version)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/events/schemas/%s/%s", pathParam0, pathParam1)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewStreamEventsRequest generates requests for StreamEvents
+func NewStreamEventsRequest(server string, params *StreamEventsParams) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/events/stream")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryUrl.Query()
+
+	if params.From != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "from", *params.From); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.LastEventID != nil {
+		var headerParam0 string
+
+		headerParam0, err = runtime.StyleParam("simple", false, "Last-Event-ID", *params.LastEventID)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Add("Last-Event-ID", headerParam0)
+	}
+
+	return req, nil
+}
+
+// NewMTLSCAsRequest generates requests for MTLSCAs
+func NewMTLSCAsRequest(server string) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/mtls/cas")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewMTLSCertificatesRequest generates requests for MTLSCertificates
+func NewMTLSCertificatesRequest(server string) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/mtls/certificates")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewVendorClaimRequest calls the generic VendorClaim builder with application/json body
+func NewVendorClaimRequest(server string, body VendorClaimJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewVendorClaimRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewVendorClaimRequestWithBody generates requests for VendorClaim with any type of body
+func NewVendorClaimRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+	return req, nil
+}
+
+// NewEndVendorClaimRequest generates requests for EndVendorClaim
+func NewEndVendorClaimRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewOrganizationByIdRequest generates requests for OrganizationById
+func NewOrganizationByIdRequest(server string, id string, params *OrganizationByIdParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryUrl.Query()
+
+	if params.AsOf != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "asOf", *params.AsOf); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRegisterEndpointRequest calls the generic RegisterEndpoint builder with application/json body
+func NewRegisterEndpointRequest(server string, id string, body RegisterEndpointJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewRegisterEndpointRequestWithBody(server, id, "application/json", bodyReader)
+}
+
+// NewRegisterEndpointRequestWithBody generates requests for RegisterEndpoint with any type of body
+func NewRegisterEndpointRequestWithBody(server string, id string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s/endpoints", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+	return req, nil
+}
+
+// NewDeregisterEndpointRequest generates requests for DeregisterEndpoint
+func NewDeregisterEndpointRequest(server string, id string, endpointId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParam("simple", false, "endpointId", endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s/endpoints/%s", pathParam0, pathParam1)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewEndpointHistoryRequest generates requests for EndpointHistory
+func NewEndpointHistoryRequest(server string, id string, endpointId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParam("simple", false, "endpointId", endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s/endpoints/%s/history", pathParam0, pathParam1)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewOrganizationHistoryRequest generates requests for OrganizationHistory
+func NewOrganizationHistoryRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s/history", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRefreshOrganizationCertificateRequest generates requests for RefreshOrganizationCertificate
+func NewRefreshOrganizationCertificateRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organization/%s/refresh-cert", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewSearchOrganizationsRequest generates requests for SearchOrganizations
+func NewSearchOrganizationsRequest(server string, params *SearchOrganizationsParams) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/organizations")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryUrl.Query()
+
+	if queryFrag, err := runtime.StyleParam("form", true, "query", params.Query); err != nil {
+		return nil, err
+	} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+		return nil, err
+	} else {
+		for k, v := range parsed {
+			for _, v2 := range v {
+				queryValues.Add(k, v2)
+			}
+		}
+	}
+
+	if params.Exact != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "exact", *params.Exact); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Offset != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "offset", *params.Offset); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Limit != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "limit", *params.Limit); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRetireVendorRequest generates requests for RetireVendor
+func NewRetireVendorRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/vendor/%s", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewVendorByIdRequest generates requests for VendorById
+func NewVendorByIdRequest(server string, id string, params *VendorByIdParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/vendor/%s", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryUrl.Query()
+
+	if params.AsOf != nil {
+
+		if queryFrag, err := runtime.StyleParam("form", true, "asOf", *params.AsOf); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryUrl.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDeprecatedVendorClaimRequest calls the generic DeprecatedVendorClaim builder with application/json body
+func NewDeprecatedVendorClaimRequest(server string, id string, body DeprecatedVendorClaimJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewDeprecatedVendorClaimRequestWithBody(server, id, "application/json", bodyReader)
+}
+
+// NewDeprecatedVendorClaimRequestWithBody generates requests for DeprecatedVendorClaim with any type of body
+func NewDeprecatedVendorClaimRequestWithBody(server string, id string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/vendor/%s/claim", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+	return req, nil
+}
+
+// NewVendorHistoryRequest generates requests for VendorHistory
+func NewVendorHistoryRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParam("simple", false, "id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/vendor/%s/history", pathParam0)
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRegisterVendorRequestWithBody generates requests for RegisterVendor with any type of body
+func NewRegisterVendorRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	queryUrl, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := fmt.Sprintf("/api/vendors")
+	if basePath[0] == '/' {
+		basePath = basePath[1:]
+	}
+
+	queryUrl, err = queryUrl.Parse(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryUrl.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+	return req, nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// GetStateDigest request
+	GetStateDigestWithResponse(ctx context.Context, params *GetStateDigestParams) (*GetStateDigestResponse, error)
+
+	// ExportEvents request
+	ExportEventsWithResponse(ctx context.Context) (*ExportEventsResponse, error)
+
+	// ListForks request
+	ListForksWithResponse(ctx context.Context) (*ListForksResponse, error)
+
+	// MergeFork request  with any body
+	MergeForkWithBodyWithResponse(ctx context.Context, ref string, contentType string, body io.Reader) (*MergeForkResponse, error)
+
+	MergeForkWithResponse(ctx context.Context, ref string, body MergeForkJSONRequestBody) (*MergeForkResponse, error)
+
+	// ImportEvents request  with any body
+	ImportEventsWithBodyWithResponse(ctx context.Context, params *ImportEventsParams, contentType string, body io.Reader) (*ImportEventsResponse, error)
+
+	// ListParkedEvents request
+	ListParkedEventsWithResponse(ctx context.Context) (*ListParkedEventsResponse, error)
+
+	// DiscardParkedEvent request
+	DiscardParkedEventWithResponse(ctx context.Context, ref string) (*DiscardParkedEventResponse, error)
+
+	// GetParkedEvent request
+	GetParkedEventWithResponse(ctx context.Context, ref string) (*GetParkedEventResponse, error)
+
+	// RetryParkedEvent request
+	RetryParkedEventWithResponse(ctx context.Context, ref string) (*RetryParkedEventResponse, error)
+
+	// Verify request
+	VerifyWithResponse(ctx context.Context, params *VerifyParams) (*VerifyResponse, error)
+
+	// EndpointsByOrganisationId request
+	EndpointsByOrganisationIdWithResponse(ctx context.Context, params *EndpointsByOrganisationIdParams) (*EndpointsByOrganisationIdResponse, error)
+
+	// SearchEndpoints request
+	SearchEndpointsWithResponse(ctx context.Context, params *SearchEndpointsParams) (*SearchEndpointsResponse, error)
+
+	// ListEventSchemas request
+	ListEventSchemasWithResponse(ctx context.Context) (*ListEventSchemasResponse, error)
+
+	// GetEventSchema request
+	GetEventSchemaWithResponse(ctx context.Context, pType string, version int) (*GetEventSchemaResponse, error)
+
+	// StreamEvents request
+	StreamEventsWithResponse(ctx context.Context, params *StreamEventsParams) (*StreamEventsResponse, error)
+
+	// MTLSCAs request
+	MTLSCAsWithResponse(ctx context.Context) (*MTLSCAsResponse, error)
+
+	// MTLSCertificates request
+	MTLSCertificatesWithResponse(ctx context.Context) (*MTLSCertificatesResponse, error)
+
+	// VendorClaim request  with any body
+	VendorClaimWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*VendorClaimResponse, error)
+
+	VendorClaimWithResponse(ctx context.Context, body VendorClaimJSONRequestBody) (*VendorClaimResponse, error)
+
+	// EndVendorClaim request
+	EndVendorClaimWithResponse(ctx context.Context, id string) (*EndVendorClaimResponse, error)
+
+	// OrganizationById request
+	OrganizationByIdWithResponse(ctx context.Context, id string, params *OrganizationByIdParams) (*OrganizationByIdResponse, error)
+
+	// RegisterEndpoint request  with any body
+	RegisterEndpointWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*RegisterEndpointResponse, error)
+
+	RegisterEndpointWithResponse(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*RegisterEndpointResponse, error)
+
+	// DeregisterEndpoint request
+	DeregisterEndpointWithResponse(ctx context.Context, id string, endpointId string) (*DeregisterEndpointResponse, error)
+
+	// EndpointHistory request
+	EndpointHistoryWithResponse(ctx context.Context, id string, endpointId string) (*EndpointHistoryResponse, error)
+
+	// OrganizationHistory request
+	OrganizationHistoryWithResponse(ctx context.Context, id string) (*OrganizationHistoryResponse, error)
+
+	// RefreshOrganizationCertificate request
+	RefreshOrganizationCertificateWithResponse(ctx context.Context, id string) (*RefreshOrganizationCertificateResponse, error)
+
+	// SearchOrganizations request
+	SearchOrganizationsWithResponse(ctx context.Context, params *SearchOrganizationsParams) (*SearchOrganizationsResponse, error)
+
+	// RetireVendor request
+	RetireVendorWithResponse(ctx context.Context, id string) (*RetireVendorResponse, error)
+
+	// VendorById request
+	VendorByIdWithResponse(ctx context.Context, id string, params *VendorByIdParams) (*VendorByIdResponse, error)
+
+	// DeprecatedVendorClaim request  with any body
+	DeprecatedVendorClaimWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*DeprecatedVendorClaimResponse, error)
+
+	DeprecatedVendorClaimWithResponse(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*DeprecatedVendorClaimResponse, error)
+
+	// VendorHistory request
+	VendorHistoryWithResponse(ctx context.Context, id string) (*VendorHistoryResponse, error)
+
+	// RegisterVendor request  with any body
+	RegisterVendorWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*RegisterVendorResponse, error)
+}
+
+type GetStateDigestResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *StateDigest
+}
+
+// Status returns HTTPResponse.Status
+func (r GetStateDigestResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetStateDigestResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ExportEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r ExportEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ExportEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListForksResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]Fork
+}
+
+// Status returns HTTPResponse.Status
+func (r ListForksResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListForksResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type MergeForkResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r MergeForkResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r MergeForkResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ImportEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ImportReport
+}
+
+// Status returns HTTPResponse.Status
+func (r ImportEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ImportEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListParkedEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ParkedEvent
+}
+
+// Status returns HTTPResponse.Status
+func (r ListParkedEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListParkedEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DiscardParkedEventResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DiscardParkedEventResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DiscardParkedEventResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetParkedEventResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ParkedEvent
+}
+
+// Status returns HTTPResponse.Status
+func (r GetParkedEventResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetParkedEventResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RetryParkedEventResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r RetryParkedEventResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RetryParkedEventResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type VerifyResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *struct {
+
+		// list of events that resulted from fixing the data, list may be empty
+		Events *[]Event `json:"events,omitempty"`
+
+		// if true, the data in the registry needs fixing/upgrading.
+		Fix *bool `json:"fix,omitempty"`
+	}
+}
+
+// Status returns HTTPResponse.Status
+func (r VerifyResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r VerifyResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type EndpointsByOrganisationIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r EndpointsByOrganisationIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r EndpointsByOrganisationIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type SearchEndpointsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]OrganizationEndpoint
+}
+
+// Status returns HTTPResponse.Status
+func (r SearchEndpointsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r SearchEndpointsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListEventSchemasResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]EventSchema
+}
+
+// Status returns HTTPResponse.Status
+func (r ListEventSchemasResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListEventSchemasResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetEventSchemaResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetEventSchemaResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetEventSchemaResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StreamEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r StreamEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StreamEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type MTLSCAsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *CAListWithChain
+}
+
+// Status returns HTTPResponse.Status
+func (r MTLSCAsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r MTLSCAsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type MTLSCertificatesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]string
+}
+
+// Status returns HTTPResponse.Status
+func (r MTLSCertificatesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r MTLSCertificatesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type VendorClaimResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r VendorClaimResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r VendorClaimResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type EndVendorClaimResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r EndVendorClaimResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r EndVendorClaimResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type OrganizationByIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Organization
+}
+
+// Status returns HTTPResponse.Status
+func (r OrganizationByIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r OrganizationByIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RegisterEndpointResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r RegisterEndpointResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RegisterEndpointResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeregisterEndpointResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r DeregisterEndpointResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeregisterEndpointResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type EndpointHistoryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]EventHistoryEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r EndpointHistoryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r EndpointHistoryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type OrganizationHistoryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]EventHistoryEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r OrganizationHistoryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r OrganizationHistoryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RefreshOrganizationCertificateResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r RefreshOrganizationCertificateResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RefreshOrganizationCertificateResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type SearchOrganizationsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r SearchOrganizationsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
 
 // StatusCode returns HTTPResponse.StatusCode
-func (r VerifyResponse) StatusCode() int {
+func (r SearchOrganizationsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RetireVendorResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r RetireVendorResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RetireVendorResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type VendorByIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Vendor
+}
+
+// Status returns HTTPResponse.Status
+func (r VendorByIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r VendorByIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeprecatedVendorClaimResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r DeprecatedVendorClaimResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeprecatedVendorClaimResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type VendorHistoryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]EventHistoryEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r VendorHistoryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r VendorHistoryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RegisterVendorResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Event
+}
+
+// Status returns HTTPResponse.Status
+func (r RegisterVendorResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RegisterVendorResponse) StatusCode() int {
 	if r.HTTPResponse != nil {
 		return r.HTTPResponse.StatusCode
 	}
-	return 0
+	return 0
+}
+
+// GetStateDigestWithResponse request returning *GetStateDigestResponse
+func (c *ClientWithResponses) GetStateDigestWithResponse(ctx context.Context, params *GetStateDigestParams) (*GetStateDigestResponse, error) {
+	rsp, err := c.GetStateDigest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetStateDigestResponse(rsp)
+}
+
+// ExportEventsWithResponse request returning *ExportEventsResponse
+func (c *ClientWithResponses) ExportEventsWithResponse(ctx context.Context) (*ExportEventsResponse, error) {
+	rsp, err := c.ExportEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseExportEventsResponse(rsp)
+}
+
+// ListForksWithResponse request returning *ListForksResponse
+func (c *ClientWithResponses) ListForksWithResponse(ctx context.Context) (*ListForksResponse, error) {
+	rsp, err := c.ListForks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListForksResponse(rsp)
+}
+
+// MergeForkWithBodyWithResponse request with arbitrary body returning *MergeForkResponse
+func (c *ClientWithResponses) MergeForkWithBodyWithResponse(ctx context.Context, ref string, contentType string, body io.Reader) (*MergeForkResponse, error) {
+	rsp, err := c.MergeForkWithBody(ctx, ref, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMergeForkResponse(rsp)
+}
+
+func (c *ClientWithResponses) MergeForkWithResponse(ctx context.Context, ref string, body MergeForkJSONRequestBody) (*MergeForkResponse, error) {
+	rsp, err := c.MergeFork(ctx, ref, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMergeForkResponse(rsp)
+}
+
+// ImportEventsWithBodyWithResponse request with arbitrary body returning *ImportEventsResponse
+func (c *ClientWithResponses) ImportEventsWithBodyWithResponse(ctx context.Context, params *ImportEventsParams, contentType string, body io.Reader) (*ImportEventsResponse, error) {
+	rsp, err := c.ImportEventsWithBody(ctx, params, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseImportEventsResponse(rsp)
+}
+
+// ListParkedEventsWithResponse request returning *ListParkedEventsResponse
+func (c *ClientWithResponses) ListParkedEventsWithResponse(ctx context.Context) (*ListParkedEventsResponse, error) {
+	rsp, err := c.ListParkedEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListParkedEventsResponse(rsp)
+}
+
+// DiscardParkedEventWithResponse request returning *DiscardParkedEventResponse
+func (c *ClientWithResponses) DiscardParkedEventWithResponse(ctx context.Context, ref string) (*DiscardParkedEventResponse, error) {
+	rsp, err := c.DiscardParkedEvent(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiscardParkedEventResponse(rsp)
+}
+
+// GetParkedEventWithResponse request returning *GetParkedEventResponse
+func (c *ClientWithResponses) GetParkedEventWithResponse(ctx context.Context, ref string) (*GetParkedEventResponse, error) {
+	rsp, err := c.GetParkedEvent(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetParkedEventResponse(rsp)
+}
+
+// RetryParkedEventWithResponse request returning *RetryParkedEventResponse
+func (c *ClientWithResponses) RetryParkedEventWithResponse(ctx context.Context, ref string) (*RetryParkedEventResponse, error) {
+	rsp, err := c.RetryParkedEvent(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRetryParkedEventResponse(rsp)
+}
+
+// VerifyWithResponse request returning *VerifyResponse
+func (c *ClientWithResponses) VerifyWithResponse(ctx context.Context, params *VerifyParams) (*VerifyResponse, error) {
+	rsp, err := c.Verify(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVerifyResponse(rsp)
+}
+
+// EndpointsByOrganisationIdWithResponse request returning *EndpointsByOrganisationIdResponse
+func (c *ClientWithResponses) EndpointsByOrganisationIdWithResponse(ctx context.Context, params *EndpointsByOrganisationIdParams) (*EndpointsByOrganisationIdResponse, error) {
+	rsp, err := c.EndpointsByOrganisationId(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEndpointsByOrganisationIdResponse(rsp)
+}
+
+// SearchEndpointsWithResponse request returning *SearchEndpointsResponse
+func (c *ClientWithResponses) SearchEndpointsWithResponse(ctx context.Context, params *SearchEndpointsParams) (*SearchEndpointsResponse, error) {
+	rsp, err := c.SearchEndpoints(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSearchEndpointsResponse(rsp)
+}
+
+// ListEventSchemasWithResponse request returning *ListEventSchemasResponse
+func (c *ClientWithResponses) ListEventSchemasWithResponse(ctx context.Context) (*ListEventSchemasResponse, error) {
+	rsp, err := c.ListEventSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListEventSchemasResponse(rsp)
+}
+
+// GetEventSchemaWithResponse request returning *GetEventSchemaResponse
+func (c *ClientWithResponses) GetEventSchemaWithResponse(ctx context.Context, pType string, version int) (*GetEventSchemaResponse, error) {
+	rsp, err := c.GetEventSchema(ctx, pType, version)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetEventSchemaResponse(rsp)
+}
+
+// StreamEventsWithResponse request returning *StreamEventsResponse
+func (c *ClientWithResponses) StreamEventsWithResponse(ctx context.Context, params *StreamEventsParams) (*StreamEventsResponse, error) {
+	rsp, err := c.StreamEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStreamEventsResponse(rsp)
+}
+
+// MTLSCAsWithResponse request returning *MTLSCAsResponse
+func (c *ClientWithResponses) MTLSCAsWithResponse(ctx context.Context) (*MTLSCAsResponse, error) {
+	rsp, err := c.MTLSCAs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMTLSCAsResponse(rsp)
+}
+
+// MTLSCertificatesWithResponse request returning *MTLSCertificatesResponse
+func (c *ClientWithResponses) MTLSCertificatesWithResponse(ctx context.Context) (*MTLSCertificatesResponse, error) {
+	rsp, err := c.MTLSCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMTLSCertificatesResponse(rsp)
+}
+
+// VendorClaimWithBodyWithResponse request with arbitrary body returning *VendorClaimResponse
+func (c *ClientWithResponses) VendorClaimWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*VendorClaimResponse, error) {
+	rsp, err := c.VendorClaimWithBody(ctx, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVendorClaimResponse(rsp)
+}
+
+func (c *ClientWithResponses) VendorClaimWithResponse(ctx context.Context, body VendorClaimJSONRequestBody) (*VendorClaimResponse, error) {
+	rsp, err := c.VendorClaim(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVendorClaimResponse(rsp)
+}
+
+// EndVendorClaimWithResponse request returning *EndVendorClaimResponse
+func (c *ClientWithResponses) EndVendorClaimWithResponse(ctx context.Context, id string) (*EndVendorClaimResponse, error) {
+	rsp, err := c.EndVendorClaim(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEndVendorClaimResponse(rsp)
+}
+
+// OrganizationByIdWithResponse request returning *OrganizationByIdResponse
+func (c *ClientWithResponses) OrganizationByIdWithResponse(ctx context.Context, id string, params *OrganizationByIdParams) (*OrganizationByIdResponse, error) {
+	rsp, err := c.OrganizationById(ctx, id, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOrganizationByIdResponse(rsp)
+}
+
+// RegisterEndpointWithBodyWithResponse request with arbitrary body returning *RegisterEndpointResponse
+func (c *ClientWithResponses) RegisterEndpointWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*RegisterEndpointResponse, error) {
+	rsp, err := c.RegisterEndpointWithBody(ctx, id, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegisterEndpointResponse(rsp)
 }
 
-type EndpointsByOrganisationIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+func (c *ClientWithResponses) RegisterEndpointWithResponse(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*RegisterEndpointResponse, error) {
+	rsp, err := c.RegisterEndpoint(ctx, id, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegisterEndpointResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r EndpointsByOrganisationIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// DeregisterEndpointWithResponse request returning *DeregisterEndpointResponse
+func (c *ClientWithResponses) DeregisterEndpointWithResponse(ctx context.Context, id string, endpointId string) (*DeregisterEndpointResponse, error) {
+	rsp, err := c.DeregisterEndpoint(ctx, id, endpointId)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseDeregisterEndpointResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r EndpointsByOrganisationIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// EndpointHistoryWithResponse request returning *EndpointHistoryResponse
+func (c *ClientWithResponses) EndpointHistoryWithResponse(ctx context.Context, id string, endpointId string) (*EndpointHistoryResponse, error) {
+	rsp, err := c.EndpointHistory(ctx, id, endpointId)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParseEndpointHistoryResponse(rsp)
 }
 
-type MTLSCAsResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *CAListWithChain
+// OrganizationHistoryWithResponse request returning *OrganizationHistoryResponse
+func (c *ClientWithResponses) OrganizationHistoryWithResponse(ctx context.Context, id string) (*OrganizationHistoryResponse, error) {
+	rsp, err := c.OrganizationHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOrganizationHistoryResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r MTLSCAsResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// RefreshOrganizationCertificateWithResponse request returning *RefreshOrganizationCertificateResponse
+func (c *ClientWithResponses) RefreshOrganizationCertificateWithResponse(ctx context.Context, id string) (*RefreshOrganizationCertificateResponse, error) {
+	rsp, err := c.RefreshOrganizationCertificate(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseRefreshOrganizationCertificateResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r MTLSCAsResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// SearchOrganizationsWithResponse request returning *SearchOrganizationsResponse
+func (c *ClientWithResponses) SearchOrganizationsWithResponse(ctx context.Context, params *SearchOrganizationsParams) (*SearchOrganizationsResponse, error) {
+	rsp, err := c.SearchOrganizations(ctx, params)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParseSearchOrganizationsResponse(rsp)
 }
 
-type MTLSCertificatesResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *[]string
+// RetireVendorWithResponse request returning *RetireVendorResponse
+func (c *ClientWithResponses) RetireVendorWithResponse(ctx context.Context, id string) (*RetireVendorResponse, error) {
+	rsp, err := c.RetireVendor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRetireVendorResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r MTLSCertificatesResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// VendorByIdWithResponse request returning *VendorByIdResponse
+func (c *ClientWithResponses) VendorByIdWithResponse(ctx context.Context, id string, params *VendorByIdParams) (*VendorByIdResponse, error) {
+	rsp, err := c.VendorById(ctx, id, params)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseVendorByIdResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r MTLSCertificatesResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// DeprecatedVendorClaimWithBodyWithResponse request with arbitrary body returning *DeprecatedVendorClaimResponse
+func (c *ClientWithResponses) DeprecatedVendorClaimWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*DeprecatedVendorClaimResponse, error) {
+	rsp, err := c.DeprecatedVendorClaimWithBody(ctx, id, contentType, body)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParseDeprecatedVendorClaimResponse(rsp)
 }
 
-type VendorClaimResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Event
+func (c *ClientWithResponses) DeprecatedVendorClaimWithResponse(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*DeprecatedVendorClaimResponse, error) {
+	rsp, err := c.DeprecatedVendorClaim(ctx, id, body)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeprecatedVendorClaimResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r VendorClaimResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// VendorHistoryWithResponse request returning *VendorHistoryResponse
+func (c *ClientWithResponses) VendorHistoryWithResponse(ctx context.Context, id string) (*VendorHistoryResponse, error) {
+	rsp, err := c.VendorHistory(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseVendorHistoryResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r VendorClaimResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// RegisterVendorWithBodyWithResponse request with arbitrary body returning *RegisterVendorResponse
+func (c *ClientWithResponses) RegisterVendorWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*RegisterVendorResponse, error) {
+	rsp, err := c.RegisterVendorWithBody(ctx, contentType, body)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParseRegisterVendorResponse(rsp)
 }
 
-type OrganizationByIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Organization
-}
+// ParseGetStateDigestResponse parses an HTTP response from a GetStateDigestWithResponse call
+func ParseGetStateDigestResponse(rsp *http.Response) (*GetStateDigestResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
 
-// Status returns HTTPResponse.Status
-func (r OrganizationByIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+	response := &GetStateDigestResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r OrganizationByIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest StateDigest
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	}
-	return 0
-}
 
-type RegisterEndpointResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Event
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r RegisterEndpointResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseExportEventsResponse parses an HTTP response from a ExportEventsWithResponse call
+func ParseExportEventsResponse(rsp *http.Response) (*ExportEventsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r RegisterEndpointResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &ExportEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type RefreshOrganizationCertificateResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Event
+	switch {
+	}
+
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r RefreshOrganizationCertificateResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseListForksResponse parses an HTTP response from a ListForksWithResponse call
+func ParseListForksResponse(rsp *http.Response) (*ListForksResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r RefreshOrganizationCertificateResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &ListForksResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type SearchOrganizationsResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []Fork
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// Status returns HTTPResponse.Status
-func (r SearchOrganizationsResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
 	}
-	return http.StatusText(0)
+
+	return response, nil
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r SearchOrganizationsResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// ParseMergeForkResponse parses an HTTP response from a MergeForkWithResponse call
+func ParseMergeForkResponse(rsp *http.Response) (*MergeForkResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
 	}
-	return 0
-}
 
-type VendorByIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Vendor
-}
+	response := &MergeForkResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Event
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// Status returns HTTPResponse.Status
-func (r VendorByIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
 	}
-	return http.StatusText(0)
+
+	return response, nil
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r VendorByIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// ParseImportEventsResponse parses an HTTP response from a ImportEventsWithResponse call
+func ParseImportEventsResponse(rsp *http.Response) (*ImportEventsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ImportEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ImportReport
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	}
-	return 0
-}
 
-type DeprecatedVendorClaimResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Event
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r DeprecatedVendorClaimResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseListParkedEventsResponse parses an HTTP response from a ListParkedEventsWithResponse call
+func ParseListParkedEventsResponse(rsp *http.Response) (*ListParkedEventsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r DeprecatedVendorClaimResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &ListParkedEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type RegisterVendorResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Event
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ParkedEvent
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// Status returns HTTPResponse.Status
-func (r RegisterVendorResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r RegisterVendorResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
-	}
-	return 0
+	return response, nil
 }
 
-// VerifyWithResponse request returning *VerifyResponse
-func (c *ClientWithResponses) VerifyWithResponse(ctx context.Context, params *VerifyParams) (*VerifyResponse, error) {
-	rsp, err := c.Verify(ctx, params)
+// ParseDiscardParkedEventResponse parses an HTTP response from a DiscardParkedEventWithResponse call
+func ParseDiscardParkedEventResponse(rsp *http.Response) (*DiscardParkedEventResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseVerifyResponse(rsp)
-}
 
-// EndpointsByOrganisationIdWithResponse request returning *EndpointsByOrganisationIdResponse
-func (c *ClientWithResponses) EndpointsByOrganisationIdWithResponse(ctx context.Context, params *EndpointsByOrganisationIdParams) (*EndpointsByOrganisationIdResponse, error) {
-	rsp, err := c.EndpointsByOrganisationId(ctx, params)
-	if err != nil {
-		return nil, err
+	response := &DiscardParkedEventResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseEndpointsByOrganisationIdResponse(rsp)
-}
 
-// MTLSCAsWithResponse request returning *MTLSCAsResponse
-func (c *ClientWithResponses) MTLSCAsWithResponse(ctx context.Context) (*MTLSCAsResponse, error) {
-	rsp, err := c.MTLSCAs(ctx)
-	if err != nil {
-		return nil, err
+	switch {
 	}
-	return ParseMTLSCAsResponse(rsp)
+
+	return response, nil
 }
 
-// MTLSCertificatesWithResponse request returning *MTLSCertificatesResponse
-func (c *ClientWithResponses) MTLSCertificatesWithResponse(ctx context.Context) (*MTLSCertificatesResponse, error) {
-	rsp, err := c.MTLSCertificates(ctx)
+// ParseGetParkedEventResponse parses an HTTP response from a GetParkedEventWithResponse call
+func ParseGetParkedEventResponse(rsp *http.Response) (*GetParkedEventResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseMTLSCertificatesResponse(rsp)
+
+	response := &GetParkedEventResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ParkedEvent
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// VendorClaimWithBodyWithResponse request with arbitrary body returning *VendorClaimResponse
-func (c *ClientWithResponses) VendorClaimWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*VendorClaimResponse, error) {
-	rsp, err := c.VendorClaimWithBody(ctx, contentType, body)
+// ParseRetryParkedEventResponse parses an HTTP response from a RetryParkedEventWithResponse call
+func ParseRetryParkedEventResponse(rsp *http.Response) (*RetryParkedEventResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseVendorClaimResponse(rsp)
+
+	response := &RetryParkedEventResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	}
+
+	return response, nil
 }
 
-func (c *ClientWithResponses) VendorClaimWithResponse(ctx context.Context, body VendorClaimJSONRequestBody) (*VendorClaimResponse, error) {
-	rsp, err := c.VendorClaim(ctx, body)
+// ParseVerifyResponse parses an HTTP response from a VerifyWithResponse call
+func ParseVerifyResponse(rsp *http.Response) (*VerifyResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseVendorClaimResponse(rsp)
+
+	response := &VerifyResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest struct {
+
+			// list of events that resulted from fixing the data, list may be empty
+			Events *[]Event `json:"events,omitempty"`
+
+			// if true, the data in the registry needs fixing/upgrading.
+			Fix *bool `json:"fix,omitempty"`
+		}
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// OrganizationByIdWithResponse request returning *OrganizationByIdResponse
-func (c *ClientWithResponses) OrganizationByIdWithResponse(ctx context.Context, id string) (*OrganizationByIdResponse, error) {
-	rsp, err := c.OrganizationById(ctx, id)
+// ParseEndpointsByOrganisationIdResponse parses an HTTP response from a EndpointsByOrganisationIdWithResponse call
+func ParseEndpointsByOrganisationIdResponse(rsp *http.Response) (*EndpointsByOrganisationIdResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseOrganizationByIdResponse(rsp)
+
+	response := &EndpointsByOrganisationIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	}
+
+	return response, nil
 }
 
-// RegisterEndpointWithBodyWithResponse request with arbitrary body returning *RegisterEndpointResponse
-func (c *ClientWithResponses) RegisterEndpointWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*RegisterEndpointResponse, error) {
-	rsp, err := c.RegisterEndpointWithBody(ctx, id, contentType, body)
+// ParseSearchEndpointsResponse parses an HTTP response from a SearchEndpointsWithResponse call
+func ParseSearchEndpointsResponse(rsp *http.Response) (*SearchEndpointsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseRegisterEndpointResponse(rsp)
+
+	response := &SearchEndpointsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []OrganizationEndpoint
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-func (c *ClientWithResponses) RegisterEndpointWithResponse(ctx context.Context, id string, body RegisterEndpointJSONRequestBody) (*RegisterEndpointResponse, error) {
-	rsp, err := c.RegisterEndpoint(ctx, id, body)
+// ParseListEventSchemasResponse parses an HTTP response from a ListEventSchemasWithResponse call
+func ParseListEventSchemasResponse(rsp *http.Response) (*ListEventSchemasResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseRegisterEndpointResponse(rsp)
+
+	response := &ListEventSchemasResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []EventSchema
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// RefreshOrganizationCertificateWithResponse request returning *RefreshOrganizationCertificateResponse
-func (c *ClientWithResponses) RefreshOrganizationCertificateWithResponse(ctx context.Context, id string) (*RefreshOrganizationCertificateResponse, error) {
-	rsp, err := c.RefreshOrganizationCertificate(ctx, id)
+// ParseGetEventSchemaResponse parses an HTTP response from a GetEventSchemaWithResponse call
+func ParseGetEventSchemaResponse(rsp *http.Response) (*GetEventSchemaResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseRefreshOrganizationCertificateResponse(rsp)
+
+	response := &GetEventSchemaResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	}
+
+	return response, nil
 }
 
-// SearchOrganizationsWithResponse request returning *SearchOrganizationsResponse
-func (c *ClientWithResponses) SearchOrganizationsWithResponse(ctx context.Context, params *SearchOrganizationsParams) (*SearchOrganizationsResponse, error) {
-	rsp, err := c.SearchOrganizations(ctx, params)
+// ParseStreamEventsResponse parses an HTTP response from a StreamEventsWithResponse call
+func ParseStreamEventsResponse(rsp *http.Response) (*StreamEventsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseSearchOrganizationsResponse(rsp)
+
+	response := &StreamEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	}
+
+	return response, nil
 }
 
-// VendorByIdWithResponse request returning *VendorByIdResponse
-func (c *ClientWithResponses) VendorByIdWithResponse(ctx context.Context, id string) (*VendorByIdResponse, error) {
-	rsp, err := c.VendorById(ctx, id)
+// ParseMTLSCAsResponse parses an HTTP response from a MTLSCAsWithResponse call
+func ParseMTLSCAsResponse(rsp *http.Response) (*MTLSCAsResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseVendorByIdResponse(rsp)
+
+	response := &MTLSCAsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest CAListWithChain
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case rsp.StatusCode == 200:
+		// Content-type (application/x-pem-file) unsupported
+
+	}
+
+	return response, nil
 }
 
-// DeprecatedVendorClaimWithBodyWithResponse request with arbitrary body returning *DeprecatedVendorClaimResponse
-func (c *ClientWithResponses) DeprecatedVendorClaimWithBodyWithResponse(ctx context.Context, id string, contentType string, body io.Reader) (*DeprecatedVendorClaimResponse, error) {
-	rsp, err := c.DeprecatedVendorClaimWithBody(ctx, id, contentType, body)
+// ParseMTLSCertificatesResponse parses an HTTP response from a MTLSCertificatesWithResponse call
+func ParseMTLSCertificatesResponse(rsp *http.Response) (*MTLSCertificatesResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
-	return ParseDeprecatedVendorClaimResponse(rsp)
-}
 
-func (c *ClientWithResponses) DeprecatedVendorClaimWithResponse(ctx context.Context, id string, body DeprecatedVendorClaimJSONRequestBody) (*DeprecatedVendorClaimResponse, error) {
-	rsp, err := c.DeprecatedVendorClaim(ctx, id, body)
-	if err != nil {
-		return nil, err
+	response := &MTLSCertificatesResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseDeprecatedVendorClaimResponse(rsp)
-}
 
-// RegisterVendorWithBodyWithResponse request with arbitrary body returning *RegisterVendorResponse
-func (c *ClientWithResponses) RegisterVendorWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader) (*RegisterVendorResponse, error) {
-	rsp, err := c.RegisterVendorWithBody(ctx, contentType, body)
-	if err != nil {
-		return nil, err
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []string
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case rsp.StatusCode == 200:
+		// Content-type (application/x-pem-file) unsupported
+
 	}
-	return ParseRegisterVendorResponse(rsp)
+
+	return response, nil
 }
 
-// ParseVerifyResponse parses an HTTP response from a VerifyWithResponse call
-func ParseVerifyResponse(rsp *http.Response) (*VerifyResponse, error) {
+// ParseVendorClaimResponse parses an HTTP response from a VendorClaimWithResponse call
+func ParseVendorClaimResponse(rsp *http.Response) (*VendorClaimResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &VerifyResponse{
+	response := &VendorClaimResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest struct {
-
-			// list of events that resulted from fixing the data, list may be empty
-			Events *[]Event `json:"events,omitempty"`
-
-			// if true, the data in the registry needs fixing/upgrading.
-			Fix *bool `json:"fix,omitempty"`
-		}
+		var dest Event
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -1556,92 +4141,93 @@ func ParseVerifyResponse(rsp *http.Response) (*VerifyResponse, error) {
 	return response, nil
 }
 
-// ParseEndpointsByOrganisationIdResponse parses an HTTP response from a EndpointsByOrganisationIdWithResponse call
-func ParseEndpointsByOrganisationIdResponse(rsp *http.Response) (*EndpointsByOrganisationIdResponse, error) {
+// ParseEndVendorClaimResponse parses an HTTP response from a EndVendorClaimWithResponse call
+func ParseEndVendorClaimResponse(rsp *http.Response) (*EndVendorClaimResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &EndpointsByOrganisationIdResponse{
+	response := &EndVendorClaimResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Event
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	}
 
 	return response, nil
 }
 
-// ParseMTLSCAsResponse parses an HTTP response from a MTLSCAsWithResponse call
-func ParseMTLSCAsResponse(rsp *http.Response) (*MTLSCAsResponse, error) {
+// ParseOrganizationByIdResponse parses an HTTP response from a OrganizationByIdWithResponse call
+func ParseOrganizationByIdResponse(rsp *http.Response) (*OrganizationByIdResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &MTLSCAsResponse{
+	response := &OrganizationByIdResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest CAListWithChain
+		var dest Organization
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
 		response.JSON200 = &dest
 
-	case rsp.StatusCode == 200:
-		// Content-type (application/x-pem-file) unsupported
-
 	}
 
 	return response, nil
 }
 
-// ParseMTLSCertificatesResponse parses an HTTP response from a MTLSCertificatesWithResponse call
-func ParseMTLSCertificatesResponse(rsp *http.Response) (*MTLSCertificatesResponse, error) {
+// ParseRegisterEndpointResponse parses an HTTP response from a RegisterEndpointWithResponse call
+func ParseRegisterEndpointResponse(rsp *http.Response) (*RegisterEndpointResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &MTLSCertificatesResponse{
+	response := &RegisterEndpointResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest []string
+		var dest Event
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
 		response.JSON200 = &dest
 
-	case rsp.StatusCode == 200:
-		// Content-type (application/x-pem-file) unsupported
-
 	}
 
 	return response, nil
 }
 
-// ParseVendorClaimResponse parses an HTTP response from a VendorClaimWithResponse call
-func ParseVendorClaimResponse(rsp *http.Response) (*VendorClaimResponse, error) {
+// ParseDeregisterEndpointResponse parses an HTTP response from a DeregisterEndpointWithResponse call
+func ParseDeregisterEndpointResponse(rsp *http.Response) (*DeregisterEndpointResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &VendorClaimResponse{
+	response := &DeregisterEndpointResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -1659,22 +4245,22 @@ func ParseVendorClaimResponse(rsp *http.Response) (*VendorClaimResponse, error)
 	return response, nil
 }
 
-// ParseOrganizationByIdResponse parses an HTTP response from a OrganizationByIdWithResponse call
-func ParseOrganizationByIdResponse(rsp *http.Response) (*OrganizationByIdResponse, error) {
+// ParseEndpointHistoryResponse parses an HTTP response from a EndpointHistoryWithResponse call
+func ParseEndpointHistoryResponse(rsp *http.Response) (*EndpointHistoryResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &OrganizationByIdResponse{
+	response := &EndpointHistoryResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest Organization
+		var dest []EventHistoryEntry
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -1685,22 +4271,22 @@ func ParseOrganizationByIdResponse(rsp *http.Response) (*OrganizationByIdRespons
 	return response, nil
 }
 
-// ParseRegisterEndpointResponse parses an HTTP response from a RegisterEndpointWithResponse call
-func ParseRegisterEndpointResponse(rsp *http.Response) (*RegisterEndpointResponse, error) {
+// ParseOrganizationHistoryResponse parses an HTTP response from a OrganizationHistoryWithResponse call
+func ParseOrganizationHistoryResponse(rsp *http.Response) (*OrganizationHistoryResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &RegisterEndpointResponse{
+	response := &OrganizationHistoryResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest Event
+		var dest []EventHistoryEntry
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -1756,6 +4342,32 @@ func ParseSearchOrganizationsResponse(rsp *http.Response) (*SearchOrganizationsR
 	return response, nil
 }
 
+// ParseRetireVendorResponse parses an HTTP response from a RetireVendorWithResponse call
+func ParseRetireVendorResponse(rsp *http.Response) (*RetireVendorResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RetireVendorResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Event
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseVendorByIdResponse parses an HTTP response from a VendorByIdWithResponse call
 func ParseVendorByIdResponse(rsp *http.Response) (*VendorByIdResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
@@ -1808,75 +4420,285 @@ func ParseDeprecatedVendorClaimResponse(rsp *http.Response) (*DeprecatedVendorCl
 	return response, nil
 }
 
+// ParseVendorHistoryResponse parses an HTTP response from a VendorHistoryWithResponse call
+func ParseVendorHistoryResponse(rsp *http.Response) (*VendorHistoryResponse, error) {
+	bodyBytes, err := ioutil.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &VendorHistoryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []EventHistoryEntry
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseRegisterVendorResponse parses an HTTP response from a RegisterVendorWithResponse call
 func ParseRegisterVendorResponse(rsp *http.Response) (*RegisterVendorResponse, error) {
 	bodyBytes, err := ioutil.ReadAll(rsp.Body)
 	defer rsp.Body.Close()
 	if err != nil {
-		return nil, err
+		return nil, err
+	}
+
+	response := &RegisterVendorResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Event
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Returns the state digest over the refs of all applied events.
+	// (GET /api/admin/digest)
+	GetStateDigest(ctx echo.Context, params GetStateDigestParams) error
+	// Exports all events in chain order as tar.gz bundle.
+	// (GET /api/admin/export)
+	ExportEvents(ctx echo.Context) error
+	// Lists the event paths which forked, because multiple events refer to the same previous event.
+	// (GET /api/admin/forks)
+	ListForks(ctx echo.Context) error
+	// Merges a fork by publishing an event which extends the canonical branch.
+	// (POST /api/admin/forks/{ref}/merge)
+	MergeFork(ctx echo.Context, ref string) error
+	// Validates and (optionally) imports a tar.gz bundle of events.
+	// (POST /api/admin/import)
+	ImportEvents(ctx echo.Context, params ImportEventsParams) error
+	// Lists the events which couldn't be applied (yet) and are parked in the retry queue.
+	// (GET /api/admin/retry-queue)
+	ListParkedEvents(ctx echo.Context) error
+	// Discards a parked event, so it won't be retried anymore.
+	// (DELETE /api/admin/retry-queue/{ref})
+	DiscardParkedEvent(ctx echo.Context, ref string) error
+	// Get a parked event by its ref.
+	// (GET /api/admin/retry-queue/{ref})
+	GetParkedEvent(ctx echo.Context, ref string) error
+	// Retries applying a parked event, even when it has been dead-lettered. Its retry budget is reset.
+	// (POST /api/admin/retry-queue/{ref}/retry)
+	RetryParkedEvent(ctx echo.Context, ref string) error
+	// Verifies the registry data (owned by the vendor) and fixes where necessarry (e.g. issue certificates) if fix = true.
+	// (POST /api/admin/verify)
+	Verify(ctx echo.Context, params VerifyParams) error
+	// Find endpoints based on organisation identifiers and type of endpoint (optional)
+	// (GET /api/endpoints)
+	EndpointsByOrganisationId(ctx echo.Context, params EndpointsByOrganisationIdParams) error
+	// Find endpoints across all organizations, e.g. all active FHIR endpoints supporting a specific version
+	// (GET /api/endpoints/search)
+	SearchEndpoints(ctx echo.Context, params SearchEndpointsParams) error
+	// Lists the JSON schemas of the event payloads.
+	// (GET /api/events/schemas)
+	ListEventSchemas(ctx echo.Context) error
+	// Get the JSON schema which applies to the payload of events of the given type and version.
+	// (GET /api/events/schemas/{type}/{version})
+	GetEventSchema(ctx echo.Context, pType string, version int) error
+	// Streams the events applied by the registry as Server-Sent Events (SSE).
+	// (GET /api/events/stream)
+	StreamEvents(ctx echo.Context, params StreamEventsParams) error
+	// Get a list of current active vendor CAs
+	// (GET /api/mtls/cas)
+	MTLSCAs(ctx echo.Context) error
+	// Get a list of current active certificates that may be used to setup a mTLS connection
+	// (GET /api/mtls/certificates)
+	MTLSCertificates(ctx echo.Context) error
+	// Claim an organization for the current vendor (registers an organization under the vendor in the registry).
+	// (POST /api/organization)
+	VendorClaim(ctx echo.Context) error
+	// Ends the current vendor's claim on the organization
+	// (DELETE /api/organization/{id})
+	EndVendorClaim(ctx echo.Context, id string) error
+	// Get organization by id
+	// (GET /api/organization/{id})
+	OrganizationById(ctx echo.Context, id string, params OrganizationByIdParams) error
+	// Adds/updates an endpoint for this organisation to the registry. If the endpoint already exists (matched by endpoint ID) it is updated.
+	// (POST /api/organization/{id}/endpoints)
+	RegisterEndpoint(ctx echo.Context, id string) error
+	// Deregisters an endpoint of the organization
+	// (DELETE /api/organization/{id}/endpoints/{endpointId})
+	DeregisterEndpoint(ctx echo.Context, id string, endpointId string) error
+	// Get the event history of an endpoint of an organization
+	// (GET /api/organization/{id}/endpoints/{endpointId}/history)
+	EndpointHistory(ctx echo.Context, id string, endpointId string) error
+	// Get the event history of an organization
+	// (GET /api/organization/{id}/history)
+	OrganizationHistory(ctx echo.Context, id string) error
+	// Refreshes the organization's certificate.
+	// (POST /api/organization/{id}/refresh-cert)
+	RefreshOrganizationCertificate(ctx echo.Context, id string) error
+	// Search for organizations
+	// (GET /api/organizations)
+	SearchOrganizations(ctx echo.Context, params SearchOrganizationsParams) error
+	// Retires the vendor
+	// (DELETE /api/vendor/{id})
+	RetireVendor(ctx echo.Context, id string) error
+	// Get vendor by id
+	// (GET /api/vendor/{id})
+	VendorById(ctx echo.Context, id string, params VendorByIdParams) error
+	// Claim an organization for a vendor (registers an organization under a vendor in the registry).
+	// (POST /api/vendor/{id}/claim)
+	DeprecatedVendorClaim(ctx echo.Context, id string) error
+	// Get the event history of a vendor
+	// (GET /api/vendor/{id}/history)
+	VendorHistory(ctx echo.Context, id string) error
+	// Registers the vendor in the registry
+	// (POST /api/vendors)
+	RegisterVendor(ctx echo.Context) error
+}
+
+// ServerInterfaceWrapper converts echo contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler ServerInterface
+}
+
+// GetStateDigest converts echo context to params.
+func (w *ServerInterfaceWrapper) GetStateDigest(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetStateDigestParams
+	// ------------- Optional query parameter "refs" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "refs", ctx.QueryParams(), &params.Refs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter refs: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetStateDigest(ctx, params)
+	return err
+}
+
+// ExportEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) ExportEvents(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ExportEvents(ctx)
+	return err
+}
+
+// ListForks converts echo context to params.
+func (w *ServerInterfaceWrapper) ListForks(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListForks(ctx)
+	return err
+}
+
+// MergeFork converts echo context to params.
+func (w *ServerInterfaceWrapper) MergeFork(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "ref" -------------
+	var ref string
+
+	err = runtime.BindStyledParameter("simple", false, "ref", ctx.Param("ref"), &ref)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ref: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.MergeFork(ctx, ref)
+	return err
+}
+
+// ImportEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) ImportEvents(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ImportEventsParams
+	// ------------- Optional query parameter "apply" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "apply", ctx.QueryParams(), &params.Apply)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter apply: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ImportEvents(ctx, params)
+	return err
+}
+
+// ListParkedEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) ListParkedEvents(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListParkedEvents(ctx)
+	return err
+}
+
+// DiscardParkedEvent converts echo context to params.
+func (w *ServerInterfaceWrapper) DiscardParkedEvent(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "ref" -------------
+	var ref string
+
+	err = runtime.BindStyledParameter("simple", false, "ref", ctx.Param("ref"), &ref)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ref: %s", err))
 	}
 
-	response := &RegisterVendorResponse{
-		Body:         bodyBytes,
-		HTTPResponse: rsp,
-	}
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DiscardParkedEvent(ctx, ref)
+	return err
+}
 
-	switch {
-	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest Event
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return nil, err
-		}
-		response.JSON200 = &dest
+// GetParkedEvent converts echo context to params.
+func (w *ServerInterfaceWrapper) GetParkedEvent(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "ref" -------------
+	var ref string
 
+	err = runtime.BindStyledParameter("simple", false, "ref", ctx.Param("ref"), &ref)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ref: %s", err))
 	}
 
-	return response, nil
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetParkedEvent(ctx, ref)
+	return err
 }
 
-// ServerInterface represents all server handlers.
-type ServerInterface interface {
-	// Verifies the registry data (owned by the vendor) and fixes where necessarry (e.g. issue certificates) if fix = true.
-	// (POST /api/admin/verify)
-	Verify(ctx echo.Context, params VerifyParams) error
-	// Find endpoints based on organisation identifiers and type of endpoint (optional)
-	// (GET /api/endpoints)
-	EndpointsByOrganisationId(ctx echo.Context, params EndpointsByOrganisationIdParams) error
-	// Get a list of current active vendor CAs
-	// (GET /api/mtls/cas)
-	MTLSCAs(ctx echo.Context) error
-	// Get a list of current active certificates that may be used to setup a mTLS connection
-	// (GET /api/mtls/certificates)
-	MTLSCertificates(ctx echo.Context) error
-	// Claim an organization for the current vendor (registers an organization under the vendor in the registry).
-	// (POST /api/organization)
-	VendorClaim(ctx echo.Context) error
-	// Get organization by id
-	// (GET /api/organization/{id})
-	OrganizationById(ctx echo.Context, id string) error
-	// Adds/updates an endpoint for this organisation to the registry. If the endpoint already exists (matched by endpoint ID) it is updated.
-	// (POST /api/organization/{id}/endpoints)
-	RegisterEndpoint(ctx echo.Context, id string) error
-	// Refreshes the organization's certificate.
-	// (POST /api/organization/{id}/refresh-cert)
-	RefreshOrganizationCertificate(ctx echo.Context, id string) error
-	// Search for organizations
-	// (GET /api/organizations)
-	SearchOrganizations(ctx echo.Context, params SearchOrganizationsParams) error
-	// Get vendor by id
-	// (GET /api/vendor/{id})
-	VendorById(ctx echo.Context, id string) error
-	// Claim an organization for a vendor (registers an organization under a vendor in the registry).
-	// (POST /api/vendor/{id}/claim)
-	DeprecatedVendorClaim(ctx echo.Context, id string) error
-	// Registers the vendor in the registry
-	// (POST /api/vendors)
-	RegisterVendor(ctx echo.Context) error
-}
+// RetryParkedEvent converts echo context to params.
+func (w *ServerInterfaceWrapper) RetryParkedEvent(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "ref" -------------
+	var ref string
 
-// ServerInterfaceWrapper converts echo contexts to parameters.
-type ServerInterfaceWrapper struct {
-	Handler ServerInterface
+	err = runtime.BindStyledParameter("simple", false, "ref", ctx.Param("ref"), &ref)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ref: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RetryParkedEvent(ctx, ref)
+	return err
 }
 
 // Verify converts echo context to params.
@@ -1924,11 +4746,153 @@ func (w *ServerInterfaceWrapper) EndpointsByOrganisationId(ctx echo.Context) err
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter strict: %s", err))
 	}
 
+	// ------------- Optional query parameter "asOf" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "asOf", ctx.QueryParams(), &params.AsOf)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter asOf: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
 	err = w.Handler.EndpointsByOrganisationId(ctx, params)
 	return err
 }
 
+// SearchEndpoints converts echo context to params.
+func (w *ServerInterfaceWrapper) SearchEndpoints(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchEndpointsParams
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", ctx.QueryParams(), &params.Type)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "includeUnavailable" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeUnavailable", ctx.QueryParams(), &params.IncludeUnavailable)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter includeUnavailable: %s", err))
+	}
+
+	// ------------- Optional query parameter "vendor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "vendor", ctx.QueryParams(), &params.Vendor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vendor: %s", err))
+	}
+
+	// ------------- Optional query parameter "domain" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "domain", ctx.QueryParams(), &params.Domain)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter domain: %s", err))
+	}
+
+	// ------------- Optional query parameter "property" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "property", ctx.QueryParams(), &params.Property)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter property: %s", err))
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.SearchEndpoints(ctx, params)
+	return err
+}
+
+// ListEventSchemas converts echo context to params.
+func (w *ServerInterfaceWrapper) ListEventSchemas(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListEventSchemas(ctx)
+	return err
+}
+
+// GetEventSchema converts echo context to params.
+func (w *ServerInterfaceWrapper) GetEventSchema(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "type" -------------
+	var pType string
+
+	err = runtime.BindStyledParameter("simple", false, "type", ctx.Param("type"), &pType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// ------------- Path parameter "version" -------------
+	var version int
+
+	err = runtime.BindStyledParameter("simple", false, "version", ctx.Param("version"), &version)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter version: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetEventSchema(ctx, pType, version)
+	return err
+}
+
+// StreamEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) StreamEvents(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StreamEventsParams
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Last-Event-ID, got %d", n))
+		}
+
+		err = runtime.BindStyledParameter("simple", false, "Last-Event-ID", valueList[0], &LastEventID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Last-Event-ID: %s", err))
+		}
+
+		params.LastEventID = &LastEventID
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.StreamEvents(ctx, params)
+	return err
+}
+
 // MTLSCAs converts echo context to params.
 func (w *ServerInterfaceWrapper) MTLSCAs(ctx echo.Context) error {
 	var err error
@@ -1956,6 +4920,22 @@ func (w *ServerInterfaceWrapper) VendorClaim(ctx echo.Context) error {
 	return err
 }
 
+// EndVendorClaim converts echo context to params.
+func (w *ServerInterfaceWrapper) EndVendorClaim(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.EndVendorClaim(ctx, id)
+	return err
+}
+
 // OrganizationById converts echo context to params.
 func (w *ServerInterfaceWrapper) OrganizationById(ctx echo.Context) error {
 	var err error
@@ -1967,8 +4947,17 @@ func (w *ServerInterfaceWrapper) OrganizationById(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params OrganizationByIdParams
+	// ------------- Optional query parameter "asOf" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "asOf", ctx.QueryParams(), &params.AsOf)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter asOf: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.OrganizationById(ctx, id)
+	err = w.Handler.OrganizationById(ctx, id, params)
 	return err
 }
 
@@ -1988,6 +4977,70 @@ func (w *ServerInterfaceWrapper) RegisterEndpoint(ctx echo.Context) error {
 	return err
 }
 
+// DeregisterEndpoint converts echo context to params.
+func (w *ServerInterfaceWrapper) DeregisterEndpoint(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "endpointId" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameter("simple", false, "endpointId", ctx.Param("endpointId"), &endpointId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpointId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeregisterEndpoint(ctx, id, endpointId)
+	return err
+}
+
+// EndpointHistory converts echo context to params.
+func (w *ServerInterfaceWrapper) EndpointHistory(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "endpointId" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameter("simple", false, "endpointId", ctx.Param("endpointId"), &endpointId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpointId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.EndpointHistory(ctx, id, endpointId)
+	return err
+}
+
+// OrganizationHistory converts echo context to params.
+func (w *ServerInterfaceWrapper) OrganizationHistory(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.OrganizationHistory(ctx, id)
+	return err
+}
+
 // RefreshOrganizationCertificate converts echo context to params.
 func (w *ServerInterfaceWrapper) RefreshOrganizationCertificate(ctx echo.Context) error {
 	var err error
@@ -2024,11 +5077,41 @@ func (w *ServerInterfaceWrapper) SearchOrganizations(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter exact: %s", err))
 	}
 
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
 	err = w.Handler.SearchOrganizations(ctx, params)
 	return err
 }
 
+// RetireVendor converts echo context to params.
+func (w *ServerInterfaceWrapper) RetireVendor(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RetireVendor(ctx, id)
+	return err
+}
+
 // VendorById converts echo context to params.
 func (w *ServerInterfaceWrapper) VendorById(ctx echo.Context) error {
 	var err error
@@ -2040,8 +5123,17 @@ func (w *ServerInterfaceWrapper) VendorById(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params VendorByIdParams
+	// ------------- Optional query parameter "asOf" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "asOf", ctx.QueryParams(), &params.AsOf)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter asOf: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.VendorById(ctx, id)
+	err = w.Handler.VendorById(ctx, id, params)
 	return err
 }
 
@@ -2061,6 +5153,22 @@ func (w *ServerInterfaceWrapper) DeprecatedVendorClaim(ctx echo.Context) error {
 	return err
 }
 
+// VendorHistory converts echo context to params.
+func (w *ServerInterfaceWrapper) VendorHistory(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.VendorHistory(ctx, id)
+	return err
+}
+
 // RegisterVendor converts echo context to params.
 func (w *ServerInterfaceWrapper) RegisterVendor(ctx echo.Context) error {
 	var err error
@@ -2098,17 +5206,36 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/api/admin/digest", wrapper.GetStateDigest)
+	router.GET(baseURL+"/api/admin/export", wrapper.ExportEvents)
+	router.GET(baseURL+"/api/admin/forks", wrapper.ListForks)
+	router.POST(baseURL+"/api/admin/forks/:ref/merge", wrapper.MergeFork)
+	router.POST(baseURL+"/api/admin/import", wrapper.ImportEvents)
+	router.GET(baseURL+"/api/admin/retry-queue", wrapper.ListParkedEvents)
+	router.DELETE(baseURL+"/api/admin/retry-queue/:ref", wrapper.DiscardParkedEvent)
+	router.GET(baseURL+"/api/admin/retry-queue/:ref", wrapper.GetParkedEvent)
+	router.POST(baseURL+"/api/admin/retry-queue/:ref/retry", wrapper.RetryParkedEvent)
 	router.POST(baseURL+"/api/admin/verify", wrapper.Verify)
 	router.GET(baseURL+"/api/endpoints", wrapper.EndpointsByOrganisationId)
+	router.GET(baseURL+"/api/endpoints/search", wrapper.SearchEndpoints)
+	router.GET(baseURL+"/api/events/schemas", wrapper.ListEventSchemas)
+	router.GET(baseURL+"/api/events/schemas/:type/:version", wrapper.GetEventSchema)
+	router.GET(baseURL+"/api/events/stream", wrapper.StreamEvents)
 	router.GET(baseURL+"/api/mtls/cas", wrapper.MTLSCAs)
 	router.GET(baseURL+"/api/mtls/certificates", wrapper.MTLSCertificates)
 	router.POST(baseURL+"/api/organization", wrapper.VendorClaim)
+	router.DELETE(baseURL+"/api/organization/:id", wrapper.EndVendorClaim)
 	router.GET(baseURL+"/api/organization/:id", wrapper.OrganizationById)
 	router.POST(baseURL+"/api/organization/:id/endpoints", wrapper.RegisterEndpoint)
+	router.DELETE(baseURL+"/api/organization/:id/endpoints/:endpointId", wrapper.DeregisterEndpoint)
+	router.GET(baseURL+"/api/organization/:id/endpoints/:endpointId/history", wrapper.EndpointHistory)
+	router.GET(baseURL+"/api/organization/:id/history", wrapper.OrganizationHistory)
 	router.POST(baseURL+"/api/organization/:id/refresh-cert", wrapper.RefreshOrganizationCertificate)
 	router.GET(baseURL+"/api/organizations", wrapper.SearchOrganizations)
+	router.DELETE(baseURL+"/api/vendor/:id", wrapper.RetireVendor)
 	router.GET(baseURL+"/api/vendor/:id", wrapper.VendorById)
 	router.POST(baseURL+"/api/vendor/:id/claim", wrapper.DeprecatedVendorClaim)
+	router.GET(baseURL+"/api/vendor/:id/history", wrapper.VendorHistory)
 	router.POST(baseURL+"/api/vendors", wrapper.RegisterVendor)
 
 }
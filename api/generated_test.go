@@ -85,13 +85,19 @@ func (e RestInterfaceStub) SearchOrganizations(ctx echo.Context, params SearchOr
 	return err
 }
 
-func (e RestInterfaceStub) OrganizationById(ctx echo.Context, id string) error {
+func (e RestInterfaceStub) SearchEndpoints(ctx echo.Context, params SearchEndpointsParams) error {
 	var err error
 
 	return err
 }
 
-func (e RestInterfaceStub) VendorById(ctx echo.Context, id string) error {
+func (e RestInterfaceStub) OrganizationById(ctx echo.Context, id string, params OrganizationByIdParams) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) VendorById(ctx echo.Context, id string, params VendorByIdParams) error {
 	var err error
 
 	return err
@@ -109,6 +115,114 @@ func (e RestInterfaceStub) MTLSCertificates(ctx echo.Context) error {
 	return err
 }
 
+func (e RestInterfaceStub) StreamEvents(ctx echo.Context, params StreamEventsParams) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) VendorHistory(ctx echo.Context, id string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) OrganizationHistory(ctx echo.Context, id string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) EndpointHistory(ctx echo.Context, id string, endpointId string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) ListParkedEvents(ctx echo.Context) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) GetParkedEvent(ctx echo.Context, ref string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) RetryParkedEvent(ctx echo.Context, ref string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) DiscardParkedEvent(ctx echo.Context, ref string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) ListForks(ctx echo.Context) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) MergeFork(ctx echo.Context, ref string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) DeregisterEndpoint(ctx echo.Context, id string, endpointId string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) EndVendorClaim(ctx echo.Context, id string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) RetireVendor(ctx echo.Context, id string) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) GetStateDigest(ctx echo.Context, params GetStateDigestParams) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) ExportEvents(ctx echo.Context) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) ImportEvents(ctx echo.Context, params ImportEventsParams) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) ListEventSchemas(ctx echo.Context) error {
+	var err error
+
+	return err
+}
+
+func (e RestInterfaceStub) GetEventSchema(ctx echo.Context, pType string, version int) error {
+	var err error
+
+	return err
+}
+
 func TestServerInterfaceWrapper_EndpointsByOrganisationId(t *testing.T) {
 	t.Run("200", func(t *testing.T) {
 		e := echo.New()
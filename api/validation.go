@@ -19,7 +19,11 @@
 
 package api
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+)
 
 func (o Organization) validate() error {
 	if err := nonEmptyString(o.Identifier.String(), "identifier"); err != nil {
@@ -38,6 +42,13 @@ func (e Endpoint) validate() error {
 	if err := nonEmptyString(e.EndpointType, "endpoint type"); err != nil {
 		return err
 	}
+	// The status can be left out, in which case the endpoint is registered as active
+	if e.Status != "" && !domain.IsEndpointStatus(e.Status) {
+		return errors.New("invalid status: " + e.Status)
+	}
+	if e.NotBefore != nil && e.NotAfter != nil && !e.NotAfter.After(*e.NotBefore) {
+		return errors.New("notAfter must be after notBefore")
+	}
 	return nil
 }
 
@@ -18,7 +18,10 @@
  */
 package api
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestEndpoint_validate(t *testing.T) {
 	type fields struct {
@@ -26,7 +29,11 @@ func TestEndpoint_validate(t *testing.T) {
 		EndpointType string
 		Identifier   Identifier
 		Status       string
+		NotBefore    *time.Time
+		NotAfter     *time.Time
 	}
+	now := time.Now()
+	later := now.Add(time.Hour)
 	tests := []struct {
 		name    string
 		fields  fields
@@ -36,6 +43,10 @@ func TestEndpoint_validate(t *testing.T) {
 		{name: "missing type", fields: fields{Identifier: "id", URL: "foo:bar"}, wantErr: true},
 		{name: "missing url", fields: fields{Identifier: "id"}, wantErr: true},
 		{name: "missing all", fields: fields{}, wantErr: true},
+		{name: "ok - status", fields: fields{Identifier: "id", URL: "foo:bar", EndpointType: "fhir", Status: "maintenance"}, wantErr: false},
+		{name: "invalid status", fields: fields{Identifier: "id", URL: "foo:bar", EndpointType: "fhir", Status: "inactive"}, wantErr: true},
+		{name: "ok - availability window", fields: fields{Identifier: "id", URL: "foo:bar", EndpointType: "fhir", NotBefore: &now, NotAfter: &later}, wantErr: false},
+		{name: "empty availability window", fields: fields{Identifier: "id", URL: "foo:bar", EndpointType: "fhir", NotBefore: &later, NotAfter: &now}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -44,6 +55,8 @@ func TestEndpoint_validate(t *testing.T) {
 				EndpointType: tt.fields.EndpointType,
 				Identifier:   tt.fields.Identifier,
 				Status:       tt.fields.Status,
+				NotBefore:    tt.fields.NotBefore,
+				NotAfter:     tt.fields.NotAfter,
 			}
 			if err := e.validate(); (err != nil) != tt.wantErr {
 				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
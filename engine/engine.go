@@ -21,6 +21,7 @@ package engine
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/nuts-foundation/nuts-registry/logging"
 	"io/ioutil"
@@ -38,8 +39,10 @@ import (
 	"github.com/nuts-foundation/nuts-registry/client"
 	"github.com/nuts-foundation/nuts-registry/pkg"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -72,6 +75,9 @@ func flagSet() *pflag.FlagSet {
 
 	defs := pkg.DefaultRegistryConfig()
 	flagSet.String(pkg.ConfDataDir, defs.Datadir, fmt.Sprintf("Location of data files, default: %s", defs.Datadir))
+	flagSet.String(pkg.ConfEventStore, defs.EventStore, fmt.Sprintf("The storage used for events, 'fs' for a file per event or 'bbolt' for an embedded transactional store, default: %s", defs.EventStore))
+	flagSet.String(pkg.ConfDatabase, defs.Database, fmt.Sprintf("The database holding the registry's state, 'memory' or 'sqlite' to store it in a SQLite database (registry.sqlite in the data directory) which can be queried by other applications. Existing tables of the SQLite database are dropped and recreated on startup, default: %s", defs.Database))
+	flagSet.Int(pkg.ConfSnapshotInterval, defs.SnapshotInterval, fmt.Sprintf("The interval in minutes at which a snapshot of the registry state is created to speed up startup, 0 disables snapshots, default: %d", defs.SnapshotInterval))
 	flagSet.String(pkg.ConfMode, defs.Mode, fmt.Sprintf("server or client, when client it uses the HttpClient, default: %s", defs.Mode))
 	flagSet.String(pkg.ConfAddress, defs.Address, fmt.Sprintf("Interface and port for http server to bind to, default: %s", defs.Address))
 	flagSet.String(pkg.ConfSyncMode, defs.SyncMode, fmt.Sprintf("The method for updating the data, 'fs' for a filesystem watch or 'github' for a periodic download, default: %s", defs.SyncMode))
@@ -80,6 +86,7 @@ func flagSet() *pflag.FlagSet {
 	flagSet.Int(pkg.ConfVendorCACertificateValidity, defs.VendorCACertificateValidity, fmt.Sprintf("Number of days vendor CA certificates are valid, default: %d", defs.VendorCACertificateValidity))
 	flagSet.Int(pkg.ConfOrganisationCertificateValidity, defs.OrganisationCertificateValidity, fmt.Sprintf("Number of days organisation certificates are valid, default: %d", defs.OrganisationCertificateValidity))
 	flagSet.Int(pkg.ConfClientTimeout, defs.ClientTimeout, fmt.Sprintf("Time-out for the client in seconds (e.g. when using the CLI), default: %d", defs.ClientTimeout))
+	flagSet.String(pkg.ConfWebhooksFile, defs.WebhooksFile, "YAML file specifying the webhooks which are notified of applied events, no webhooks are notified when not set")
 
 	return flagSet
 }
@@ -98,17 +105,33 @@ func cmd() *cobra.Command {
 		},
 	})
 
-	cmd.AddCommand(&cobra.Command{
-		Use:   "search [organization]",
-		Short: "Find organizations within the registry",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			cl := registryClientCreator()
-			os, _ := cl.SearchOrganizations(args[0])
-
-			logging.Log().Errorf("Found %d organizations\n", len(os))
-		},
-	})
+	{
+		var offset, limit *int
+		command := &cobra.Command{
+			Use:   "search [organization]",
+			Short: "Find organizations within the registry",
+			Long:  "Finds organizations by (part of) their name, best matches first. Use --offset and --limit to page through the results.",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cl := registryClientCreator()
+				result, err := cl.SearchOrganizations(args[0], db.SearchOptions{Offset: *offset, Limit: *limit})
+				if err != nil {
+					logging.Log().Errorf("Unable to search organizations: %v", err)
+					return err
+				}
+				logging.Log().Infof("Found %d organizations, showing %d:", result.Total, len(result.Organizations))
+				for _, org := range result.Organizations {
+					logging.Log().Infof("  %s (%s)", org.Name, org.Identifier)
+				}
+				return nil
+			},
+		}
+		flagSet := pflag.NewFlagSet("search", pflag.ContinueOnError)
+		offset = flagSet.Int("offset", 0, "number of results to skip")
+		limit = flagSet.Int("limit", 0, "maximum number of results to show, all when not set")
+		command.Flags().AddFlagSet(flagSet)
+		cmd.AddCommand(command)
+	}
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "server",
@@ -253,18 +276,30 @@ func cmd() *cobra.Command {
 	{
 		var properties *[]string
 		var id *string
+		var status *string
+		var notBefore *string
+		var notAfter *string
 		command := &cobra.Command{
 			Use:   "register-endpoint [org-identifier] [type] [url]",
 			Short: "Registers an endpoint",
-			Long:  "Registers an endpoint for an organization.",
-			Args:  cobra.ExactArgs(3),
+			Long: "Registers an endpoint for an organization. A migration to another endpoint can be announced ahead of time " +
+				"by registering the current endpoint as deprecated with --not-after and the new one with --not-before.",
+			Args: cobra.ExactArgs(3),
 			RunE: func(cmd *cobra.Command, args []string) error {
 				cl := registryClientCreator()
 				partyID, err := core.ParsePartyID(args[0])
 				if err != nil {
 					return err
 				}
-				event, err := cl.RegisterEndpoint(partyID, *id, args[2], args[1], db.StatusActive, parseCLIProperties(*properties))
+				notBeforeTime, err := parseCLITime(*notBefore)
+				if err != nil {
+					return err
+				}
+				notAfterTime, err := parseCLITime(*notAfter)
+				if err != nil {
+					return err
+				}
+				event, err := cl.RegisterEndpoint(partyID, *id, args[2], args[1], *status, notBeforeTime, notAfterTime, parseCLIProperties(*properties))
 				if err != nil {
 					logging.Log().Errorf("Unable to register endpoint: %v", err)
 					return err
@@ -277,13 +312,406 @@ func cmd() *cobra.Command {
 		flagSet := pflag.NewFlagSet("register-endpoint", pflag.ContinueOnError)
 		properties = flagSet.StringArrayP("property", "p", nil, "extra properties for the endpoint, in the format: key=value")
 		id = flagSet.StringP("id", "i", "", "endpoint identifier, defaults to a random GUID when not set")
+		status = flagSet.StringP("status", "s", db.StatusActive, fmt.Sprintf("status of the endpoint, one of: %s", strings.Join(domain.EndpointStatuses(), ", ")))
+		notBefore = flagSet.String("not-before", "", "moment from which the endpoint is available (RFC3339, e.g. 2020-10-20T20:30:00Z), right away when not set")
+		notAfter = flagSet.String("not-after", "", "moment from which the endpoint is no longer available (RFC3339), it stays available when not set")
+		command.Flags().AddFlagSet(flagSet)
+		cmd.AddCommand(command)
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "deregister-endpoint [org-identifier] [endpoint-identifier]",
+		Short: "Deregisters an endpoint",
+		Long:  "Deregisters an endpoint of an organization. Deregistration is final, the endpoint can't be registered again.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			partyID, err := core.ParsePartyID(args[0])
+			if err != nil {
+				return err
+			}
+			event, err := cl.DeregisterEndpoint(partyID, types.EndpointID(args[1]))
+			if err != nil {
+				logging.Log().Errorf("Unable to deregister endpoint: %v", err)
+				return err
+			}
+			logging.Log().Info("Endpoint deregistered.")
+			logEventToConsole(event)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "end-vendor-claim [org-identifier]",
+		Short: "Ends a vendor claim.",
+		Long:  "Ends the vendor's claim on a care organization, after which it can be claimed by another vendor.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			organizationID, err := core.ParsePartyID(args[0])
+			if err != nil {
+				return err
+			}
+			event, err := cl.EndVendorClaim(organizationID)
+			if err != nil {
+				logging.Log().Errorf("Unable to end vendor organisation claim: %v", err)
+				return err
+			}
+			logging.Log().Info("Vendor organisation claim ended.")
+			logEventToConsole(event)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "retire-vendor [vendor-identifier]",
+		Short: "Retires the vendor.",
+		Long:  "Retires the vendor, removing it and the organizations it claimed from the registry. Retirement is final, the vendor can't be registered again.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			vendorID, err := core.ParsePartyID(args[0])
+			if err != nil {
+				return err
+			}
+			event, err := cl.RetireVendor(vendorID)
+			if err != nil {
+				logging.Log().Errorf("Unable to retire vendor: %v", err)
+				return err
+			}
+			logging.Log().Info("Vendor retired.")
+			logEventToConsole(event)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export [output-file]",
+		Short: "Exports all events as tar.gz bundle.",
+		Long: "Exports all events in chain order as tar.gz bundle, including a manifest listing each event's ref and SHA-256 hash " +
+			"which is signed with the vendor's signing certificate. The bundle can be used to seed another registry.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			file, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			err = cl.Export(file)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				logging.Log().Errorf("Unable to export events: %v", err)
+				_ = os.Remove(args[0])
+				return err
+			}
+			logging.Log().Infof("Events exported to %s", args[0])
+			return nil
+		},
+	})
+
+	{
+		var apply *bool
+		command := &cobra.Command{
+			Use:   "import [bundle-file]",
+			Short: "Validates and imports a tar.gz bundle of events.",
+			Long: "Validates the events in a tar.gz bundle (e.g. created using export) against a scratch copy of the registry " +
+				"state and reports per event whether it would be applied, skipped as duplicate, parked because the event " +
+				"it refers to is missing, or rejected. The events are only imported when --apply is given. Importing isn't " +
+				"atomic: the events are imported one by one, and importing stops at the first event that can't be imported.",
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cl := registryClientCreator()
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				report, err := cl.Import(file, *apply)
+				if err != nil {
+					logging.Log().Errorf("Unable to import events: %v", err)
+					return err
+				}
+				for _, entry := range report.Events {
+					line := fmt.Sprintf("%s %s", entry.Outcome, entry.File)
+					if !entry.Ref.IsZero() {
+						line += fmt.Sprintf(" (ref = %s, type = %s)", entry.Ref, entry.Type)
+					}
+					if entry.Reason != "" {
+						line += ": " + entry.Reason
+					}
+					if entry.Imported {
+						line += " [imported]"
+					}
+					println(line)
+				}
+				if report.Error != "" {
+					logging.Log().Errorf("Import stopped, only the events marked as imported have been imported: %s", report.Error)
+					return errors.New(report.Error)
+				}
+				if report.Applied {
+					logging.Log().Info("Events imported.")
+				} else {
+					logging.Log().Info("Dry run, no events imported. Use --apply to import the events.")
+				}
+				return nil
+			},
+		}
+		flagSet := pflag.NewFlagSet("import", pflag.ContinueOnError)
+		apply = flagSet.Bool("apply", false, "import the events, otherwise only report what would happen (dry run)")
 		command.Flags().AddFlagSet(flagSet)
 		cmd.AddCommand(command)
 	}
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "compare [node-address|bundle-file] [node-address|bundle-file]",
+		Short: "Compares the state of two registries and lists the events that differ.",
+		Long: "Compares the state digests of two nodes (specified by their address, e.g. http://localhost:1323) or a node " +
+			"and a tar.gz bundle (e.g. created using export) and lists the events that are only present on one side. " +
+			"When only one node or bundle is given, it's compared to the configured registry.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources := args
+			if len(sources) == 1 {
+				sources = []string{"", args[0]}
+			}
+			left, err := digestOf(sources[0], false)
+			if err != nil {
+				logging.Log().Errorf("Unable to calculate digest: %v", err)
+				return err
+			}
+			right, err := digestOf(sources[1], false)
+			if err != nil {
+				logging.Log().Errorf("Unable to calculate digest: %v", err)
+				return err
+			}
+			if left.Root == right.Root {
+				logging.Log().Infof("Registries are identical (digest = %s, events = %d).", left.Root, left.EventCount)
+				return nil
+			}
+			// Only retrieve the refs (which can be a lot of data) when the digests differ
+			if left, err = digestOf(sources[0], true); err != nil {
+				return err
+			}
+			if right, err = digestOf(sources[1], true); err != nil {
+				return err
+			}
+			differences := digest.Compare(*left, *right)
+			for _, difference := range differences {
+				source := sources[1]
+				if difference.InLeft {
+					source = sources[0]
+				}
+				if source == "" {
+					source = "configured registry"
+				}
+				println(fmt.Sprintf("%s only in %s (vendor = %s)", difference.Ref, source, difference.Vendor))
+			}
+			logging.Log().Infof("Registries differ (events = %d vs %d, differences = %d).", left.EventCount, right.EventCount, len(differences))
+			return nil
+		},
+	})
+
+	cmd.AddCommand(retryQueueCmd())
+	cmd.AddCommand(forksCmd())
+
 	return cmd
 }
 
+// retryQueueCmd creates the commands for managing the events which couldn't be applied (yet) and are parked in the retry queue.
+func retryQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retry-queue",
+		Short: "Manages events which couldn't be applied (yet)",
+		Long: "Manages events which couldn't be applied (yet) and are parked in the retry queue. Pending events are retried " +
+			"automatically, dead-lettered events (which failed too often) only on request.",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Lists the parked events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			parkedEvents, err := cl.ParkedEvents()
+			if err != nil {
+				logging.Log().Errorf("Unable to list parked events: %v", err)
+				return err
+			}
+			if len(parkedEvents) == 0 {
+				logging.Log().Info("No parked events.")
+			}
+			for _, parked := range parkedEvents {
+				println(fmt.Sprintf("%s %s (state = %s, attempts = %d): %s", parked.Event.Ref(), parked.Event.Type(), parked.State, parked.Attempts, parked.LastError))
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show [ref]",
+		Short: "Shows a parked event",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			ref, err := events.ParseRef(args[0])
+			if err != nil {
+				return err
+			}
+			parked, err := cl.ParkedEvent(ref)
+			if err != nil {
+				logging.Log().Errorf("Unable to get parked event: %v", err)
+				return err
+			}
+			println("State:", string(parked.State))
+			println("Attempts:", parked.Attempts)
+			println("Parked at:", parked.ParkedAt.String())
+			if !parked.LastAttempt.IsZero() {
+				println("Last attempt:", parked.LastAttempt.String())
+			}
+			println("Last error:", parked.LastError)
+			logEventToConsole(parked.Event)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "retry [ref]",
+		Short: "Retries applying a parked event",
+		Long:  "Retries applying a parked event, even when it has been dead-lettered. Its retry budget is reset.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			ref, err := events.ParseRef(args[0])
+			if err != nil {
+				return err
+			}
+			if err := cl.RetryParkedEvent(ref); err != nil {
+				logging.Log().Errorf("Unable to apply parked event: %v", err)
+				return err
+			}
+			logging.Log().Info("Parked event applied.")
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "discard [ref]",
+		Short: "Discards a parked event, so it won't be retried anymore",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			ref, err := events.ParseRef(args[0])
+			if err != nil {
+				return err
+			}
+			if err := cl.DiscardParkedEvent(ref); err != nil {
+				logging.Log().Errorf("Unable to discard parked event: %v", err)
+				return err
+			}
+			logging.Log().Info("Parked event discarded.")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func forksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forks",
+		Short: "Manages event paths which forked",
+		Long: "Manages event paths which forked, because multiple events refer to the same previous event (e.g. when an entity " +
+			"is updated concurrently by two nodes). Forks are resolved automatically, but changes on the branches which " +
+			"aren't used are lost until the fork is merged.",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Lists the forks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			forks, err := cl.Forks()
+			if err != nil {
+				logging.Log().Errorf("Unable to list forks: %v", err)
+				return err
+			}
+			if len(forks) == 0 {
+				logging.Log().Info("No forks.")
+			}
+			for _, fork := range forks {
+				println(fmt.Sprintf("%s (merged = %t)", fork.Parent, fork.Merged))
+				for _, branch := range fork.Branches {
+					println(fmt.Sprintf("  %s..%s (canonical = %t)", branch.First, branch.Last, branch.Canonical))
+				}
+			}
+			return nil
+		},
+	})
+
+	var keep *string
+	mergeCmd := &cobra.Command{
+		Use:   "merge [ref]",
+		Short: "Merges a fork by publishing an event which extends the canonical branch",
+		Long: "Merges the fork whose branches refer to the event with the given ref, by publishing an event which extends " +
+			"the canonical branch. It contains the data of the last event of the branch specified by --keep, or of the " +
+			"canonical branch when not specified.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl := registryClientCreator()
+			parent, err := events.ParseRef(args[0])
+			if err != nil {
+				return err
+			}
+			var keepRef events.Ref
+			if *keep != "" {
+				if keepRef, err = events.ParseRef(*keep); err != nil {
+					return err
+				}
+			}
+			event, err := cl.MergeFork(parent, keepRef)
+			if err != nil {
+				logging.Log().Errorf("Unable to merge fork: %v", err)
+				return err
+			}
+			logging.Log().Info("Fork merged.")
+			logEventToConsole(event)
+			return nil
+		},
+	}
+	flagSet := pflag.NewFlagSet("merge", pflag.ContinueOnError)
+	keep = flagSet.String("keep", "", "ref of the last event of the branch whose data should be kept, defaults to the canonical branch")
+	mergeCmd.Flags().AddFlagSet(flagSet)
+	cmd.AddCommand(mergeCmd)
+
+	return cmd
+}
+
+// digestOf calculates the state digest of the given source: a node address (http:// or https://), a tar.gz bundle file or
+// the configured registry when empty.
+func digestOf(source string, includeRefs bool) (*digest.Digest, error) {
+	if source == "" {
+		return registryClientCreator().Digest(includeRefs)
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		cl := api.HttpClient{
+			ServerAddress: source,
+			Timeout:       time.Duration(pkg.RegistryInstance().Config.ClientTimeout) * time.Second,
+		}
+		return cl.Digest(includeRefs)
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result, err := digest.CalculateFromBundle(file)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // parseCLIProperties parses a slice of key-value entries (key=value) to a map.
 func parseCLIProperties(keysAndValues []string) map[string]string {
 	result := make(map[string]string, 0)
@@ -296,6 +724,18 @@ func parseCLIProperties(keysAndValues []string) map[string]string {
 	return result
 }
 
+// parseCLITime parses a moment formatted as RFC3339, nil when it's empty.
+func parseCLITime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	moment, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid moment (expected RFC3339, e.g. 2020-10-20T20:30:00Z): %s", value)
+	}
+	return &moment, nil
+}
+
 func logEventToConsole(event events.Event) {
 	println("Event:", events.SuggestEventFileName(event))
 	println(string(event.Marshal()))
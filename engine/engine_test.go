@@ -19,14 +19,20 @@
 package engine
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"github.com/nuts-foundation/nuts-go-test/io"
 	"github.com/spf13/cobra"
+	goio "io"
+	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -36,9 +42,12 @@ import (
 	core "github.com/nuts-foundation/nuts-go-core"
 	"github.com/nuts-foundation/nuts-registry/mock"
 	"github.com/nuts-foundation/nuts-registry/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
 	"github.com/nuts-foundation/nuts-registry/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -189,23 +198,31 @@ func TestRegisterEndpoint(t *testing.T) {
 	var orgID, _ = core.ParsePartyID("urn:oid:1.2.3:foo")
 	t.Run("ok - bare minimum parameters", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
 		event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
-		client.EXPECT().RegisterEndpoint(orgID, "", "url", "type", db.StatusActive, map[string]string{}).Return(event, nil)
+		client.EXPECT().RegisterEndpoint(orgID, "", "url", "type", db.StatusActive, nil, nil, map[string]string{}).Return(event, nil)
 		command.SetArgs([]string{"register-endpoint", orgID.String(), "type", "url"})
 		err := command.Execute()
 		assert.NoError(t, err)
 	}))
 	t.Run("ok - all parameters", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
 		event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
-		client.EXPECT().RegisterEndpoint(orgID, "id", "url", "type", db.StatusActive, map[string]string{"k1": "v1", "k2": "v2"}).Return(event, nil)
-		command.SetArgs([]string{"register-endpoint", orgID.String(), "type", "url", "-i", "id", "-p", "k1=v1", "-p", "k2=v2"})
+		notBefore := time.Date(2020, 10, 20, 20, 30, 0, 0, time.UTC)
+		notAfter := notBefore.AddDate(1, 0, 0)
+		client.EXPECT().RegisterEndpoint(orgID, "id", "url", "type", db.StatusDeprecated, &notBefore, &notAfter, map[string]string{"k1": "v1", "k2": "v2"}).Return(event, nil)
+		command.SetArgs([]string{"register-endpoint", orgID.String(), "type", "url", "-i", "id", "-p", "k1=v1", "-p", "k2=v2",
+			"-s", db.StatusDeprecated, "--not-before", "2020-10-20T20:30:00Z", "--not-after", "2021-10-20T20:30:00Z"})
 		err := command.Execute()
 		assert.NoError(t, err)
 	}))
 	t.Run("error", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
-		client.EXPECT().RegisterEndpoint(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
+		client.EXPECT().RegisterEndpoint(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
 		command.SetArgs([]string{"register-endpoint", orgID.String(), "type", "url"})
 		command.Execute()
 	}))
+	t.Run("error - invalid moment", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"register-endpoint", orgID.String(), "type", "url", "--not-before", "tomorrow"})
+		err := command.Execute()
+		assert.EqualError(t, err, "invalid moment (expected RFC3339, e.g. 2020-10-20T20:30:00Z): tomorrow")
+	}))
 }
 
 func TestSearchOrg(t *testing.T) {
@@ -213,11 +230,268 @@ func TestSearchOrg(t *testing.T) {
 	pkg.NewTestRegistryInstance(io.TestDirectory(t))
 	command := cmd()
 	t.Run("ok", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
-		client.EXPECT().SearchOrganizations("foo")
+		client.EXPECT().SearchOrganizations("foo", db.SearchOptions{}).Return(&db.SearchResult{}, nil)
 		command.SetArgs([]string{"search", "foo"})
 		err := command.Execute()
 		assert.NoError(t, err)
 	}))
+	t.Run("ok - paginated", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().SearchOrganizations("foo", db.SearchOptions{Offset: 10, Limit: 5}).Return(&db.SearchResult{
+			Organizations: []db.Organization{{Identifier: test.OrganizationID("foo"), Name: "Foo"}},
+			Total:         11,
+		}, nil)
+		command.SetArgs([]string{"search", "foo", "--offset", "10", "--limit", "5"})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().SearchOrganizations("foo", db.SearchOptions{}).Return(nil, errors.New("failed"))
+		command.SetArgs([]string{"search", "foo", "--offset", "0", "--limit", "0"})
+		err := command.Execute()
+		assert.EqualError(t, err, "failed")
+	}))
+}
+
+func TestRetryQueue(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	parked := events.ParkedEvent{Event: event, State: events.ParkedEventPending, Attempts: 1, LastError: "failed", LastAttempt: time.Now()}
+	t.Run("list", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().ParkedEvents().Return([]events.ParkedEvent{parked}, nil)
+		command.SetArgs([]string{"retry-queue", "list"})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("show", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().ParkedEvent(event.Ref()).Return(&parked, nil)
+		command.SetArgs([]string{"retry-queue", "show", event.Ref().String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("retry", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().RetryParkedEvent(event.Ref()).Return(nil)
+		command.SetArgs([]string{"retry-queue", "retry", event.Ref().String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("retry - still failing", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().RetryParkedEvent(event.Ref()).Return(errors.New("failed"))
+		command.SetArgs([]string{"retry-queue", "retry", event.Ref().String()})
+		err := command.Execute()
+		assert.EqualError(t, err, "failed")
+	}))
+	t.Run("discard", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().DiscardParkedEvent(event.Ref()).Return(nil)
+		command.SetArgs([]string{"retry-queue", "discard", event.Ref().String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error - invalid ref", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"retry-queue", "discard", "not-hex"})
+		err := command.Execute()
+		assert.Error(t, err)
+	}))
+}
+
+func TestForks(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	parent := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, nil)
+	branch1 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{}, parent.Ref())
+	branch2 := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{URL: "url"}, parent.Ref())
+	fork := events.Fork{Parent: parent.Ref(), Branches: []events.ForkBranch{
+		{First: branch1.Ref(), Last: branch1.Ref(), Canonical: true},
+		{First: branch2.Ref(), Last: branch2.Ref()},
+	}}
+	t.Run("list", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Forks().Return([]events.Fork{fork}, nil)
+		command.SetArgs([]string{"forks", "list"})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("merge", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().MergeFork(parent.Ref(), events.Ref(nil)).Return(branch1, nil)
+		command.SetArgs([]string{"forks", "merge", parent.Ref().String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("merge - keep other branch", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().MergeFork(parent.Ref(), branch2.Ref()).Return(branch1, nil)
+		command.SetArgs([]string{"forks", "merge", parent.Ref().String(), "--keep", branch2.Ref().String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error - merge fails", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().MergeFork(parent.Ref(), branch2.Ref()).Return(nil, pkg.ErrForkNotFound)
+		command.SetArgs([]string{"forks", "merge", parent.Ref().String()})
+		err := command.Execute()
+		assert.Equal(t, pkg.ErrForkNotFound, err)
+	}))
+	t.Run("error - invalid ref", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"forks", "merge", "not-hex"})
+		err := command.Execute()
+		assert.Error(t, err)
+	}))
+}
+
+func TestDeregistration(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	orgID := test.OrganizationID("org")
+	vendorID := test.VendorID("vendor")
+	event := events.CreateEvent(domain.DeregisterEndpoint, domain.DeregisterEndpointEvent{}, nil)
+	t.Run("deregister endpoint", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().DeregisterEndpoint(orgID, types.EndpointID("endpoint")).Return(event, nil)
+		command.SetArgs([]string{"deregister-endpoint", orgID.String(), "endpoint"})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("end vendor claim", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().EndVendorClaim(orgID).Return(event, nil)
+		command.SetArgs([]string{"end-vendor-claim", orgID.String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("retire vendor", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().RetireVendor(vendorID).Return(event, nil)
+		command.SetArgs([]string{"retire-vendor", vendorID.String()})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error - retire vendor fails", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().RetireVendor(vendorID).Return(nil, pkg.ErrNotManaged)
+		command.SetArgs([]string{"retire-vendor", vendorID.String()})
+		err := command.Execute()
+		assert.Equal(t, pkg.ErrNotManaged, err)
+	}))
+	t.Run("error - invalid organization ID", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"end-vendor-claim", "invalid"})
+		err := command.Execute()
+		assert.Error(t, err)
+	}))
+}
+
+func TestExport(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	t.Run("ok", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		file := filepath.Join(io.TestDirectory(t), "export.tar.gz")
+		client.EXPECT().Export(gomock.Any()).DoAndReturn(func(writer goio.Writer) error {
+			_, err := writer.Write([]byte("bundle"))
+			return err
+		})
+		command.SetArgs([]string{"export", file})
+		err := command.Execute()
+		if !assert.NoError(t, err) {
+			return
+		}
+		data, _ := ioutil.ReadFile(file)
+		assert.Equal(t, "bundle", string(data))
+	}))
+	t.Run("error - export fails", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		file := filepath.Join(io.TestDirectory(t), "export.tar.gz")
+		client.EXPECT().Export(gomock.Any()).Return(errors.New("failed"))
+		command.SetArgs([]string{"export", file})
+		err := command.Execute()
+		assert.EqualError(t, err, "failed")
+		assert.NoFileExists(t, file)
+	}))
+}
+
+func TestImport(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	file := filepath.Join(io.TestDirectory(t), "export.tar.gz")
+	_ = ioutil.WriteFile(file, []byte("bundle"), 0644)
+	report := &bundle.ImportReport{Events: []bundle.ImportReportEntry{
+		{File: "events/1.json", Ref: []byte{1}, Type: domain.RegisterVendor, Outcome: bundle.ImportOutcomeApplied},
+		{File: "events/2.json", Outcome: bundle.ImportOutcomeRejected, Reason: "invalid"},
+	}}
+	t.Run("dry run", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Import(gomock.Any(), false).Return(report, nil)
+		command.SetArgs([]string{"import", file})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("apply", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Import(gomock.Any(), true).DoAndReturn(func(reader goio.Reader, _ bool) (*bundle.ImportReport, error) {
+			data, _ := ioutil.ReadAll(reader)
+			assert.Equal(t, "bundle", string(data))
+			return &bundle.ImportReport{Applied: true}, nil
+		})
+		command.SetArgs([]string{"import", file, "--apply"})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error - import stopped", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Import(gomock.Any(), true).Return(&bundle.ImportReport{Error: "disk full", Events: []bundle.ImportReportEntry{
+			{File: "events/1.json", Ref: []byte{1}, Type: domain.RegisterVendor, Outcome: bundle.ImportOutcomeApplied, Imported: true},
+			{File: "events/2.json", Ref: []byte{2}, Type: domain.VendorClaim, Outcome: bundle.ImportOutcomeApplied},
+		}}, nil)
+		command.SetArgs([]string{"import", file, "--apply"})
+		err := command.Execute()
+		assert.EqualError(t, err, "disk full")
+	}))
+	t.Run("error - invalid bundle", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Import(gomock.Any(), gomock.Any()).Return(nil, pkg.ErrInvalidBundle)
+		command.SetArgs([]string{"import", file})
+		err := command.Execute()
+		assert.Equal(t, pkg.ErrInvalidBundle, err)
+	}))
+	t.Run("error - file not found", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"import", "non-existing.tar.gz"})
+		err := command.Execute()
+		assert.Error(t, err)
+	}))
+}
+
+func TestCompare(t *testing.T) {
+	// Register test instance singleton
+	pkg.NewTestRegistryInstance(io.TestDirectory(t))
+	command := cmd()
+	event := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: test.VendorID("vendor"), Name: "Vendor"}, nil)
+	buf := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	_ = bundle.WriteEntry(tarWriter, "events/1.json", event.Marshal(), time.Now())
+	_ = tarWriter.Close()
+	_ = gzipWriter.Close()
+	file := filepath.Join(io.TestDirectory(t), "export.tar.gz")
+	_ = ioutil.WriteFile(file, buf.Bytes(), 0644)
+	bundleDigest := digest.Calculate([]events.Event{event})
+
+	t.Run("identical", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		nodeDigest := bundleDigest.WithoutRefs()
+		client.EXPECT().Digest(false).Return(&nodeDigest, nil)
+		command.SetArgs([]string{"compare", file})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("different", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		nodeDigest := digest.Calculate(nil)
+		client.EXPECT().Digest(false).Return(&nodeDigest, nil)
+		client.EXPECT().Digest(true).Return(&nodeDigest, nil)
+		command.SetArgs([]string{"compare", file})
+		err := command.Execute()
+		assert.NoError(t, err)
+	}))
+	t.Run("error - digest fails", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		client.EXPECT().Digest(false).Return(nil, errors.New("failed"))
+		command.SetArgs([]string{"compare", file})
+		err := command.Execute()
+		assert.EqualError(t, err, "failed")
+	}))
+	t.Run("error - file not found", withMock(func(t *testing.T, client *mock.MockRegistryClient) {
+		command.SetArgs([]string{"compare", file, "non-existing.tar.gz"})
+		err := command.Execute()
+		assert.Error(t, err)
+	}))
 }
 
 func TestPrintVersion(t *testing.T) {
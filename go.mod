@@ -6,6 +6,7 @@ require (
 	github.com/cyberphone/json-canonicalization v0.0.0-20200417180520-cd6247b5f11e
 	github.com/deepmap/oapi-codegen v1.4.1
 	github.com/fsnotify/fsnotify v1.4.7
+	github.com/getkin/kin-openapi v0.26.0
 	github.com/golang/mock v1.4.4
 	github.com/google/uuid v1.1.4
 	github.com/labstack/echo/v4 v4.1.17
@@ -13,6 +14,7 @@ require (
 	github.com/leodido/go-urn v1.2.1-0.20201207182545-66fa6ee96763
 	github.com/lestrrat-go/jwx v1.0.7
 	github.com/magiconair/properties v1.8.4
+	github.com/mattn/go-sqlite3 v2.0.3+incompatible
 	github.com/nuts-foundation/nuts-crypto v0.16.0
 	github.com/nuts-foundation/nuts-go-core v0.16.0
 	github.com/nuts-foundation/nuts-go-test v0.16.0
@@ -23,4 +25,7 @@ require (
 	github.com/spf13/cobra v0.0.7
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.6.1
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/text v0.3.3
+	gopkg.in/yaml.v2 v2.3.0
 )
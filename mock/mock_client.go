@@ -5,12 +5,18 @@
 package mock
 
 import (
+	context "context"
 	x509 "crypto/x509"
 	gomock "github.com/golang/mock/gomock"
 	nuts_go_core "github.com/nuts-foundation/nuts-go-core"
+	bundle "github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	db "github.com/nuts-foundation/nuts-registry/pkg/db"
+	digest "github.com/nuts-foundation/nuts-registry/pkg/digest"
 	events "github.com/nuts-foundation/nuts-registry/pkg/events"
+	types "github.com/nuts-foundation/nuts-registry/pkg/types"
+	io "io"
 	reflect "reflect"
+	time "time"
 )
 
 // MockRegistryClient is a mock of RegistryClient interface
@@ -52,18 +58,33 @@ func (mr *MockRegistryClientMockRecorder) EndpointsByOrganizationAndType(organiz
 }
 
 // SearchOrganizations mocks base method
-func (m *MockRegistryClient) SearchOrganizations(query string) ([]db.Organization, error) {
+func (m *MockRegistryClient) SearchOrganizations(query string, options db.SearchOptions) (*db.SearchResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchOrganizations", query)
-	ret0, _ := ret[0].([]db.Organization)
+	ret := m.ctrl.Call(m, "SearchOrganizations", query, options)
+	ret0, _ := ret[0].(*db.SearchResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchOrganizations indicates an expected call of SearchOrganizations
-func (mr *MockRegistryClientMockRecorder) SearchOrganizations(query interface{}) *gomock.Call {
+func (mr *MockRegistryClientMockRecorder) SearchOrganizations(query, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrganizations", reflect.TypeOf((*MockRegistryClient)(nil).SearchOrganizations), query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrganizations", reflect.TypeOf((*MockRegistryClient)(nil).SearchOrganizations), query, options)
+}
+
+// SearchEndpoints mocks base method
+func (m *MockRegistryClient) SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) (*db.EndpointSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchEndpoints", query, options)
+	ret0, _ := ret[0].(*db.EndpointSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchEndpoints indicates an expected call of SearchEndpoints
+func (mr *MockRegistryClientMockRecorder) SearchEndpoints(query, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEndpoints", reflect.TypeOf((*MockRegistryClient)(nil).SearchEndpoints), query, options)
 }
 
 // OrganizationById mocks base method
@@ -97,18 +118,18 @@ func (mr *MockRegistryClientMockRecorder) ReverseLookup(name interface{}) *gomoc
 }
 
 // RegisterEndpoint mocks base method
-func (m *MockRegistryClient) RegisterEndpoint(organizationID nuts_go_core.PartyID, id, url, endpointType, status string, properties map[string]string) (events.Event, error) {
+func (m *MockRegistryClient) RegisterEndpoint(organizationID nuts_go_core.PartyID, id, url, endpointType, status string, notBefore, notAfter *time.Time, properties map[string]string) (events.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterEndpoint", organizationID, id, url, endpointType, status, properties)
+	ret := m.ctrl.Call(m, "RegisterEndpoint", organizationID, id, url, endpointType, status, notBefore, notAfter, properties)
 	ret0, _ := ret[0].(events.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RegisterEndpoint indicates an expected call of RegisterEndpoint
-func (mr *MockRegistryClientMockRecorder) RegisterEndpoint(organizationID, id, url, endpointType, status, properties interface{}) *gomock.Call {
+func (mr *MockRegistryClientMockRecorder) RegisterEndpoint(organizationID, id, url, endpointType, status, notBefore, notAfter, properties interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterEndpoint", reflect.TypeOf((*MockRegistryClient)(nil).RegisterEndpoint), organizationID, id, url, endpointType, status, properties)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterEndpoint", reflect.TypeOf((*MockRegistryClient)(nil).RegisterEndpoint), organizationID, id, url, endpointType, status, notBefore, notAfter, properties)
 }
 
 // VendorClaim mocks base method
@@ -126,6 +147,51 @@ func (mr *MockRegistryClientMockRecorder) VendorClaim(orgID, orgName, orgKeys in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VendorClaim", reflect.TypeOf((*MockRegistryClient)(nil).VendorClaim), orgID, orgName, orgKeys)
 }
 
+// DeregisterEndpoint mocks base method
+func (m *MockRegistryClient) DeregisterEndpoint(organizationID nuts_go_core.PartyID, endpointID types.EndpointID) (events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeregisterEndpoint", organizationID, endpointID)
+	ret0, _ := ret[0].(events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeregisterEndpoint indicates an expected call of DeregisterEndpoint
+func (mr *MockRegistryClientMockRecorder) DeregisterEndpoint(organizationID, endpointID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeregisterEndpoint", reflect.TypeOf((*MockRegistryClient)(nil).DeregisterEndpoint), organizationID, endpointID)
+}
+
+// EndVendorClaim mocks base method
+func (m *MockRegistryClient) EndVendorClaim(organizationID nuts_go_core.PartyID) (events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndVendorClaim", organizationID)
+	ret0, _ := ret[0].(events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndVendorClaim indicates an expected call of EndVendorClaim
+func (mr *MockRegistryClientMockRecorder) EndVendorClaim(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndVendorClaim", reflect.TypeOf((*MockRegistryClient)(nil).EndVendorClaim), organizationID)
+}
+
+// RetireVendor mocks base method
+func (m *MockRegistryClient) RetireVendor(vendorID nuts_go_core.PartyID) (events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetireVendor", vendorID)
+	ret0, _ := ret[0].(events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetireVendor indicates an expected call of RetireVendor
+func (mr *MockRegistryClientMockRecorder) RetireVendor(vendorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireVendor", reflect.TypeOf((*MockRegistryClient)(nil).RetireVendor), vendorID)
+}
+
 // RegisterVendor mocks base method
 func (m *MockRegistryClient) RegisterVendor(certificate *x509.Certificate) (events.Event, error) {
 	m.ctrl.T.Helper()
@@ -200,3 +266,240 @@ func (mr *MockRegistryClientMockRecorder) VendorById(vID interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VendorById", reflect.TypeOf((*MockRegistryClient)(nil).VendorById), vID)
 }
+
+// OrganizationByIdAsOf mocks base method
+func (m *MockRegistryClient) OrganizationByIdAsOf(id nuts_go_core.PartyID, moment time.Time) (*db.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrganizationByIdAsOf", id, moment)
+	ret0, _ := ret[0].(*db.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrganizationByIdAsOf indicates an expected call of OrganizationByIdAsOf
+func (mr *MockRegistryClientMockRecorder) OrganizationByIdAsOf(id, moment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrganizationByIdAsOf", reflect.TypeOf((*MockRegistryClient)(nil).OrganizationByIdAsOf), id, moment)
+}
+
+// VendorByIdAsOf mocks base method
+func (m *MockRegistryClient) VendorByIdAsOf(vID nuts_go_core.PartyID, moment time.Time) (*db.Vendor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VendorByIdAsOf", vID, moment)
+	ret0, _ := ret[0].(*db.Vendor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VendorByIdAsOf indicates an expected call of VendorByIdAsOf
+func (mr *MockRegistryClientMockRecorder) VendorByIdAsOf(vID, moment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VendorByIdAsOf", reflect.TypeOf((*MockRegistryClient)(nil).VendorByIdAsOf), vID, moment)
+}
+
+// EndpointsByOrganizationAndTypeAsOf mocks base method
+func (m *MockRegistryClient) EndpointsByOrganizationAndTypeAsOf(organizationIdentifier nuts_go_core.PartyID, endpointType *string, moment time.Time) ([]db.Endpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndpointsByOrganizationAndTypeAsOf", organizationIdentifier, endpointType, moment)
+	ret0, _ := ret[0].([]db.Endpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndpointsByOrganizationAndTypeAsOf indicates an expected call of EndpointsByOrganizationAndTypeAsOf
+func (mr *MockRegistryClientMockRecorder) EndpointsByOrganizationAndTypeAsOf(organizationIdentifier, endpointType, moment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndpointsByOrganizationAndTypeAsOf", reflect.TypeOf((*MockRegistryClient)(nil).EndpointsByOrganizationAndTypeAsOf), organizationIdentifier, endpointType, moment)
+}
+
+// Subscribe mocks base method
+func (m *MockRegistryClient) Subscribe(ctx context.Context, from events.Ref) (<-chan events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, from)
+	ret0, _ := ret[0].(<-chan events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockRegistryClientMockRecorder) Subscribe(ctx, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockRegistryClient)(nil).Subscribe), ctx, from)
+}
+
+// VendorHistory mocks base method
+func (m *MockRegistryClient) VendorHistory(vendorID nuts_go_core.PartyID) ([]events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VendorHistory", vendorID)
+	ret0, _ := ret[0].([]events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VendorHistory indicates an expected call of VendorHistory
+func (mr *MockRegistryClientMockRecorder) VendorHistory(vendorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VendorHistory", reflect.TypeOf((*MockRegistryClient)(nil).VendorHistory), vendorID)
+}
+
+// OrganizationHistory mocks base method
+func (m *MockRegistryClient) OrganizationHistory(organizationID nuts_go_core.PartyID) ([]events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrganizationHistory", organizationID)
+	ret0, _ := ret[0].([]events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrganizationHistory indicates an expected call of OrganizationHistory
+func (mr *MockRegistryClientMockRecorder) OrganizationHistory(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrganizationHistory", reflect.TypeOf((*MockRegistryClient)(nil).OrganizationHistory), organizationID)
+}
+
+// EndpointHistory mocks base method
+func (m *MockRegistryClient) EndpointHistory(organizationID nuts_go_core.PartyID, endpointID types.EndpointID) ([]events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndpointHistory", organizationID, endpointID)
+	ret0, _ := ret[0].([]events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndpointHistory indicates an expected call of EndpointHistory
+func (mr *MockRegistryClientMockRecorder) EndpointHistory(organizationID, endpointID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndpointHistory", reflect.TypeOf((*MockRegistryClient)(nil).EndpointHistory), organizationID, endpointID)
+}
+
+// ParkedEvents mocks base method
+func (m *MockRegistryClient) ParkedEvents() ([]events.ParkedEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParkedEvents")
+	ret0, _ := ret[0].([]events.ParkedEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParkedEvents indicates an expected call of ParkedEvents
+func (mr *MockRegistryClientMockRecorder) ParkedEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParkedEvents", reflect.TypeOf((*MockRegistryClient)(nil).ParkedEvents))
+}
+
+// ParkedEvent mocks base method
+func (m *MockRegistryClient) ParkedEvent(ref events.Ref) (*events.ParkedEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParkedEvent", ref)
+	ret0, _ := ret[0].(*events.ParkedEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParkedEvent indicates an expected call of ParkedEvent
+func (mr *MockRegistryClientMockRecorder) ParkedEvent(ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParkedEvent", reflect.TypeOf((*MockRegistryClient)(nil).ParkedEvent), ref)
+}
+
+// RetryParkedEvent mocks base method
+func (m *MockRegistryClient) RetryParkedEvent(ref events.Ref) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryParkedEvent", ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryParkedEvent indicates an expected call of RetryParkedEvent
+func (mr *MockRegistryClientMockRecorder) RetryParkedEvent(ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryParkedEvent", reflect.TypeOf((*MockRegistryClient)(nil).RetryParkedEvent), ref)
+}
+
+// DiscardParkedEvent mocks base method
+func (m *MockRegistryClient) DiscardParkedEvent(ref events.Ref) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardParkedEvent", ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardParkedEvent indicates an expected call of DiscardParkedEvent
+func (mr *MockRegistryClientMockRecorder) DiscardParkedEvent(ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardParkedEvent", reflect.TypeOf((*MockRegistryClient)(nil).DiscardParkedEvent), ref)
+}
+
+// Forks mocks base method
+func (m *MockRegistryClient) Forks() ([]events.Fork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forks")
+	ret0, _ := ret[0].([]events.Fork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Forks indicates an expected call of Forks
+func (mr *MockRegistryClientMockRecorder) Forks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forks", reflect.TypeOf((*MockRegistryClient)(nil).Forks))
+}
+
+// MergeFork mocks base method
+func (m *MockRegistryClient) MergeFork(parent, keep events.Ref) (events.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeFork", parent, keep)
+	ret0, _ := ret[0].(events.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeFork indicates an expected call of MergeFork
+func (mr *MockRegistryClientMockRecorder) MergeFork(parent, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeFork", reflect.TypeOf((*MockRegistryClient)(nil).MergeFork), parent, keep)
+}
+
+// Export mocks base method
+func (m *MockRegistryClient) Export(writer io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", writer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export
+func (mr *MockRegistryClientMockRecorder) Export(writer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockRegistryClient)(nil).Export), writer)
+}
+
+// Digest mocks base method
+func (m *MockRegistryClient) Digest(includeRefs bool) (*digest.Digest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Digest", includeRefs)
+	ret0, _ := ret[0].(*digest.Digest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Digest indicates an expected call of Digest
+func (mr *MockRegistryClientMockRecorder) Digest(includeRefs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Digest", reflect.TypeOf((*MockRegistryClient)(nil).Digest), includeRefs)
+}
+
+// Import mocks base method
+func (m *MockRegistryClient) Import(reader io.Reader, apply bool) (*bundle.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", reader, apply)
+	ret0, _ := ret[0].(*bundle.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockRegistryClientMockRecorder) Import(reader, apply interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockRegistryClient)(nil).Import), reader, apply)
+}
@@ -63,17 +63,31 @@ func (mr *MockDbMockRecorder) FindEndpointsByOrganizationAndType(organizationID,
 }
 
 // SearchOrganizations mocks base method
-func (m *MockDb) SearchOrganizations(query string) []db.Organization {
+func (m *MockDb) SearchOrganizations(query string, options db.SearchOptions) db.SearchResult {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchOrganizations", query)
-	ret0, _ := ret[0].([]db.Organization)
+	ret := m.ctrl.Call(m, "SearchOrganizations", query, options)
+	ret0, _ := ret[0].(db.SearchResult)
 	return ret0
 }
 
 // SearchOrganizations indicates an expected call of SearchOrganizations
-func (mr *MockDbMockRecorder) SearchOrganizations(query interface{}) *gomock.Call {
+func (mr *MockDbMockRecorder) SearchOrganizations(query, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrganizations", reflect.TypeOf((*MockDb)(nil).SearchOrganizations), query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrganizations", reflect.TypeOf((*MockDb)(nil).SearchOrganizations), query, options)
+}
+
+// SearchEndpoints mocks base method
+func (m *MockDb) SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) db.EndpointSearchResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchEndpoints", query, options)
+	ret0, _ := ret[0].(db.EndpointSearchResult)
+	return ret0
+}
+
+// SearchEndpoints indicates an expected call of SearchEndpoints
+func (mr *MockDbMockRecorder) SearchEndpoints(query, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEndpoints", reflect.TypeOf((*MockDb)(nil).SearchEndpoints), query, options)
 }
 
 // OrganizationById mocks base method
@@ -133,3 +147,32 @@ func (mr *MockDbMockRecorder) ReverseLookup(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseLookup", reflect.TypeOf((*MockDb)(nil).ReverseLookup), name)
 }
+
+// Snapshot mocks base method
+func (m *MockDb) Snapshot() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockDbMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockDb)(nil).Snapshot))
+}
+
+// Restore mocks base method
+func (m *MockDb) Restore(snapshot []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", snapshot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockDbMockRecorder) Restore(snapshot interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockDb)(nil).Restore), snapshot)
+}
@@ -52,7 +52,7 @@ var ErrOrganizationNotFound = errors.New("organization not found")
 func (r *Registry) RegisterVendor(certificate *x509.Certificate) (events.Event, error) {
 	id := core.NutsConfig().VendorID()
 	// Find out whether this is a registration or update operation
-	previousEvent, err := r.EventSystem.FindLastEvent(dom.VendorEventMatcher(id))
+	previousEvent, err := r.EventSystem.FindLastEventByKey(dom.VendorEventKey(id), dom.VendorEventMatcher(id))
 	if err != nil {
 		return nil, err
 	}
@@ -68,15 +68,21 @@ func (r *Registry) RegisterVendor(certificate *x509.Certificate) (events.Event,
 	}
 	certificateAsJWK, _ := cert.CertificateToJWK(certificate)
 	certificateAsMap, _ := cert.JwkToMap(certificateAsJWK)
-	if err := r.crypto.StoreVendorCACertificate(certificate); err != nil {
-		return nil, err
-	}
-	// The event is signed with the vendor certificate, which need to be issued by the just issued vendor CA.
 	entity := types.LegalEntity{URI: id.String()}
-	if _, _, err := r.crypto.RenewSigningCertificate(entity); err != nil {
-		return nil, err
+	// Updates must be signed with the current signing certificate, since it's issued by the vendor's registered CA.
+	// Registrations are signed with a certificate issued by the new vendor CA (which is registered by the event itself).
+	var signingCertificate *x509.Certificate
+	if previousEvent != nil {
+		if signingCertificate, _, err = r.crypto.GetSigningCertificate(entity); err != nil {
+			return nil, err
+		}
+	}
+	if signingCertificate == nil {
+		if err := r.useVendorCACertificate(entity, certificate); err != nil {
+			return nil, err
+		}
 	}
-	return r.signAndPublishEvent(dom.RegisterVendor, dom.RegisterVendorEvent{
+	event, err := r.signAndPublishEvent(dom.RegisterVendor, dom.RegisterVendorEvent{
 		Identifier: id,
 		Name:       name,
 		Domain:     domain,
@@ -84,6 +90,26 @@ func (r *Registry) RegisterVendor(certificate *x509.Certificate) (events.Event,
 	}, previousEvent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
 		return r.crypto.SignJWS(dataToBeSigned, types.KeyForEntity(entity).WithQualifier(crypto.SigningCertificateQualifier))
 	})
+	if err != nil {
+		return nil, err
+	}
+	if signingCertificate != nil {
+		// Now the new vendor CA has been registered, following events can be signed with a certificate issued by it.
+		if err := r.useVendorCACertificate(entity, certificate); err != nil {
+			return nil, errors2.Wrap(err, "vendor has been updated, but unable to use its new CA certificate")
+		}
+	}
+	return event, nil
+}
+
+// useVendorCACertificate stores the given vendor CA certificate and renews the vendor's signing certificate, so it's
+// issued by that CA.
+func (r *Registry) useVendorCACertificate(entity types.LegalEntity, certificate *x509.Certificate) error {
+	if err := r.crypto.StoreVendorCACertificate(certificate); err != nil {
+		return err
+	}
+	_, _, err := r.crypto.RenewSigningCertificate(entity)
+	return err
 }
 
 // VendorClaim registers an organization under a vendor. The specified vendor has to exist and have a valid CA certificate
@@ -170,7 +196,7 @@ func (r *Registry) RefreshOrganizationCertificate(organizationID core.PartyID) (
 	}
 	// This operation can only be used to issue a new certificate for an existing organization. The resulting event refers
 	// to the last VendorClaimEvent.
-	prevEvent, err := r.EventSystem.FindLastEvent(dom.OrganizationEventMatcher(vendor.Identifier, organizationID))
+	prevEvent, err := r.EventSystem.FindLastEventByKey(dom.OrganizationEventKey(organizationID), dom.OrganizationEventMatcher(vendor.Identifier, organizationID))
 	if err != nil {
 		return nil, err
 	}
@@ -190,38 +216,40 @@ func (r *Registry) RefreshOrganizationCertificate(organizationID core.PartyID) (
 	})
 }
 
-// RegisterEndpoint registers an endpoint for an organization
-func (r *Registry) RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, properties map[string]string) (events.Event, error) {
+// RegisterEndpoint registers an endpoint for an organization. When no status is given, the endpoint is registered as active.
+func (r *Registry) RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, notBefore *time.Time, notAfter *time.Time, properties map[string]string) (events.Event, error) {
 	logging.Log().Infof("Registering/updating endpoint, organization=%s, id=%s, type=%s, url=%s, status=%s",
 		organizationID, id, endpointType, url, status)
 	if id == "" {
 		id = uuid.New().String()
 	}
+	if status == "" {
+		status = db.StatusActive
+	}
+	payload := dom.RegisterEndpointEvent{
+		Organization: organizationID,
+		URL:          url,
+		EndpointType: endpointType,
+		Identifier:   types2.EndpointID(id),
+		Status:       status,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		Properties:   properties,
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
 	org, err := r.Db.OrganizationById(organizationID)
 	if err != nil {
 		return nil, err
 	}
 	// Find out if this should be an update. That's the case if there's a RegisterEndpointEvent for the same organization
 	// and endpoint (ID).
-	parentEvent, err := r.EventSystem.FindLastEvent(func(event events.Event) bool {
-		if event.Type() != dom.RegisterEndpoint {
-			return false
-		}
-		var payload = dom.RegisterEndpointEvent{}
-		_ = event.Unmarshal(&payload)
-		return types2.EndpointID(id) == payload.Identifier && organizationID == payload.Organization
-	})
+	parentEvent, err := r.EventSystem.FindLastEventByKey(dom.EndpointEventKey(organizationID, types2.EndpointID(id)), dom.EndpointEventMatcher(organizationID, types2.EndpointID(id)))
 	if err != nil {
 		return nil, err
 	}
-	return r.signAndPublishEvent(dom.RegisterEndpoint, dom.RegisterEndpointEvent{
-		Organization: organizationID,
-		URL:          url,
-		EndpointType: endpointType,
-		Identifier:   types2.EndpointID(id),
-		Status:       status,
-		Properties:   properties,
-	}, parentEvent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+	return r.signAndPublishEvent(dom.RegisterEndpoint, payload, parentEvent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
 		return r.signAsOrganization(org.Identifier, org.Name, dataToBeSigned, instant, len(org.GetActiveCertificates()) > 0)
 	})
 }
@@ -23,7 +23,9 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -33,7 +35,9 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
 	crypto "github.com/nuts-foundation/nuts-crypto/pkg"
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	cryptoTypes "github.com/nuts-foundation/nuts-crypto/pkg/types"
@@ -73,7 +77,8 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 		if !assert.NoError(t, err) {
 			return
 		}
-		event, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", "status", map[string]string{"foo": "bar"})
+		notBefore := time.Now().UTC().Truncate(time.Second)
+		event, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", domain.EndpointStatusDeprecated, &notBefore, nil, map[string]string{"foo": "bar"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -82,7 +87,9 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 		assert.Equal(t, "endpointId", string(payload.Identifier))
 		assert.Equal(t, "url", payload.URL)
 		assert.Equal(t, "type", payload.EndpointType)
-		assert.Equal(t, "status", payload.Status)
+		assert.Equal(t, domain.EndpointStatusDeprecated, payload.Status)
+		assert.Equal(t, &notBefore, payload.NotBefore)
+		assert.Nil(t, payload.NotAfter)
 		assert.Len(t, payload.Properties, 1)
 	})
 	t.Run("ok - update", func(t *testing.T) {
@@ -93,9 +100,9 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 		})
 		cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
 		cxt.registry.VendorClaim(orgID, "org", nil)
-		cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", "status", map[string]string{"foo": "bar"})
+		cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", domain.EndpointStatusActive, nil, nil, map[string]string{"foo": "bar"})
 		// Now update endpoint
-		event, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url-updated", "type-updated", "status-updated", map[string]string{"foo": "bar-updated"})
+		event, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url-updated", "type-updated", domain.EndpointStatusMaintenance, nil, nil, map[string]string{"foo": "bar-updated"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -105,7 +112,7 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 		assert.Equal(t, "endpointId", string(payload.Identifier))
 		assert.Equal(t, "url-updated", payload.URL)
 		assert.Equal(t, "type-updated", payload.EndpointType)
-		assert.Equal(t, "status-updated", payload.Status)
+		assert.Equal(t, domain.EndpointStatusMaintenance, payload.Status)
 		assert.Len(t, payload.Properties, 1)
 	})
 	t.Run("ok - auto generate id", func(t *testing.T) {
@@ -116,12 +123,13 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 		})
 		cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
 		cxt.registry.VendorClaim(orgID, "org", nil)
-		event, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", "status", map[string]string{"foo": "bar"})
+		event, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", "", nil, nil, map[string]string{"foo": "bar"})
 		if !assert.NoError(t, err) {
 			return
 		}
 		assert.NotNil(t, event.Signature())
 		assert.Len(t, payload.Identifier, 36) // 36 = length of UUIDv4 as string
+		assert.Equal(t, domain.EndpointStatusActive, payload.Status)
 	})
 	t.Run("ok - org has no certificates", func(t *testing.T) {
 		cxt := createTestContext(t)
@@ -134,7 +142,7 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 			Name:       vendorName,
 		}, nil))
 		cxt.registry.VendorClaim(orgID, "org", nil)
-		event, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", "status", map[string]string{"foo": "bar"})
+		event, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", domain.EndpointStatusActive, nil, nil, map[string]string{"foo": "bar"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -143,10 +151,26 @@ func TestRegistryAdministration_RegisterEndpoint(t *testing.T) {
 	t.Run("error - org not found", func(t *testing.T) {
 		cxt := createTestContext(t)
 		defer cxt.close()
-		endpoint, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", "status", map[string]string{"foo": "bar"})
+		endpoint, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", domain.EndpointStatusActive, nil, nil, map[string]string{"foo": "bar"})
 		assert.Nil(t, endpoint)
 		assert.Error(t, err)
 	})
+	t.Run("error - invalid status", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		endpoint, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", "inactive", nil, nil, nil)
+		assert.Nil(t, endpoint)
+		assert.EqualError(t, err, "invalid endpoint status: inactive (expected one of: active, maintenance, deprecated, disabled)")
+	})
+	t.Run("error - empty availability window", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		notBefore := time.Now()
+		notAfter := notBefore.Add(-time.Hour)
+		endpoint, err := cxt.registry.RegisterEndpoint(orgID, "", "url", "type", domain.EndpointStatusActive, &notBefore, &notAfter, nil)
+		assert.Nil(t, endpoint)
+		assert.Contains(t, err.Error(), "must be after its notBefore")
+	})
 }
 
 func TestRegistryAdministration_VendorClaim(t *testing.T) {
@@ -357,6 +381,42 @@ func TestRegistryAdministration_RegisterVendor(t *testing.T) {
 		_, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
 		assert.Contains(t, err.Error(), "unit test error")
 	})
+	t.Run("error - update signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		registration, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
+		if !assert.NoError(t, err) {
+			return
+		}
+		// Anyone can self-sign a CA certificate containing the vendor's ID and register it
+		caCSR, _ := certutil.VendorCertificateRequest(vendorId, "Other Vendor", "CA", "healthcare")
+		caCertificate, caKey := test.SelfSignCertificateFromCSR(caCSR, time.Now(), 1)
+		signingKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		signingCSR, _ := certutil.VendorCertificateRequest(vendorId, "Other Vendor", "", "healthcare")
+		signingCSR.PublicKey = &signingKey.PublicKey
+		signingCertificate := test.SignCertificateFromCSRWithKey(signingCSR, time.Now(), 1, caCertificate, caKey)
+		caAsJWK, _ := cert.CertificateToJWK(caCertificate)
+		caAsMap, _ := cert.JwkToMap(caAsJWK)
+		event := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{
+			Identifier: vendorId,
+			Name:       "Other Vendor",
+			Domain:     "healthcare",
+			Keys:       []interface{}{caAsMap},
+		}, registration.Ref())
+		err = event.Sign(func(data []byte) ([]byte, error) {
+			headers := jws.NewHeaders()
+			_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(signingCertificate.Raw)})
+			return jws.Sign(data, jwa.RS256, signingKey, jws.WithHeaders(headers))
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		err = cxt.registry.EventSystem.PublishEvent(event)
+		assert.Contains(t, fmt.Sprintf("%v", err), "certificate isn't issued by vendor")
+		assert.Equal(t, vendorName, cxt.registry.Db.VendorByID(vendorId).Name)
+		// The CA certificate mustn't have been added to the truststore
+		assert.Error(t, cxt.registry.crypto.TrustStore().Verify(signingCertificate, time.Now(), []x509.ExtKeyUsage{x509.ExtKeyUsageAny}))
+	})
 }
 
 func TestRegistryAdministration_RefreshOrganizationCertificate(t *testing.T) {
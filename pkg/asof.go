@@ -0,0 +1,156 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package pkg
+
+import (
+	"sync"
+	"time"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+)
+
+// OrganizationByIdAsOf returns the organization as it was registered at the given moment. When it wasn't registered
+// at that moment db.ErrOrganizationNotFound is returned.
+func (r *Registry) OrganizationByIdAsOf(id core.PartyID, moment time.Time) (*db.Organization, error) {
+	asOf, err := r.dbAsOf(moment)
+	if err != nil {
+		return nil, err
+	}
+	return asOf.OrganizationById(id)
+}
+
+// VendorByIdAsOf returns the vendor as it was registered at the given moment. When it wasn't registered at that moment
+// ErrVendorNotFound is returned.
+func (r *Registry) VendorByIdAsOf(id core.PartyID, moment time.Time) (*db.Vendor, error) {
+	asOf, err := r.dbAsOf(moment)
+	if err != nil {
+		return nil, err
+	}
+	v := asOf.VendorByID(id)
+	if v == nil {
+		return nil, ErrVendorNotFound
+	}
+	return v, nil
+}
+
+// EndpointsByOrganizationAndTypeAsOf returns the endpoints of the organization as they were registered at the given moment.
+func (r *Registry) EndpointsByOrganizationAndTypeAsOf(organizationID core.PartyID, endpointType *string, moment time.Time) ([]db.Endpoint, error) {
+	asOf, err := r.dbAsOf(moment)
+	if err != nil {
+		return nil, err
+	}
+	return asOf.FindEndpointsByOrganizationAndType(organizationID, endpointType)
+}
+
+// maxCachedReplays specifies how many replayed databases are cached for point in time queries.
+const maxCachedReplays = 8
+
+// replayCache holds the databases replayed for point in time queries, until an event is applied. Since the replayed
+// state only depends on which events were issued at or before the moment, the databases are keyed by the number of
+// those events: all moments between two consecutive events share the same replay.
+type replayCache struct {
+	subscribe sync.Once
+	// replay makes sure only one replay runs at a time, which bounds the cost of point in time queries.
+	replay sync.Mutex
+	mux    sync.Mutex
+	dbs    map[int]*db.MemoryDb
+	// keys holds the keys of dbs in order of caching, so the oldest replay is evicted first.
+	keys []int
+	// generation is incremented every time the cache is invalidated, so a replay made while an event was being applied
+	// isn't cached.
+	generation uint64
+}
+
+func (c *replayCache) invalidate(_ events.Event) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.dbs = nil
+	c.keys = nil
+	c.generation++
+}
+
+func (c *replayCache) get(key int) (*db.MemoryDb, uint64) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.dbs[key], c.generation
+}
+
+func (c *replayCache) put(key int, generation uint64, replayed *db.MemoryDb) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if c.dbs == nil {
+		c.dbs = make(map[int]*db.MemoryDb)
+	}
+	if len(c.keys) == maxCachedReplays {
+		delete(c.dbs, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.dbs[key] = replayed
+	c.keys = append(c.keys, key)
+}
+
+// dbAsOf rebuilds the database as it was at the given moment, from the events issued at or before that moment. Replays
+// are cached until an event is applied (see replayCache).
+func (r *Registry) dbAsOf(moment time.Time) (db.Db, error) {
+	cache := &r.replayCache
+	cache.subscribe.Do(func() {
+		r.EventSystem.Subscribe(cache.invalidate)
+	})
+	key, err := r.countEventsIssuedAt(moment)
+	if err != nil {
+		return nil, err
+	}
+	if cached, _ := cache.get(key); cached != nil {
+		return cached.AsOf(moment), nil
+	}
+	cache.replay.Lock()
+	defer cache.replay.Unlock()
+	// Another query might have replayed the same events while waiting
+	cached, generation := cache.get(key)
+	if cached != nil {
+		return cached.AsOf(moment), nil
+	}
+	result := db.NewAsOf(moment)
+	r.EventSystem.Replay(moment, result.RegisterEventHandlers)
+	// Only cache the replay when no events issued at or before the moment were added while replaying
+	if after, err := r.countEventsIssuedAt(moment); err == nil && after == key {
+		cache.put(key, generation, result)
+	}
+	return result, nil
+}
+
+// countEventsIssuedAt returns the number of applied events which were issued at or before the given moment.
+func (r *Registry) countEventsIssuedAt(moment time.Time) (int, error) {
+	evts, err := r.EventSystem.EventsAfter(nil)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, event := range evts {
+		if !event.IssuedAt().After(moment) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_AsOf(t *testing.T) {
+	cxt := createTestContext(t)
+	defer cxt.close()
+	orgID := test.OrganizationID("orgId")
+	beforeVendor := time.Now()
+	if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+		return
+	}
+	beforeUpdate := time.Now()
+	if _, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url-updated", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.EndVendorClaim(orgID); !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("endpoints", func(t *testing.T) {
+		endpoints, err := cxt.registry.EndpointsByOrganizationAndTypeAsOf(orgID, nil, beforeUpdate)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, endpoints, 1) {
+			assert.Equal(t, "url", endpoints[0].URL)
+		}
+	})
+	t.Run("endpoints - current data is left untouched", func(t *testing.T) {
+		_, err := cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+		assert.Error(t, err, "claim has ended, so organization should not be found")
+	})
+	t.Run("organization", func(t *testing.T) {
+		org, err := cxt.registry.OrganizationByIdAsOf(orgID, beforeUpdate)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "org", org.Name)
+		assert.Equal(t, vendorId, org.Vendor)
+	})
+	t.Run("organization - not registered at that moment", func(t *testing.T) {
+		_, err := cxt.registry.OrganizationByIdAsOf(orgID, beforeVendor)
+		assert.True(t, errors.Is(err, db.ErrOrganizationNotFound))
+	})
+	t.Run("vendor", func(t *testing.T) {
+		vendor, err := cxt.registry.VendorByIdAsOf(vendorId, beforeUpdate)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, vendorName, vendor.Name)
+	})
+	t.Run("vendor - not registered at that moment", func(t *testing.T) {
+		_, err := cxt.registry.VendorByIdAsOf(vendorId, beforeVendor)
+		assert.Equal(t, ErrVendorNotFound, err)
+	})
+}
+
+func TestRegistry_AsOfCache(t *testing.T) {
+	cxt := createTestContext(t)
+	defer cxt.close()
+	orgID := test.OrganizationID("orgId")
+	if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+		return
+	}
+	cache := &cxt.registry.replayCache
+
+	t.Run("moments between the same events share the replay", func(t *testing.T) {
+		moment := time.Now()
+		if _, err := cxt.registry.OrganizationByIdAsOf(orgID, moment); !assert.NoError(t, err) {
+			return
+		}
+		if _, err := cxt.registry.OrganizationByIdAsOf(orgID, moment.Add(time.Millisecond)); !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, cache.dbs, 1)
+	})
+	t.Run("cached replay evaluates claims at the given moment", func(t *testing.T) {
+		_, err := cxt.registry.OrganizationByIdAsOf(orgID, time.Now().Add(-time.Hour))
+		assert.True(t, errors.Is(err, db.ErrOrganizationNotFound))
+	})
+	t.Run("invalidated when an event is applied", func(t *testing.T) {
+		if _, err := cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, cache.dbs)
+		endpoints, err := cxt.registry.EndpointsByOrganizationAndTypeAsOf(orgID, nil, time.Now())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, endpoints, 1)
+	})
+	t.Run("number of cached replays is bounded", func(t *testing.T) {
+		for i := 0; i < maxCachedReplays+2; i++ {
+			if _, err := cxt.registry.RegisterEndpoint(orgID, fmt.Sprintf("endpoint-%d", i), "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+				return
+			}
+		}
+		evts, _ := cxt.registry.EventSystem.EventsAfter(nil)
+		for _, event := range evts {
+			_, _ = cxt.registry.VendorByIdAsOf(vendorId, event.IssuedAt())
+		}
+		assert.Len(t, cache.dbs, maxCachedReplays)
+		assert.Len(t, cache.keys, maxCachedReplays)
+	})
+}
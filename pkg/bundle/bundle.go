@@ -0,0 +1,157 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package bundle contains the format of tar.gz bundles of events, which are used to export and import events.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	errors2 "github.com/pkg/errors"
+)
+
+// RootDir is the top-level directory of a bundle created by the registry. It's stripped when the bundle is read.
+const RootDir = "registry-export"
+
+// ManifestFile is the name of the file in a bundle which holds the signed manifest (as compact JWS).
+const ManifestFile = "manifest.jws"
+
+// EventsDir is the directory in a bundle (relative to the top-level directory) which holds the event files.
+const EventsDir = "events"
+
+// Manifest describes the contents of a bundle: the events it contains (in chain order) and the vendor that created it.
+type Manifest struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Vendor    core.PartyID    `json:"vendor"`
+	Events    []ManifestEntry `json:"events"`
+}
+
+// ManifestEntry describes a single event file in a bundle.
+type ManifestEntry struct {
+	Ref    events.Ref `json:"ref"`
+	File   string     `json:"file"`
+	SHA256 string     `json:"sha256"`
+}
+
+// ImportOutcome describes what happens (or would happen) to an event when importing it.
+type ImportOutcome string
+
+const (
+	// ImportOutcomeApplied indicates the event is applied.
+	ImportOutcomeApplied ImportOutcome = "applied"
+	// ImportOutcomeDuplicate indicates the event is skipped, since it has already been applied or occurs earlier in the bundle.
+	ImportOutcomeDuplicate ImportOutcome = "duplicate"
+	// ImportOutcomeParked indicates the event is parked in the retry queue, since the event it refers to is missing.
+	ImportOutcomeParked ImportOutcome = "parked"
+	// ImportOutcomeRejected indicates the event is rejected, e.g. because it can't be parsed or its signature is invalid.
+	ImportOutcomeRejected ImportOutcome = "rejected"
+)
+
+// ImportReport describes the outcome of importing the events of a bundle.
+type ImportReport struct {
+	// Applied indicates whether the events have actually been imported. If false, the report is the result of a dry run
+	// or importing stopped at the error specified by Error.
+	Applied bool `json:"applied"`
+	// Error holds the error at which importing stopped. Only the events marked as imported have been imported.
+	Error string `json:"error,omitempty"`
+	// Manifest holds the (verified) manifest of the bundle, if the bundle contains one.
+	Manifest *Manifest `json:"manifest,omitempty"`
+	// Events holds the outcome per event, in the order they're imported.
+	Events []ImportReportEntry `json:"events"`
+}
+
+// ImportReportEntry describes the outcome of importing a single event.
+type ImportReportEntry struct {
+	File    string           `json:"file"`
+	Ref     events.Ref       `json:"ref,omitempty"`
+	Type    events.EventType `json:"type,omitempty"`
+	Outcome ImportOutcome    `json:"outcome"`
+	Reason  string           `json:"reason,omitempty"`
+	// Imported indicates whether the event has actually been imported (only when applying the import).
+	Imported bool `json:"imported,omitempty"`
+}
+
+// WriteEntry writes a file with the given name (relative to RootDir) and data to the bundle.
+func WriteEntry(writer *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Join(RootDir, name),
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return errors2.Wrap(err, "unable to write bundle")
+	}
+	if _, err := writer.Write(data); err != nil {
+		return errors2.Wrap(err, "unable to write bundle")
+	}
+	return nil
+}
+
+// Read reads the JSON files (keyed by their path in the bundle, without top-level directory) and the signed manifest
+// (nil if absent) from the given tar.gz bundle. Like when ingesting the tar.gz from sync mode 'github', the top-level
+// directory may have any name. A bundle containing the same file more than once is rejected.
+func Read(reader io.Reader) (map[string][]byte, []byte, error) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tarReader := tar.NewReader(gzipReader)
+	files := make(map[string][]byte)
+	var signedManifest []byte
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files, signedManifest, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		parts := strings.SplitN(path.Clean(header.Name), "/", 2)
+		if len(parts) == 1 || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := parts[1]
+		if name != ManifestFile && path.Ext(name) != ".json" {
+			continue
+		}
+		if _, exists := files[name]; exists || (name == ManifestFile && signedManifest != nil) {
+			return nil, nil, fmt.Errorf("duplicate entry in bundle: %s", name)
+		}
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if name == ManifestFile {
+			signedManifest = data
+		} else {
+			files[name] = data
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		gzipWriter := gzip.NewWriter(buf)
+		tarWriter := tar.NewWriter(gzipWriter)
+		_ = WriteEntry(tarWriter, ManifestFile, []byte("manifest"), time.Now())
+		_ = WriteEntry(tarWriter, "events/1.json", []byte("event"), time.Now())
+		_ = WriteEntry(tarWriter, "events/README.md", []byte("readme"), time.Now())
+		_ = tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "top-level.json", Size: 0})
+		_ = tarWriter.Close()
+		_ = gzipWriter.Close()
+
+		files, manifest, err := Read(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "manifest", string(manifest))
+		assert.Equal(t, map[string][]byte{"events/1.json": []byte("event")}, files)
+	})
+	t.Run("error - duplicate entry", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		gzipWriter := gzip.NewWriter(buf)
+		tarWriter := tar.NewWriter(gzipWriter)
+		_ = WriteEntry(tarWriter, "events/1.json", []byte("event"), time.Now())
+		_ = WriteEntry(tarWriter, "events/1.json", []byte("other event"), time.Now())
+		_ = tarWriter.Close()
+		_ = gzipWriter.Close()
+
+		_, _, err := Read(buf)
+		assert.EqualError(t, err, "duplicate entry in bundle: events/1.json")
+	})
+	t.Run("error - not gzipped", func(t *testing.T) {
+		_, _, err := Read(bytes.NewReader([]byte("foo")))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,1302 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	test2 "github.com/nuts-foundation/nuts-crypto/test"
+	core "github.com/nuts-foundation/nuts-go-core"
+	cert2 "github.com/nuts-foundation/nuts-registry/pkg/cert"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// DbFactory creates an empty Db for a single conformance test. Resources held by the Db (files, connections) should
+// be released using t.Cleanup.
+type DbFactory func(t *testing.T) Db
+
+// RunConformanceTests specifies the behaviour of a Db by running tests against Dbs created by the given factory.
+// The tests feed sequences of signed events to the Db through an event system, like the registry does, and check the
+// results of its queries: which events are rejected, which organizations and endpoints are returned (in what order)
+// and the errors returned for organizations that can't be found (ErrOrganizationNotFound). Every Db implementation
+// (or wrapper around one) should pass them:
+//
+//	func TestMyDb(t *testing.T) {
+//		db.RunConformanceTests(t, func(t *testing.T) db.Db {
+//			return NewMyDb()
+//		})
+//	}
+func RunConformanceTests(t *testing.T, factory DbFactory) {
+	s := newConformanceSuite(t, factory)
+	t.Run("RegisterVendor", s.testRegisterVendor)
+	t.Run("VendorClaim", s.testVendorClaim)
+	t.Run("RegisterEndpoint", s.testRegisterEndpoint)
+	t.Run("DeregisterEndpoint", s.testDeregisterEndpoint)
+	t.Run("EndVendorClaim", s.testEndVendorClaim)
+	t.Run("RetireVendor", s.testRetireVendor)
+	t.Run("VendorByID", s.testVendorByID)
+	t.Run("FindEndpointsByOrganizationAndType", s.testFindEndpointsByOrganizationAndType)
+	t.Run("SearchOrganizations", s.testSearchOrganizations)
+	t.Run("SearchEndpoints", s.testSearchEndpoints)
+	t.Run("ReverseLookup", s.testReverseLookup)
+	t.Run("OrganizationById", s.testOrganizationById)
+	t.Run("OrganizationsByVendorID", s.testOrganizationsByVendorID)
+	t.Run("Snapshot", s.testSnapshot)
+}
+
+// conformanceSuite holds the test data of the conformance tests:
+//
+//	v1 Vendor Uno
+//	  o1 Organization Uno
+//	    e1 Endpoint Uno
+//	    e2 Endpoint Dos (disabled)
+//	  o2 Organization Dos
+//	v2 Vendor Dos
+type conformanceSuite struct {
+	factory DbFactory
+	// key is used to sign all events, using a certificate for the vendor or organization the event concerns. Vendor
+	// certificates are issued by the vendor's CA certificate, organization certificates by the CA certificate of their vendor.
+	key *rsa.PrivateKey
+	// vendorCAs contains the CA certificates of the vendors (with their keys), which are added to the vendors' RegisterVendorEvents.
+	vendorCAs map[core.PartyID]vendorCA
+	// orgVendors contains the vendor which first claimed the organization, which issues the organization's certificates.
+	orgVendors map[core.PartyID]core.PartyID
+
+	registerVendor1     events.Event
+	registerVendor2     events.Event
+	vendorClaim1        events.Event
+	vendorClaim2        events.Event
+	registerEndpoint1   events.Event
+	registerEndpoint2   events.Event
+	deregisterEndpoint1 events.Event
+	retireVendor2       events.Event
+}
+
+func newConformanceSuite(t *testing.T, factory DbFactory) *conformanceSuite {
+	s := &conformanceSuite{
+		factory:    factory,
+		key:        test2.GenerateRSAKey(),
+		vendorCAs:  make(map[core.PartyID]vendorCA),
+		orgVendors: make(map[core.PartyID]core.PartyID),
+	}
+	s.registerVendor1 = s.event(t, domain.RegisterVendor, domain.RegisterVendorEvent{
+		Identifier: test.VendorID("v1"),
+		Name:       "Vendor Uno",
+	}, nil)
+	s.registerVendor2 = s.event(t, domain.RegisterVendor, domain.RegisterVendorEvent{
+		Identifier: test.VendorID("v2"),
+		Name:       "Vendor Dos",
+	}, nil)
+	s.vendorClaim1 = s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+		VendorID:       test.VendorID("v1"),
+		OrganizationID: test.OrganizationID("o1"),
+		OrgName:        "Organization Uno",
+	}, nil)
+	s.vendorClaim2 = s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+		VendorID:       test.VendorID("v1"),
+		OrganizationID: test.OrganizationID("o2"),
+		OrgName:        "Organization Dos",
+	}, nil)
+	s.registerEndpoint1 = s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+		Organization: test.OrganizationID("o1"),
+		URL:          "foo:bar",
+		EndpointType: "simple",
+		Identifier:   "e1",
+		Status:       StatusActive,
+	}, nil)
+	s.registerEndpoint2 = s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+		Organization: test.OrganizationID("o1"),
+		URL:          "foo:bar",
+		EndpointType: "simple",
+		Identifier:   "e2",
+		Status:       StatusDisabled,
+	}, nil)
+	s.deregisterEndpoint1 = s.event(t, domain.DeregisterEndpoint, domain.DeregisterEndpointEvent{
+		Organization: test.OrganizationID("o1"),
+		Identifier:   "e1",
+	}, nil)
+	s.retireVendor2 = s.event(t, domain.RetireVendor, domain.RetireVendorEvent{
+		Identifier: test.VendorID("v2"),
+	}, nil)
+	return s
+}
+
+// vendorCA holds the CA certificate of a vendor, which issues the certificates of the organizations it claims.
+type vendorCA struct {
+	certificate *x509.Certificate
+	key         *rsa.PrivateKey
+	jwk         map[string]interface{}
+}
+
+// vendorCA returns the CA certificate of the given vendor, creating it if it doesn't exist yet.
+func (s *conformanceSuite) vendorCA(t *testing.T, vendorID core.PartyID) vendorCA {
+	if ca, ok := s.vendorCAs[vendorID]; ok {
+		return ca
+	}
+	csr, err := cert2.VendorCertificateRequest(vendorID, "Vendor "+vendorID.Value(), "CA", types.HealthcareDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := vendorCA{key: test2.GenerateRSAKey()}
+	csr.PublicKey = &ca.key.PublicKey
+	ca.certificate = test.SignCertificateFromCSRWithKey(csr, time.Now().Add(-time.Hour), 1, nil, ca.key)
+	key, _ := cert.CertificateToJWK(ca.certificate)
+	if ca.jwk, err = cert.JwkToMap(key); err != nil {
+		t.Fatal(err)
+	}
+	s.vendorCAs[vendorID] = ca
+	return ca
+}
+
+// event creates an event which refers to the given previous event (if any). It's signed by the vendor or organization
+// specified in the payload, which is the party authorized to sign it (see domain.SignerAuthorizer). RegisterVendorEvents
+// without keys get the vendor's CA certificate, which issues the certificates of the organizations it claims.
+func (s *conformanceSuite) event(t *testing.T, eventType events.EventType, payload interface{}, previous events.Event) events.Event {
+	switch p := payload.(type) {
+	case domain.RegisterVendorEvent:
+		if len(p.Keys) == 0 {
+			p.Keys = []interface{}{s.vendorCA(t, p.Identifier).jwk}
+			payload = p
+		}
+	case domain.VendorClaimEvent:
+		if _, ok := s.orgVendors[p.OrganizationID]; !ok {
+			s.orgVendors[p.OrganizationID] = p.VendorID
+		}
+	}
+	csr, issuer, err := s.signerCertificateRequest(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr.PublicKey = &s.key.PublicKey
+	ca := s.vendorCA(t, issuer)
+	certificate := test.SignCertificateFromCSRWithKey(csr, time.Now().Add(-time.Hour), 1, ca.certificate, ca.key)
+	var previousRef events.Ref
+	if previous != nil {
+		previousRef = previous.Ref()
+	}
+	event := events.CreateEvent(eventType, payload, previousRef)
+	err = event.Sign(func(data []byte) ([]byte, error) {
+		headers := jws.NewHeaders()
+		_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+		return jws.Sign(data, jwa.RS256, s.key, jws.WithHeaders(headers))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// signerCertificateRequest returns the CSR for the certificate of the party authorized to sign the event with the
+// given payload, together with the ID of the vendor whose CA certificate issues the certificate.
+func (s *conformanceSuite) signerCertificateRequest(payload interface{}) (x509.CertificateRequest, core.PartyID, error) {
+	var vendorID, organizationID core.PartyID
+	switch p := payload.(type) {
+	case domain.RegisterVendorEvent:
+		vendorID = p.Identifier
+	case domain.VendorClaimEvent:
+		vendorID = p.VendorID
+	case domain.EndVendorClaimEvent:
+		vendorID = p.VendorID
+	case domain.RetireVendorEvent:
+		vendorID = p.Identifier
+	case domain.RegisterEndpointEvent:
+		organizationID = p.Organization
+	case domain.DeregisterEndpointEvent:
+		organizationID = p.Organization
+	default:
+		return x509.CertificateRequest{}, core.PartyID{}, errors.New("no signer for event payload")
+	}
+	if organizationID.IsZero() {
+		csr, err := cert2.VendorCertificateRequest(vendorID, "Vendor", "", types.HealthcareDomain)
+		return csr, vendorID, err
+	}
+	csr, err := cert2.OrganisationCertificateRequest("Vendor", organizationID, "Organization", types.HealthcareDomain)
+	return csr, s.orgVendors[organizationID], err
+}
+
+// withDb runs the test with an empty Db and an event system which applies events to the Db, after verifying the
+// signatures (without checking certificate trust) and authorizing the event signers.
+func (s *conformanceSuite) withDb(fn func(t *testing.T, eventSystem events.EventSystem, db Db)) func(*testing.T) {
+	return func(t *testing.T) {
+		repo, err := test.NewTestRepo(t)
+		if !assert.NoError(t, err) {
+			return
+		}
+		db := s.factory(t)
+		eventSystem := events.NewEventSystem(domain.GetEventTypes()...)
+		if !assert.NoError(t, eventSystem.Configure(repo.Directory+"/events")) {
+			return
+		}
+		events.NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).RegisterEventHandlers(eventSystem.RegisterEventHandler, domain.GetEventTypes())
+		domain.NewSignerAuthorizer().RegisterEventHandlers(eventSystem.RegisterEventHandler)
+		db.RegisterEventHandlers(eventSystem.RegisterEventHandler)
+		fn(t, eventSystem, db)
+	}
+}
+
+// publish publishes the given events in order, stopping at the first event that fails.
+func publish(t *testing.T, eventSystem events.EventSystem, events ...events.Event) bool {
+	for _, e := range events {
+		err := eventSystem.PublishEvent(e)
+		if !assert.NoError(t, err) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *conformanceSuite) testRegisterVendor(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		err := eventSystem.PublishEvent(s.registerVendor1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotNil(t, db.VendorByID(test.VendorID("v1")))
+		err = eventSystem.PublishEvent(s.registerVendor2)
+		if assert.NoError(t, err) {
+			assert.NotNil(t, db.VendorByID(test.VendorID("v2")))
+		}
+	}))
+	t.Run("ok - update", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		payload := domain.RegisterVendorEvent{}
+		s.registerVendor1.Unmarshal(&payload)
+		payload.Name = "Foobar"
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterVendor, payload, s.registerVendor1))
+		if assert.NoError(t, err) {
+			assert.Equal(t, payload.Name, db.VendorByID(payload.Identifier).Name)
+		}
+	}))
+	t.Run("error - update - different vendorID", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		payload := domain.RegisterVendorEvent{}
+		s.registerVendor1.Unmarshal(&payload)
+		payload.Name = "Foobar"
+		payload.Identifier = test.VendorID("123")
+		payload.Keys = nil
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterVendor, payload, s.registerVendor1))
+		assert.EqualError(t, err, "referred event contains a different vendor: actual vendorId (urn:oid:1.3.6.1.4.1.54851.4:v1) differs from expected (urn:oid:1.3.6.1.4.1.54851.4:123)")
+		assert.Nil(t, db.VendorByID(test.VendorID("123")))
+	}))
+	t.Run("error - vendor already exists", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		duplicateVendor := s.event(t, domain.RegisterVendor, domain.RegisterVendorEvent{
+			Identifier: test.VendorID("v1"),
+			Name:       "Vendor Uno (duplicate)",
+		}, nil)
+		err := eventSystem.PublishEvent(duplicateVendor)
+		assert.EqualError(t, err, "vendor already registered (id = urn:oid:1.3.6.1.4.1.54851.4:v1)")
+		assert.Equal(t, "Vendor Uno", db.VendorByID(test.VendorID("v1")).Name)
+	}))
+}
+
+func (s *conformanceSuite) testVendorClaim(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		vendorID := test.VendorID("v1")
+		assert.Len(t, db.OrganizationsByVendorID(vendorID), 0)
+		if !publish(t, eventSystem, s.vendorClaim1) {
+			return
+		}
+		assert.Len(t, db.OrganizationsByVendorID(vendorID), 1)
+		if !publish(t, eventSystem, s.vendorClaim2) {
+			return
+		}
+		assert.Len(t, db.OrganizationsByVendorID(vendorID), 2)
+	}))
+	t.Run("ok - update", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.OrgName = "Foobar"
+		err := eventSystem.PublishEvent(s.event(t, domain.VendorClaim, payload, s.vendorClaim1))
+		if !assert.NoError(t, err) {
+			return
+		}
+		org, err := db.OrganizationById(payload.OrganizationID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, payload.OrgName, org.Name)
+		}
+	}))
+	t.Run("ok - update of update", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.OrgName = "Foo"
+		update1 := s.event(t, domain.VendorClaim, payload, s.vendorClaim1)
+		payload.OrgName = "Bar"
+		update2 := s.event(t, domain.VendorClaim, payload, update1)
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, update1, update2) {
+			return
+		}
+		org, err := db.OrganizationById(payload.OrganizationID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Bar", org.Name)
+		}
+		assert.Len(t, db.OrganizationsByVendorID(test.VendorID("v1")), 1)
+	}))
+	t.Run("error - update - org ID differs", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.OrganizationID = test.OrganizationID("1234")
+		payload.OrgName = "Foobar"
+		err := eventSystem.PublishEvent(s.event(t, domain.VendorClaim, payload, s.vendorClaim1))
+		assert.EqualError(t, err, "can't change organization ID: actual organizationId (urn:oid:2.16.840.1.113883.2.4.6.1:o1) differs from expected (urn:oid:2.16.840.1.113883.2.4.6.1:1234)")
+		_, err = db.OrganizationById(test.OrganizationID("1234"))
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+	}))
+	t.Run("error - update - vendor ID differs", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1) {
+			return
+		}
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.VendorID = test.VendorID("v2")
+		payload.OrgName = "Foobar"
+		err := eventSystem.PublishEvent(s.event(t, domain.VendorClaim, payload, s.vendorClaim1))
+		assert.EqualError(t, err, "can't change organization's vendor: actual vendorId (urn:oid:1.3.6.1.4.1.54851.4:v1) differs from expected (urn:oid:1.3.6.1.4.1.54851.4:v2)")
+		assert.Empty(t, db.OrganizationsByVendorID(test.VendorID("v2")))
+	}))
+	t.Run("error - unknown vendor", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		err := eventSystem.PublishEvent(s.vendorClaim1)
+		assert.Error(t, err)
+	}))
+	t.Run("error - duplicate organization", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		duplicateOrg := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v1"),
+			OrganizationID: test.OrganizationID("o1"),
+			OrgName:        "Organization Uno (duplicate)",
+		}, nil)
+		err := eventSystem.PublishEvent(duplicateOrg)
+		assert.Error(t, err)
+		assert.Len(t, db.OrganizationsByVendorID(test.VendorID("v1")), 1)
+	}))
+}
+
+func (s *conformanceSuite) testRegisterEndpoint(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		err := eventSystem.PublishEvent(s.registerEndpoint1)
+		assert.NoError(t, err)
+	}))
+	t.Run("ok - update", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		payload := domain.RegisterEndpointEvent{}
+		s.registerEndpoint1.Unmarshal(&payload)
+		payload.Properties = map[string]string{"hello": "world"}
+		payload.EndpointType += "-updated"
+		payload.URL += "-updated"
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterEndpoint, payload, s.registerEndpoint1))
+		if !assert.NoError(t, err) {
+			return
+		}
+		endpoints, _ := db.FindEndpointsByOrganizationAndType(payload.Organization, nil)
+		if !assert.Len(t, endpoints, 1) {
+			return
+		}
+		assert.Equal(t, payload.EndpointType, endpoints[0].EndpointType)
+		assert.Equal(t, payload.URL, endpoints[0].URL)
+		assert.Equal(t, payload.Properties, endpoints[0].Properties)
+	}))
+	t.Run("ok - status and availability window", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		notBefore := time.Now().UTC().Truncate(time.Second)
+		notAfter := notBefore.Add(24 * time.Hour)
+		registerEndpoint := s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: test.OrganizationID("o1"),
+			URL:          "foo:bar",
+			EndpointType: "simple",
+			Identifier:   "e1",
+			Status:       StatusDeprecated,
+			NotBefore:    &notBefore,
+			NotAfter:     &notAfter,
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, registerEndpoint) {
+			return
+		}
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		if assert.Len(t, org.Endpoints, 1) {
+			assert.Equal(t, StatusDeprecated, org.Endpoints[0].Status)
+			assert.Equal(t, &notBefore, org.Endpoints[0].NotBefore)
+			assert.Equal(t, &notAfter, org.Endpoints[0].NotAfter)
+		}
+	}))
+	t.Run("error - invalid status", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		registerEndpoint := s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: test.OrganizationID("o1"),
+			URL:          "foo:bar",
+			EndpointType: "simple",
+			Identifier:   "e1",
+			Status:       "inactive",
+		}, nil)
+		err := eventSystem.PublishEvent(registerEndpoint)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid endpoint status: inactive")
+		}
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		assert.Empty(t, org.Endpoints)
+	}))
+	t.Run("error - empty availability window", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		notBefore := time.Now()
+		registerEndpoint := s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: test.OrganizationID("o1"),
+			URL:          "foo:bar",
+			EndpointType: "simple",
+			Identifier:   "e1",
+			Status:       StatusActive,
+			NotBefore:    &notBefore,
+			NotAfter:     &notBefore,
+		}, nil)
+		err := eventSystem.PublishEvent(registerEndpoint)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "must be after its notBefore")
+		}
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		assert.Empty(t, org.Endpoints)
+	}))
+	t.Run("error - can't change org for endpoint", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1, s.vendorClaim2) {
+			return
+		}
+		payload := domain.RegisterEndpointEvent{}
+		s.registerEndpoint1.Unmarshal(&payload)
+		payload.Organization = test.OrganizationID("o2") // this is org from vendorClaim2
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterEndpoint, payload, s.registerEndpoint1))
+		assert.EqualError(t, err, "can't change endpoint's organization: actual organizationId (urn:oid:2.16.840.1.113883.2.4.6.1:o1) differs from expected (urn:oid:2.16.840.1.113883.2.4.6.1:o2)")
+		endpoints, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o2"), nil)
+		assert.Empty(t, endpoints)
+	}))
+	t.Run("error - endpoint already registered", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		duplicateEndpoint := s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: test.OrganizationID("o1"),
+			URL:          "foo:baz",
+			EndpointType: "simple",
+			Identifier:   "e1",
+			Status:       StatusActive,
+		}, nil)
+		err := eventSystem.PublishEvent(duplicateEndpoint)
+		assert.EqualError(t, err, "endpoint already registered for this organization (id = e1)")
+		endpoints, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if assert.Len(t, endpoints, 1) {
+			assert.Equal(t, "foo:bar", endpoints[0].URL)
+		}
+	}))
+	t.Run("error - unknown organization", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		// Events of organizations that aren't claimed are already rejected because their signer can't be authorized
+		err := eventSystem.PublishEvent(s.registerEndpoint1)
+		assert.EqualError(t, err, fmt.Sprintf("event signer not authorized, it will not be processed (event = %v): "+
+			"organization isn't claimed by a vendor (id = urn:oid:2.16.840.1.113883.2.4.6.1:o1)", s.registerEndpoint1.IssuedAt()))
+	}))
+}
+
+func (s *conformanceSuite) testDeregisterEndpoint(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1, s.deregisterEndpoint1) {
+			return
+		}
+		endpoints, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Empty(t, endpoints)
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		assert.Empty(t, org.Endpoints)
+	}))
+	t.Run("error - update after deregistration", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1, s.deregisterEndpoint1) {
+			return
+		}
+		payload := domain.RegisterEndpointEvent{}
+		s.registerEndpoint1.Unmarshal(&payload)
+		payload.URL += "-updated"
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterEndpoint, payload, s.registerEndpoint1))
+		assert.EqualError(t, err, "endpoint has been deregistered (id = e1)")
+		endpoints, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Empty(t, endpoints)
+	}))
+	t.Run("error - registration after deregistration", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1, s.deregisterEndpoint1) {
+			return
+		}
+		payload := domain.RegisterEndpointEvent{}
+		s.registerEndpoint1.Unmarshal(&payload)
+		err := eventSystem.PublishEvent(s.event(t, domain.RegisterEndpoint, payload, nil))
+		assert.EqualError(t, err, "endpoint has been deregistered (id = e1)")
+	}))
+	t.Run("error - unknown endpoint", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		err := eventSystem.PublishEvent(s.deregisterEndpoint1)
+		assert.EqualError(t, err, "endpoint not registered for this organization (id = e1)")
+	}))
+	t.Run("error - unknown organization", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		// Events of organizations that aren't claimed are already rejected because their signer can't be authorized
+		err := eventSystem.PublishEvent(s.deregisterEndpoint1)
+		assert.EqualError(t, err, fmt.Sprintf("event signer not authorized, it will not be processed (event = %v): "+
+			"organization isn't claimed by a vendor (id = urn:oid:2.16.840.1.113883.2.4.6.1:o1)", s.deregisterEndpoint1.IssuedAt()))
+	}))
+}
+
+func (s *conformanceSuite) testEndVendorClaim(t *testing.T) {
+	endVendorClaim := func(t *testing.T, end time.Time) events.Event {
+		return s.event(t, domain.EndVendorClaim, domain.EndVendorClaimEvent{
+			VendorID:       test.VendorID("v1"),
+			OrganizationID: test.OrganizationID("o1"),
+			End:            end,
+		}, nil)
+	}
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.vendorClaim2, endVendorClaim(t, time.Now())) {
+			return
+		}
+		_, err := db.OrganizationById(test.OrganizationID("o1"))
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		_, err = db.ReverseLookup("Organization Uno")
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		assert.Len(t, db.OrganizationsByVendorID(test.VendorID("v1")), 1)
+		assert.Empty(t, db.SearchOrganizations("Uno", SearchOptions{}).Organizations)
+		_, err = db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Error(t, err)
+	}))
+	t.Run("ok - end in the future", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, endVendorClaim(t, time.Now().Add(time.Hour))) {
+			return
+		}
+		org, err := db.OrganizationById(test.OrganizationID("o1"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "Organization Uno", org.Name)
+	}))
+	t.Run("ok - update can't undo end", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, endVendorClaim(t, time.Now())) {
+			return
+		}
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.OrgName = "Foobar"
+		err := eventSystem.PublishEvent(s.event(t, domain.VendorClaim, payload, s.vendorClaim1))
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = db.OrganizationById(test.OrganizationID("o1"))
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+	}))
+	t.Run("ok - organization claimed by other vendor", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		end := endVendorClaim(t, time.Now())
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o1"),
+			OrgName:        "Organization Uno (v2)",
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1, end, claim) {
+			return
+		}
+		org, err := db.OrganizationById(test.OrganizationID("o1"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, test.VendorID("v2"), org.Vendor)
+		assert.Empty(t, db.OrganizationsByVendorID(test.VendorID("v1")))
+		assert.Len(t, db.OrganizationsByVendorID(test.VendorID("v2")), 1)
+	}))
+	t.Run("error - organization claimed by other vendor when the claim was issued", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		end := endVendorClaim(t, time.Now().Add(100*time.Millisecond))
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o1"),
+			OrgName:        "Organization Uno (v2)",
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1, end) {
+			return
+		}
+		// The claim is applied after the first claim ended, but it was issued while it was still active
+		time.Sleep(200 * time.Millisecond)
+		err := eventSystem.PublishEvent(claim)
+		assert.EqualError(t, err, "organization already registered (id = urn:oid:2.16.840.1.113883.2.4.6.1:o1)")
+	}))
+	t.Run("error - organization not claimed by vendor", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		err := eventSystem.PublishEvent(endVendorClaim(t, time.Now()))
+		assert.EqualError(t, err, "organization not claimed by vendor (id = urn:oid:2.16.840.1.113883.2.4.6.1:o1)")
+	}))
+}
+
+func (s *conformanceSuite) testRetireVendor(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o3"),
+			OrgName:        "Organization Tres",
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, claim, s.retireVendor2) {
+			return
+		}
+		assert.Nil(t, db.VendorByID(test.VendorID("v2")))
+		assert.NotNil(t, db.VendorByID(test.VendorID("v1")))
+		assert.Nil(t, db.OrganizationsByVendorID(test.VendorID("v2")))
+		_, err := db.OrganizationById(test.OrganizationID("o3"))
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		_, err = db.ReverseLookup("Organization Tres")
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		assert.Empty(t, db.SearchOrganizations("tres", SearchOptions{}).Organizations)
+	}))
+	t.Run("error - claim by retired vendor", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor2, s.retireVendor2) {
+			return
+		}
+		err := eventSystem.PublishEvent(s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o3"),
+		}, nil))
+		assert.EqualError(t, err, "vendor has been retired (id = urn:oid:1.3.6.1.4.1.54851.4:v2)")
+	}))
+	t.Run("error - unknown vendor", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		err := eventSystem.PublishEvent(s.retireVendor2)
+		assert.EqualError(t, err, "vendor is not registered (id = urn:oid:1.3.6.1.4.1.54851.4:v2)")
+	}))
+}
+
+func (s *conformanceSuite) testVendorByID(t *testing.T) {
+	t.Run("tests", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		t.Run("found", func(t *testing.T) {
+			vendor := db.VendorByID(test.VendorID("v1"))
+			if assert.NotNil(t, vendor) {
+				assert.Equal(t, test.VendorID("v1"), vendor.Identifier)
+				assert.Equal(t, "Vendor Uno", vendor.Name)
+			}
+		})
+		t.Run("not found", func(t *testing.T) {
+			assert.Nil(t, db.VendorByID(test.VendorID("v2")))
+		})
+	}))
+}
+
+func (s *conformanceSuite) testFindEndpointsByOrganizationAndType(t *testing.T) {
+	t.Run("ok", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, test.OrganizationID("o1"), result[0].Organization)
+			assert.Equal(t, "e1", string(result[0].Identifier))
+		}
+	}))
+	t.Run("ok - with type", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		et := "simple"
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), &et)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, result, 1)
+	}))
+	t.Run("ok - incorrect type", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1) {
+			return
+		}
+		et := "unknown"
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), &et)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, result, 0)
+	}))
+	t.Run("ok - inactive endpoints are not returned", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.registerEndpoint1, s.registerEndpoint2) {
+			return
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, "e1", string(result[0].Identifier))
+		}
+		// They're still part of the organization
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		assert.Len(t, org.Endpoints, 2)
+	}))
+	t.Run("ok - only active and deprecated endpoints are returned", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		for _, status := range domain.EndpointStatuses() {
+			if !publish(t, eventSystem, s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+				Organization: test.OrganizationID("o1"),
+				URL:          "foo:bar",
+				EndpointType: "simple",
+				Identifier:   types.EndpointID(status),
+				Status:       status,
+			}, nil)) {
+				return
+			}
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var ids []string
+		for _, e := range result {
+			ids = append(ids, string(e.Identifier))
+		}
+		assert.ElementsMatch(t, []string{StatusActive, StatusDeprecated}, ids)
+	}))
+	t.Run("ok - endpoints outside their availability window are not returned", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		now := time.Now()
+		past := now.Add(-time.Hour)
+		future := now.Add(time.Hour)
+		windows := map[string][2]*time.Time{
+			"current": {&past, &future},
+			"started": {&past, nil},
+			"ended":   {nil, &past},
+			"future":  {&future, nil},
+		}
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		for id, window := range windows {
+			if !publish(t, eventSystem, s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+				Organization: test.OrganizationID("o1"),
+				URL:          "foo:bar",
+				EndpointType: "simple",
+				Identifier:   types.EndpointID(id),
+				Status:       StatusActive,
+				NotBefore:    window[0],
+				NotAfter:     window[1],
+			}, nil)) {
+				return
+			}
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var ids []string
+		for _, e := range result {
+			ids = append(ids, string(e.Identifier))
+		}
+		assert.ElementsMatch(t, []string{"current", "started"}, ids)
+		// They're still part of the organization
+		org, _ := db.OrganizationById(test.OrganizationID("o1"))
+		assert.Len(t, org.Endpoints, 4)
+	}))
+	t.Run("ok - no endpoints", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, result, 0)
+	}))
+	t.Run("error - organization unknown", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		result, err := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Error(t, err)
+		assert.Len(t, result, 0)
+	}))
+}
+
+func (s *conformanceSuite) testSearchOrganizations(t *testing.T) {
+	t.Run("tests", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.vendorClaim2, s.registerEndpoint1) {
+			return
+		}
+		t.Run("complete valid example", func(t *testing.T) {
+			result := db.SearchOrganizations("organization uno", SearchOptions{})
+			if assert.Len(t, result.Organizations, 1) {
+				assert.Equal(t, test.OrganizationID("o1"), result.Organizations[0].Identifier)
+				assert.Equal(t, test.VendorID("v1"), result.Organizations[0].Vendor)
+				assert.Len(t, result.Organizations[0].Endpoints, 1)
+			}
+			assert.Equal(t, 1, result.Total)
+		})
+		t.Run("partial match returns organization", func(t *testing.T) {
+			result := db.SearchOrganizations("uno", SearchOptions{}).Organizations
+			assert.Len(t, result, 1)
+		})
+		t.Run("wide match returns 2 organizations", func(t *testing.T) {
+			result := db.SearchOrganizations("organization", SearchOptions{}).Organizations
+			assert.Len(t, result, 2)
+		})
+		t.Run("searching for unknown organization returns empty list", func(t *testing.T) {
+			result := db.SearchOrganizations("organization tres", SearchOptions{})
+			assert.NotNil(t, result.Organizations)
+			assert.Empty(t, result.Organizations)
+			assert.Equal(t, 0, result.Total)
+		})
+		t.Run("case, diacritics and punctuation are ignored", func(t *testing.T) {
+			result := db.SearchOrganizations("ORGANIZATIÓN-dós", SearchOptions{}).Organizations
+			if assert.Len(t, result, 1) {
+				assert.Equal(t, "Organization Dos", result[0].Name)
+			}
+		})
+		t.Run("typo matches", func(t *testing.T) {
+			result := db.SearchOrganizations("organisation", SearchOptions{}).Organizations
+			assert.Len(t, result, 2)
+		})
+		t.Run("short words must match exactly", func(t *testing.T) {
+			result := db.SearchOrganizations("une", SearchOptions{}).Organizations
+			assert.Len(t, result, 0)
+		})
+		t.Run("characters in different order don't match", func(t *testing.T) {
+			result := db.SearchOrganizations("sod", SearchOptions{}).Organizations
+			assert.Len(t, result, 0)
+		})
+		t.Run("empty query returns all organizations", func(t *testing.T) {
+			result := db.SearchOrganizations("", SearchOptions{}).Organizations
+			assert.Len(t, result, 2)
+		})
+	}))
+	t.Run("ranking and pagination", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		claim := func(id string, name string) events.Event {
+			return s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+				VendorID:       test.VendorID("v1"),
+				OrganizationID: test.OrganizationID(id),
+				OrgName:        name,
+			}, nil)
+		}
+		if !publish(t, eventSystem, s.registerVendor1,
+			claim("pool", "Huisarts De Noordpool"),
+			claim("nord", "Nord Kliniek"),
+			claim("ziekenhuis", "Ziekenhuis Noord"),
+			claim("noorderzorg", "Noorderzorg"),
+			claim("noord", "Noord"),
+		) {
+			return
+		}
+		names := func(result SearchResult) []string {
+			var names []string
+			for _, org := range result.Organizations {
+				names = append(names, org.Name)
+			}
+			return names
+		}
+		t.Run("best matches first", func(t *testing.T) {
+			result := db.SearchOrganizations("noord", SearchOptions{})
+			assert.Equal(t, []string{"Noord", "Noorderzorg", "Ziekenhuis Noord", "Huisarts De Noordpool", "Nord Kliniek"}, names(result))
+			assert.Equal(t, 5, result.Total)
+		})
+		t.Run("equal scores ordered by name length", func(t *testing.T) {
+			result := db.SearchOrganizations("", SearchOptions{})
+			assert.Equal(t, []string{"Noord", "Noorderzorg", "Nord Kliniek", "Ziekenhuis Noord", "Huisarts De Noordpool"}, names(result))
+		})
+		t.Run("page", func(t *testing.T) {
+			result := db.SearchOrganizations("noord", SearchOptions{Offset: 1, Limit: 2})
+			assert.Equal(t, []string{"Noorderzorg", "Ziekenhuis Noord"}, names(result))
+			assert.Equal(t, 5, result.Total)
+		})
+		t.Run("offset beyond results", func(t *testing.T) {
+			result := db.SearchOrganizations("noord", SearchOptions{Offset: 5})
+			assert.Empty(t, result.Organizations)
+			assert.NotNil(t, result.Organizations)
+			assert.Equal(t, 5, result.Total)
+		})
+		t.Run("all words must match", func(t *testing.T) {
+			result := db.SearchOrganizations("noord ziekenhuis", SearchOptions{})
+			assert.Equal(t, []string{"Ziekenhuis Noord"}, names(result))
+		})
+	}))
+	t.Run("renamed organization is found by its new name", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		payload := domain.VendorClaimEvent{}
+		s.vendorClaim1.Unmarshal(&payload)
+		payload.OrgName = "Organization Renamed"
+		rename := s.event(t, domain.VendorClaim, payload, s.vendorClaim1)
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, rename) {
+			return
+		}
+		assert.Len(t, db.SearchOrganizations("renamed", SearchOptions{}).Organizations, 1)
+		assert.Empty(t, db.SearchOrganizations("uno", SearchOptions{}).Organizations)
+		_, err := db.ReverseLookup("organization uno")
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		org, err := db.ReverseLookup("organization renamed")
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.OrganizationID("o1"), org.Identifier)
+		}
+	}))
+}
+
+func (s *conformanceSuite) testSearchEndpoints(t *testing.T) {
+	registerVendor := func(t *testing.T, id string, name string, vendorDomain string) events.Event {
+		return s.event(t, domain.RegisterVendor, domain.RegisterVendorEvent{
+			Identifier: test.VendorID(id),
+			Name:       name,
+			Domain:     vendorDomain,
+		}, nil)
+	}
+	registerEndpoint := func(t *testing.T, orgID string, id string, endpointType string, properties map[string]string) events.Event {
+		return s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: test.OrganizationID(orgID),
+			URL:          "https://" + orgID + "/" + id,
+			EndpointType: endpointType,
+			Identifier:   types.EndpointID(id),
+			Status:       StatusActive,
+			Properties:   properties,
+		}, nil)
+	}
+	// ids returns the IDs of the organizations and endpoints in the result as "org/endpoint"
+	ids := func(result EndpointSearchResult) []string {
+		var ids []string
+		for _, e := range result.Endpoints {
+			ids = append(ids, e.Organization.Identifier.Value()+"/"+string(e.Endpoint.Identifier))
+		}
+		return ids
+	}
+	t.Run("tests", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o3"),
+			OrgName:        "Organization Tres",
+		}, nil)
+		if !publish(t, eventSystem,
+			registerVendor(t, "v1", "Vendor Uno", types.HealthcareDomain),
+			registerVendor(t, "v2", "Vendor Dos", types.PersonalDomain),
+			s.vendorClaim1, s.vendorClaim2, claim,
+			s.registerEndpoint1, s.registerEndpoint2,
+			registerEndpoint(t, "o1", "e3", "fhir", map[string]string{"version": "stu3"}),
+			registerEndpoint(t, "o2", "e1", "fhir", map[string]string{"version": "r4", "auth": "oauth"}),
+			registerEndpoint(t, "o3", "e1", "fhir", map[string]string{"version": "r4"}),
+		) {
+			return
+		}
+		t.Run("all active endpoints", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e1", "o1/e3", "o2/e1", "o3/e1"}, ids(result))
+			assert.Equal(t, 4, result.Total)
+		})
+		t.Run("endpoint is returned with its organization", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{EndpointType: "fhir", Vendor: test.VendorID("v2")}, SearchOptions{})
+			if !assert.Len(t, result.Endpoints, 1) {
+				return
+			}
+			assert.Equal(t, "https://o3/e1", result.Endpoints[0].Endpoint.URL)
+			assert.Equal(t, map[string]string{"version": "r4"}, result.Endpoints[0].Endpoint.Properties)
+			assert.Equal(t, "Organization Tres", result.Endpoints[0].Organization.Name)
+			assert.Equal(t, test.VendorID("v2"), result.Endpoints[0].Organization.Vendor)
+			assert.Len(t, result.Endpoints[0].Organization.Endpoints, 1)
+		})
+		t.Run("by type", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{EndpointType: "fhir"}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e3", "o2/e1", "o3/e1"}, ids(result))
+		})
+		t.Run("by status", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Status: StatusDisabled}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e2"}, ids(result))
+		})
+		t.Run("by property", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Properties: map[string]string{"version": "r4"}}, SearchOptions{})
+			assert.Equal(t, []string{"o2/e1", "o3/e1"}, ids(result))
+		})
+		t.Run("by multiple properties", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Properties: map[string]string{"version": "r4", "auth": "oauth"}}, SearchOptions{})
+			assert.Equal(t, []string{"o2/e1"}, ids(result))
+		})
+		t.Run("by vendor", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Vendor: test.VendorID("v1")}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e1", "o1/e3", "o2/e1"}, ids(result))
+		})
+		t.Run("by domain", func(t *testing.T) {
+			query := EndpointQuery{EndpointType: "fhir", Domain: types.HealthcareDomain, Properties: map[string]string{"version": "r4"}}
+			result := db.SearchEndpoints(query, SearchOptions{})
+			assert.Equal(t, []string{"o2/e1"}, ids(result))
+		})
+		t.Run("no matches", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Properties: map[string]string{"version": "r5"}}, SearchOptions{})
+			assert.NotNil(t, result.Endpoints)
+			assert.Empty(t, result.Endpoints)
+			assert.Equal(t, 0, result.Total)
+		})
+		t.Run("page", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{}, SearchOptions{Offset: 1, Limit: 2})
+			assert.Equal(t, []string{"o1/e3", "o2/e1"}, ids(result))
+			assert.Equal(t, 4, result.Total)
+		})
+		t.Run("offset beyond results", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{}, SearchOptions{Offset: 4})
+			assert.NotNil(t, result.Endpoints)
+			assert.Empty(t, result.Endpoints)
+			assert.Equal(t, 4, result.Total)
+		})
+	}))
+	t.Run("deregistered endpoints and unavailable organizations don't match", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v2"),
+			OrganizationID: test.OrganizationID("o3"),
+			OrgName:        "Organization Tres",
+		}, nil)
+		endClaim := s.event(t, domain.EndVendorClaim, domain.EndVendorClaimEvent{
+			VendorID:       test.VendorID("v1"),
+			OrganizationID: test.OrganizationID("o2"),
+			End:            time.Now(),
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1, s.vendorClaim2, claim,
+			s.registerEndpoint1, s.deregisterEndpoint1,
+			registerEndpoint(t, "o1", "e3", "simple", nil),
+			registerEndpoint(t, "o2", "e1", "simple", nil), endClaim,
+			registerEndpoint(t, "o3", "e1", "simple", nil), s.retireVendor2,
+		) {
+			return
+		}
+		result := db.SearchEndpoints(EndpointQuery{}, SearchOptions{})
+		assert.Equal(t, []string{"o1/e3"}, ids(result))
+		assert.Equal(t, 1, result.Total)
+	}))
+	t.Run("status and availability window", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		now := time.Now()
+		future := now.Add(time.Hour)
+		// Migration from e1 to e2, announced ahead of time
+		endpoint := func(id string, status string, notBefore *time.Time, notAfter *time.Time) events.Event {
+			return s.event(t, domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+				Organization: test.OrganizationID("o1"),
+				URL:          "https://o1/" + id,
+				EndpointType: "fhir",
+				Identifier:   types.EndpointID(id),
+				Status:       status,
+				NotBefore:    notBefore,
+				NotAfter:     notAfter,
+			}, nil)
+		}
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1,
+			endpoint("e1", StatusDeprecated, nil, &future),
+			endpoint("e2", StatusActive, &future, nil),
+			endpoint("e3", StatusMaintenance, nil, nil),
+		) {
+			return
+		}
+		t.Run("usable endpoints by default", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e1"}, ids(result))
+			assert.Equal(t, 1, result.Total)
+		})
+		t.Run("by status", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{Status: StatusMaintenance}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e3"}, ids(result))
+		})
+		t.Run("including unavailable", func(t *testing.T) {
+			result := db.SearchEndpoints(EndpointQuery{IncludeUnavailable: true}, SearchOptions{})
+			assert.Equal(t, []string{"o1/e1", "o1/e2"}, ids(result))
+			assert.Equal(t, 2, result.Total)
+			if assert.Len(t, result.Endpoints, 2) {
+				assert.True(t, future.Equal(*result.Endpoints[0].Endpoint.NotAfter))
+				assert.True(t, future.Equal(*result.Endpoints[1].Endpoint.NotBefore))
+			}
+		})
+	}))
+}
+
+func (s *conformanceSuite) testReverseLookup(t *testing.T) {
+	t.Run("tests", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		t.Run("finds exact match", func(t *testing.T) {
+			result, err := db.ReverseLookup("organization uno")
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.OrganizationID("o1"), result.Identifier)
+			}
+		})
+		t.Run("finds exact match, case insensitive", func(t *testing.T) {
+			result, err := db.ReverseLookup("ORGANIZATION UNO")
+			assert.NoError(t, err)
+			assert.NotNil(t, result)
+		})
+		t.Run("does not find partial match", func(t *testing.T) {
+			result, err := db.ReverseLookup("uno")
+			assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+			assert.Nil(t, result)
+		})
+	}))
+	t.Run("diacritics and punctuation", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		claim := s.event(t, domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v1"),
+			OrganizationID: test.OrganizationID("o3"),
+			OrgName:        "Zorggroep Sint-Jözef",
+		}, nil)
+		if !publish(t, eventSystem, s.registerVendor1, claim) {
+			return
+		}
+		t.Run("finds exact match, case insensitive", func(t *testing.T) {
+			result, err := db.ReverseLookup("ZORGGROEP SINT-JÖZEF")
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.OrganizationID("o3"), result.Identifier)
+			}
+		})
+		t.Run("does not fold diacritics or punctuation (unlike SearchOrganizations)", func(t *testing.T) {
+			for _, name := range []string{"zorggroep sint-jozef", "zorggroep sint jözef"} {
+				result, err := db.ReverseLookup(name)
+				assert.True(t, errors.Is(err, ErrOrganizationNotFound), name)
+				assert.Nil(t, result)
+			}
+			assert.Len(t, db.SearchOrganizations("zorggroep sint jozef", SearchOptions{}).Organizations, 1)
+		})
+	}))
+}
+
+func (s *conformanceSuite) testOrganizationById(t *testing.T) {
+	t.Run("organization is found", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		result, err := db.OrganizationById(test.OrganizationID("o1"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "Organization Uno", result.Name)
+		assert.Equal(t, test.VendorID("v1"), result.Vendor)
+	}))
+	t.Run("organization is not found", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1) {
+			return
+		}
+		result, err := db.OrganizationById(test.OrganizationID("unknown"))
+		assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+		assert.Nil(t, result)
+	}))
+}
+
+func (s *conformanceSuite) testOrganizationsByVendorID(t *testing.T) {
+	t.Run("vendor with 2 orgs", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.vendorClaim1, s.vendorClaim2) {
+			return
+		}
+		var names []string
+		for _, org := range db.OrganizationsByVendorID(test.VendorID("v1")) {
+			names = append(names, org.Name)
+		}
+		assert.ElementsMatch(t, []string{"Organization Uno", "Organization Dos"}, names)
+	}))
+	t.Run("vendor not found", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		orgs := db.OrganizationsByVendorID(test.VendorID("unknown vendor"))
+		assert.Len(t, orgs, 0)
+	}))
+	t.Run("vendor with no orgs", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		orgs := db.OrganizationsByVendorID(test.VendorID("v1"))
+		assert.Len(t, orgs, 0)
+	}))
+}
+
+func (s *conformanceSuite) testSnapshot(t *testing.T) {
+	t.Run("ok - roundtrip", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1, s.vendorClaim2, s.registerEndpoint1, s.registerEndpoint2) {
+			return
+		}
+		data, err := db.Snapshot()
+		if !assert.NoError(t, err) {
+			return
+		}
+		restored := s.factory(t)
+		if !assert.NoError(t, restored.Restore(data)) {
+			return
+		}
+		assert.NotNil(t, restored.VendorByID(test.VendorID("v1")))
+		assert.NotNil(t, restored.VendorByID(test.VendorID("v2")))
+		assert.Len(t, restored.OrganizationsByVendorID(test.VendorID("v1")), 2)
+		if orgs := restored.SearchOrganizations("Uno", SearchOptions{}).Organizations; assert.Len(t, orgs, 1) {
+			assert.Equal(t, "Organization Uno", orgs[0].Name)
+			assert.ElementsMatch(t, db.SearchOrganizations("Uno", SearchOptions{}).Organizations[0].Endpoints, orgs[0].Endpoints)
+		}
+		expected, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		actual, _ := restored.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Equal(t, expected, actual)
+		organization, _ := restored.OrganizationById(test.OrganizationID("o1"))
+		assert.Len(t, organization.Endpoints, 2)
+	}))
+	t.Run("ok - roundtrip with deregistrations", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		if !publish(t, eventSystem, s.registerVendor1, s.registerVendor2, s.vendorClaim1, s.registerEndpoint1, s.registerEndpoint2, s.deregisterEndpoint1, s.retireVendor2) {
+			return
+		}
+		data, _ := db.Snapshot()
+		restored := s.factory(t)
+		if !assert.NoError(t, restored.Restore(data)) {
+			return
+		}
+		assert.Nil(t, restored.VendorByID(test.VendorID("v2")))
+		organization, _ := restored.OrganizationById(test.OrganizationID("o1"))
+		if assert.Len(t, organization.Endpoints, 1) {
+			assert.Equal(t, "e2", string(organization.Endpoints[0].Identifier))
+		}
+	}))
+	t.Run("ok - restore replaces state", s.withDb(func(t *testing.T, eventSystem events.EventSystem, db Db) {
+		emptyState, _ := s.factory(t).Snapshot()
+		if !publish(t, eventSystem, s.registerVendor1) {
+			return
+		}
+		if !assert.NoError(t, db.Restore(emptyState)) {
+			return
+		}
+		assert.Nil(t, db.VendorByID(test.VendorID("v1")))
+	}))
+}
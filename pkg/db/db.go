@@ -30,11 +30,21 @@ import (
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	core "github.com/nuts-foundation/nuts-go-core"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
 	"github.com/nuts-foundation/nuts-registry/pkg/types"
 )
 
 // StatusActive represents the "active" status
-const StatusActive = "active"
+const StatusActive = domain.EndpointStatusActive
+
+// StatusMaintenance represents the "maintenance" status
+const StatusMaintenance = domain.EndpointStatusMaintenance
+
+// StatusDeprecated represents the "deprecated" status
+const StatusDeprecated = domain.EndpointStatusDeprecated
+
+// StatusDisabled represents the "disabled" status
+const StatusDisabled = domain.EndpointStatusDisabled
 
 // Endpoint defines component schema for Endpoint.
 type Endpoint struct {
@@ -43,9 +53,27 @@ type Endpoint struct {
 	EndpointType string            `json:"endpointType"`
 	Identifier   types.EndpointID  `json:"identifier"`
 	Status       string            `json:"status"`
+	NotBefore    *time.Time        `json:"notBefore,omitempty"`
+	NotAfter     *time.Time        `json:"notAfter,omitempty"`
 	Properties   map[string]string `json:"properties,omitempty"`
 }
 
+// IsUsable returns whether the endpoint can be used at the given moment: its status is active or deprecated and the
+// moment lies within its availability window (NotBefore and NotAfter).
+func (e Endpoint) IsUsable(moment time.Time) bool {
+	return isUsableStatus(e.Status) && e.IsAvailable(moment)
+}
+
+// IsAvailable returns whether the given moment lies within the availability window (NotBefore and NotAfter) of the endpoint.
+func (e Endpoint) IsAvailable(moment time.Time) bool {
+	return domain.RegisterEndpointEvent{NotBefore: e.NotBefore, NotAfter: e.NotAfter}.IsAvailable(moment)
+}
+
+// isUsableStatus returns whether endpoints with the given status can be used (StatusActive or StatusDeprecated).
+func isUsableStatus(status string) bool {
+	return status == StatusActive || status == StatusDeprecated
+}
+
 // Organization defines component schema for Organization.
 type Organization struct {
 	Identifier core.PartyID `json:"identifier"`
@@ -155,12 +183,106 @@ func (o Organization) CurrentPublicKey() (jwk.Key, error) {
 	}
 }
 
+// SearchOptions specifies which page of the (ranked) search results should be returned.
+type SearchOptions struct {
+	// Offset is the number of results to skip.
+	Offset int
+	// Limit is the maximum number of results to return, 0 meaning all results.
+	Limit int
+}
+
+// SearchResult holds a page of the organizations matching a search query.
+type SearchResult struct {
+	// Organizations holds the matching organizations on the requested page, ordered by relevance (best match first).
+	Organizations []Organization
+	// Total is the total number of matching organizations.
+	Total int
+}
+
+// EndpointQuery specifies the endpoints to find across all organizations (see Db.SearchEndpoints). Criteria that aren't
+// set match all endpoints.
+type EndpointQuery struct {
+	// EndpointType matches endpoints of the given type.
+	EndpointType string
+	// Status matches endpoints with the given status. When not set, only endpoints which can be used (StatusActive or
+	// StatusDeprecated) match.
+	Status string
+	// IncludeUnavailable makes endpoints match regardless of their availability window (NotBefore and NotAfter), e.g.
+	// to find endpoints which become available in the future. By default only endpoints available at the moment of the
+	// query match.
+	IncludeUnavailable bool
+	// Vendor matches endpoints of organizations claimed by the given vendor.
+	Vendor core.PartyID
+	// Domain matches endpoints of organizations claimed by a vendor operating in the given domain (e.g. healthcare).
+	Domain string
+	// Properties matches endpoints having all the given properties (name and value).
+	Properties map[string]string
+}
+
+// statuses returns the statuses an endpoint can have to match the query.
+func (q EndpointQuery) statuses() []string {
+	if q.Status == "" {
+		return []string{StatusActive, StatusDeprecated}
+	}
+	return []string{q.Status}
+}
+
+// matches returns whether the endpoint of an organization claimed by the given vendor matches the query at the given moment.
+func (q EndpointQuery) matches(v Vendor, e Endpoint, moment time.Time) bool {
+	if (q.EndpointType != "" && e.EndpointType != q.EndpointType) ||
+		!q.matchesStatus(e.Status) ||
+		(!q.IncludeUnavailable && !e.IsAvailable(moment)) ||
+		(!q.Vendor.IsZero() && v.Identifier != q.Vendor) ||
+		(q.Domain != "" && v.Domain != q.Domain) {
+		return false
+	}
+	for name, value := range q.Properties {
+		if actual, ok := e.Properties[name]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (q EndpointQuery) matchesStatus(status string) bool {
+	for _, curr := range q.statuses() {
+		if curr == status {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationEndpoint holds an endpoint and the organization it belongs to.
+type OrganizationEndpoint struct {
+	Endpoint     Endpoint
+	Organization Organization
+}
+
+// EndpointSearchResult holds a page of the endpoints matching an EndpointQuery.
+type EndpointSearchResult struct {
+	// Endpoints holds the matching endpoints on the requested page, ordered by organization ID and endpoint ID.
+	Endpoints []OrganizationEndpoint
+	// Total is the total number of matching endpoints.
+	Total int
+}
+
 type Db interface {
 	RegisterEventHandlers(fn events.EventRegistrar)
+	// FindEndpointsByOrganizationAndType returns the endpoints of the organization (optionally of the given type) which
+	// can be used at the moment (see Endpoint.IsUsable).
 	FindEndpointsByOrganizationAndType(organizationID core.PartyID, endpointType *string) ([]Endpoint, error)
-	SearchOrganizations(query string) []Organization
+	// SearchOrganizations returns the requested page of the organizations matching the query, ordered by relevance.
+	SearchOrganizations(query string, options SearchOptions) SearchResult
+	// SearchEndpoints returns the requested page of the endpoints matching the query, across all organizations.
+	// Deregistered endpoints and endpoints of organizations which aren't part of the registry (anymore) never match.
+	SearchEndpoints(query EndpointQuery, options SearchOptions) EndpointSearchResult
 	OrganizationById(id core.PartyID) (*Organization, error)
 	VendorByID(id core.PartyID) *Vendor
 	OrganizationsByVendorID(id core.PartyID) []*Organization
 	ReverseLookup(name string) (*Organization, error)
+	// Snapshot captures the state of the database, so it can be restored later using Restore.
+	Snapshot() ([]byte, error)
+	// Restore replaces the state of the database with the state captured in the given snapshot (created by Snapshot).
+	Restore(snapshot []byte) error
 }
@@ -0,0 +1,404 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Scores of the ways a query can match an organization name, see score.
+const (
+	scoreExactName  = 100
+	scoreNamePrefix = 50
+	scoreWord       = 10
+	scoreWordPrefix = 6
+	scoreSubstring  = 3
+	scoreFuzzy      = 2
+)
+
+// normalizeName normalizes an organization name (or query) for indexing and matching: diacritics are removed, letters
+// are lowercased and punctuation is replaced by spaces, so "Zorggroep St.-Jozef" matches "zorggroep st jozef".
+func normalizeName(name string) string {
+	var builder strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Diacritic, which has been separated from its letter by the decomposition
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && builder.Len() > 0 {
+				builder.WriteRune(' ')
+			}
+			space = false
+			builder.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return builder.String()
+}
+
+// tokenize splits a normalized name (or query) into its words.
+func tokenize(normalized string) []string {
+	return strings.Fields(normalized)
+}
+
+// maxEdits returns the maximum edit distance for a query word of the given length to still match a word in a name:
+// short words must match exactly, longer words may contain typos.
+func maxEdits(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// scoreWords returns how well the query word matches the given word of a name, 0 if it doesn't match.
+func scoreWords(queryWord string, word string) int {
+	switch {
+	case queryWord == word:
+		return scoreWord
+	case strings.HasPrefix(word, queryWord):
+		return scoreWordPrefix
+	case strings.Contains(word, queryWord):
+		return scoreSubstring
+	}
+	if max := maxEdits(len([]rune(queryWord))); max > 0 && editDistance(queryWord, word, max) <= max {
+		return scoreFuzzy
+	}
+	return 0
+}
+
+// editDistance returns the Levenshtein distance between a and b (in runes). Since only small distances are of interest,
+// it returns max+1 as soon as the distance is known to exceed max.
+func editDistance(a string, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// wordIndex indexes organizations by the words of their normalized name, so a search only has to score the
+// organizations which contain a word matching the query rather than every organization. The words themselves are
+// indexed by their n-grams, so only the words sharing n-grams with a query word have to be scored.
+type wordIndex struct {
+	// postings contains the organizations by the words in their name.
+	postings map[string]map[*org]bool
+	// grams contains the words in postings by their n-grams (see wordGrams).
+	grams map[string]map[string]bool
+	// all contains all indexed organizations, which all match an empty query.
+	all map[*org]bool
+}
+
+func newWordIndex() *wordIndex {
+	return &wordIndex{
+		postings: make(map[string]map[*org]bool),
+		grams:    make(map[string]map[string]bool),
+		all:      make(map[*org]bool),
+	}
+}
+
+// add indexes the organization under the words of the given (normalized) name.
+func (i *wordIndex) add(o *org, name string) {
+	i.all[o] = true
+	for _, word := range tokenize(name) {
+		if i.postings[word] == nil {
+			i.postings[word] = make(map[*org]bool)
+			for gram := range wordGrams(word) {
+				if i.grams[gram] == nil {
+					i.grams[gram] = make(map[string]bool)
+				}
+				i.grams[gram][word] = true
+			}
+		}
+		i.postings[word][o] = true
+	}
+}
+
+// remove removes the organization from the index, which must have been indexed under the given (normalized) name.
+func (i *wordIndex) remove(o *org, name string) {
+	delete(i.all, o)
+	for _, word := range tokenize(name) {
+		if posting := i.postings[word]; posting != nil {
+			delete(posting, o)
+			if len(posting) == 0 {
+				delete(i.postings, word)
+				for gram := range wordGrams(word) {
+					delete(i.grams[gram], word)
+					if len(i.grams[gram]) == 0 {
+						delete(i.grams, gram)
+					}
+				}
+			}
+		}
+	}
+}
+
+// search returns the organizations matching the given (normalized) query with their score: every word of the query
+// must match a word of the name (see scoreWords), the score being the sum of the best matches of the query words
+// plus a bonus when the name equals or starts with the query. All organizations match an empty query (with score 0).
+func (i *wordIndex) search(query string) map[*org]int {
+	result := make(map[*org]int)
+	queryWords := tokenize(query)
+	if len(queryWords) == 0 {
+		for o := range i.all {
+			result[o] = 0
+		}
+		return result
+	}
+	// Score the matching words of every query word, starting with the query word matching the fewest organizations
+	matches := make([]map[string]int, len(queryWords))
+	sizes := make([]int, len(queryWords))
+	for n, queryWord := range queryWords {
+		matches[n] = make(map[string]int)
+		for word := range i.candidates(queryWord) {
+			if score := scoreWords(queryWord, word); score > 0 {
+				matches[n][word] = score
+				sizes[n] += len(i.postings[word])
+			}
+		}
+	}
+	sort.Sort(bySize{matches: matches, sizes: sizes})
+	for word, score := range matches[0] {
+		for o := range i.postings[word] {
+			if score > result[o] {
+				result[o] = score
+			}
+		}
+	}
+	// Only organizations matching every query word remain
+	for _, words := range matches[1:] {
+		next := make(map[*org]int, len(result))
+		for o, total := range result {
+			best := 0
+			for _, word := range tokenize(o.normalizedName) {
+				if score := words[word]; score > best {
+					best = score
+				}
+			}
+			if best > 0 {
+				next[o] = total + best
+			}
+		}
+		result = next
+	}
+	for o := range result {
+		result[o] += scoreNameBonus(query, o.normalizedName)
+	}
+	return result
+}
+
+// candidates returns the indexed words which might match the given query word (see scoreWords): the words containing
+// it and, when typos are allowed, the words sharing enough trigrams with it to be within the allowed edit distance.
+func (i *wordIndex) candidates(queryWord string) map[string]bool {
+	runes := []rune(queryWord)
+	result := make(map[string]bool)
+	if len(runes) <= 3 {
+		// Words containing the query word have it as one of their n-grams
+		for word := range i.grams[queryWord] {
+			result[word] = true
+		}
+	} else {
+		// Words containing the query word contain all its trigrams
+		var smallest map[string]bool
+		trigrams := make([]string, 0, len(runes)-2)
+		for n := 0; n+3 <= len(runes); n++ {
+			trigram := string(runes[n : n+3])
+			trigrams = append(trigrams, trigram)
+			if smallest == nil || len(i.grams[trigram]) < len(smallest) {
+				smallest = i.grams[trigram]
+			}
+		}
+	words:
+		for word := range smallest {
+			for _, trigram := range trigrams {
+				if !i.grams[trigram][word] {
+					continue words
+				}
+			}
+			result[word] = true
+		}
+	}
+	max := maxEdits(len(runes))
+	if max == 0 {
+		return result
+	}
+	// Every edit changes at most 3 (padded) trigrams, so words within the allowed edit distance share at least the
+	// remaining trigrams with the query word.
+	queryTrigrams := paddedTrigrams(queryWord)
+	minShared := len(queryTrigrams) - 3*max
+	if minShared < 1 {
+		// The query word is too repetitive to filter on trigrams
+		for word := range i.postings {
+			result[word] = true
+		}
+		return result
+	}
+	shared := make(map[string]int)
+	for trigram := range queryTrigrams {
+		for word := range i.grams[trigram] {
+			shared[word]++
+		}
+	}
+	for word, count := range shared {
+		if count >= minShared {
+			result[word] = true
+		}
+	}
+	return result
+}
+
+// wordGrams returns the n-grams a word is indexed by: its unigrams and bigrams, so words containing a short query word
+// can be found, and its padded trigrams (see paddedTrigrams), which include the trigrams of every longer query word it
+// contains.
+func wordGrams(word string) map[string]bool {
+	runes := []rune(word)
+	result := paddedTrigrams(word)
+	for n := range runes {
+		result[string(runes[n])] = true
+		if n+2 <= len(runes) {
+			result[string(runes[n:n+2])] = true
+		}
+	}
+	return result
+}
+
+// paddedTrigrams returns the distinct trigrams of the word padded with 2 spaces on both sides, so the start and end of
+// the word are represented by trigrams as well. Since words don't contain spaces, these don't clash with other n-grams.
+func paddedTrigrams(word string) map[string]bool {
+	runes := []rune("  " + word + "  ")
+	result := make(map[string]bool, len(runes)-2)
+	for n := 0; n+3 <= len(runes); n++ {
+		result[string(runes[n:n+3])] = true
+	}
+	return result
+}
+
+// bySize sorts the matching words of query words by the number of organizations containing them.
+type bySize struct {
+	matches []map[string]int
+	sizes   []int
+}
+
+func (s bySize) Len() int {
+	return len(s.sizes)
+}
+
+func (s bySize) Less(i, j int) bool {
+	return s.sizes[i] < s.sizes[j]
+}
+
+func (s bySize) Swap(i, j int) {
+	s.matches[i], s.matches[j] = s.matches[j], s.matches[i]
+	s.sizes[i], s.sizes[j] = s.sizes[j], s.sizes[i]
+}
+
+// scoreNameBonus returns the bonus for a name which equals or starts with the query.
+func scoreNameBonus(query string, name string) int {
+	if name == query {
+		return scoreExactName
+	} else if strings.HasPrefix(name, query) {
+		return scoreNamePrefix
+	}
+	return 0
+}
+
+// rank orders the scored organizations by relevance: highest score first, then shortest name, then alphabetically
+// (by name, then ID), so the order is stable.
+func rank(scores map[*org]int) []*org {
+	result := make([]*org, 0, len(scores))
+	for o := range scores {
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if len(a.normalizedName) != len(b.normalizedName) {
+			return len(a.normalizedName) < len(b.normalizedName)
+		}
+		if a.normalizedName != b.normalizedName {
+			return a.normalizedName < b.normalizedName
+		}
+		return a.OrganizationID.String() < b.OrganizationID.String()
+	})
+	return result
+}
+
+// paginate returns the page of the given (ranked) organizations specified by the options.
+func paginate(ranked []*org, options SearchOptions) []*org {
+	from, to := pageBounds(len(ranked), options)
+	return ranked[from:to]
+}
+
+// pageBounds returns the bounds (from inclusive, to exclusive) of the page specified by the options, in a list of
+// results of the given length.
+func pageBounds(length int, options SearchOptions) (int, int) {
+	if options.Offset < 0 || options.Offset >= length {
+		return 0, 0
+	}
+	if options.Limit > 0 && options.Limit < length-options.Offset {
+		return options.Offset, options.Offset + options.Limit
+	}
+	return options.Offset, length
+}
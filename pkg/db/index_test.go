@@ -0,0 +1,135 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_normalizeName(t *testing.T) {
+	assert.Equal(t, "organization uno", normalizeName("Organization UNO"))
+	assert.Equal(t, "zorggroep st jozef", normalizeName(" Zorggroep St.-Jozef "))
+	assert.Equal(t, "ziekenhuis l ancre", normalizeName("Ziekenhuis L'Ancré"))
+	assert.Equal(t, "huisartsen 2 go", normalizeName("Huisartsen (2) & Go"))
+	assert.Equal(t, "", normalizeName("--"))
+}
+
+func Test_scoreWords(t *testing.T) {
+	assert.Equal(t, scoreWord, scoreWords("noord", "noord"))
+	assert.Equal(t, scoreWordPrefix, scoreWords("noord", "noordpool"))
+	assert.Equal(t, scoreSubstring, scoreWords("zorg", "noorderzorg"))
+	assert.Equal(t, scoreFuzzy, scoreWords("noord", "nord"))
+	assert.Equal(t, scoreFuzzy, scoreWords("ziekenhuis", "zeikenhuis"))
+	assert.Equal(t, 0, scoreWords("uno", "una"))
+	assert.Equal(t, 0, scoreWords("noord", "zuid"))
+}
+
+func Test_editDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("name", "name", 2))
+	assert.Equal(t, 1, editDistance("name", "nme", 2))
+	assert.Equal(t, 1, editDistance("name", "nama", 2))
+	assert.Equal(t, 2, editDistance("name", "nmea", 2))
+	assert.Equal(t, 1, editDistance("café", "cafe", 2))
+	t.Run("exceeding max returns max + 1", func(t *testing.T) {
+		assert.Equal(t, 2, editDistance("name", "other", 1))
+		assert.Equal(t, 2, editDistance("a", "abc", 1))
+	})
+}
+
+func Test_wordIndex(t *testing.T) {
+	o1 := &org{normalizedName: "organization uno"}
+	o2 := &org{normalizedName: "organization dos"}
+	index := newWordIndex()
+	index.add(o1, o1.normalizedName)
+	index.add(o2, o2.normalizedName)
+
+	t.Run("exact name scores highest", func(t *testing.T) {
+		scores := index.search("organization uno")
+		assert.Len(t, scores, 1)
+		assert.Equal(t, 2*scoreWord+scoreExactName, scores[o1])
+	})
+	t.Run("every query word must match", func(t *testing.T) {
+		assert.Empty(t, index.search("organization tres"))
+	})
+	t.Run("all organizations match empty query", func(t *testing.T) {
+		assert.Equal(t, map[*org]int{o1: 0, o2: 0}, index.search(""))
+	})
+	t.Run("query words match through n-grams", func(t *testing.T) {
+		assert.Equal(t, map[*org]int{o1: scoreWordPrefix}, index.search("u"))
+		assert.Equal(t, map[*org]int{o2: scoreWordPrefix}, index.search("do"))
+		assert.Equal(t, map[*org]int{o1: scoreSubstring, o2: scoreSubstring}, index.search("ganiz"))
+		assert.Equal(t, map[*org]int{o1: scoreFuzzy + scoreSubstring}, index.search("organisation no"))
+		assert.Equal(t, map[*org]int{o1: scoreFuzzy, o2: scoreFuzzy}, index.search("orgxnizxtion"))
+		assert.Empty(t, index.search("orgxnizxtixn"))
+	})
+	t.Run("candidates", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"organization": true}, index.candidates("organisation"))
+		assert.Equal(t, map[string]bool{"uno": true}, index.candidates("un"))
+	})
+	t.Run("removed organization doesn't match", func(t *testing.T) {
+		index.remove(o1, o1.normalizedName)
+		assert.Equal(t, map[*org]int{o2: scoreWord + scoreNamePrefix}, index.search("organization"))
+		assert.NotContains(t, index.postings, "uno")
+		assert.NotContains(t, index.grams, "un")
+		assert.Len(t, index.search(""), 1)
+	})
+}
+
+func Test_wordIndex_candidates(t *testing.T) {
+	words := []string{"a", "aa", "aaaaaaaa", "zorg", "zorginstelling", "zorginstellingen", "ziekenhuis", "ziekenhuizen", "huisarts", "huisartsen", "apotheek", "apotheken", "st", "jozef", "josef", "50", "500", "1500"}
+	index := newWordIndex()
+	for n, word := range words {
+		index.add(&org{normalizedName: word}, word)
+		assert.Len(t, index.all, n+1)
+	}
+	queries := append([]string{"aaaaaaab", "zrog", "zorgisntelling", "ziekenhuisen", "huisart", "apoteek", "joze", "jozfe", "5", "0", "15", "150"}, words...)
+	for _, query := range queries {
+		candidates := index.candidates(query)
+		for _, word := range words {
+			if scoreWords(query, word) > 0 {
+				assert.True(t, candidates[word], "query %s should match %s", query, word)
+			}
+		}
+	}
+}
+
+func Test_paginate(t *testing.T) {
+	ranked := []*org{{}, {}, {}}
+	assert.Len(t, paginate(ranked, SearchOptions{}), 3)
+	assert.Equal(t, ranked[1:2], paginate(ranked, SearchOptions{Offset: 1, Limit: 1}))
+	assert.Equal(t, ranked[2:], paginate(ranked, SearchOptions{Offset: 2, Limit: 5}))
+	assert.Empty(t, paginate(ranked, SearchOptions{Offset: 3}))
+	assert.Empty(t, paginate(ranked, SearchOptions{Offset: -1}))
+}
+
+func Test_pageBounds(t *testing.T) {
+	from, to := pageBounds(3, SearchOptions{})
+	assert.Equal(t, []int{0, 3}, []int{from, to})
+	from, to = pageBounds(3, SearchOptions{Offset: 1, Limit: 1})
+	assert.Equal(t, []int{1, 2}, []int{from, to})
+	from, to = pageBounds(3, SearchOptions{Offset: 2, Limit: 5})
+	assert.Equal(t, []int{2, 3}, []int{from, to})
+	from, to = pageBounds(3, SearchOptions{Offset: 3})
+	assert.Equal(t, from, to)
+	from, to = pageBounds(0, SearchOptions{Limit: 1})
+	assert.Equal(t, from, to)
+}
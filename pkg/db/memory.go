@@ -23,7 +23,10 @@ import (
 	"crypto/rsa"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	core "github.com/nuts-foundation/nuts-go-core"
@@ -32,22 +35,55 @@ import (
 	errors2 "github.com/pkg/errors"
 )
 
+// MemoryDb is an in-memory Db which is built by applying events. It's safe for concurrent use: event handlers acquire
+// a write lock while queries acquire a read lock, so queries always see the state between events.
 type MemoryDb struct {
+	// vendors holds the vendors by ID, each holding the organizations it claimed by ID.
 	vendors map[string]*vendor
+	// orgs indexes the organizations by ID. Since an organization can be claimed by another vendor after its claim
+	// ended, there can be multiple entries per ID (in order of claim).
+	orgs map[string][]*org
+	// names indexes the organizations by their lowercased name, for ReverseLookup (which is case-insensitive only).
+	names map[string][]*org
+	// words indexes the organizations by the words of their normalized name, for SearchOrganizations.
+	words *wordIndex
+	mux   *sync.RWMutex
+	// now returns the moment at which claims are evaluated (e.g. whether they've ended), which is the current time
+	// unless the database reflects the state at a specific moment (see NewAsOf).
+	now func() time.Time
 }
 
 type vendor struct {
 	domain.RegisterVendorEvent
 	orgs map[string]*org
+	// retired indicates the vendor has been retired (RetireVendorEvent), which hides it and its organizations.
+	retired bool
 }
 
 type org struct {
 	domain.VendorClaimEvent
 	endpoints map[string]*endpoint
+	// vendor is the vendor which claimed the organization.
+	vendor *vendor
+	// normalizedName is the organization's name as it's indexed (see normalizeName).
+	normalizedName string
 }
 
 type endpoint struct {
 	domain.RegisterEndpointEvent
+	// deregistered indicates the endpoint has been deregistered (DeregisterEndpointEvent), which hides it.
+	deregistered bool
+}
+
+// isActive returns whether the vendor's claim on the organization is active (hasn't ended) at the given moment.
+func (o org) isActive(moment time.Time) bool {
+	return o.End == nil || moment.Before(*o.End)
+}
+
+// isAvailable returns whether the organization is part of the registry at the given moment: its claim is active and
+// its vendor hasn't been retired.
+func (o org) isAvailable(moment time.Time) bool {
+	return !o.vendor.retired && o.isActive(moment)
 }
 
 func (o org) toDb() Organization {
@@ -91,6 +127,8 @@ func (e endpoint) toDb() Endpoint {
 		EndpointType: e.EndpointType,
 		Identifier:   e.Identifier,
 		Status:       e.Status,
+		NotBefore:    e.NotBefore,
+		NotAfter:     e.NotAfter,
 		Properties:   e.Properties,
 	}
 }
@@ -98,7 +136,9 @@ func (e endpoint) toDb() Endpoint {
 func (o org) toDbEndpoints() []Endpoint {
 	r := make([]Endpoint, 0, len(o.endpoints))
 	for _, e := range o.endpoints {
-		r = append(r, e.toDb())
+		if !e.deregistered {
+			r = append(r, e.toDb())
+		}
 	}
 	return r
 }
@@ -157,6 +197,7 @@ func assertSameOrganization(expectedId core.PartyID, event events.Event) error {
 
 // RegisterEventHandlers registers event handlers on this database
 func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
+	fn = db.lockingRegistrar(fn)
 	fn(domain.RegisterVendor, func(event events.Event, lookup events.EventLookup) error {
 		// Unmarshal
 		payload := domain.RegisterVendorEvent{}
@@ -177,7 +218,9 @@ func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
 				return fmt.Errorf("vendor already registered (id = %s)", payload.Identifier)
 			}
 			// Update event
-
+			if db.vendors[idAsString].retired {
+				return fmt.Errorf("vendor has been retired (id = %s)", payload.Identifier)
+			}
 			db.vendors[idAsString].RegisterVendorEvent = payload
 		} else {
 			// Registration event
@@ -195,9 +238,13 @@ func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
 			return err
 		}
 		// Validate
-		if db.vendors[payload.VendorID.String()] == nil {
+		v := db.vendors[payload.VendorID.String()]
+		if v == nil {
 			return fmt.Errorf("vendor is not registered (id = %s)", payload.VendorID)
 		}
+		if v.retired {
+			return fmt.Errorf("vendor has been retired (id = %s)", payload.VendorID)
+		}
 		if !event.PreviousRef().IsZero() {
 			if err := assertSameVendor(payload.VendorID, lookup.Get(event.PreviousRef())); err != nil {
 				return errors2.Wrap(err, "can't change organization's vendor")
@@ -207,18 +254,27 @@ func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
 			}
 		}
 		// Process
-		if db.lookupOrg(payload.OrganizationID) != nil {
-			if event.PreviousRef() == nil {
-				return fmt.Errorf("organization already registered (id = %s)", payload.OrganizationID)
+		existing := v.orgs[payload.OrganizationID.String()]
+		if event.PreviousRef() == nil && (existing != nil || db.lookupActiveOrg(payload.OrganizationID, event.IssuedAt()) != nil) {
+			return fmt.Errorf("organization already registered (id = %s)", payload.OrganizationID)
+		}
+		if existing != nil {
+			// Update event, which can't undo or postpone the end of the claim
+			if existing.End != nil && (payload.End == nil || existing.End.Before(*payload.End)) {
+				payload.End = existing.End
 			}
-			// Update event
-			db.vendors[payload.VendorID.String()].orgs[payload.OrganizationID.String()].VendorClaimEvent = payload
+			db.unindexName(existing)
+			existing.VendorClaimEvent = payload
+			db.indexName(existing)
 		} else {
 			// Registration event
-			db.vendors[payload.VendorID.String()].orgs[payload.OrganizationID.String()] = &org{
+			o := &org{
 				VendorClaimEvent: payload,
 				endpoints:        make(map[string]*endpoint),
+				vendor:           v,
 			}
+			v.orgs[payload.OrganizationID.String()] = o
+			db.indexOrg(o)
 		}
 		return nil
 	})
@@ -229,11 +285,18 @@ func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
 			return err
 		}
 		// Validate
-		o := db.lookupOrg(payload.Organization)
+		if err := payload.Validate(); err != nil {
+			return err
+		}
+		o := db.lookupOrg(payload.Organization, event.IssuedAt())
 		if o == nil {
 			return fmt.Errorf("organization not registered (id = %s)", payload.Organization)
 		}
-		if o.endpoints[string(payload.Identifier)] != nil {
+		existing := o.endpoints[string(payload.Identifier)]
+		if existing != nil {
+			if existing.deregistered {
+				return fmt.Errorf("endpoint has been deregistered (id = %s)", payload.Identifier)
+			}
 			if event.PreviousRef() == nil {
 				return fmt.Errorf("endpoint already registered for this organization (id = %s)", payload.Identifier)
 			}
@@ -249,28 +312,183 @@ func (db *MemoryDb) RegisterEventHandlers(fn events.EventRegistrar) {
 		}
 		return nil
 	})
+	fn(domain.DeregisterEndpoint, func(event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.DeregisterEndpointEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		o := db.lookupOrg(payload.Organization, event.IssuedAt())
+		if o == nil {
+			return fmt.Errorf("organization not registered (id = %s)", payload.Organization)
+		}
+		e := o.endpoints[string(payload.Identifier)]
+		if e == nil {
+			return fmt.Errorf("endpoint not registered for this organization (id = %s)", payload.Identifier)
+		}
+		// Process
+		e.deregistered = true
+		return nil
+	})
+	fn(domain.EndVendorClaim, func(event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.EndVendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		v := db.vendors[payload.VendorID.String()]
+		if v == nil {
+			return fmt.Errorf("vendor is not registered (id = %s)", payload.VendorID)
+		}
+		o := v.orgs[payload.OrganizationID.String()]
+		if o == nil {
+			return fmt.Errorf("organization not claimed by vendor (id = %s)", payload.OrganizationID)
+		}
+		// Process: when the claim was already ended, the earliest end wins
+		if o.End == nil || payload.End.Before(*o.End) {
+			end := payload.End
+			o.End = &end
+		}
+		return nil
+	})
+	fn(domain.RetireVendor, func(event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.RetireVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		v := db.vendors[payload.Identifier.String()]
+		if v == nil {
+			return fmt.Errorf("vendor is not registered (id = %s)", payload.Identifier)
+		}
+		// Process
+		v.retired = true
+		return nil
+	})
 }
 
-func (db *MemoryDb) lookupOrg(orgID core.PartyID) *org {
-	for _, vendor := range db.vendors {
-		o := vendor.orgs[orgID.String()]
-		if o != nil {
+// lockingRegistrar wraps the given registrar so the registered event handlers hold the write lock while they're executed.
+func (db *MemoryDb) lockingRegistrar(fn events.EventRegistrar) events.EventRegistrar {
+	return func(eventType events.EventType, handler events.EventHandler) {
+		fn(eventType, func(event events.Event, lookup events.EventLookup) error {
+			db.mux.Lock()
+			defer db.mux.Unlock()
+			return handler(event, lookup)
+		})
+	}
+}
+
+// lookupOrg looks up the organization with the given ID. When multiple vendors claimed the organization (because
+// earlier claims ended), the claim active at the given moment is preferred.
+func (db *MemoryDb) lookupOrg(orgID core.PartyID, moment time.Time) *org {
+	if o := db.lookupActiveOrg(orgID, moment); o != nil {
+		return o
+	}
+	claims := db.orgs[orgID.String()]
+	if len(claims) == 0 {
+		return nil
+	}
+	return claims[len(claims)-1]
+}
+
+// lookupActiveOrg looks up the organization with the given ID, if it's claimed at the given moment by a vendor which
+// isn't retired. Event handlers must pass the moment the event was issued so applying an event doesn't depend on when
+// it's applied, queries pass the current time.
+func (db *MemoryDb) lookupActiveOrg(orgID core.PartyID, moment time.Time) *org {
+	for _, o := range db.orgs[orgID.String()] {
+		if o.isAvailable(moment) {
 			return o
 		}
 	}
 	return nil
 }
 
+// indexOrg adds the (newly claimed) organization to the indexes.
+func (db *MemoryDb) indexOrg(o *org) {
+	id := o.OrganizationID.String()
+	db.orgs[id] = append(db.orgs[id], o)
+	db.indexName(o)
+}
+
+// indexName adds the organization to the name indexes, under its current name.
+func (db *MemoryDb) indexName(o *org) {
+	o.normalizedName = normalizeName(o.OrgName)
+	name := strings.ToLower(o.OrgName)
+	db.names[name] = append(db.names[name], o)
+	db.words.add(o, o.normalizedName)
+}
+
+// unindexName removes the organization from the name indexes, e.g. because its name is about to change.
+func (db *MemoryDb) unindexName(o *org) {
+	name := strings.ToLower(o.OrgName)
+	entries := db.names[name]
+	for i, entry := range entries {
+		if entry == o {
+			entries = append(entries[:i:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(db.names, name)
+	} else {
+		db.names[name] = entries
+	}
+	db.words.remove(o, o.normalizedName)
+}
+
+// reindex rebuilds the organization indexes from the vendors, e.g. after restoring a snapshot.
+func (db *MemoryDb) reindex() {
+	db.orgs = make(map[string][]*org)
+	db.names = make(map[string][]*org)
+	db.words = newWordIndex()
+	for _, v := range db.vendors {
+		for _, o := range v.orgs {
+			o.vendor = v
+			db.indexOrg(o)
+		}
+	}
+}
+
 func New() *MemoryDb {
 	return &MemoryDb{
-		make(map[string]*vendor),
+		vendors: make(map[string]*vendor),
+		orgs:    make(map[string][]*org),
+		names:   make(map[string][]*org),
+		words:   newWordIndex(),
+		mux:     &sync.RWMutex{},
+		now:     time.Now,
 	}
 }
 
+// NewAsOf creates a MemoryDb which reflects the state at the given moment, when filled with the events issued at or
+// before that moment (see events.EventSystem.Replay): claims are evaluated at that moment instead of the current time.
+func NewAsOf(moment time.Time) *MemoryDb {
+	result := New()
+	result.now = func() time.Time {
+		return moment
+	}
+	return result
+}
+
+// AsOf returns a view on the database which evaluates claims at the given moment, like NewAsOf. The view shares its
+// state with the database, so it reflects events applied to either of them.
+func (db *MemoryDb) AsOf(moment time.Time) *MemoryDb {
+	result := *db
+	result.now = func() time.Time {
+		return moment
+	}
+	return &result
+}
+
 // VendorByID looks up the vendor by the given ID.
 func (db *MemoryDb) VendorByID(id core.PartyID) *Vendor {
+	db.mux.RLock()
+	defer db.mux.RUnlock()
 	idAsString := id.String()
-	if db.vendors[idAsString] == nil {
+	if db.vendors[idAsString] == nil || db.vendors[idAsString].retired {
 		return nil
 	}
 	result := db.vendors[idAsString].toDb()
@@ -278,12 +496,18 @@ func (db *MemoryDb) VendorByID(id core.PartyID) *Vendor {
 }
 
 func (db *MemoryDb) OrganizationsByVendorID(id core.PartyID) []*Organization {
+	db.mux.RLock()
+	defer db.mux.RUnlock()
 	vendor := db.vendors[id.String()]
-	if vendor == nil {
+	if vendor == nil || vendor.retired {
 		return nil
 	}
+	now := db.now()
 	orgs := make([]*Organization, 0, len(vendor.orgs))
 	for _, org := range vendor.orgs {
+		if !org.isActive(now) {
+			continue
+		}
 		o := org.toDb()
 		orgs = append(orgs, &o)
 	}
@@ -291,13 +515,16 @@ func (db *MemoryDb) OrganizationsByVendorID(id core.PartyID) []*Organization {
 }
 
 func (db *MemoryDb) FindEndpointsByOrganizationAndType(organizationIdentifier core.PartyID, endpointType *string) ([]Endpoint, error) {
-	o := db.lookupOrg(organizationIdentifier)
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	now := db.now()
+	o := db.lookupActiveOrg(organizationIdentifier, now)
 	if o == nil {
 		return nil, fmt.Errorf("organization with identifier [%s] does not exist", organizationIdentifier)
 	}
 	var endpoints []Endpoint
 	for _, e := range o.endpoints {
-		if e.Status == StatusActive {
+		if !e.deregistered && e.toDb().IsUsable(now) {
 			if endpointType == nil || *endpointType == e.EndpointType {
 				endpoints = append(endpoints, e.toDb())
 			}
@@ -306,62 +533,90 @@ func (db *MemoryDb) FindEndpointsByOrganizationAndType(organizationIdentifier co
 	return endpoints, nil
 }
 
-func (db *MemoryDb) SearchOrganizations(query string) []Organization {
+// SearchOrganizations returns the organizations matching the query, ordered by relevance: organizations whose name
+// equals or starts with the query rank highest, followed by names containing the query's words (whole words first,
+// then words starting with or containing them, then words differing by a typo). Names and query are normalized (see
+// normalizeName) so the search is insensitive to case, diacritics and punctuation.
+func (db *MemoryDb) SearchOrganizations(query string, options SearchOptions) SearchResult {
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	now := db.now()
+	scores := db.words.search(normalizeName(query))
+	for o := range scores {
+		if !o.isAvailable(now) {
+			delete(scores, o)
+		}
+	}
+	ranked := rank(scores)
+	result := SearchResult{Total: len(ranked), Organizations: []Organization{}}
+	for _, o := range paginate(ranked, options) {
+		result.Organizations = append(result.Organizations, o.toDb())
+	}
+	return result
+}
 
-	// all organization names to lowercase and to slice
-	// query to slice
-	// compare slices: if both match: pop both, if not pop organization slice
-	// continue until one is empty
-	// if query is empty, match is found
-	var matches []Organization
-	for _, v := range db.vendors {
-		for _, o := range v.orgs {
-			if searchRecursive(strings.Split(strings.ToLower(query), ""), strings.Split(strings.ToLower(o.OrgName), "")) {
-				matches = append(matches, o.toDb())
+// SearchEndpoints returns the requested page of the endpoints matching the query, across all organizations. They're
+// ordered by organization ID and endpoint ID.
+func (db *MemoryDb) SearchEndpoints(query EndpointQuery, options SearchOptions) EndpointSearchResult {
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	now := db.now()
+	type match struct {
+		org      *org
+		endpoint *endpoint
+	}
+	var matches []match
+	for _, orgs := range db.orgs {
+		for _, o := range orgs {
+			if !o.isAvailable(now) {
+				continue
+			}
+			v := o.vendor.toDb()
+			for _, e := range o.endpoints {
+				if !e.deregistered && query.matches(v, e.toDb(), now) {
+					matches = append(matches, match{org: o, endpoint: e})
+				}
 			}
 		}
 	}
-	return matches
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.org.OrganizationID != b.org.OrganizationID {
+			return a.org.OrganizationID.String() < b.org.OrganizationID.String()
+		}
+		return a.endpoint.Identifier < b.endpoint.Identifier
+	})
+	result := EndpointSearchResult{Total: len(matches), Endpoints: []OrganizationEndpoint{}}
+	from, to := pageBounds(len(matches), options)
+	for _, m := range matches[from:to] {
+		result.Endpoints = append(result.Endpoints, OrganizationEndpoint{Endpoint: m.endpoint.toDb(), Organization: m.org.toDb()})
+	}
+	return result
 }
 
 // ErrOrganizationNotFound is returned when an organization is not found
 var ErrOrganizationNotFound = errors.New("organization not found")
 
 func (db *MemoryDb) ReverseLookup(name string) (*Organization, error) {
-	for _, v := range db.vendors {
-		for _, o := range v.orgs {
-			if strings.ToLower(name) == strings.ToLower(o.OrgName) {
-				r := o.toDb()
-				return &r, nil
-			}
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	now := db.now()
+	for _, o := range db.names[strings.ToLower(name)] {
+		if o.isAvailable(now) {
+			r := o.toDb()
+			return &r, nil
 		}
 	}
 	return nil, fmt.Errorf("reverse lookup failed for %s: %w", name, ErrOrganizationNotFound)
 }
 
 func (db *MemoryDb) OrganizationById(id core.PartyID) (*Organization, error) {
-	org := db.lookupOrg(id)
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	org := db.lookupActiveOrg(id, db.now())
 	if org == nil {
 		return nil, fmt.Errorf("%s: %w", id, ErrOrganizationNotFound)
 	}
 	r := org.toDb()
 	return &r, nil
 }
-
-func searchRecursive(query []string, orgName []string) bool {
-	// search string empty, return match
-	if len(query) == 0 {
-		return true
-	}
-
-	// no more organizations to search for
-	if len(orgName) == 0 {
-		return false
-	}
-
-	if query[0] == orgName[0] {
-		return searchRecursive(query[1:], orgName[1:])
-	} else {
-		return searchRecursive(query, orgName[1:])
-	}
-}
@@ -0,0 +1,118 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"encoding/json"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	errors2 "github.com/pkg/errors"
+)
+
+// memoryDbSnapshot is the state of the MemoryDb as captured in a snapshot. SQLiteDb uses the same format, so snapshots
+// can be restored regardless of the database in use.
+type memoryDbSnapshot struct {
+	Vendors []vendorSnapshot `json:"vendors"`
+}
+
+type vendorSnapshot struct {
+	Vendor        domain.RegisterVendorEvent `json:"vendor"`
+	Organizations []organizationSnapshot     `json:"organizations"`
+	Retired       bool                       `json:"retired,omitempty"`
+}
+
+type organizationSnapshot struct {
+	Organization domain.VendorClaimEvent        `json:"organization"`
+	Endpoints    []domain.RegisterEndpointEvent `json:"endpoints"`
+	// DeregisteredEndpoints contains the IDs of the endpoints (in Endpoints) which have been deregistered.
+	DeregisteredEndpoints []types.EndpointID `json:"deregisteredEndpoints,omitempty"`
+}
+
+// Snapshot captures the state of the database, so it can be restored later using Restore.
+func (db *MemoryDb) Snapshot() ([]byte, error) {
+	db.mux.RLock()
+	defer db.mux.RUnlock()
+	snapshot := memoryDbSnapshot{Vendors: make([]vendorSnapshot, 0, len(db.vendors))}
+	for _, v := range db.vendors {
+		vs := vendorSnapshot{
+			Vendor:        v.RegisterVendorEvent,
+			Organizations: make([]organizationSnapshot, 0, len(v.orgs)),
+			Retired:       v.retired,
+		}
+		for _, o := range v.orgs {
+			orgSnapshot := organizationSnapshot{
+				Organization: o.VendorClaimEvent,
+				Endpoints:    make([]domain.RegisterEndpointEvent, 0, len(o.endpoints)),
+			}
+			for _, e := range o.endpoints {
+				orgSnapshot.Endpoints = append(orgSnapshot.Endpoints, e.RegisterEndpointEvent)
+				if e.deregistered {
+					orgSnapshot.DeregisteredEndpoints = append(orgSnapshot.DeregisteredEndpoints, e.Identifier)
+				}
+			}
+			vs.Organizations = append(vs.Organizations, orgSnapshot)
+		}
+		snapshot.Vendors = append(snapshot.Vendors, vs)
+	}
+	return json.Marshal(snapshot)
+}
+
+// Restore replaces the state of the database with the state captured in the given snapshot (created by Snapshot).
+func (db *MemoryDb) Restore(data []byte) error {
+	snapshot := memoryDbSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors2.Wrap(err, "unable to parse snapshot")
+	}
+	vendors := make(map[string]*vendor, len(snapshot.Vendors))
+	for _, vs := range snapshot.Vendors {
+		v := &vendor{
+			RegisterVendorEvent: vs.Vendor,
+			orgs:                make(map[string]*org, len(vs.Organizations)),
+			retired:             vs.Retired,
+		}
+		for _, orgSnapshot := range vs.Organizations {
+			o := &org{
+				VendorClaimEvent: orgSnapshot.Organization,
+				endpoints:        make(map[string]*endpoint, len(orgSnapshot.Endpoints)),
+			}
+			for _, e := range orgSnapshot.Endpoints {
+				o.endpoints[string(e.Identifier)] = &endpoint{RegisterEndpointEvent: e}
+			}
+			for _, id := range orgSnapshot.DeregisteredEndpoints {
+				if e := o.endpoints[string(id)]; e != nil {
+					e.deregistered = true
+				}
+			}
+			v.orgs[orgSnapshot.Organization.OrganizationID.String()] = o
+		}
+		vendors[vs.Vendor.Identifier.String()] = v
+	}
+	restored := New()
+	restored.vendors = vendors
+	restored.reindex()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.vendors = restored.vendors
+	db.orgs = restored.orgs
+	db.names = restored.names
+	db.words = restored.words
+	return nil
+}
@@ -0,0 +1,1216 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// Registers the SQLite driver
+	_ "github.com/mattn/go-sqlite3"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	errors2 "github.com/pkg/errors"
+)
+
+// sqliteSchema (re)creates the tables of the SQLiteDb. Since an organization can be claimed by another vendor after its
+// claim ended, organizations (and their keys and endpoints) are identified by the claiming vendor and their ID. Keys
+// without organization are the vendor's keys. The words of the organizations' normalized names are indexed in
+// organization_words for SearchOrganizations, like wordIndex.
+const sqliteSchema = `
+DROP TABLE IF EXISTS organization_words;
+DROP TABLE IF EXISTS endpoint_properties;
+DROP TABLE IF EXISTS endpoints;
+DROP TABLE IF EXISTS keys;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS vendors;
+CREATE TABLE vendors (
+	id      TEXT PRIMARY KEY,
+	name    TEXT NOT NULL,
+	domain  TEXT NOT NULL,
+	retired INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE organizations (
+	vendor_id       TEXT NOT NULL REFERENCES vendors (id),
+	id              TEXT NOT NULL,
+	name            TEXT NOT NULL,
+	lower_name      TEXT NOT NULL,
+	normalized_name TEXT NOT NULL,
+	claim_start     TEXT NOT NULL,
+	claim_end       TEXT,
+	PRIMARY KEY (vendor_id, id)
+);
+CREATE INDEX organizations_id ON organizations (id);
+CREATE INDEX organizations_lower_name ON organizations (lower_name);
+CREATE TABLE organization_words (
+	word            TEXT NOT NULL,
+	vendor_id       TEXT NOT NULL,
+	organization_id TEXT NOT NULL,
+	PRIMARY KEY (word, vendor_id, organization_id),
+	FOREIGN KEY (vendor_id, organization_id) REFERENCES organizations (vendor_id, id)
+);
+CREATE INDEX organization_words_owner ON organization_words (vendor_id, organization_id);
+CREATE TABLE keys (
+	vendor_id       TEXT NOT NULL REFERENCES vendors (id),
+	organization_id TEXT,
+	position        INTEGER NOT NULL,
+	jwk             TEXT NOT NULL
+);
+CREATE INDEX keys_owner ON keys (vendor_id, organization_id);
+CREATE TABLE endpoints (
+	vendor_id       TEXT NOT NULL,
+	organization_id TEXT NOT NULL,
+	id              TEXT NOT NULL,
+	type            TEXT NOT NULL,
+	url             TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	not_before      TEXT,
+	not_after       TEXT,
+	deregistered    INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (vendor_id, organization_id, id),
+	FOREIGN KEY (vendor_id, organization_id) REFERENCES organizations (vendor_id, id)
+);
+CREATE TABLE endpoint_properties (
+	vendor_id       TEXT NOT NULL,
+	organization_id TEXT NOT NULL,
+	endpoint_id     TEXT NOT NULL,
+	name            TEXT NOT NULL,
+	value           TEXT NOT NULL,
+	PRIMARY KEY (vendor_id, organization_id, endpoint_id, name),
+	FOREIGN KEY (vendor_id, organization_id, endpoint_id) REFERENCES endpoints (vendor_id, organization_id, id)
+);
+`
+
+// sqliteTimeFormat is the format of the moments stored in the SQLiteDb (in UTC): since the number of fractional
+// digits is fixed, moments can be compared as text.
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+const sqliteOrgColumns = "o.vendor_id, o.id, o.name, o.normalized_name, o.claim_start, o.claim_end"
+
+// sqliteAvailableOrg is the condition for organizations (o) which are part of the registry at a moment (parameter):
+// their claim is active and their vendor (v) hasn't been retired, see org.isAvailable.
+const sqliteAvailableOrg = "v.retired = 0 AND (o.claim_end IS NULL OR o.claim_end > ?)"
+
+// sqliteAvailableEndpoint is the condition for endpoints (e) whose availability window contains a moment (parameters,
+// twice), see Endpoint.IsAvailable.
+const sqliteAvailableEndpoint = "(e.not_before IS NULL OR e.not_before <= ?) AND (e.not_after IS NULL OR e.not_after > ?)"
+
+// SQLiteDb is a Db which is built by applying events, like MemoryDb, but stores its state in a SQLite database so it can
+// be queried using SQL by other processes as well. Every event is applied in a transaction, so queries always see the
+// state between events.
+type SQLiteDb struct {
+	conn *sql.DB
+	// now returns the moment at which claims are evaluated (e.g. whether they've ended).
+	now func() time.Time
+}
+
+// NewSQLiteDb creates a SQLiteDb which stores its state in the given file. Since the registry's state is built by
+// applying events (or restoring a snapshot), the tables are recreated empty when they already exist.
+func NewSQLiteDb(file string) (*SQLiteDb, error) {
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000", file))
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to open SQLite database")
+	}
+	var tables int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		_ = conn.Close()
+		return nil, errors2.Wrap(err, "unable to create SQLite database schema")
+	}
+	if tables > 0 {
+		logging.Log().Warnf("Dropping the existing tables of the SQLite database, the registry's state is rebuilt from the snapshots and events (file = %s)", file)
+	}
+	if _, err := conn.Exec(sqliteSchema); err != nil {
+		_ = conn.Close()
+		return nil, errors2.Wrap(err, "unable to create SQLite database schema")
+	}
+	return &SQLiteDb{conn: conn, now: time.Now}, nil
+}
+
+// Close closes the database.
+func (db *SQLiteDb) Close() error {
+	return db.conn.Close()
+}
+
+// sqliteEventHandler applies an event to the database using the given transaction.
+type sqliteEventHandler func(tx *sql.Tx, event events.Event, lookup events.EventLookup) error
+
+// RegisterEventHandlers registers event handlers on this database
+func (db *SQLiteDb) RegisterEventHandlers(registrar events.EventRegistrar) {
+	fn := db.transactionalRegistrar(registrar)
+	fn(domain.RegisterVendor, func(tx *sql.Tx, event events.Event, lookup events.EventLookup) error {
+		// Unmarshal
+		payload := domain.RegisterVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		id := payload.Identifier
+		// Validate
+		if !event.PreviousRef().IsZero() {
+			if err := assertSameVendor(id, lookup.Get(event.PreviousRef())); err != nil {
+				return errors2.Wrap(err, "referred event contains a different vendor")
+			}
+		}
+		existing, err := selectVendor(tx, id)
+		if err != nil {
+			return err
+		}
+		// Process
+		if existing != nil {
+			if event.PreviousRef() == nil {
+				return fmt.Errorf("vendor already registered (id = %s)", id)
+			}
+			// Update event
+			if existing.retired {
+				return fmt.Errorf("vendor has been retired (id = %s)", id)
+			}
+			_, err = tx.Exec("UPDATE vendors SET name = ?, domain = ? WHERE id = ?", payload.Name, payload.Domain, id.String())
+		} else {
+			// Registration event
+			_, err = tx.Exec("INSERT INTO vendors (id, name, domain) VALUES (?, ?, ?)", id.String(), payload.Name, payload.Domain)
+		}
+		if err != nil {
+			return err
+		}
+		return replaceKeys(tx, id, nil, payload.Keys)
+	})
+	fn(domain.VendorClaim, func(tx *sql.Tx, event events.Event, lookup events.EventLookup) error {
+		// Unmarshal
+		payload := domain.VendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		v, err := selectVendor(tx, payload.VendorID)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("vendor is not registered (id = %s)", payload.VendorID)
+		}
+		if v.retired {
+			return fmt.Errorf("vendor has been retired (id = %s)", payload.VendorID)
+		}
+		if !event.PreviousRef().IsZero() {
+			if err := assertSameVendor(payload.VendorID, lookup.Get(event.PreviousRef())); err != nil {
+				return errors2.Wrap(err, "can't change organization's vendor")
+			}
+			if err := assertSameOrganization(payload.OrganizationID, lookup.Get(event.PreviousRef())); err != nil {
+				return errors2.Wrap(err, "can't change organization ID")
+			}
+		}
+		// Process
+		existing, err := selectOrg(tx, payload.VendorID, payload.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if event.PreviousRef() == nil {
+			active, err := db.lookupActiveOrg(tx, payload.OrganizationID, event.IssuedAt())
+			if err != nil {
+				return err
+			}
+			if existing != nil || active != nil {
+				return fmt.Errorf("organization already registered (id = %s)", payload.OrganizationID)
+			}
+		}
+		if existing != nil {
+			// Update event, which can't undo or postpone the end of the claim
+			if existing.End != nil && (payload.End == nil || existing.End.Before(*payload.End)) {
+				payload.End = existing.End
+			}
+			_, err = tx.Exec("UPDATE organizations SET name = ?, lower_name = ?, normalized_name = ?, claim_start = ?, claim_end = ? WHERE vendor_id = ? AND id = ?",
+				payload.OrgName, strings.ToLower(payload.OrgName), normalizeName(payload.OrgName), formatSqliteTime(&payload.Start), formatSqliteTime(payload.End),
+				payload.VendorID.String(), payload.OrganizationID.String())
+			if err == nil {
+				err = indexOrgName(tx, payload.VendorID, payload.OrganizationID, normalizeName(payload.OrgName))
+			}
+		} else {
+			// Registration event
+			err = insertOrg(tx, payload)
+		}
+		if err != nil {
+			return err
+		}
+		return replaceKeys(tx, payload.VendorID, &payload.OrganizationID, payload.OrgKeys)
+	})
+	fn(domain.RegisterEndpoint, func(tx *sql.Tx, event events.Event, lookup events.EventLookup) error {
+		// Unmarshal
+		payload := domain.RegisterEndpointEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		if err := payload.Validate(); err != nil {
+			return err
+		}
+		o, err := db.lookupOrg(tx, payload.Organization, event.IssuedAt())
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			return fmt.Errorf("organization not registered (id = %s)", payload.Organization)
+		}
+		existing, err := selectEndpoint(tx, o, payload.Identifier)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.deregistered {
+				return fmt.Errorf("endpoint has been deregistered (id = %s)", payload.Identifier)
+			}
+			if event.PreviousRef() == nil {
+				return fmt.Errorf("endpoint already registered for this organization (id = %s)", payload.Identifier)
+			}
+		}
+		if !event.PreviousRef().IsZero() {
+			if err := assertSameOrganization(payload.Organization, lookup.Get(event.PreviousRef())); err != nil {
+				return errors2.Wrap(err, "can't change endpoint's organization")
+			}
+		}
+		// Process
+		if existing != nil {
+			if err := deleteEndpoint(tx, o, payload.Identifier); err != nil {
+				return err
+			}
+		}
+		return insertEndpoint(tx, o.VendorID, endpoint{RegisterEndpointEvent: payload})
+	})
+	fn(domain.DeregisterEndpoint, func(tx *sql.Tx, event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.DeregisterEndpointEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		o, err := db.lookupOrg(tx, payload.Organization, event.IssuedAt())
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			return fmt.Errorf("organization not registered (id = %s)", payload.Organization)
+		}
+		e, err := selectEndpoint(tx, o, payload.Identifier)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return fmt.Errorf("endpoint not registered for this organization (id = %s)", payload.Identifier)
+		}
+		// Process
+		_, err = tx.Exec("UPDATE endpoints SET deregistered = 1 WHERE vendor_id = ? AND organization_id = ? AND id = ?",
+			o.VendorID.String(), o.OrganizationID.String(), string(payload.Identifier))
+		return err
+	})
+	fn(domain.EndVendorClaim, func(tx *sql.Tx, event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.EndVendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		v, err := selectVendor(tx, payload.VendorID)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("vendor is not registered (id = %s)", payload.VendorID)
+		}
+		o, err := selectOrg(tx, payload.VendorID, payload.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			return fmt.Errorf("organization not claimed by vendor (id = %s)", payload.OrganizationID)
+		}
+		// Process: when the claim was already ended, the earliest end wins
+		if o.End != nil && !payload.End.Before(*o.End) {
+			return nil
+		}
+		_, err = tx.Exec("UPDATE organizations SET claim_end = ? WHERE vendor_id = ? AND id = ?",
+			formatSqliteTime(&payload.End), payload.VendorID.String(), payload.OrganizationID.String())
+		return err
+	})
+	fn(domain.RetireVendor, func(tx *sql.Tx, event events.Event, _ events.EventLookup) error {
+		// Unmarshal
+		payload := domain.RetireVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return err
+		}
+		// Validate
+		v, err := selectVendor(tx, payload.Identifier)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("vendor is not registered (id = %s)", payload.Identifier)
+		}
+		// Process
+		_, err = tx.Exec("UPDATE vendors SET retired = 1 WHERE id = ?", payload.Identifier.String())
+		return err
+	})
+}
+
+// transactionalRegistrar wraps the given registrar so the registered event handlers are executed in a transaction,
+// which is only committed when the handler succeeds.
+func (db *SQLiteDb) transactionalRegistrar(fn events.EventRegistrar) func(events.EventType, sqliteEventHandler) {
+	return func(eventType events.EventType, handler sqliteEventHandler) {
+		fn(eventType, func(event events.Event, lookup events.EventLookup) error {
+			return db.write(func(tx *sql.Tx) error {
+				return handler(tx, event, lookup)
+			})
+		})
+	}
+}
+
+// write executes the given function in a transaction, which is committed when the function succeeds.
+func (db *SQLiteDb) write(fn func(tx *sql.Tx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return errors2.Wrap(err, "unable to start transaction")
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return errors2.Wrap(tx.Commit(), "unable to commit transaction")
+}
+
+// read executes the given function in a transaction, so it sees the same state for all its queries.
+func (db *SQLiteDb) read(fn func(tx *sql.Tx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return errors2.Wrap(err, "unable to start transaction")
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// lookupOrg looks up the organization with the given ID. When multiple vendors claimed the organization (because
+// earlier claims ended), the claim active at the given moment is preferred.
+func (db *SQLiteDb) lookupOrg(tx *sql.Tx, orgID core.PartyID, moment time.Time) (*org, error) {
+	if o, err := db.lookupActiveOrg(tx, orgID, moment); o != nil || err != nil {
+		return o, err
+	}
+	return scanOrg(tx.QueryRow("SELECT "+sqliteOrgColumns+" FROM organizations o WHERE o.id = ? ORDER BY o.rowid DESC LIMIT 1",
+		orgID.String()))
+}
+
+// lookupActiveOrg looks up the organization with the given ID, if it's claimed at the given moment by a vendor which
+// isn't retired. Event handlers must pass the moment the event was issued so applying an event doesn't depend on when
+// it's applied, queries pass the current time.
+func (db *SQLiteDb) lookupActiveOrg(tx *sql.Tx, orgID core.PartyID, moment time.Time) (*org, error) {
+	return scanOrg(tx.QueryRow("SELECT "+sqliteOrgColumns+" FROM organizations o JOIN vendors v ON v.id = o.vendor_id "+
+		"WHERE o.id = ? AND "+sqliteAvailableOrg+" ORDER BY o.rowid LIMIT 1",
+		orgID.String(), formatSqliteTime(&moment)))
+}
+
+func (db *SQLiteDb) nowPtr() *time.Time {
+	now := db.now()
+	return &now
+}
+
+// VendorByID looks up the vendor by the given ID.
+func (db *SQLiteDb) VendorByID(id core.PartyID) *Vendor {
+	var result *Vendor
+	err := db.read(func(tx *sql.Tx) error {
+		v, err := selectVendor(tx, id)
+		if v == nil || v.retired || err != nil {
+			return err
+		}
+		if v.Keys, err = selectKeys(tx, id, nil); err != nil {
+			return err
+		}
+		r := v.toDb()
+		result = &r
+		return nil
+	})
+	if err != nil {
+		logging.Log().WithError(err).Errorf("Unable to query vendor (id = %s)", id)
+		return nil
+	}
+	return result
+}
+
+func (db *SQLiteDb) OrganizationsByVendorID(id core.PartyID) []*Organization {
+	var result []*Organization
+	err := db.read(func(tx *sql.Tx) error {
+		v, err := selectVendor(tx, id)
+		if v == nil || v.retired || err != nil {
+			return err
+		}
+		orgs, err := scanOrgs(tx.Query("SELECT "+sqliteOrgColumns+" FROM organizations o "+
+			"WHERE o.vendor_id = ? AND (o.claim_end IS NULL OR o.claim_end > ?) ORDER BY o.id",
+			id.String(), formatSqliteTime(db.nowPtr())))
+		if err != nil {
+			return err
+		}
+		result = make([]*Organization, 0, len(orgs))
+		for _, o := range orgs {
+			if err := loadOrgDetails(tx, o); err != nil {
+				return err
+			}
+			r := o.toDb()
+			result = append(result, &r)
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Log().WithError(err).Errorf("Unable to query organizations of vendor (id = %s)", id)
+		return nil
+	}
+	return result
+}
+
+func (db *SQLiteDb) FindEndpointsByOrganizationAndType(organizationIdentifier core.PartyID, endpointType *string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := db.read(func(tx *sql.Tx) error {
+		o, err := db.lookupActiveOrg(tx, organizationIdentifier, db.now())
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			return fmt.Errorf("organization with identifier [%s] does not exist", organizationIdentifier)
+		}
+		if err := loadOrgDetails(tx, o); err != nil {
+			return err
+		}
+		now := db.now()
+		for _, e := range sortedEndpoints(o) {
+			if !e.deregistered && e.toDb().IsUsable(now) {
+				if endpointType == nil || *endpointType == e.EndpointType {
+					endpoints = append(endpoints, e.toDb())
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// SearchOrganizations returns the organizations matching the query, ordered by relevance like
+// MemoryDb.SearchOrganizations.
+func (db *SQLiteDb) SearchOrganizations(query string, options SearchOptions) SearchResult {
+	result := SearchResult{Organizations: []Organization{}}
+	err := db.read(func(tx *sql.Tx) error {
+		now := formatSqliteTime(db.nowPtr())
+		normalizedQuery := normalizeName(query)
+		var page []*org
+		if len(tokenize(normalizedQuery)) == 0 {
+			// All organizations match, ordered like rank does for equal scores
+			if err := tx.QueryRow("SELECT COUNT(*) FROM organizations o JOIN vendors v ON v.id = o.vendor_id WHERE "+sqliteAvailableOrg,
+				now).Scan(&result.Total); err != nil {
+				return err
+			}
+			from, to := pageBounds(result.Total, options)
+			var err error
+			page, err = scanOrgs(tx.Query("SELECT "+sqliteOrgColumns+" FROM organizations o JOIN vendors v ON v.id = o.vendor_id "+
+				"WHERE "+sqliteAvailableOrg+" ORDER BY length(CAST(o.normalized_name AS BLOB)), o.normalized_name, o.id LIMIT ? OFFSET ?",
+				now, to-from, from))
+			if err != nil {
+				return err
+			}
+		} else {
+			scores, err := searchOrgs(tx, normalizedQuery, now)
+			if err != nil {
+				return err
+			}
+			ranked := rank(scores)
+			result.Total = len(ranked)
+			page = paginate(ranked, options)
+		}
+		for _, o := range page {
+			if err := loadOrgDetails(tx, o); err != nil {
+				return err
+			}
+			result.Organizations = append(result.Organizations, o.toDb())
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Log().WithError(err).Errorf("Unable to search organizations (query = %s)", query)
+		return SearchResult{Organizations: []Organization{}}
+	}
+	return result
+}
+
+// sqliteMaxWordsPerQuery limits the number of words selected per query, to stay below SQLite's maximum number of
+// parameters.
+const sqliteMaxWordsPerQuery = 500
+
+// searchOrgs returns the organizations available at the given moment which match the given (normalized, non-empty)
+// query with their score, like wordIndex.search. Only the indexed words that can match a query word (containing it, or
+// of similar length when typos are allowed) are scored, after which only the organizations having matching words are
+// loaded.
+func searchOrgs(tx *sql.Tx, query string, now interface{}) (map[*org]int, error) {
+	queryWords := tokenize(query)
+	// Score of the matching words per query word
+	wordScores := make([]map[string]int, len(queryWords))
+	var matchingWords []string
+	matched := make(map[string]bool)
+	for n, queryWord := range queryWords {
+		length := len([]rune(queryWord))
+		condition := "instr(word, ?) > 0"
+		args := []interface{}{queryWord}
+		if max := maxEdits(length); max > 0 {
+			condition += " OR length(word) BETWEEN ? AND ?"
+			args = append(args, length-max, length+max)
+		}
+		words, err := scanStrings(tx.Query("SELECT DISTINCT word FROM organization_words WHERE "+condition, args...))
+		if err != nil {
+			return nil, err
+		}
+		wordScores[n] = make(map[string]int)
+		for _, word := range words {
+			if score := scoreWords(queryWord, word); score > 0 {
+				wordScores[n][word] = score
+				if !matched[word] {
+					matched[word] = true
+					matchingWords = append(matchingWords, word)
+				}
+			}
+		}
+		if len(wordScores[n]) == 0 {
+			// Every query word must match
+			return map[*org]int{}, nil
+		}
+	}
+	// Matching words per organization, identified by vendor and organization ID
+	orgs := make(map[string]*org)
+	orgWords := make(map[*org][]string)
+	for from := 0; from < len(matchingWords); from += sqliteMaxWordsPerQuery {
+		to := from + sqliteMaxWordsPerQuery
+		if to > len(matchingWords) {
+			to = len(matchingWords)
+		}
+		args := []interface{}{now}
+		for _, word := range matchingWords[from:to] {
+			args = append(args, word)
+		}
+		rows, err := tx.Query("SELECT "+sqliteOrgColumns+", w.word FROM organization_words w "+
+			"JOIN organizations o ON o.vendor_id = w.vendor_id AND o.id = w.organization_id JOIN vendors v ON v.id = o.vendor_id "+
+			"WHERE "+sqliteAvailableOrg+" AND w.word IN (?"+strings.Repeat(", ?", to-from-1)+")", args...)
+		if err != nil {
+			return nil, err
+		}
+		err = func() error {
+			defer rows.Close()
+			for rows.Next() {
+				var word string
+				o, err := scanOrg(withColumns(rows, &word))
+				if err != nil {
+					return err
+				}
+				key := o.VendorID.String() + "/" + o.OrganizationID.String()
+				if existing, ok := orgs[key]; ok {
+					o = existing
+				} else {
+					orgs[key] = o
+				}
+				orgWords[o] = append(orgWords[o], word)
+			}
+			return rows.Err()
+		}()
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := make(map[*org]int)
+	for o, words := range orgWords {
+		score := 0
+		for n := range queryWords {
+			best := 0
+			for _, word := range words {
+				if wordScore := wordScores[n][word]; wordScore > best {
+					best = wordScore
+				}
+			}
+			if best == 0 {
+				score = -1
+				break
+			}
+			score += best
+		}
+		if score >= 0 {
+			result[o] = score + scoreNameBonus(query, o.normalizedName)
+		}
+	}
+	return result, nil
+}
+
+// SearchEndpoints returns the requested page of the endpoints matching the query, across all organizations. They're
+// ordered by organization ID and endpoint ID, like MemoryDb.SearchEndpoints.
+func (db *SQLiteDb) SearchEndpoints(query EndpointQuery, options SearchOptions) EndpointSearchResult {
+	now := formatSqliteTime(db.nowPtr())
+	statuses := query.statuses()
+	conditions := []string{sqliteAvailableOrg, "e.deregistered = 0", "e.status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"}
+	args := []interface{}{now}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	if !query.IncludeUnavailable {
+		conditions = append(conditions, sqliteAvailableEndpoint)
+		args = append(args, now, now)
+	}
+	if query.EndpointType != "" {
+		conditions = append(conditions, "e.type = ?")
+		args = append(args, query.EndpointType)
+	}
+	if !query.Vendor.IsZero() {
+		conditions = append(conditions, "o.vendor_id = ?")
+		args = append(args, query.Vendor.String())
+	}
+	if query.Domain != "" {
+		conditions = append(conditions, "v.domain = ?")
+		args = append(args, query.Domain)
+	}
+	for name, value := range query.Properties {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM endpoint_properties p WHERE p.vendor_id = e.vendor_id "+
+			"AND p.organization_id = e.organization_id AND p.endpoint_id = e.id AND p.name = ? AND p.value = ?)")
+		args = append(args, name, value)
+	}
+	from := " FROM endpoints e JOIN organizations o ON o.vendor_id = e.vendor_id AND o.id = e.organization_id " +
+		"JOIN vendors v ON v.id = o.vendor_id WHERE " + strings.Join(conditions, " AND ")
+
+	result := EndpointSearchResult{Endpoints: []OrganizationEndpoint{}}
+	err := db.read(func(tx *sql.Tx) error {
+		if err := tx.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&result.Total); err != nil {
+			return err
+		}
+		offset, end := pageBounds(result.Total, options)
+		rows, err := tx.Query("SELECT o.vendor_id, o.id, e.id"+from+" ORDER BY o.id, e.id LIMIT ? OFFSET ?",
+			append(args, end-offset, offset)...)
+		if err != nil {
+			return err
+		}
+		type match struct {
+			vendorID, orgID, endpointID string
+		}
+		var matches []match
+		for rows.Next() {
+			var m match
+			if err := rows.Scan(&m.vendorID, &m.orgID, &m.endpointID); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			matches = append(matches, m)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		// Load the organizations (with their endpoints) of the matches, now the rows have been read
+		orgs := make(map[string]*org)
+		for _, m := range matches {
+			o := orgs[m.vendorID+" "+m.orgID]
+			if o == nil {
+				if o, err = scanOrg(tx.QueryRow("SELECT "+sqliteOrgColumns+" FROM organizations o WHERE o.vendor_id = ? AND o.id = ?",
+					m.vendorID, m.orgID)); err != nil {
+					return err
+				}
+				if err := loadOrgDetails(tx, o); err != nil {
+					return err
+				}
+				orgs[m.vendorID+" "+m.orgID] = o
+			}
+			result.Endpoints = append(result.Endpoints, OrganizationEndpoint{
+				Endpoint:     o.endpoints[m.endpointID].toDb(),
+				Organization: o.toDb(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Log().WithError(err).Error("Unable to search endpoints")
+		return EndpointSearchResult{Endpoints: []OrganizationEndpoint{}}
+	}
+	return result
+}
+
+func (db *SQLiteDb) ReverseLookup(name string) (*Organization, error) {
+	var result *Organization
+	err := db.read(func(tx *sql.Tx) error {
+		o, err := scanOrg(tx.QueryRow("SELECT "+sqliteOrgColumns+" FROM organizations o JOIN vendors v ON v.id = o.vendor_id "+
+			"WHERE o.lower_name = ? AND "+sqliteAvailableOrg+" ORDER BY o.rowid LIMIT 1",
+			strings.ToLower(name), formatSqliteTime(db.nowPtr())))
+		if o == nil || err != nil {
+			return err
+		}
+		if err := loadOrgDetails(tx, o); err != nil {
+			return err
+		}
+		r := o.toDb()
+		result = &r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("reverse lookup failed for %s: %w", name, ErrOrganizationNotFound)
+	}
+	return result, nil
+}
+
+func (db *SQLiteDb) OrganizationById(id core.PartyID) (*Organization, error) {
+	var result *Organization
+	err := db.read(func(tx *sql.Tx) error {
+		o, err := db.lookupActiveOrg(tx, id, db.now())
+		if o == nil || err != nil {
+			return err
+		}
+		if err := loadOrgDetails(tx, o); err != nil {
+			return err
+		}
+		r := o.toDb()
+		result = &r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%s: %w", id, ErrOrganizationNotFound)
+	}
+	return result, nil
+}
+
+// Snapshot captures the state of the database, so it can be restored later using Restore. The snapshot has the same
+// format as a snapshot of the MemoryDb.
+func (db *SQLiteDb) Snapshot() ([]byte, error) {
+	snapshot := memoryDbSnapshot{Vendors: []vendorSnapshot{}}
+	err := db.read(func(tx *sql.Tx) error {
+		vendors, err := scanVendors(tx.Query("SELECT id, name, domain, retired FROM vendors ORDER BY id"))
+		if err != nil {
+			return err
+		}
+		for _, v := range vendors {
+			if v.Keys, err = selectKeys(tx, v.Identifier, nil); err != nil {
+				return err
+			}
+			orgs, err := scanOrgs(tx.Query("SELECT "+sqliteOrgColumns+" FROM organizations o WHERE o.vendor_id = ? ORDER BY o.rowid",
+				v.Identifier.String()))
+			if err != nil {
+				return err
+			}
+			vs := vendorSnapshot{
+				Vendor:        v.RegisterVendorEvent,
+				Organizations: make([]organizationSnapshot, 0, len(orgs)),
+				Retired:       v.retired,
+			}
+			for _, o := range orgs {
+				if err := loadOrgDetails(tx, o); err != nil {
+					return err
+				}
+				orgSnapshot := organizationSnapshot{
+					Organization: o.VendorClaimEvent,
+					Endpoints:    make([]domain.RegisterEndpointEvent, 0, len(o.endpoints)),
+				}
+				for _, e := range sortedEndpoints(o) {
+					orgSnapshot.Endpoints = append(orgSnapshot.Endpoints, e.RegisterEndpointEvent)
+					if e.deregistered {
+						orgSnapshot.DeregisteredEndpoints = append(orgSnapshot.DeregisteredEndpoints, e.Identifier)
+					}
+				}
+				vs.Organizations = append(vs.Organizations, orgSnapshot)
+			}
+			snapshot.Vendors = append(snapshot.Vendors, vs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to read database")
+	}
+	return json.Marshal(snapshot)
+}
+
+// Restore replaces the state of the database with the state captured in the given snapshot (created by Snapshot).
+func (db *SQLiteDb) Restore(data []byte) error {
+	snapshot := memoryDbSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors2.Wrap(err, "unable to parse snapshot")
+	}
+	return db.write(func(tx *sql.Tx) error {
+		for _, table := range []string{"organization_words", "endpoint_properties", "endpoints", "keys", "organizations", "vendors"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				return errors2.Wrap(err, "unable to clear database")
+			}
+		}
+		for _, vs := range snapshot.Vendors {
+			vendorID := vs.Vendor.Identifier
+			if _, err := tx.Exec("INSERT INTO vendors (id, name, domain, retired) VALUES (?, ?, ?, ?)",
+				vendorID.String(), vs.Vendor.Name, vs.Vendor.Domain, vs.Retired); err != nil {
+				return errors2.Wrap(err, "unable to restore vendor")
+			}
+			if err := replaceKeys(tx, vendorID, nil, vs.Vendor.Keys); err != nil {
+				return err
+			}
+			for _, orgSnapshot := range vs.Organizations {
+				claim := orgSnapshot.Organization
+				if err := insertOrg(tx, claim); err != nil {
+					return errors2.Wrap(err, "unable to restore organization")
+				}
+				if err := replaceKeys(tx, vendorID, &claim.OrganizationID, claim.OrgKeys); err != nil {
+					return err
+				}
+				deregistered := make(map[types.EndpointID]bool, len(orgSnapshot.DeregisteredEndpoints))
+				for _, id := range orgSnapshot.DeregisteredEndpoints {
+					deregistered[id] = true
+				}
+				for _, e := range orgSnapshot.Endpoints {
+					if err := insertEndpoint(tx, vendorID, endpoint{RegisterEndpointEvent: e, deregistered: deregistered[e.Identifier]}); err != nil {
+						return errors2.Wrap(err, "unable to restore endpoint")
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// rowScanner is implemented by both sql.Row and sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumnsScanner scans the row into the given destinations, followed by its extra destinations.
+type extraColumnsScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s extraColumnsScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// withColumns returns a rowScanner which scans the columns following the ones the scanner is passed into the given
+// destinations, e.g. to scan extra columns selected after sqliteOrgColumns using scanOrg.
+func withColumns(row rowScanner, extra ...interface{}) rowScanner {
+	return extraColumnsScanner{row: row, extra: extra}
+}
+
+// selectVendor loads the vendor with the given ID (without its keys), nil if it isn't registered.
+func selectVendor(tx *sql.Tx, id core.PartyID) (*vendor, error) {
+	return scanVendor(tx.QueryRow("SELECT id, name, domain, retired FROM vendors WHERE id = ?", id.String()))
+}
+
+func scanVendor(row rowScanner) (*vendor, error) {
+	var id string
+	v := &vendor{}
+	if err := row.Scan(&id, &v.Name, &v.Domain, &v.retired); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var err error
+	if v.Identifier, err = core.ParsePartyID(id); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func scanVendors(rows *sql.Rows, err error) ([]*vendor, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []*vendor
+	for rows.Next() {
+		v, err := scanVendor(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// selectOrg loads the organization with the given ID claimed by the given vendor (without its keys and endpoints),
+// nil if the vendor didn't claim it.
+func selectOrg(tx *sql.Tx, vendorID core.PartyID, orgID core.PartyID) (*org, error) {
+	return scanOrg(tx.QueryRow("SELECT "+sqliteOrgColumns+" FROM organizations o WHERE o.vendor_id = ? AND o.id = ?",
+		vendorID.String(), orgID.String()))
+}
+
+// scanOrg scans an organization selected using sqliteOrgColumns, nil if there's no row.
+func scanOrg(row rowScanner) (*org, error) {
+	var vendorID, orgID, start string
+	var end sql.NullString
+	o := &org{endpoints: make(map[string]*endpoint)}
+	if err := row.Scan(&vendorID, &orgID, &o.OrgName, &o.normalizedName, &start, &end); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var err error
+	if o.VendorID, err = core.ParsePartyID(vendorID); err != nil {
+		return nil, err
+	}
+	if o.OrganizationID, err = core.ParsePartyID(orgID); err != nil {
+		return nil, err
+	}
+	if o.Start, err = time.Parse(sqliteTimeFormat, start); err != nil {
+		return nil, err
+	}
+	if o.End, err = parseSqliteTime(end); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func scanOrgs(rows *sql.Rows, err error) ([]*org, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []*org
+	for rows.Next() {
+		o, err := scanOrg(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, o)
+	}
+	return result, rows.Err()
+}
+
+// scanStrings scans the rows of a single text column.
+func scanStrings(rows *sql.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
+
+func insertOrg(tx *sql.Tx, claim domain.VendorClaimEvent) error {
+	normalizedName := normalizeName(claim.OrgName)
+	_, err := tx.Exec("INSERT INTO organizations (vendor_id, id, name, lower_name, normalized_name, claim_start, claim_end) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		claim.VendorID.String(), claim.OrganizationID.String(), claim.OrgName, strings.ToLower(claim.OrgName), normalizedName,
+		formatSqliteTime(&claim.Start), formatSqliteTime(claim.End))
+	if err != nil {
+		return err
+	}
+	return indexOrgName(tx, claim.VendorID, claim.OrganizationID, normalizedName)
+}
+
+// indexOrgName (re)indexes the organization under the words of the given (normalized) name.
+func indexOrgName(tx *sql.Tx, vendorID core.PartyID, orgID core.PartyID, normalizedName string) error {
+	if _, err := tx.Exec("DELETE FROM organization_words WHERE vendor_id = ? AND organization_id = ?", vendorID.String(), orgID.String()); err != nil {
+		return err
+	}
+	for _, word := range tokenize(normalizedName) {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO organization_words (word, vendor_id, organization_id) VALUES (?, ?, ?)",
+			word, vendorID.String(), orgID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadOrgDetails loads the keys and endpoints of the organization.
+func loadOrgDetails(tx *sql.Tx, o *org) error {
+	var err error
+	if o.OrgKeys, err = selectKeys(tx, o.VendorID, &o.OrganizationID); err != nil {
+		return err
+	}
+	rows, err := tx.Query("SELECT id, type, url, status, not_before, not_after, deregistered FROM endpoints WHERE vendor_id = ? AND organization_id = ?",
+		o.VendorID.String(), o.OrganizationID.String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := &endpoint{}
+		var notBefore, notAfter sql.NullString
+		if err := rows.Scan(&e.Identifier, &e.EndpointType, &e.URL, &e.Status, &notBefore, &notAfter, &e.deregistered); err != nil {
+			return err
+		}
+		if e.NotBefore, err = parseSqliteTime(notBefore); err != nil {
+			return err
+		}
+		if e.NotAfter, err = parseSqliteTime(notAfter); err != nil {
+			return err
+		}
+		e.Organization = o.OrganizationID
+		o.endpoints[string(e.Identifier)] = e
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows, err = tx.Query("SELECT endpoint_id, name, value FROM endpoint_properties WHERE vendor_id = ? AND organization_id = ?",
+		o.VendorID.String(), o.OrganizationID.String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var endpointID, name, value string
+		if err := rows.Scan(&endpointID, &name, &value); err != nil {
+			return err
+		}
+		if e := o.endpoints[endpointID]; e != nil {
+			if e.Properties == nil {
+				e.Properties = make(map[string]string)
+			}
+			e.Properties[name] = value
+		}
+	}
+	return rows.Err()
+}
+
+// sortedEndpoints returns the (loaded) endpoints of the organization, ordered by ID.
+func sortedEndpoints(o *org) []*endpoint {
+	result := make([]*endpoint, 0, len(o.endpoints))
+	for _, e := range o.endpoints {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Identifier < result[j].Identifier
+	})
+	return result
+}
+
+// selectEndpoint loads the endpoint (without its properties) of the organization, nil if it isn't registered.
+func selectEndpoint(tx *sql.Tx, o *org, id types.EndpointID) (*endpoint, error) {
+	e := &endpoint{}
+	err := tx.QueryRow("SELECT deregistered FROM endpoints WHERE vendor_id = ? AND organization_id = ? AND id = ?",
+		o.VendorID.String(), o.OrganizationID.String(), string(id)).Scan(&e.deregistered)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	e.Identifier = id
+	e.Organization = o.OrganizationID
+	return e, nil
+}
+
+func insertEndpoint(tx *sql.Tx, vendorID core.PartyID, e endpoint) error {
+	if _, err := tx.Exec("INSERT INTO endpoints (vendor_id, organization_id, id, type, url, status, not_before, not_after, deregistered) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		vendorID.String(), e.Organization.String(), string(e.Identifier), e.EndpointType, e.URL, e.Status,
+		formatSqliteTime(e.NotBefore), formatSqliteTime(e.NotAfter), e.deregistered); err != nil {
+		return err
+	}
+	for name, value := range e.Properties {
+		if _, err := tx.Exec("INSERT INTO endpoint_properties (vendor_id, organization_id, endpoint_id, name, value) VALUES (?, ?, ?, ?, ?)",
+			vendorID.String(), e.Organization.String(), string(e.Identifier), name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteEndpoint(tx *sql.Tx, o *org, id types.EndpointID) error {
+	for _, statement := range []string{
+		"DELETE FROM endpoint_properties WHERE vendor_id = ? AND organization_id = ? AND endpoint_id = ?",
+		"DELETE FROM endpoints WHERE vendor_id = ? AND organization_id = ? AND id = ?",
+	} {
+		if _, err := tx.Exec(statement, o.VendorID.String(), o.OrganizationID.String(), string(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectKeys loads the keys (JWKs) of the organization claimed by the given vendor, or of the vendor itself when
+// the organization is nil.
+func selectKeys(tx *sql.Tx, vendorID core.PartyID, orgID *core.PartyID) ([]interface{}, error) {
+	rows, err := tx.Query("SELECT jwk FROM keys WHERE vendor_id = ? AND organization_id IS ? ORDER BY position",
+		vendorID.String(), partyIDOrNil(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []interface{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		key := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// replaceKeys replaces the keys (JWKs) of the organization claimed by the given vendor, or of the vendor itself when
+// the organization is nil.
+func replaceKeys(tx *sql.Tx, vendorID core.PartyID, orgID *core.PartyID, keys []interface{}) error {
+	if _, err := tx.Exec("DELETE FROM keys WHERE vendor_id = ? AND organization_id IS ?", vendorID.String(), partyIDOrNil(orgID)); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO keys (vendor_id, organization_id, position, jwk) VALUES (?, ?, ?, ?)",
+			vendorID.String(), partyIDOrNil(orgID), i, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func partyIDOrNil(id *core.PartyID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// formatSqliteTime formats the moment for storage (see sqliteTimeFormat), nil when there's no moment.
+func formatSqliteTime(moment *time.Time) interface{} {
+	if moment == nil {
+		return nil
+	}
+	return moment.UTC().Format(sqliteTimeFormat)
+}
+
+// parseSqliteTime parses a moment as it's stored (see sqliteTimeFormat), nil when there's no moment.
+func parseSqliteTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	moment, err := time.Parse(sqliteTimeFormat, value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &moment, nil
+}
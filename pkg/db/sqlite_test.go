@@ -0,0 +1,221 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	test2 "github.com/nuts-foundation/nuts-crypto/test"
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteDb_Conformance(t *testing.T) {
+	RunConformanceTests(t, func(t *testing.T) Db {
+		return newTestDb(t, &SQLiteDb{})
+	})
+}
+
+func TestNewSQLiteDb(t *testing.T) {
+	t.Run("ok - existing database is recreated empty", func(t *testing.T) {
+		repo, _ := test.NewTestRepo(t)
+		file := filepath.Join(repo.Directory, "registry.db")
+		db, err := NewSQLiteDb(file)
+		if !assert.NoError(t, err) {
+			return
+		}
+		eventSystem := initEventSystem(*repo, db)
+		if !publish(t, eventSystem, registerVendor1) {
+			return
+		}
+		_ = db.Close()
+
+		db, err = NewSQLiteDb(file)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer db.Close()
+		assert.Nil(t, db.VendorByID(test.VendorID("v1")))
+	})
+	t.Run("error - invalid location", func(t *testing.T) {
+		_, err := NewSQLiteDb(filepath.Join(io.TestDirectory(t), "non-existing", "registry.db"))
+		assert.Contains(t, err.Error(), "unable to create SQLite database schema")
+	})
+}
+
+func TestSQLiteDb_Tables(t *testing.T) {
+	repo, _ := test.NewTestRepo(t)
+	file := filepath.Join(repo.Directory, "registry.db")
+	db, err := NewSQLiteDb(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	rsaKey := test2.GenerateRSAKey()
+	key, _ := jwk.New(&rsaKey.PublicKey)
+	keyAsMap, _ := cert.JwkToMap(key)
+	keyAsMap["kty"] = "RSA"
+	end := time.Date(2040, 1, 1, 12, 0, 0, 0, time.UTC)
+	claim := events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{
+		VendorID:       test.VendorID("v1"),
+		OrganizationID: test.OrganizationID("o1"),
+		OrgName:        "Organization Uno",
+		OrgKeys:        []interface{}{keyAsMap},
+		End:            &end,
+	}, nil)
+	endpoint := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+		Organization: test.OrganizationID("o1"),
+		URL:          "foo:bar",
+		EndpointType: "simple",
+		Identifier:   "e1",
+		Status:       StatusActive,
+		NotAfter:     &end,
+		Properties:   map[string]string{"version": "2"},
+	}, nil)
+	if !publish(t, initEventSystem(*repo, db), registerVendor1, claim, endpoint) {
+		return
+	}
+
+	// Query the tables using a separate connection, like external applications would
+	conn, err := sql.Open("sqlite3", "file:"+file+"?mode=ro")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	t.Run("organizations", func(t *testing.T) {
+		var name, claimStart, claimEnd string
+		err := conn.QueryRow("SELECT name, claim_start, claim_end FROM organizations WHERE vendor_id = ? AND id = ?",
+			test.VendorID("v1").String(), test.OrganizationID("o1").String()).Scan(&name, &claimStart, &claimEnd)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Organization Uno", name)
+			assert.Len(t, claimStart, len(claimEnd), "claim start and end must have the same format")
+			assert.Equal(t, "2040-01-01T12:00:00.000000000Z", claimEnd)
+		}
+	})
+	t.Run("organization words", func(t *testing.T) {
+		words, err := scanStrings(conn.Query("SELECT word FROM organization_words WHERE organization_id = ? ORDER BY word",
+			test.OrganizationID("o1").String()))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"organization", "uno"}, words)
+		}
+	})
+	t.Run("keys", func(t *testing.T) {
+		var count int
+		err := conn.QueryRow("SELECT COUNT(*) FROM keys WHERE organization_id = ?", test.OrganizationID("o1").String()).Scan(&count)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, count)
+		}
+	})
+	t.Run("endpoints", func(t *testing.T) {
+		var url, notAfter, version string
+		err := conn.QueryRow("SELECT e.url, e.not_after, p.value FROM endpoints e JOIN endpoint_properties p "+
+			"ON p.vendor_id = e.vendor_id AND p.organization_id = e.organization_id AND p.endpoint_id = e.id "+
+			"WHERE e.id = ? AND p.name = ?", "e1", "version").Scan(&url, &notAfter, &version)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "foo:bar", url)
+			assert.Equal(t, "2040-01-01T12:00:00.000000000Z", notAfter)
+			assert.Equal(t, "2", version)
+		}
+	})
+	t.Run("keys are returned", func(t *testing.T) {
+		organization, err := db.OrganizationById(test.OrganizationID("o1"))
+		if assert.NoError(t, err) {
+			keys, err := organization.KeysAsSet()
+			if assert.NoError(t, err) && assert.Len(t, keys.Keys, 2) {
+				// The key itself and the (deprecated) PublicKey derived from it
+				assert.Equal(t, key, keys.Keys[0])
+			}
+			assert.NotNil(t, organization.PublicKey)
+		}
+	})
+}
+
+func TestSQLiteDb_SearchOrganizations(t *testing.T) {
+	repo, _ := test.NewTestRepo(t)
+	db := newTestDb(t, &SQLiteDb{})
+	memoryDb := New()
+	eventSystem := initEventSystem(*repo, db)
+	memoryDb.RegisterEventHandlers(eventSystem.RegisterEventHandler)
+	evts := []events.Event{registerVendor1}
+	names := []string{"Noord", "Noorderzorg", "Ziekenhuis Noord", "Huisarts de Noordpool", "Nord Kliniek", "Zuid", "De Noord de Zorg"}
+	for i, name := range names {
+		evts = append(evts, events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{
+			VendorID:       test.VendorID("v1"),
+			OrganizationID: test.OrganizationID(fmt.Sprintf("o%d", i)),
+			OrgName:        name,
+		}, nil))
+	}
+	if !publish(t, eventSystem, evts...) {
+		return
+	}
+	for _, query := range []string{"", "noord", "noord ziekenhuis", "zorg", "kliniek nord", "de", "west"} {
+		t.Run("ranks like MemoryDb: "+query, func(t *testing.T) {
+			options := SearchOptions{Offset: 1, Limit: 3}
+			expected := memoryDb.SearchOrganizations(query, options)
+			actual := db.SearchOrganizations(query, options)
+			assert.Equal(t, expected.Total, actual.Total)
+			assert.Equal(t, orgNames(expected.Organizations), orgNames(actual.Organizations))
+		})
+	}
+}
+
+func orgNames(orgs []Organization) []string {
+	var result []string
+	for _, o := range orgs {
+		result = append(result, o.Name)
+	}
+	return result
+}
+
+func TestSQLiteDb_Snapshot(t *testing.T) {
+	t.Run("ok - restore snapshot of MemoryDb", func(t *testing.T) {
+		repo, _ := test.NewTestRepo(t)
+		eventSystem, memoryDb := initDb(*repo)
+		if !publish(t, eventSystem, registerVendor1, vendorClaim1, vendorClaim2, registerEndpoint1, registerEndpoint2, deregisterEndpoint1) {
+			return
+		}
+		data, err := memoryDb.Snapshot()
+		if !assert.NoError(t, err) {
+			return
+		}
+		db := newTestDb(t, &SQLiteDb{})
+		if !assert.NoError(t, db.Restore(data)) {
+			return
+		}
+		assert.Len(t, db.OrganizationsByVendorID(test.VendorID("v1")), 2)
+		expected, _ := memoryDb.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		actual, _ := db.FindEndpointsByOrganizationAndType(test.OrganizationID("o1"), nil)
+		assert.Equal(t, expected, actual)
+	})
+	t.Run("error - invalid snapshot", func(t *testing.T) {
+		db := newTestDb(t, &SQLiteDb{})
+		err := db.Restore([]byte("{"))
+		assert.EqualError(t, err, "unable to parse snapshot: unexpected end of JSON input")
+	})
+}
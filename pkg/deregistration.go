@@ -0,0 +1,111 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	crypto "github.com/nuts-foundation/nuts-crypto/pkg"
+	"github.com/nuts-foundation/nuts-crypto/pkg/types"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	dom "github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	types2 "github.com/nuts-foundation/nuts-registry/pkg/types"
+)
+
+// ErrNotManaged is returned when data is to be altered which isn't managed by this node (e.g. an organization claimed
+// by another vendor).
+var ErrNotManaged = errors.New("not managed by this node")
+
+// DeregisterEndpoint deregisters the endpoint of an organization claimed by this node's vendor. Deregistration is final,
+// the endpoint can't be registered or updated again.
+func (r *Registry) DeregisterEndpoint(organizationID core.PartyID, endpointID types2.EndpointID) (events.Event, error) {
+	logging.Log().Infof("Deregistering endpoint, organization=%s, id=%s", organizationID, endpointID)
+	org, err := r.managedOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	var found bool
+	for _, endpoint := range org.Endpoints {
+		found = found || endpoint.Identifier == endpointID
+	}
+	if !found {
+		return nil, ErrEndpointNotFound
+	}
+	return r.signAndPublishEvent(dom.DeregisterEndpoint, dom.DeregisterEndpointEvent{
+		Organization: organizationID,
+		Identifier:   endpointID,
+	}, nil, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+		return r.signAsOrganization(org.Identifier, org.Name, dataToBeSigned, instant, len(org.GetActiveCertificates()) > 0)
+	})
+}
+
+// EndVendorClaim ends this node's vendor's claim on the organization, effective immediately. Afterwards the
+// organization (and its endpoints) can be claimed by another vendor.
+func (r *Registry) EndVendorClaim(organizationID core.PartyID) (events.Event, error) {
+	logging.Log().Infof("Ending vendor claim, organization=%s", organizationID)
+	org, err := r.managedOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return r.signAndPublishEvent(dom.EndVendorClaim, dom.EndVendorClaimEvent{
+		VendorID:       org.Vendor,
+		OrganizationID: organizationID,
+		End:            time.Now(),
+	}, nil, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+		return r.signAsOrganization(org.Identifier, org.Name, dataToBeSigned, instant, len(org.GetActiveCertificates()) > 0)
+	})
+}
+
+// RetireVendor retires this node's vendor (which must be the specified vendor), removing it and the organizations it
+// claimed from the registry. Retirement is final, the vendor can't be registered again.
+func (r *Registry) RetireVendor(vendorID core.PartyID) (events.Event, error) {
+	logging.Log().Infof("Retiring vendor: %s", vendorID)
+	if r.Db.VendorByID(vendorID) == nil {
+		return nil, ErrVendorNotFound
+	}
+	if vendorID != core.NutsConfig().VendorID() {
+		return nil, fmt.Errorf("%w: vendor %s", ErrNotManaged, vendorID)
+	}
+	return r.signAndPublishEvent(dom.RetireVendor, dom.RetireVendorEvent{
+		Identifier: vendorID,
+	}, nil, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+		entity := types.LegalEntity{URI: vendorID.String()}
+		return r.crypto.SignJWS(dataToBeSigned, types.KeyForEntity(entity).WithQualifier(crypto.SigningCertificateQualifier))
+	})
+}
+
+// managedOrganization returns the organization with the given ID, if it's claimed by this node's vendor.
+func (r *Registry) managedOrganization(organizationID core.PartyID) (*db.Organization, error) {
+	org, err := r.Db.OrganizationById(organizationID)
+	if errors.Is(err, db.ErrOrganizationNotFound) {
+		return nil, ErrOrganizationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if org.Vendor != core.NutsConfig().VendorID() {
+		return nil, fmt.Errorf("%w: organization %s is claimed by vendor %s", ErrNotManaged, organizationID, org.Vendor)
+	}
+	return org, nil
+}
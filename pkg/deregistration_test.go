@@ -0,0 +1,117 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"testing"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Deregistration(t *testing.T) {
+	orgID := test.OrganizationID("org")
+	// setup registers the vendor, an organization and an endpoint
+	setup := func(t *testing.T, cxt *testContext) bool {
+		if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+			return false
+		}
+		if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+			return false
+		}
+		_, err := cxt.registry.RegisterEndpoint(orgID, "endpoint", "url", "type", db.StatusActive, nil, nil, nil)
+		return assert.NoError(t, err)
+	}
+
+	t.Run("deregister endpoint", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		if !setup(t, &cxt) {
+			return
+		}
+		event, err := cxt.registry.DeregisterEndpoint(orgID, "endpoint")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, domain.DeregisterEndpoint, event.Type())
+		assert.NotEmpty(t, event.Signature())
+		endpoints, _ := cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+		assert.Empty(t, endpoints)
+		t.Run("error - already deregistered", func(t *testing.T) {
+			_, err := cxt.registry.DeregisterEndpoint(orgID, "endpoint")
+			assert.Equal(t, ErrEndpointNotFound, err)
+		})
+		t.Run("error - register again", func(t *testing.T) {
+			_, err := cxt.registry.RegisterEndpoint(orgID, "endpoint", "url", "type", db.StatusActive, nil, nil, nil)
+			assert.EqualError(t, err, "endpoint has been deregistered (id = endpoint)")
+		})
+	})
+	t.Run("deregister endpoint - organization not found", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		_, err := cxt.registry.DeregisterEndpoint(orgID, "endpoint")
+		assert.Equal(t, ErrOrganizationNotFound, err)
+	})
+	t.Run("end vendor claim", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		if !setup(t, &cxt) {
+			return
+		}
+		event, err := cxt.registry.EndVendorClaim(orgID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, domain.EndVendorClaim, event.Type())
+		_, err = cxt.registry.OrganizationById(orgID)
+		assert.Error(t, err)
+		_, err = cxt.registry.VendorClaim(orgID, "org", nil)
+		assert.Error(t, err)
+	})
+	t.Run("retire vendor", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		if !setup(t, &cxt) {
+			return
+		}
+		vendorID := core.NutsConfig().VendorID()
+		event, err := cxt.registry.RetireVendor(vendorID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, domain.RetireVendor, event.Type())
+		assert.NotEmpty(t, event.Signature())
+		_, err = cxt.registry.VendorById(vendorID)
+		assert.Equal(t, ErrVendorNotFound, err)
+		_, err = cxt.registry.OrganizationById(orgID)
+		assert.Error(t, err)
+		_, err = cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
+		assert.Error(t, err)
+	})
+	t.Run("retire vendor - vendor not found", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		_, err := cxt.registry.RetireVendor(test.VendorID("other"))
+		assert.Equal(t, ErrVendorNotFound, err)
+	})
+}
@@ -0,0 +1,74 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package pkg
+
+import (
+	"sync"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+)
+
+// digestCache holds the last calculated state digest, until an event is applied.
+type digestCache struct {
+	subscribe sync.Once
+	mux       sync.Mutex
+	digest    *digest.Digest
+	// generation is incremented every time the cache is invalidated, so a digest calculated while an event was being
+	// applied isn't cached.
+	generation uint64
+}
+
+func (c *digestCache) invalidate(_ events.Event) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.digest = nil
+	c.generation++
+}
+
+// Digest calculates the state digest over all applied events. The refs of the events per vendor are only included when
+// includeRefs is true. Since calculating it requires all events, the digest is cached until an event is applied.
+func (r *Registry) Digest(includeRefs bool) (*digest.Digest, error) {
+	cache := &r.digestCache
+	cache.subscribe.Do(func() {
+		r.EventSystem.Subscribe(cache.invalidate)
+	})
+	cache.mux.Lock()
+	cached := cache.digest
+	generation := cache.generation
+	cache.mux.Unlock()
+	if cached == nil {
+		evts, err := r.EventSystem.EventsAfter(nil)
+		if err != nil {
+			return nil, err
+		}
+		calculated := digest.Calculate(evts)
+		cached = &calculated
+		cache.mux.Lock()
+		if cache.generation == generation {
+			cache.digest = cached
+		}
+		cache.mux.Unlock()
+	}
+	result := *cached
+	if !includeRefs {
+		result = result.WithoutRefs()
+	}
+	return &result, nil
+}
@@ -0,0 +1,286 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+// Package digest calculates Merkle-style digests over the refs of applied events, which allow cheaply checking whether two
+// registries (or a registry and an export bundle) hold the same events.
+package digest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+)
+
+// Prefixes of the hashed data of leaf and interior nodes of the Merkle tree, so a leaf can't be presented as interior node.
+const (
+	leafPrefix     byte = 0
+	interiorPrefix byte = 1
+)
+
+// Digest is the state digest of a set of events. The events are grouped by the vendor they concern, each group forming a
+// subtree whose root is the vendor's digest. Since the refs are ordered before hashing, the digest doesn't depend on the
+// order in which the events were applied.
+type Digest struct {
+	// Root is the hex-encoded Merkle root over the vendor digests.
+	Root string `json:"root"`
+	// EventCount is the number of events the digest has been calculated over.
+	EventCount int `json:"eventCount"`
+	// Vendors holds the digests of the vendor subtrees, ordered by vendor ID.
+	Vendors []VendorDigest `json:"vendors"`
+}
+
+// VendorDigest is the digest of the events concerning a single vendor: its own events and the events of the organizations
+// it claimed (including their endpoints).
+type VendorDigest struct {
+	// Vendor is the ID of the vendor, zero for events which can't be attributed to a vendor.
+	Vendor core.PartyID `json:"vendor"`
+	// Digest is the hex-encoded Merkle root over the refs of the vendor's events.
+	Digest string `json:"digest"`
+	// EventCount is the number of events of the vendor.
+	EventCount int `json:"eventCount"`
+	// Refs holds the (ordered) refs of the vendor's events. It's only filled when requested (see WithoutRefs).
+	Refs []events.Ref `json:"refs,omitempty"`
+}
+
+// Calculate calculates the digest over the given events, including the refs of the events per vendor.
+func Calculate(evts []events.Event) Digest {
+	organizations := organizationVendors(evts)
+	refs := make(map[string][]events.Ref)
+	vendors := make(map[string]core.PartyID)
+	for _, event := range evts {
+		vendorID := vendorOf(event, organizations)
+		refs[vendorID.String()] = append(refs[vendorID.String()], event.Ref())
+		vendors[vendorID.String()] = vendorID
+	}
+	result := Digest{EventCount: len(evts), Vendors: make([]VendorDigest, 0, len(vendors))}
+	for key, vendorID := range vendors {
+		vendorRefs := refs[key]
+		sort.Slice(vendorRefs, func(i, j int) bool {
+			return bytes.Compare(vendorRefs[i], vendorRefs[j]) < 0
+		})
+		leaves := make([][]byte, len(vendorRefs))
+		for i, ref := range vendorRefs {
+			leaves[i] = ref
+		}
+		result.Vendors = append(result.Vendors, VendorDigest{
+			Vendor:     vendorID,
+			Digest:     hex.EncodeToString(merkleRoot(leaves)),
+			EventCount: len(vendorRefs),
+			Refs:       vendorRefs,
+		})
+	}
+	sort.Slice(result.Vendors, func(i, j int) bool {
+		return result.Vendors[i].Vendor.String() < result.Vendors[j].Vendor.String()
+	})
+	leaves := make([][]byte, len(result.Vendors))
+	for i, vendor := range result.Vendors {
+		leaves[i] = []byte(vendor.Vendor.String() + ":" + vendor.Digest)
+	}
+	result.Root = hex.EncodeToString(merkleRoot(leaves))
+	return result
+}
+
+// CalculateFromBundle calculates the digest over the events in the given tar.gz bundle (e.g. created using export).
+func CalculateFromBundle(reader io.Reader) (Digest, error) {
+	files, _, err := bundle.Read(reader)
+	if err != nil {
+		return Digest{}, err
+	}
+	evts := make([]events.Event, 0, len(files))
+	for name, data := range files {
+		event, err := events.EventFromJSON(data)
+		if err != nil {
+			return Digest{}, fmt.Errorf("unable to parse event (file = %s): %w", name, err)
+		}
+		evts = append(evts, event)
+	}
+	return Calculate(evts), nil
+}
+
+// WithoutRefs returns a copy of the digest without the refs of the events, which is considerably smaller.
+func (d Digest) WithoutRefs() Digest {
+	result := d
+	result.Vendors = make([]VendorDigest, len(d.Vendors))
+	for i, vendor := range d.Vendors {
+		vendor.Refs = nil
+		result.Vendors[i] = vendor
+	}
+	return result
+}
+
+// Difference describes an event which is present in only one of the compared digests.
+type Difference struct {
+	Vendor core.PartyID
+	Ref    events.Ref
+	// InLeft indicates the event is only present in the left digest, otherwise it's only present in the right digest.
+	InLeft bool
+}
+
+// Compare lists the events which are present in only one of the given digests. Only the vendors whose digests differ are
+// compared, so the refs are only required for those vendors. The differences are ordered by vendor, then ref.
+func Compare(left Digest, right Digest) []Difference {
+	var result []Difference
+	rightVendors := make(map[string]VendorDigest, len(right.Vendors))
+	for _, vendor := range right.Vendors {
+		rightVendors[vendor.Vendor.String()] = vendor
+	}
+	leftVendors := make(map[string]VendorDigest, len(left.Vendors))
+	for _, vendor := range left.Vendors {
+		leftVendors[vendor.Vendor.String()] = vendor
+		other := rightVendors[vendor.Vendor.String()]
+		if other.Digest != vendor.Digest {
+			result = append(result, diffRefs(vendor.Vendor, vendor.Refs, other.Refs)...)
+		}
+	}
+	for _, vendor := range right.Vendors {
+		if _, exists := leftVendors[vendor.Vendor.String()]; !exists {
+			result = append(result, diffRefs(vendor.Vendor, nil, vendor.Refs)...)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Vendor.String() != result[j].Vendor.String() {
+			return result[i].Vendor.String() < result[j].Vendor.String()
+		}
+		return bytes.Compare(result[i].Ref, result[j].Ref) < 0
+	})
+	return result
+}
+
+func diffRefs(vendorID core.PartyID, left []events.Ref, right []events.Ref) []Difference {
+	var result []Difference
+	inRight := make(map[string]bool, len(right))
+	for _, ref := range right {
+		inRight[ref.String()] = true
+	}
+	inLeft := make(map[string]bool, len(left))
+	for _, ref := range left {
+		inLeft[ref.String()] = true
+		if !inRight[ref.String()] {
+			result = append(result, Difference{Vendor: vendorID, Ref: ref, InLeft: true})
+		}
+	}
+	for _, ref := range right {
+		if !inLeft[ref.String()] {
+			result = append(result, Difference{Vendor: vendorID, Ref: ref})
+		}
+	}
+	return result
+}
+
+// merkleRoot calculates the root of the Merkle tree over the given leaves. When a level has an odd number of nodes, the
+// last node is promoted to the next level. The root of an empty tree is the hash of no data.
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = hash(leafPrefix, leaf)
+	}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hash(interiorPrefix, level[i], level[i+1]))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func hash(prefix byte, parts ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefix})
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// organizationVendors determines the vendor of every organization claimed by the given events. When an organization has
+// been claimed by multiple vendors, the first claim (by moment of issuance, then ref) is used so the outcome doesn't
+// depend on the order of the events.
+func organizationVendors(evts []events.Event) map[string]core.PartyID {
+	result := make(map[string]core.PartyID)
+	firstClaims := make(map[string]events.Event)
+	for _, event := range evts {
+		if event.Type() != domain.VendorClaim {
+			continue
+		}
+		payload := domain.VendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			continue
+		}
+		key := payload.OrganizationID.String()
+		if first := firstClaims[key]; first == nil || precedes(event, first) {
+			firstClaims[key] = event
+			result[key] = payload.VendorID
+		}
+	}
+	return result
+}
+
+func precedes(a events.Event, b events.Event) bool {
+	if !a.IssuedAt().Equal(b.IssuedAt()) {
+		return a.IssuedAt().Before(b.IssuedAt())
+	}
+	return bytes.Compare(a.Ref(), b.Ref()) < 0
+}
+
+// vendorOf returns the vendor the event concerns, zero when it can't be determined.
+func vendorOf(event events.Event, organizations map[string]core.PartyID) core.PartyID {
+	switch event.Type() {
+	case domain.RegisterVendor:
+		payload := domain.RegisterVendorEvent{}
+		_ = event.Unmarshal(&payload)
+		return payload.Identifier
+	case domain.RetireVendor:
+		payload := domain.RetireVendorEvent{}
+		_ = event.Unmarshal(&payload)
+		return payload.Identifier
+	case domain.VendorClaim:
+		payload := domain.VendorClaimEvent{}
+		_ = event.Unmarshal(&payload)
+		return payload.VendorID
+	case domain.EndVendorClaim:
+		payload := domain.EndVendorClaimEvent{}
+		_ = event.Unmarshal(&payload)
+		return payload.VendorID
+	case domain.RegisterEndpoint:
+		payload := domain.RegisterEndpointEvent{}
+		_ = event.Unmarshal(&payload)
+		return organizations[payload.Organization.String()]
+	case domain.DeregisterEndpoint:
+		payload := domain.DeregisterEndpointEvent{}
+		_ = event.Unmarshal(&payload)
+		return organizations[payload.Organization.String()]
+	}
+	return core.PartyID{}
+}
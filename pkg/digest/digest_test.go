@@ -0,0 +1,162 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package digest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculate(t *testing.T) {
+	evts := testEvents()
+	t.Run("ok", func(t *testing.T) {
+		result := Calculate(evts)
+		assert.Equal(t, 5, result.EventCount)
+		assert.Len(t, result.Root, 64)
+		if !assert.Len(t, result.Vendors, 2) {
+			return
+		}
+		assert.Equal(t, test.VendorID("a"), result.Vendors[0].Vendor)
+		assert.Equal(t, 3, result.Vendors[0].EventCount)
+		assert.Len(t, result.Vendors[0].Refs, 3)
+		assert.Equal(t, test.VendorID("b"), result.Vendors[1].Vendor)
+		assert.Equal(t, 2, result.Vendors[1].EventCount)
+	})
+	t.Run("order independent", func(t *testing.T) {
+		reversed := make([]events.Event, len(evts))
+		for i, event := range evts {
+			reversed[len(evts)-1-i] = event
+		}
+		assert.Equal(t, Calculate(evts), Calculate(reversed))
+	})
+	t.Run("different events yield different digests", func(t *testing.T) {
+		expected := Calculate(evts)
+		actual := Calculate(evts[:4])
+		assert.NotEqual(t, expected.Root, actual.Root)
+		assert.Equal(t, expected.Vendors[0].Digest, actual.Vendors[0].Digest)
+		assert.NotEqual(t, expected.Vendors[1].Digest, actual.Vendors[1].Digest)
+	})
+	t.Run("no events", func(t *testing.T) {
+		result := Calculate(nil)
+		assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", result.Root)
+		assert.Empty(t, result.Vendors)
+	})
+	t.Run("endpoint of unclaimed organization", func(t *testing.T) {
+		result := Calculate([]events.Event{
+			events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: test.OrganizationID("other"), Identifier: "e"}, nil),
+		})
+		if !assert.Len(t, result.Vendors, 1) {
+			return
+		}
+		assert.True(t, result.Vendors[0].Vendor.IsZero())
+	})
+}
+
+func TestDigest_WithoutRefs(t *testing.T) {
+	expected := Calculate(testEvents())
+	actual := expected.WithoutRefs()
+	assert.Equal(t, expected.Root, actual.Root)
+	assert.Nil(t, actual.Vendors[0].Refs)
+	assert.NotNil(t, expected.Vendors[0].Refs)
+}
+
+func TestCompare(t *testing.T) {
+	evts := testEvents()
+	t.Run("identical", func(t *testing.T) {
+		assert.Empty(t, Compare(Calculate(evts), Calculate(evts)))
+	})
+	t.Run("event missing on the right", func(t *testing.T) {
+		differences := Compare(Calculate(evts), Calculate(evts[:4]))
+		if !assert.Len(t, differences, 1) {
+			return
+		}
+		assert.Equal(t, test.VendorID("b"), differences[0].Vendor)
+		assert.Equal(t, evts[4].Ref(), differences[0].Ref)
+		assert.True(t, differences[0].InLeft)
+	})
+	t.Run("vendor missing on the left", func(t *testing.T) {
+		differences := Compare(Calculate(evts[:3]), Calculate(evts))
+		if !assert.Len(t, differences, 2) {
+			return
+		}
+		for _, difference := range differences {
+			assert.Equal(t, test.VendorID("b"), difference.Vendor)
+			assert.False(t, difference.InLeft)
+		}
+	})
+}
+
+func TestCalculateFromBundle(t *testing.T) {
+	evts := testEvents()
+	t.Run("ok", func(t *testing.T) {
+		files := make(map[string][]byte)
+		for i, event := range evts {
+			files[fmt.Sprintf("events/%d.json", i)] = event.Marshal()
+		}
+		result, err := CalculateFromBundle(bytes.NewReader(createBundle(t, files)))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, Calculate(evts), result)
+	})
+	t.Run("error - invalid event", func(t *testing.T) {
+		_, err := CalculateFromBundle(bytes.NewReader(createBundle(t, map[string][]byte{"events/1.json": []byte("{}")})))
+		assert.Contains(t, err.Error(), "unable to parse event (file = events/1.json)")
+	})
+	t.Run("error - invalid bundle", func(t *testing.T) {
+		_, err := CalculateFromBundle(bytes.NewReader([]byte("foo")))
+		assert.Error(t, err)
+	})
+}
+
+// testEvents creates the events of two vendors, vendor A having claimed an organization which registered an endpoint.
+func testEvents() []events.Event {
+	orgID := test.OrganizationID("org")
+	return []events.Event{
+		events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: test.VendorID("a"), Name: "A"}, nil),
+		events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{VendorID: test.VendorID("a"), OrganizationID: orgID, OrgName: "Org"}, nil),
+		events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: orgID, Identifier: "e"}, nil),
+		events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: test.VendorID("b"), Name: "B"}, nil),
+		events.CreateEvent(domain.RetireVendor, domain.RetireVendorEvent{Identifier: test.VendorID("b")}, nil),
+	}
+}
+
+func createBundle(t *testing.T, files map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, data := range files {
+		if !assert.NoError(t, bundle.WriteEntry(tarWriter, name, data, time.Now())) {
+			t.FailNow()
+		}
+	}
+	_ = tarWriter.Close()
+	_ = gzipWriter.Close()
+	return buf.Bytes()
+}
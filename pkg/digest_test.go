@@ -0,0 +1,90 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Digest(t *testing.T) {
+	cxt := createTestContext(t)
+	defer cxt.close()
+	empty, err := cxt.registry.Digest(false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.VendorClaim(test.OrganizationID("org"), "org", nil); !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("with refs", func(t *testing.T) {
+		actual, err := cxt.registry.Digest(true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEqual(t, empty.Root, actual.Root)
+		assert.Equal(t, 2, actual.EventCount)
+		if !assert.Len(t, actual.Vendors, 1) {
+			return
+		}
+		assert.Equal(t, vendorId, actual.Vendors[0].Vendor)
+		assert.Len(t, actual.Vendors[0].Refs, 2)
+	})
+	t.Run("without refs", func(t *testing.T) {
+		actual, err := cxt.registry.Digest(false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 2, actual.Vendors[0].EventCount)
+		assert.Nil(t, actual.Vendors[0].Refs)
+	})
+	t.Run("diagnostics", func(t *testing.T) {
+		actual, _ := cxt.registry.Digest(false)
+		var titles []string
+		for _, diagnostic := range cxt.registry.Diagnostics() {
+			titles = append(titles, diagnostic.Name())
+			if diagnostic.Name() == "State digest" {
+				assert.Equal(t, actual.Root+" (events = 2, vendors = 1)", diagnostic.String())
+			}
+		}
+		assert.Contains(t, titles, "State digest")
+	})
+	t.Run("cached until an event is applied", func(t *testing.T) {
+		first, _ := cxt.registry.Digest(true)
+		second, _ := cxt.registry.Digest(true)
+		assert.Equal(t, first, second)
+		if _, err := cxt.registry.RegisterEndpoint(test.OrganizationID("org"), "endpoint", "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+			return
+		}
+		actual, err := cxt.registry.Digest(true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEqual(t, first.Root, actual.Root)
+		assert.Equal(t, 3, actual.EventCount)
+		assert.Len(t, actual.Vendors[0].Refs, 3)
+	})
+}
@@ -0,0 +1,275 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	errors2 "github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+// bboltFileName is the name of the bbolt database file, which is stored in the events directory.
+const bboltFileName = "events.db"
+
+// bboltOpenTimeout specifies how long to wait for the lock on the database file, which is held by other processes
+// that opened the database.
+const bboltOpenTimeout = 5 * time.Second
+
+var (
+	// eventsBucket contains the events in order of arrival, keyed by sequence number.
+	eventsBucket = []byte("events")
+	// refsBucket indexes the events by ref; it maps the event ref to the key of the event in eventsBucket.
+	refsBucket = []byte("refs")
+	// filesBucket contains the names of the event files which have been imported into the store.
+	filesBucket = []byte("files")
+)
+
+// storedEvent is the structure in which events are stored in the database and in snapshots. IssuedAt is stored alongside
+// the event data since it isn't present in the JSON of v0 events (it's taken from the file name instead).
+type storedEvent struct {
+	IssuedAt time.Time       `json:"issuedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// bboltEventSystem is an EventSystem that stores events in an embedded bbolt key/value store instead of a file per event.
+// Events are stored before they're processed, so an event which can't be applied yet (and is parked in the retry queue)
+// isn't lost when it's applied later on. Event files in the events directory (e.g. downloaded through Github sync) are
+// imported into the store when events are (re)loaded.
+type bboltEventSystem struct {
+	*diskEventSystem
+	db *bbolt.DB
+	// replayed indicates whether the events in the store have been applied
+	replayed bool
+}
+
+// NewBBoltEventSystem creates and initializes a new event system which stores events in a bbolt database.
+func NewBBoltEventSystem(eventTypes ...EventType) EventSystem {
+	return &bboltEventSystem{diskEventSystem: newDiskEventSystem(eventTypes...)}
+}
+
+func (system *bboltEventSystem) Configure(location string) error {
+	if err := system.diskEventSystem.Configure(location); err != nil {
+		return err
+	}
+	db, err := bbolt.Open(normalizeLocation(location, bboltFileName), 0600, &bbolt.Options{Timeout: bboltOpenTimeout})
+	if err != nil {
+		return errors2.Wrap(err, "unable to open event store")
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{eventsBucket, refsBucket, filesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return errors2.Wrap(err, "unable to initialize event store")
+	}
+	system.db = db
+	return nil
+}
+
+func (system *bboltEventSystem) Close() error {
+	if system.db == nil {
+		return nil
+	}
+	// Close the store after the event being applied (if any), so it isn't closed halfway a transaction
+	err := system.pipeline.submit(func() error {
+		err := system.db.Close()
+		system.db = nil
+		return err
+	})
+	system.pipeline.stop()
+	return err
+}
+
+func (system *bboltEventSystem) Diagnostics() []core.DiagnosticResult {
+	var count int
+	if system.db != nil {
+		_ = system.db.View(func(tx *bbolt.Tx) error {
+			count = tx.Bucket(refsBucket).Stats().KeyN
+			return nil
+		})
+	}
+	return append(system.diskEventSystem.Diagnostics(), &core.GenericDiagnosticResult{
+		Title:   "Number of events in store",
+		Outcome: fmt.Sprintf("%d", count),
+	})
+}
+
+// PublishEvent stores the event and then processes it. The event is stored regardless of the outcome: when it can't be
+// processed it's parked in the retry queue, and when it's stored it's applied again after a restart.
+func (system *bboltEventSystem) PublishEvent(event Event) error {
+	if err := system.assertConfigured(); err != nil {
+		return err
+	}
+	return system.submit(func() error {
+		if !system.isEventType(event.Type()) {
+			return fmt.Errorf("unknown event type: %s", event.Type())
+		}
+		err := system.db.Update(func(tx *bbolt.Tx) error {
+			return appendEvent(tx, event)
+		})
+		if err != nil {
+			return errors2.Wrap(err, "unable to store event")
+		}
+		return system.handleEvent(event)
+	})
+}
+
+// LoadAndApplyEvents applies the events in the store (only on first invocation) followed by any new event files in the
+// events directory, which are imported into the store.
+func (system *bboltEventSystem) LoadAndApplyEvents() error {
+	if err := system.assertConfigured(); err != nil {
+		return err
+	}
+	return system.submit(system.loadAndApplyEvents)
+}
+
+func (system *bboltEventSystem) loadAndApplyEvents() error {
+	if !system.replayed {
+		if err := system.replay(); err != nil {
+			return err
+		}
+		system.replayed = true
+	}
+	if err := system.importEventFiles(); err != nil {
+		return err
+	}
+	// Parked events which aren't in the store (e.g. received from other nodes) are retried as well
+	system.retryEvents()
+	return nil
+}
+
+func (system *bboltEventSystem) replay() error {
+	return system.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(key, value []byte) error {
+			stored := storedEvent{}
+			if err := json.Unmarshal(value, &stored); err != nil {
+				return errors2.Wrapf(err, "unable to read stored event (seq = %d)", binary.BigEndian.Uint64(key))
+			}
+			event, err := EventFromJSONWithIssuedAt(stored.Data, stored.IssuedAt)
+			if errors.Is(err, ErrInvalidRef) {
+				system.reject(fmt.Sprintf("stored event (seq = %d)", binary.BigEndian.Uint64(key)), err)
+				return nil
+			} else if err != nil {
+				return errors2.Wrapf(err, "unable to parse stored event (seq = %d)", binary.BigEndian.Uint64(key))
+			}
+			system.applyStoredEvent(event)
+			return nil
+		})
+	})
+}
+
+// applyStoredEvent applies an event from the store. Since events are stored regardless of whether they could be
+// processed, failing to apply it doesn't fail loading the events: the event is parked in the retry queue instead.
+func (system *bboltEventSystem) applyStoredEvent(event Event) {
+	if err := system.handleEvent(event); err != nil {
+		logging.Log().Warnf("Stored event %s couldn't be applied, it will be retried later: %v", event.Ref(), err)
+	}
+}
+
+func (system *bboltEventSystem) importEventFiles() error {
+	entries, err := ioutil.ReadDir(system.location)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !isJSONFile(entry) || system.isFileImported(entry.Name()) {
+			continue
+		}
+		logging.Log().Debugf("Importing event: %s", entry.Name())
+		matches := eventFileRegex.FindStringSubmatch(entry.Name())
+		if len(matches) != 3 {
+			return fmt.Errorf("file does not match event file name format (file = %s, expected format = %s)", entry.Name(), eventFileFormat)
+		}
+		event, err := readEvent(normalizeLocation(system.location, entry.Name()), matches[1])
+		if errors.Is(err, ErrInvalidRef) {
+			system.reject(entry.Name(), err)
+			continue
+		} else if err != nil {
+			return errors2.Wrapf(err, "error reading event: %s", entry.Name())
+		}
+		if !system.isEventType(event.Type()) {
+			return fmt.Errorf("error while importing event (event = %s): unknown event type: %s", entry.Name(), event.Type())
+		}
+		err = system.db.Update(func(tx *bbolt.Tx) error {
+			if err := appendEvent(tx, event); err != nil {
+				return err
+			}
+			return tx.Bucket(filesBucket).Put([]byte(entry.Name()), event.Ref())
+		})
+		if err != nil {
+			return errors2.Wrapf(err, "unable to store event (event = %s)", entry.Name())
+		}
+		system.applyStoredEvent(event)
+	}
+	return nil
+}
+
+func (system *bboltEventSystem) isFileImported(name string) bool {
+	var imported bool
+	_ = system.db.View(func(tx *bbolt.Tx) error {
+		imported = tx.Bucket(filesBucket).Get([]byte(name)) != nil
+		return nil
+	})
+	return imported
+}
+
+func (system *bboltEventSystem) assertConfigured() error {
+	if system.db == nil {
+		return ErrEventSystemNotConfigured
+	}
+	return system.diskEventSystem.assertConfigured()
+}
+
+// appendEvent appends the event to the store, unless an event with the same ref is already stored.
+func appendEvent(tx *bbolt.Tx, event Event) error {
+	ref := event.Ref()
+	refs := tx.Bucket(refsBucket)
+	if refs.Get(ref) != nil {
+		return nil
+	}
+	data, err := json.Marshal(storedEvent{IssuedAt: event.IssuedAt(), Data: event.Marshal()})
+	if err != nil {
+		return err
+	}
+	bucket := tx.Bucket(eventsBucket)
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	if err := bucket.Put(key, data); err != nil {
+		return err
+	}
+	return refs.Put(ref, key)
+}
@@ -0,0 +1,291 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBBoltEventSystem_PublishEvent(t *testing.T) {
+	t.Run("ok - events are replayed in order after restart", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		system := NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			return nil
+		})
+		var prev Ref
+		for i := 0; i < 10; i++ {
+			event := CreateTestEvent(eventType, i, prev, time.Unix(int64(10000+i), 0))
+			if !assert.NoError(t, system.PublishEvent(event)) {
+				return
+			}
+			prev = event.Ref()
+		}
+		// No event files should be written
+		entries, _ := ioutil.ReadDir(dir)
+		assert.Len(t, entries, 1)
+		if !assert.NoError(t, system.Close()) {
+			return
+		}
+
+		system = NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		defer system.Close()
+		var applied []int
+		system.RegisterEventHandler(eventType, func(event Event, _ EventLookup) error {
+			var value int
+			if err := event.Unmarshal(&value); err != nil {
+				return err
+			}
+			applied = append(applied, value)
+			return nil
+		})
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, applied)
+		assert.Equal(t, prev, system.Get(prev).Ref())
+		// Loading again shouldn't replay the events
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Len(t, applied, 10)
+	})
+	t.Run("ok - event which failed is stored and applied after retry", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		system := NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		fail := true
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			if fail {
+				return errors.New("failed")
+			}
+			return nil
+		})
+		event := CreateEvent(eventType, eventPayload, nil)
+		err := system.PublishEvent(event)
+		assert.EqualError(t, err, "failed")
+		diagnostics := system.Diagnostics()
+		assert.Equal(t, "1", diagnostics[len(diagnostics)-1].String())
+		// Succeeds from the retry queue
+		fail = false
+		if !assert.NoError(t, system.RetryParkedEvent(event.Ref())) {
+			return
+		}
+		if !assert.NoError(t, system.Close()) {
+			return
+		}
+
+		// Restart: the event is replayed from the store
+		system = NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		defer system.Close()
+		applied := 0
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			applied++
+			return nil
+		})
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 1, applied)
+		assert.NotNil(t, system.Get(event.Ref()))
+		assert.Empty(t, system.ParkedEvents())
+	})
+	t.Run("ok - stored event which fails when replayed is parked", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		system := NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			return nil
+		})
+		event := CreateEvent(eventType, eventPayload, nil)
+		if !assert.NoError(t, system.PublishEvent(event)) || !assert.NoError(t, system.Close()) {
+			return
+		}
+
+		system = NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(dir)) {
+			return
+		}
+		defer system.Close()
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			return errors.New("failed")
+		})
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Nil(t, system.Get(event.Ref()))
+		assert.Len(t, system.ParkedEvents(), 1)
+	})
+	t.Run("error - unknown event type", func(t *testing.T) {
+		system := NewBBoltEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(io.TestDirectory(t))) {
+			return
+		}
+		defer system.Close()
+		err := system.PublishEvent(CreateEvent("other", eventPayload, nil))
+		assert.EqualError(t, err, "unknown event type: other")
+		diagnostics := system.Diagnostics()
+		assert.Equal(t, "0", diagnostics[len(diagnostics)-1].String())
+	})
+	t.Run("error - not configured", func(t *testing.T) {
+		system := NewBBoltEventSystem(eventType)
+		err := system.PublishEvent(CreateEvent(eventType, eventPayload, nil))
+		assert.Equal(t, ErrEventSystemNotConfigured, err)
+	})
+}
+
+func TestBBoltEventSystem_LoadAndApplyEvents(t *testing.T) {
+	repo, err := test.NewTestRepoFrom(t, "../../test_data/valid_files")
+	if !assert.NoError(t, err) {
+		return
+	}
+	eventsDir := filepath.Join(repo.Directory, "events")
+	system := NewBBoltEventSystem("RegisterVendorEvent", "VendorClaimEvent", "RegisterEndpointEvent")
+	if !assert.NoError(t, system.Configure(eventsDir)) {
+		return
+	}
+	defer system.Close()
+	handled := 0
+	for _, eventType := range []EventType{"RegisterVendorEvent", "VendorClaimEvent", "RegisterEndpointEvent"} {
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			handled++
+			return nil
+		})
+	}
+	t.Run("event files are imported", func(t *testing.T) {
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 5, handled)
+		diagnostics := system.Diagnostics()
+		assert.Len(t, diagnostics, 5)
+		assert.Equal(t, "5", diagnostics[4].String())
+	})
+	t.Run("imported files are skipped", func(t *testing.T) {
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 5, handled)
+	})
+	t.Run("imported events are stored", func(t *testing.T) {
+		// Remove the event files, the events should still be replayed from the store
+		entries, _ := ioutil.ReadDir(eventsDir)
+		for _, entry := range entries {
+			if isJSONFile(entry) {
+				_ = os.Remove(filepath.Join(eventsDir, entry.Name()))
+			}
+		}
+		_ = system.Close()
+		system := NewBBoltEventSystem("RegisterVendorEvent", "VendorClaimEvent", "RegisterEndpointEvent")
+		if !assert.NoError(t, system.Configure(eventsDir)) {
+			return
+		}
+		defer system.Close()
+		replayed := 0
+		for _, eventType := range []EventType{"RegisterVendorEvent", "VendorClaimEvent", "RegisterEndpointEvent"} {
+			system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+				replayed++
+				return nil
+			})
+		}
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 5, replayed)
+		// v0 events have no issuedAt in their JSON, it should be restored from the store
+		assert.Equal(t, "90824e95c6f6be1cbf82bdad5260161be889c0aa", system.Get(mustParseRef("90824e95c6f6be1cbf82bdad5260161be889c0aa")).Ref().String())
+	})
+	t.Run("event file with invalid ref is rejected", func(t *testing.T) {
+		repo, err := test.NewTestRepo(t)
+		if !assert.NoError(t, err) {
+			return
+		}
+		system := NewBBoltEventSystem("Test")
+		if !assert.NoError(t, system.Configure(repo.Directory)) {
+			return
+		}
+		defer system.Close()
+		event := CreateEvent("Test", map[string]interface{}{"Hello": "World"}, nil)
+		tampered := strings.Replace(string(event.Marshal()), "World", "Mars", 1)
+		_ = ioutil.WriteFile(filepath.Join(repo.Directory, SuggestEventFileName(event)), []byte(tampered), os.ModePerm)
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Nil(t, system.Get(event.Ref()))
+		assert.Equal(t, "1", system.Diagnostics()[3].String())
+	})
+	t.Run("error - invalid event file", func(t *testing.T) {
+		repo, err := test.NewTestRepoFrom(t, "../../test_data/invalid_files")
+		if !assert.NoError(t, err) {
+			return
+		}
+		system := NewBBoltEventSystem()
+		if !assert.NoError(t, system.Configure(filepath.Join(repo.Directory, "events"))) {
+			return
+		}
+		defer system.Close()
+		err = system.LoadAndApplyEvents()
+		assert.EqualError(t, err, "error reading event: 20200123091400001-InvalidJson.json: unable to parse event JSON: invalid character '{' looking for beginning of object key string")
+	})
+}
+
+func TestBBoltEventSystem_Configure(t *testing.T) {
+	t.Run("error - location is a file", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		file := filepath.Join(dir, "file")
+		_ = ioutil.WriteFile(file, []byte{}, os.ModePerm)
+		err := NewBBoltEventSystem().Configure(file)
+		assert.Error(t, err)
+	})
+	t.Run("ok - close when not configured", func(t *testing.T) {
+		assert.NoError(t, NewBBoltEventSystem().Close())
+	})
+}
+
+func mustParseRef(input string) Ref {
+	ref, err := ParseRef(input)
+	if err != nil {
+		panic(err)
+	}
+	return ref
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/nuts-foundation/nuts-registry/logging"
 
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	core "github.com/nuts-foundation/nuts-go-core"
 	cert2 "github.com/nuts-foundation/nuts-registry/pkg/cert"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	errors2 "github.com/pkg/errors"
@@ -78,7 +79,26 @@ func (t certificateEventHandler) verify(certificate *x509.Certificate, moment ti
 	return chains[0], nil
 }
 
-func (t *certificateEventHandler) handleEvent(event events.Event, _ events.EventLookup) error {
+// RegisteredCACertificates returns the CA certificates registered by the given event (see
+// events.SignatureValidator.WithEventCertificates): the vendor CA certificates of a RegisterVendorEvent are roots,
+// since vendors (for now) self-sign them, the CA certificates of a VendorClaimEvent are intermediates.
+func RegisteredCACertificates(event events.Event) (roots []*x509.Certificate, intermediates []*x509.Certificate) {
+	switch event.Type() {
+	case RegisterVendor:
+		payload := RegisterVendorEvent{}
+		if err := event.Unmarshal(&payload); err == nil {
+			roots = caCertificates(payload.Keys)
+		}
+	case VendorClaim:
+		payload := VendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err == nil {
+			intermediates = caCertificates(payload.OrgKeys)
+		}
+	}
+	return roots, intermediates
+}
+
+func (t *certificateEventHandler) handleEvent(event events.Event, lookup events.EventLookup) error {
 	certificates := make([]*x509.Certificate, 0)
 	var err error
 	if event.Type() == RegisterVendor {
@@ -87,6 +107,9 @@ func (t *certificateEventHandler) handleEvent(event events.Event, _ events.Event
 		if err = event.Unmarshal(&payload); err != nil {
 			return err
 		}
+		if err = assertVendorNotRetired(payload.Identifier, lookup); err != nil {
+			return errors2.Wrap(err, "certificate problem in RegisterVendor event")
+		}
 		if certificates, err = t.getCertificatesToBeTrusted(payload.Keys, event.IssuedAt(), false); err != nil {
 			return errors2.Wrap(err, "certificate problem in RegisterVendor event")
 		}
@@ -95,6 +118,10 @@ func (t *certificateEventHandler) handleEvent(event events.Event, _ events.Event
 		if err := event.Unmarshal(&payload); err != nil {
 			return err
 		}
+		// Organization certificates are issued by the vendor CA, which isn't trusted anymore when the vendor retired
+		if err = assertVendorNotRetired(payload.VendorID, lookup); err != nil {
+			return errors2.Wrap(err, "certificate problem in VendorClaim event")
+		}
 		if certificates, err = t.getCertificatesToBeTrusted(payload.OrgKeys, event.IssuedAt(), true); err != nil {
 			return errors2.Wrap(err, "certificate problem in VendorClaim event")
 		}
@@ -108,6 +135,23 @@ func (t *certificateEventHandler) handleEvent(event events.Event, _ events.Event
 	return nil
 }
 
+// assertVendorNotRetired returns an error when the vendor with the given ID retired (see RetireVendorEvent), since
+// certificates of retired vendors must not be (re)added to the truststore. Deregistration events (DeregisterEndpoint,
+// EndVendorClaim, RetireVendor) don't contain certificates, so they don't alter the truststore.
+func assertVendorNotRetired(vendorID core.PartyID, lookup events.EventLookup) error {
+	if lookup == nil {
+		return nil
+	}
+	retirement, err := lookup.FindLastEventByKey(RetireVendorEventKey(vendorID), RetireVendorEventMatcher(vendorID))
+	if err != nil {
+		return err
+	}
+	if retirement != nil {
+		return fmt.Errorf("vendor has been retired (id = %s)", vendorID)
+	}
+	return nil
+}
+
 func (t *certificateEventHandler) getCertificatesToBeTrusted(jwks []interface{}, moment time.Time, mustVerify bool) ([]*x509.Certificate, error) {
 	certificates := make([]*x509.Certificate, 0)
 	for _, key := range jwks {
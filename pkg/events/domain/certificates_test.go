@@ -121,6 +121,69 @@ func Test_CertificateEventHandler_HandleEvent(t *testing.T) {
 		roots, _ := handler.trustStore.Roots()
 		assert.Len(t, roots, 0)
 	})
+	t.Run("error - vendor retired", func(t *testing.T) {
+		handler := NewCertificateEventHandler(memoryTrustStore{certPool: x509.NewCertPool()}).(*certificateEventHandler)
+		vendorID := test.VendorID("vendorId")
+		lookup := eventLookupStub{events.CreateEvent(RetireVendor, RetireVendorEvent{Identifier: vendorID}, nil)}
+		err := handler.handleEvent(events.CreateEvent(RegisterVendor, RegisterVendorEvent{Identifier: vendorID}, nil), lookup)
+		assert.EqualError(t, err, "certificate problem in RegisterVendor event: vendor has been retired (id = urn:oid:1.3.6.1.4.1.54851.4:vendorId)")
+		err = handler.handleEvent(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: vendorID}, nil), lookup)
+		assert.EqualError(t, err, "certificate problem in VendorClaim event: vendor has been retired (id = urn:oid:1.3.6.1.4.1.54851.4:vendorId)")
+		err = handler.handleEvent(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: test.VendorID("other")}, nil), lookup)
+		assert.NoError(t, err)
+	})
+}
+
+// eventLookupStub is an events.EventLookup which holds standalone events (events without previous events) only.
+type eventLookupStub []events.Event
+
+func (e eventLookupStub) Get(ref events.Ref) events.Event {
+	for _, event := range e {
+		if event.Ref().Equal(ref) {
+			return event
+		}
+	}
+	return nil
+}
+
+func (e eventLookupStub) FindLastEvent(matcher events.EventMatcher) (events.Event, error) {
+	path, err := e.FindEventPath(matcher)
+	if len(path) == 0 {
+		return nil, err
+	}
+	return path[len(path)-1], err
+}
+
+func (e eventLookupStub) FindLastEventByKey(_ events.EntityKey, matcher events.EventMatcher) (events.Event, error) {
+	return e.FindLastEvent(matcher)
+}
+
+func (e eventLookupStub) FindEventPathByKey(_ events.EntityKey, matcher events.EventMatcher) ([]events.Event, error) {
+	return e.FindEventPath(matcher)
+}
+
+// FindEventsByKey returns all events of the key's event type, so callers should match the events themselves.
+func (e eventLookupStub) FindEventsByKey(key events.EntityKey) []events.Event {
+	var result []events.Event
+	for _, event := range e {
+		if event.Type() == key.EventType {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func (e eventLookupStub) FindEventPath(matcher events.EventMatcher) ([]events.Event, error) {
+	var result []events.Event
+	for _, event := range e {
+		if matcher(event) {
+			result = append(result, event)
+		}
+	}
+	if len(result) > 1 {
+		return nil, errors.New("multiple event paths match")
+	}
+	return result, nil
 }
 
 func Test_CertificateEventHandler_RegisterEventHandlers(t *testing.T) {
@@ -177,6 +240,27 @@ func Test_CertificateEventHandler_Verify(t *testing.T) {
 	})
 }
 
+func TestRegisteredCACertificates(t *testing.T) {
+	vendorCA := newTestVendorCA(t, test.VendorID("vendor"))
+	orgCSR, _ := cert2.OrganisationCertificateRequest("Vendor", test.OrganizationID("org"), "Org", "healthcare")
+	orgCA := vendorCA.issue(t, orgCSR)
+	t.Run("ok - register vendor", func(t *testing.T) {
+		roots, intermediates := RegisteredCACertificates(events.CreateEvent(RegisterVendor, RegisterVendorEvent{Identifier: test.VendorID("vendor"), Keys: []interface{}{certToMap(vendorCA.certificate)}}, nil))
+		assert.Equal(t, []*x509.Certificate{vendorCA.certificate}, roots)
+		assert.Empty(t, intermediates)
+	})
+	t.Run("ok - vendor claim", func(t *testing.T) {
+		roots, intermediates := RegisteredCACertificates(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: test.VendorID("vendor"), OrganizationID: test.OrganizationID("org"), OrgKeys: []interface{}{certToMap(orgCA.certificate)}}, nil))
+		assert.Empty(t, roots)
+		assert.Equal(t, []*x509.Certificate{orgCA.certificate}, intermediates)
+	})
+	t.Run("ok - other event", func(t *testing.T) {
+		roots, intermediates := RegisteredCACertificates(events.CreateEvent(RetireVendor, RetireVendorEvent{}, nil))
+		assert.Empty(t, roots)
+		assert.Empty(t, intermediates)
+	})
+}
+
 func certToMap(certificate *x509.Certificate) map[string]interface{} {
 	key, _ := cert.CertificateToJWK(certificate)
 	keyAsMap, _ := cert.JwkToMap(key)
@@ -0,0 +1,36 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+import (
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+)
+
+// DeregisterEndpoint event type
+const DeregisterEndpoint events.EventType = "DeregisterEndpointEvent"
+
+// DeregisterEndpointEvent event, which deregisters an endpoint of an organization. It doesn't refer to a previous event;
+// once deregistered, the endpoint (identified by organization and identifier) can't be registered or updated again.
+type DeregisterEndpointEvent struct {
+	Organization core.PartyID     `json:"organization"`
+	Identifier   types.EndpointID `json:"identifier"`
+}
@@ -0,0 +1,38 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+import (
+	"time"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+)
+
+// EndVendorClaim event type
+const EndVendorClaim events.EventType = "EndVendorClaimEvent"
+
+// EndVendorClaimEvent event, which ends a vendor's claim on an organization (registered by a VendorClaimEvent) at the
+// specified moment. It doesn't refer to a previous event; once ended, the claim can't be extended or renewed by the same vendor.
+type EndVendorClaimEvent struct {
+	VendorID       core.PartyID `json:"vendorIdentifier"`
+	OrganizationID core.PartyID `json:"orgIdentifier"`
+	End            time.Time    `json:"end"`
+}
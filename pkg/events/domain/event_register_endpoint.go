@@ -19,6 +19,10 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	core "github.com/nuts-foundation/nuts-go-core"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/types"
@@ -27,12 +31,75 @@ import (
 // RegisterEndpoint event type
 const RegisterEndpoint events.EventType = "RegisterEndpointEvent"
 
+// EndpointStatusActive indicates the endpoint is in use.
+const EndpointStatusActive = "active"
+
+// EndpointStatusMaintenance indicates the endpoint is temporarily out of use, e.g. during an upgrade.
+const EndpointStatusMaintenance = "maintenance"
+
+// EndpointStatusDeprecated indicates the endpoint can still be used, but is going to be replaced. Combined with
+// NotAfter (and a replacing endpoint with NotBefore) it announces a migration to another endpoint.
+const EndpointStatusDeprecated = "deprecated"
+
+// EndpointStatusDisabled indicates the endpoint is out of use.
+const EndpointStatusDisabled = "disabled"
+
+// EndpointStatuses returns all valid statuses of an endpoint.
+func EndpointStatuses() []string {
+	return []string{EndpointStatusActive, EndpointStatusMaintenance, EndpointStatusDeprecated, EndpointStatusDisabled}
+}
+
+// IsEndpointStatus returns whether the given status is one of the EndpointStatuses.
+func IsEndpointStatus(status string) bool {
+	for _, curr := range EndpointStatuses() {
+		if curr == status {
+			return true
+		}
+	}
+	return false
+}
+
 // RegisterEndpointEvent event
 type RegisterEndpointEvent struct {
-	Organization core.PartyID      `json:"organization"`
-	URL          string            `json:"URL"`
-	EndpointType string            `json:"endpointType"`
-	Identifier   types.EndpointID  `json:"identifier"`
-	Status       string            `json:"status"`
-	Properties   map[string]string `json:"properties,omitempty"`
+	Organization core.PartyID     `json:"organization"`
+	URL          string           `json:"URL"`
+	EndpointType string           `json:"endpointType"`
+	Identifier   types.EndpointID `json:"identifier"`
+	Status       string           `json:"status"`
+	// NotBefore is the moment from which the endpoint is available. When not set, it's available right away.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// NotAfter is the moment from which the endpoint is no longer available. When not set, it stays available.
+	NotAfter   *time.Time        `json:"notAfter,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// Validate checks that the status of the endpoint is one of the EndpointStatuses and that its availability window
+// isn't empty.
+func (e RegisterEndpointEvent) Validate() error {
+	if !IsEndpointStatus(e.Status) {
+		return fmt.Errorf("invalid endpoint status: %s (expected one of: %s)", e.Status, strings.Join(EndpointStatuses(), ", "))
+	}
+	if e.NotBefore != nil && e.NotAfter != nil && !e.NotAfter.After(*e.NotBefore) {
+		return fmt.Errorf("endpoint's notAfter (%s) must be after its notBefore (%s)", e.NotAfter.Format(time.RFC3339), e.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// IsAvailable returns whether the given moment lies within the availability window (NotBefore and NotAfter) of the
+// endpoint. It doesn't take the status of the endpoint into account.
+func (e RegisterEndpointEvent) IsAvailable(moment time.Time) bool {
+	return (e.NotBefore == nil || !moment.Before(*e.NotBefore)) && (e.NotAfter == nil || moment.Before(*e.NotAfter))
+}
+
+// EndpointEventMatcher returns an EventMatcher which matches the RegisterEndpointEvent for the endpoint with the
+// specified ID of the specified organization.
+func EndpointEventMatcher(organizationID core.PartyID, endpointID types.EndpointID) events.EventMatcher {
+	return func(event events.Event) bool {
+		if event.Type() != RegisterEndpoint {
+			return false
+		}
+		var payload = RegisterEndpointEvent{}
+		_ = event.Unmarshal(&payload)
+		return endpointID == payload.Identifier && organizationID == payload.Organization
+	}
 }
@@ -19,9 +19,11 @@
 package domain
 
 import (
+	"testing"
+	"time"
+
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestRegisterEndpointEvent(t *testing.T) {
@@ -36,3 +38,48 @@ func TestRegisterEndpointEvent(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterEndpointEvent_Validate(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	t.Run("ok", func(t *testing.T) {
+		for _, status := range EndpointStatuses() {
+			assert.NoError(t, RegisterEndpointEvent{Status: status}.Validate())
+		}
+	})
+	t.Run("ok - availability window", func(t *testing.T) {
+		assert.NoError(t, RegisterEndpointEvent{Status: EndpointStatusActive, NotBefore: &now, NotAfter: &later}.Validate())
+		assert.NoError(t, RegisterEndpointEvent{Status: EndpointStatusActive, NotBefore: &now}.Validate())
+		assert.NoError(t, RegisterEndpointEvent{Status: EndpointStatusActive, NotAfter: &now}.Validate())
+	})
+	t.Run("error - invalid status", func(t *testing.T) {
+		err := RegisterEndpointEvent{Status: "inactive"}.Validate()
+		assert.EqualError(t, err, "invalid endpoint status: inactive (expected one of: active, maintenance, deprecated, disabled)")
+	})
+	t.Run("error - empty availability window", func(t *testing.T) {
+		err := RegisterEndpointEvent{Status: EndpointStatusActive, NotBefore: &later, NotAfter: &now}.Validate()
+		assert.Contains(t, err.Error(), "must be after its notBefore")
+		err = RegisterEndpointEvent{Status: EndpointStatusActive, NotBefore: &now, NotAfter: &now}.Validate()
+		assert.Contains(t, err.Error(), "must be after its notBefore")
+	})
+}
+
+func TestRegisterEndpointEvent_IsAvailable(t *testing.T) {
+	now := time.Now()
+	before := now.Add(-1 * time.Hour)
+	after := now.Add(time.Hour)
+	t.Run("no window", func(t *testing.T) {
+		assert.True(t, RegisterEndpointEvent{}.IsAvailable(now))
+	})
+	t.Run("within window", func(t *testing.T) {
+		assert.True(t, RegisterEndpointEvent{NotBefore: &before, NotAfter: &after}.IsAvailable(now))
+		assert.True(t, RegisterEndpointEvent{NotBefore: &now}.IsAvailable(now))
+	})
+	t.Run("before window", func(t *testing.T) {
+		assert.False(t, RegisterEndpointEvent{NotBefore: &after}.IsAvailable(now))
+	})
+	t.Run("after window", func(t *testing.T) {
+		assert.False(t, RegisterEndpointEvent{NotAfter: &before}.IsAvailable(now))
+		assert.False(t, RegisterEndpointEvent{NotAfter: &now}.IsAvailable(now))
+	})
+}
@@ -69,14 +69,6 @@ func TestRegisterVendorEvent_PostProcessUnmarshal(t *testing.T) {
 		err := event.PostProcessUnmarshal(events.CreateEvent(RegisterVendor, event, nil))
 		assert.NoError(t, err)
 	})
-	t.Run("ok - fallback to healthcare domain", func(t *testing.T) {
-		event := RegisterVendorEvent{}
-		err := event.PostProcessUnmarshal(events.CreateEvent(RegisterVendor, event, nil))
-		if !assert.NoError(t, err) {
-			return
-		}
-		assert.Equal(t, "healthcare", event.Domain)
-	})
 	t.Run("certificate vendor doesn't match", func(t *testing.T) {
 		csr, _ := cert2.VendorCertificateRequest(test.VendorID("def"), "Vendor", "CA", types.HealthcareDomain)
 		cert, _ := test.SelfSignCertificateFromCSR(csr, time.Now(), 2)
@@ -97,10 +89,14 @@ func TestRegisterVendorEvent_PostProcessUnmarshal(t *testing.T) {
 		}
 		keyAsMap := event.Keys[0].(map[string]interface{})
 		keyAsMap["x5c"] = base64.StdEncoding.EncodeToString(keyAsMap["x5c"].(jwk.CertificateChain).Get()[0].Raw)
-		err := event.PostProcessUnmarshal(nil)
+		// The string x5c is converted by the upcaster when unmarshalling the event
+		unmarshalledEvent, _ := events.EventFromJSON(events.CreateEvent(RegisterVendor, event, nil).Marshal())
+		var payload = RegisterVendorEvent{}
+		err := unmarshalledEvent.Unmarshal(&payload)
 		if !assert.NoError(t, err) {
 			return
 		}
+		assert.Len(t, payload.Keys[0].(map[string]interface{})["x5c"], 1)
 	})
 }
 
@@ -0,0 +1,46 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+import (
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+)
+
+// RetireVendor event type
+const RetireVendor events.EventType = "RetireVendorEvent"
+
+// RetireVendorEvent event, which retires a vendor: the vendor and the organizations it claimed are no longer part of
+// the registry. It doesn't refer to a previous event; a retired vendor can't be registered again.
+type RetireVendorEvent struct {
+	Identifier core.PartyID `json:"identifier"`
+}
+
+// RetireVendorEventMatcher returns an EventMatcher which matches the RetireVendorEvent for the vendor with the specified ID.
+func RetireVendorEventMatcher(vendorID core.PartyID) events.EventMatcher {
+	return func(event events.Event) bool {
+		if event.Type() != RetireVendor {
+			return false
+		}
+		var payload = RetireVendorEvent{}
+		_ = event.Unmarshal(&payload)
+		return vendorID == payload.Identifier
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package domain
+
+import (
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+)
+
+func init() {
+	events.RegisterEntityKey(RegisterVendor, func(event events.Event) (events.EntityKey, bool) {
+		payload := RegisterVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.Identifier.IsZero() {
+			return events.EntityKey{}, false
+		}
+		return VendorEventKey(payload.Identifier), true
+	})
+	events.RegisterEntityKey(RetireVendor, func(event events.Event) (events.EntityKey, bool) {
+		payload := RetireVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.Identifier.IsZero() {
+			return events.EntityKey{}, false
+		}
+		return RetireVendorEventKey(payload.Identifier), true
+	})
+	events.RegisterEntityKey(VendorClaim, func(event events.Event) (events.EntityKey, bool) {
+		payload := VendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.OrganizationID.IsZero() {
+			return events.EntityKey{}, false
+		}
+		return OrganizationEventKey(payload.OrganizationID), true
+	})
+	events.RegisterEntityKey(EndVendorClaim, func(event events.Event) (events.EntityKey, bool) {
+		payload := EndVendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.OrganizationID.IsZero() {
+			return events.EntityKey{}, false
+		}
+		return EndVendorClaimEventKey(payload.OrganizationID), true
+	})
+	events.RegisterEntityKey(RegisterEndpoint, func(event events.Event) (events.EntityKey, bool) {
+		payload := RegisterEndpointEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.Organization.IsZero() {
+			return events.EntityKey{}, false
+		}
+		return EndpointEventKey(payload.Organization, payload.Identifier), true
+	})
+}
+
+// VendorEventKey returns the key under which the RegisterVendorEvents of the vendor with the specified ID are indexed.
+func VendorEventKey(vendorID core.PartyID) events.EntityKey {
+	return events.EntityKey{EventType: RegisterVendor, ID: vendorID.String()}
+}
+
+// RetireVendorEventKey returns the key under which the RetireVendorEvent of the vendor with the specified ID is indexed.
+func RetireVendorEventKey(vendorID core.PartyID) events.EntityKey {
+	return events.EntityKey{EventType: RetireVendor, ID: vendorID.String()}
+}
+
+// OrganizationEventKey returns the key under which the VendorClaimEvents of the organization with the specified ID are
+// indexed. Since an organization can be claimed by multiple vendors, the events should be matched using
+// OrganizationEventMatcher to find the claim of a specific vendor.
+func OrganizationEventKey(organizationID core.PartyID) events.EntityKey {
+	return events.EntityKey{EventType: VendorClaim, ID: organizationID.String()}
+}
+
+// EndVendorClaimEventKey returns the key under which the EndVendorClaimEvents of the organization with the specified
+// ID are indexed. Like OrganizationEventKey, the events of all vendors which claimed the organization are indexed under it.
+func EndVendorClaimEventKey(organizationID core.PartyID) events.EntityKey {
+	return events.EntityKey{EventType: EndVendorClaim, ID: organizationID.String()}
+}
+
+// EndpointEventKey returns the key under which the RegisterEndpointEvents of the endpoint with the specified ID of the
+// specified organization are indexed.
+func EndpointEventKey(organizationID core.PartyID, endpointID types.EndpointID) events.EntityKey {
+	return events.EntityKey{EventType: RegisterEndpoint, ID: organizationID.String() + "/" + string(endpointID)}
+}
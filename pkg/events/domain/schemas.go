@@ -0,0 +1,153 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+//go:generate go run schemas/generate.go
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	errors2 "github.com/pkg/errors"
+)
+
+// schemaFileNamePattern matches the file names of the schemas, which are formatted as <event type>.v<version>.json
+var schemaFileNamePattern = regexp.MustCompile(`^([A-Za-z]+)\.v([0-9]+)\.json$`)
+
+// Schema is the JSON schema of the payload of an event type, which applies to events of the given version and up
+// (until a schema with a higher version is present).
+type Schema struct {
+	EventType events.EventType
+	Version   events.Version
+	// Data contains the JSON schema document
+	Data   []byte
+	schema *openapi3.Schema
+}
+
+// PayloadValidationError is returned when the payload of an event doesn't conform to the JSON schema of its type.
+type PayloadValidationError struct {
+	EventType events.EventType
+	// Violations contains the violations of the schema, each prefixed with the JSON pointer of the offending field.
+	Violations []string
+}
+
+func (e PayloadValidationError) Error() string {
+	return fmt.Sprintf("payload of %s doesn't conform to its schema: %s", e.EventType, strings.Join(e.Violations, ", "))
+}
+
+var schemas []Schema
+
+func init() {
+	var err error
+	if schemas, err = loadSchemas(schemaFiles); err != nil {
+		panic(err)
+	}
+}
+
+// Schemas returns the JSON schemas of all event types, sorted by event type and version.
+func Schemas() []Schema {
+	return schemas
+}
+
+// GetSchema returns the JSON schema which applies to events of the given type and version: the schema with the
+// highest version that isn't higher than the given version. If there's no such schema, nil is returned.
+func GetSchema(eventType events.EventType, version events.Version) *Schema {
+	var result *Schema
+	for i, schema := range schemas {
+		if schema.EventType == eventType && schema.Version <= version {
+			result = &schemas[i]
+		}
+	}
+	return result
+}
+
+// ValidatePayload checks the payload of the event against the JSON schema of its type and version. It can be
+// registered as the events.PayloadValidator of the event system.
+func ValidatePayload(event events.Event) error {
+	schema := GetSchema(event.Type(), event.Version())
+	if schema == nil {
+		return fmt.Errorf("no schema for event type: %s (version %d)", event.Type(), event.Version())
+	}
+	var payload interface{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	err := schema.schema.VisitJSON(payload, openapi3.MultiErrors())
+	if err == nil {
+		return nil
+	}
+	result := PayloadValidationError{EventType: event.Type()}
+	for _, schemaErr := range flattenSchemaErrors(err) {
+		result.Violations = append(result.Violations, fmt.Sprintf("/%s: %s", strings.Join(schemaErr.JSONPointer(), "/"), schemaErr.Reason))
+	}
+	if len(result.Violations) == 0 {
+		result.Violations = []string{err.Error()}
+	}
+	return result
+}
+
+func flattenSchemaErrors(err error) []*openapi3.SchemaError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var result []*openapi3.SchemaError
+		for _, child := range e {
+			result = append(result, flattenSchemaErrors(child)...)
+		}
+		return result
+	case *openapi3.SchemaError:
+		return []*openapi3.SchemaError{e}
+	default:
+		return nil
+	}
+}
+
+func loadSchemas(files map[string]string) ([]Schema, error) {
+	var result []Schema
+	for fileName, data := range files {
+		matches := schemaFileNamePattern.FindStringSubmatch(fileName)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid schema file name: %s", fileName)
+		}
+		version, _ := strconv.Atoi(matches[2])
+		schema := &openapi3.Schema{}
+		if err := json.Unmarshal([]byte(data), schema); err != nil {
+			return nil, errors2.Wrapf(err, "unable to parse schema %s", fileName)
+		}
+		result = append(result, Schema{
+			EventType: events.EventType(matches[1]),
+			Version:   events.Version(version),
+			Data:      []byte(data),
+			schema:    schema,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].EventType != result[j].EventType {
+			return result[i].EventType < result[j].EventType
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
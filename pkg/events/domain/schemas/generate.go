@@ -0,0 +1,70 @@
+//go:build ignore
+// +build ignore
+
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+// This program generates schemas_generated.go, which contains the JSON schemas of the event payloads in this
+// directory. It's invoked by go generate in the domain package.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const schemasDir = "schemas"
+const outputFile = "schemas_generated.go"
+
+func main() {
+	files, err := filepath.Glob(filepath.Join(schemasDir, "*.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+	buf := new(bytes.Buffer)
+	buf.WriteString("// Code generated by schemas/generate.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package domain\n\n")
+	buf.WriteString("// schemaFiles contains the JSON schemas in the schemas directory, keyed by file name.\n")
+	buf.WriteString("var schemaFiles = map[string]string{\n")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if strings.Contains(string(data), "`") {
+			log.Fatalf("schema %s contains a backtick", file)
+		}
+		buf.WriteString(fmt.Sprintf("\t%q: `%s`,\n", filepath.Base(file), string(data)))
+	}
+	buf.WriteString("}\n")
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(outputFile, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
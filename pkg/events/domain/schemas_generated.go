@@ -0,0 +1,227 @@
+// Code generated by schemas/generate.go; DO NOT EDIT.
+
+package domain
+
+// schemaFiles contains the JSON schemas in the schemas directory, keyed by file name.
+var schemaFiles = map[string]string{
+	"DeregisterEndpointEvent.v2.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "DeregisterEndpointEvent.v2.json",
+  "title": "DeregisterEndpointEvent",
+  "description": "Payload of the event which deregisters an endpoint of an organization.",
+  "type": "object",
+  "required": ["organization", "identifier"],
+  "properties": {
+    "organization": {
+      "description": "Identifier of the organization (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "identifier": {
+      "type": "string",
+      "minLength": 1
+    }
+  }
+}
+`,
+	"EndVendorClaimEvent.v2.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "EndVendorClaimEvent.v2.json",
+  "title": "EndVendorClaimEvent",
+  "description": "Payload of the event which ends a vendor's claim on an organization.",
+  "type": "object",
+  "required": ["vendorIdentifier", "orgIdentifier", "end"],
+  "properties": {
+    "vendorIdentifier": {
+      "description": "Identifier of the vendor (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "orgIdentifier": {
+      "description": "Identifier of the organization (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "end": {
+      "type": "string",
+      "format": "date-time"
+    }
+  }
+}
+`,
+	"RegisterEndpointEvent.v0.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "RegisterEndpointEvent.v0.json",
+  "title": "RegisterEndpointEvent",
+  "description": "Payload of the event which registers an endpoint of an organization (or updates its registration).",
+  "type": "object",
+  "required": ["organization", "identifier", "endpointType", "URL", "status"],
+  "properties": {
+    "organization": {
+      "description": "Identifier of the organization (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "identifier": {
+      "type": "string",
+      "minLength": 1
+    },
+    "endpointType": {
+      "type": "string",
+      "minLength": 1
+    },
+    "URL": {
+      "type": "string",
+      "minLength": 1
+    },
+    "status": {
+      "type": "string"
+    },
+    "properties": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    }
+  }
+}
+`,
+	"RegisterEndpointEvent.v4.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "RegisterEndpointEvent.v4.json",
+  "title": "RegisterEndpointEvent",
+  "description": "Payload of the event which registers an endpoint of an organization (or updates its registration).",
+  "type": "object",
+  "required": ["organization", "identifier", "endpointType", "URL", "status"],
+  "properties": {
+    "organization": {
+      "description": "Identifier of the organization (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "identifier": {
+      "type": "string",
+      "minLength": 1
+    },
+    "endpointType": {
+      "type": "string",
+      "minLength": 1
+    },
+    "URL": {
+      "type": "string",
+      "minLength": 1
+    },
+    "status": {
+      "description": "Status of the endpoint. Events before v4 might contain other (legacy) statuses, which are read as 'disabled'.",
+      "type": "string",
+      "enum": ["active", "maintenance", "deprecated", "disabled"]
+    },
+    "notBefore": {
+      "description": "Moment from which the endpoint is available.",
+      "type": "string",
+      "format": "date-time"
+    },
+    "notAfter": {
+      "description": "Moment from which the endpoint is no longer available.",
+      "type": "string",
+      "format": "date-time"
+    },
+    "properties": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    }
+  }
+}
+`,
+	"RegisterVendorEvent.v0.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "RegisterVendorEvent.v0.json",
+  "title": "RegisterVendorEvent",
+  "description": "Payload of the event which registers a vendor (or updates its registration).",
+  "type": "object",
+  "required": ["identifier", "name"],
+  "properties": {
+    "identifier": {
+      "description": "Identifier of the vendor (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "name": {
+      "type": "string",
+      "minLength": 1
+    },
+    "domain": {
+      "description": "Domain the vendor operates in, defaults to healthcare when absent.",
+      "type": "string"
+    },
+    "keys": {
+      "description": "Keys of the vendor as JWKs, e.g. containing the vendor CA certificate.",
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["kty"]
+      }
+    }
+  }
+}
+`,
+	"RetireVendorEvent.v2.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "RetireVendorEvent.v2.json",
+  "title": "RetireVendorEvent",
+  "description": "Payload of the event which retires a vendor.",
+  "type": "object",
+  "required": ["identifier"],
+  "properties": {
+    "identifier": {
+      "description": "Identifier of the vendor (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    }
+  }
+}
+`,
+	"VendorClaimEvent.v0.json": `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "VendorClaimEvent.v0.json",
+  "title": "VendorClaimEvent",
+  "description": "Payload of the event in which a vendor claims an organization (or updates the claim).",
+  "type": "object",
+  "required": ["vendorIdentifier", "orgIdentifier", "orgName"],
+  "properties": {
+    "vendorIdentifier": {
+      "description": "Identifier of the vendor (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "orgIdentifier": {
+      "description": "Identifier of the organization (PartyID).",
+      "type": "string",
+      "pattern": "^urn:oid:[0-9]+(\\.[0-9]+)*:.+$"
+    },
+    "orgName": {
+      "type": "string",
+      "minLength": 1
+    },
+    "orgKeys": {
+      "description": "Keys of the organization as JWKs.",
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["kty"]
+      }
+    },
+    "start": {
+      "type": "string",
+      "format": "date-time"
+    },
+    "end": {
+      "type": "string",
+      "format": "date-time"
+    }
+  }
+}
+`,
+}
@@ -0,0 +1,150 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package domain
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemas(t *testing.T) {
+	t.Run("schema for every event type", func(t *testing.T) {
+		for _, eventType := range GetEventTypes() {
+			assert.NotNil(t, GetSchema(eventType, events.Version(2)), "no schema for %s", eventType)
+		}
+	})
+	t.Run("sorted", func(t *testing.T) {
+		all := Schemas()
+		for i := 1; i < len(all); i++ {
+			assert.True(t, all[i-1].EventType < all[i].EventType || all[i-1].Version < all[i].Version)
+		}
+	})
+	t.Run("generated source is up-to-date", func(t *testing.T) {
+		files, _ := filepath.Glob("schemas/*.json")
+		if !assert.Len(t, schemaFiles, len(files)) {
+			return
+		}
+		for _, file := range files {
+			data, _ := ioutil.ReadFile(file)
+			assert.Equal(t, string(data), schemaFiles[filepath.Base(file)], "%s changed, run go generate", file)
+		}
+	})
+	t.Run("schemas are valid JSON", func(t *testing.T) {
+		for _, schema := range Schemas() {
+			assert.True(t, json.Valid(schema.Data))
+		}
+	})
+}
+
+func TestGetSchema(t *testing.T) {
+	t.Run("ok - exact version", func(t *testing.T) {
+		schema := GetSchema(RetireVendor, 2)
+		if !assert.NotNil(t, schema) {
+			return
+		}
+		assert.Equal(t, RetireVendor, schema.EventType)
+		assert.Equal(t, events.Version(2), schema.Version)
+	})
+	t.Run("ok - schema of lower version applies", func(t *testing.T) {
+		schema := GetSchema(RegisterVendor, 2)
+		if !assert.NotNil(t, schema) {
+			return
+		}
+		assert.Equal(t, events.Version(0), schema.Version)
+	})
+	t.Run("no schema for version", func(t *testing.T) {
+		assert.Nil(t, GetSchema(RetireVendor, 1))
+	})
+	t.Run("unknown event type", func(t *testing.T) {
+		assert.Nil(t, GetSchema("foo", 2))
+	})
+}
+
+func TestValidatePayload(t *testing.T) {
+	vendorID := test.VendorID("vendor")
+	orgID := test.OrganizationID("org")
+	t.Run("ok", func(t *testing.T) {
+		notBefore := time.Now()
+		payloads := map[events.EventType]interface{}{
+			RegisterVendor:     RegisterVendorEvent{Identifier: vendorID, Name: "Vendor"},
+			VendorClaim:        VendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, OrgName: "Org", Start: time.Now()},
+			RegisterEndpoint:   RegisterEndpointEvent{Organization: orgID, Identifier: "endpoint", EndpointType: "type", URL: "http://foo", Status: "active", NotBefore: &notBefore},
+			DeregisterEndpoint: DeregisterEndpointEvent{Organization: orgID, Identifier: "endpoint"},
+			EndVendorClaim:     EndVendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, End: time.Now()},
+			RetireVendor:       RetireVendorEvent{Identifier: vendorID},
+		}
+		for eventType, payload := range payloads {
+			assert.NoError(t, ValidatePayload(events.CreateEvent(eventType, payload, nil)), "%s", eventType)
+		}
+	})
+	t.Run("ok - unknown fields are allowed", func(t *testing.T) {
+		payload := map[string]interface{}{"identifier": vendorID.String(), "version": 1}
+		assert.NoError(t, ValidatePayload(events.CreateEvent(RetireVendor, payload, nil)))
+	})
+	t.Run("error - field-level violations", func(t *testing.T) {
+		payload := map[string]interface{}{"organization": "org", "identifier": "endpoint", "endpointType": "type", "URL": "", "status": "active"}
+		err := ValidatePayload(events.CreateEvent(RegisterEndpoint, payload, nil))
+		if !assert.IsType(t, PayloadValidationError{}, err) {
+			return
+		}
+		violations := err.(PayloadValidationError).Violations
+		assert.Len(t, violations, 2)
+		assert.Contains(t, violations, "/URL: Minimum string length is 1")
+		assert.Contains(t, err.Error(), "payload of RegisterEndpointEvent doesn't conform to its schema: ")
+		assert.Contains(t, err.Error(), "/organization: ")
+	})
+	t.Run("error - missing field", func(t *testing.T) {
+		payload := map[string]interface{}{"organization": orgID.String()}
+		err := ValidatePayload(events.CreateEvent(DeregisterEndpoint, payload, nil))
+		if !assert.Error(t, err) {
+			return
+		}
+		assert.Contains(t, err.Error(), "/identifier: Property 'identifier' is missing")
+	})
+	t.Run("error - invalid identifier", func(t *testing.T) {
+		payload := map[string]interface{}{"identifier": "vendor"}
+		err := ValidatePayload(events.CreateEvent(RetireVendor, payload, nil))
+		if !assert.Error(t, err) {
+			return
+		}
+		assert.Contains(t, err.Error(), "/identifier: ")
+	})
+	t.Run("error - no schema", func(t *testing.T) {
+		err := ValidatePayload(events.CreateEvent("foo", struct{}{}, nil))
+		assert.EqualError(t, err, "no schema for event type: foo (version 4)")
+	})
+}
+
+func TestLoadSchemas(t *testing.T) {
+	t.Run("error - invalid file name", func(t *testing.T) {
+		_, err := loadSchemas(map[string]string{"foo.json": "{}"})
+		assert.EqualError(t, err, "invalid schema file name: foo.json")
+	})
+	t.Run("error - invalid JSON", func(t *testing.T) {
+		_, err := loadSchemas(map[string]string{"Foo.v1.json": "{"})
+		assert.Error(t, err)
+	})
+}
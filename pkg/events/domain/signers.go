@@ -0,0 +1,406 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package domain
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	core "github.com/nuts-foundation/nuts-go-core"
+	cert2 "github.com/nuts-foundation/nuts-registry/pkg/cert"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	errors2 "github.com/pkg/errors"
+)
+
+// SignerRule checks whether the given certificate is authorized to sign the given event. If not, an error is returned.
+// The lookup can be used to find the events registering the entities the event concerns, e.g. the claim of an organization.
+type SignerRule func(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error
+
+// SignerAuthorizer verifies that events are signed by the entity they concern, e.g. that an endpoint is registered
+// by the organization it belongs to. It doesn't verify the signature itself, that's done by events.SignatureValidator
+// which must be registered before the SignerAuthorizer: only signers of which the signature has been verified are authorized.
+type SignerAuthorizer struct {
+	rules map[events.EventType]SignerRule
+}
+
+// NewSignerAuthorizer creates a SignerAuthorizer with the signer rules for all event types (see GetEventTypes).
+func NewSignerAuthorizer() SignerAuthorizer {
+	return SignerAuthorizer{rules: map[events.EventType]SignerRule{
+		RegisterVendor:     registerVendorSignerRule,
+		VendorClaim:        vendorClaimSignerRule,
+		RegisterEndpoint:   registerEndpointSignerRule,
+		DeregisterEndpoint: deregisterEndpointSignerRule,
+		EndVendorClaim:     endVendorClaimSignerRule,
+		RetireVendor:       retireVendorSignerRule,
+	}}
+}
+
+// RegisterEventHandlers registers event handlers which will authorize the event signers.
+func (a SignerAuthorizer) RegisterEventHandlers(fn func(events.EventType, events.EventHandler)) {
+	for _, eventType := range GetEventTypes() {
+		fn(eventType, a.authorize)
+	}
+}
+
+func (a SignerAuthorizer) authorize(event events.Event, lookup events.EventLookup) error {
+	if len(event.Signature()) == 0 {
+		// https://github.com/nuts-foundation/nuts-registry/issues/84
+		// Unsigned events are accepted for now (the SignatureValidator logs a warning), so there's no signer to check.
+		// They can't alter what's registered by signed events though, since that would circumvent the signer rules.
+		if err := requireUnsignedRegistration(event, lookup); err != nil {
+			return errors2.Wrapf(err, "event not signed, it will not be processed (event = %v)", event.IssuedAt())
+		}
+		return nil
+	}
+	rule, ok := a.rules[event.Type()]
+	if !ok {
+		return fmt.Errorf("no signer rule for event type: %s", event.Type())
+	}
+	certificate := events.VerifiedSigner(event)
+	if certificate == nil {
+		return fmt.Errorf("unable to determine event signer, its signature hasn't been verified (event = %v)", event.IssuedAt())
+	}
+	if err := rule(event, cert2.NewNutsCertificate(certificate), lookup); err != nil {
+		return errors2.Wrapf(err, "event signer not authorized, it will not be processed (event = %v)", event.IssuedAt())
+	}
+	return nil
+}
+
+// registerVendorSignerRule requires RegisterVendorEvents to be signed by the vendor itself. When the vendor has
+// already been registered, the signer's certificate must be issued by its registered CA (see requireRegisteredVendorSigner).
+func registerVendorSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := RegisterVendorEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireRegisteredVendorSigner(event, payload.Identifier, signer, lookup)
+}
+
+// vendorClaimSignerRule requires VendorClaimEvents to be signed by the vendor claiming the organization or (which is
+// what vendors currently do) the organization itself, using the certificate issued by the vendor.
+func vendorClaimSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := VendorClaimEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireVendorOrOrganizationSigner(event, payload.VendorID, payload.OrganizationID, payload.OrgKeys, signer, lookup)
+}
+
+// registerEndpointSignerRule requires RegisterEndpointEvents to be signed by the organization the endpoint belongs to,
+// using a certificate issued by the vendor which claims the organization.
+func registerEndpointSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := RegisterEndpointEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireOrganizationSigner(event, payload.Organization, signer, lookup)
+}
+
+// deregisterEndpointSignerRule requires DeregisterEndpointEvents to be signed by the organization the endpoint belongs
+// to, using a certificate issued by the vendor which claims the organization.
+func deregisterEndpointSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := DeregisterEndpointEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireOrganizationSigner(event, payload.Organization, signer, lookup)
+}
+
+// endVendorClaimSignerRule requires EndVendorClaimEvents to be signed by the vendor which claimed the organization or
+// the organization itself, like VendorClaimEvents.
+func endVendorClaimSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := EndVendorClaimEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	claim, err := lookup.FindLastEventByKey(OrganizationEventKey(payload.OrganizationID), OrganizationEventMatcher(payload.VendorID, payload.OrganizationID))
+	if err != nil {
+		return err
+	}
+	var orgKeys []interface{}
+	if claim != nil {
+		claimPayload := VendorClaimEvent{}
+		if err := claim.Unmarshal(&claimPayload); err != nil {
+			return err
+		}
+		orgKeys = claimPayload.OrgKeys
+	}
+	return requireVendorOrOrganizationSigner(event, payload.VendorID, payload.OrganizationID, orgKeys, signer, lookup)
+}
+
+// retireVendorSignerRule requires RetireVendorEvents to be signed by the vendor itself, using a certificate issued by
+// its registered CA (see requireRegisteredVendorSigner).
+func retireVendorSignerRule(event events.Event, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	payload := RetireVendorEvent{}
+	if err := event.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireRegisteredVendorSigner(event, payload.Identifier, signer, lookup)
+}
+
+// requireVendorOrOrganizationSigner checks that the signer is either the expected vendor or the expected organization.
+// The certificate must be issued by the vendor (when signed by the organization possibly through one of the given
+// organization keys): otherwise any vendor could issue a certificate for the vendor or organization.
+func requireVendorOrOrganizationSigner(event events.Event, vendorID core.PartyID, organizationID core.PartyID, orgKeys []interface{}, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	if vendorErr := requireSigner("vendor", vendorID, signer.GetVendorID); vendorErr == nil {
+		return requireRegisteredVendorSigner(event, vendorID, signer, lookup)
+	} else if orgErr := requireSigner("organization", organizationID, signer.GetOrganizationID); orgErr != nil {
+		return fmt.Errorf("event should either be signed by vendor or organization: %v, %v", vendorErr, orgErr)
+	}
+	return requireIssuedByVendor(signer, vendorID, orgKeys, event.IssuedAt(), lookup)
+}
+
+// requireRegisteredVendorSigner checks that the signer is the expected vendor. When the vendor has been registered with
+// CA certificates, the signer's certificate must be issued by one of them: otherwise any certificate containing the
+// vendor's ID could be used to alter the vendor, e.g. one issued by a CA the event itself registers. Only the first
+// registration of a vendor is signed using a certificate issued by the (self-signed) CA it registers.
+func requireRegisteredVendorSigner(event events.Event, vendorID core.PartyID, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	if err := requireSigner("vendor", vendorID, signer.GetVendorID); err != nil {
+		return err
+	}
+	vendorEvents, err := lookup.FindEventPathByKey(VendorEventKey(vendorID), VendorEventMatcher(vendorID))
+	if err != nil {
+		return err
+	}
+	for _, vendorEvent := range vendorEvents {
+		payload := RegisterVendorEvent{}
+		if err := vendorEvent.Unmarshal(&payload); err != nil {
+			return err
+		}
+		if len(caCertificates(payload.Keys)) > 0 {
+			return requireIssuedByVendor(signer, vendorID, nil, event.IssuedAt(), lookup)
+		}
+	}
+	// Vendor hasn't been registered (yet) or only without CA certificates, which is only possible for unsigned events
+	return nil
+}
+
+// requireOrganizationSigner checks that the signer is the expected organization and that its certificate is issued by
+// the vendor which claims the organization at the moment the event was issued.
+func requireOrganizationSigner(event events.Event, organizationID core.PartyID, signer *cert2.NutsCertificate, lookup events.EventLookup) error {
+	if err := requireSigner("organization", organizationID, signer.GetOrganizationID); err != nil {
+		return err
+	}
+	claim, err := findActiveVendorClaim(organizationID, event.IssuedAt(), lookup)
+	if err != nil {
+		return err
+	}
+	if claim == nil {
+		return fmt.Errorf("organization isn't claimed by a vendor (id = %s)", organizationID)
+	}
+	payload := VendorClaimEvent{}
+	if err := claim.Unmarshal(&payload); err != nil {
+		return err
+	}
+	return requireIssuedByVendor(signer, payload.VendorID, payload.OrgKeys, event.IssuedAt(), lookup)
+}
+
+// requireIssuedByVendor checks that the signer's certificate is issued by one of the CA certificates of the vendor
+// (as registered by its RegisterVendorEvents). Since organizations sign using certificates issued by their own
+// certificate, the CA certificates in the given organization keys may be part of the chain.
+func requireIssuedByVendor(signer *cert2.NutsCertificate, vendorID core.PartyID, orgKeys []interface{}, moment time.Time, lookup events.EventLookup) error {
+	vendorEvents, err := lookup.FindEventPathByKey(VendorEventKey(vendorID), VendorEventMatcher(vendorID))
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	for _, vendorEvent := range vendorEvents {
+		payload := RegisterVendorEvent{}
+		if err := vendorEvent.Unmarshal(&payload); err != nil {
+			return err
+		}
+		addCACertificates(roots, payload.Keys)
+	}
+	intermediates := x509.NewCertPool()
+	addCACertificates(intermediates, orgKeys)
+	_, err = (*x509.Certificate)(signer).Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         roots,
+		CurrentTime:   moment,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return errors2.Wrapf(err, "certificate isn't issued by vendor (id = %s)", vendorID)
+	}
+	return nil
+}
+
+// addCACertificates adds the CA certificates in the certificate chains of the given JWKs to the pool.
+func addCACertificates(pool *x509.CertPool, jwks []interface{}) {
+	for _, certificate := range caCertificates(jwks) {
+		pool.AddCert(certificate)
+	}
+}
+
+// caCertificates returns the CA certificates in the certificate chains of the given JWKs.
+func caCertificates(jwks []interface{}) []*x509.Certificate {
+	var result []*x509.Certificate
+	for _, key := range jwks {
+		keyAsMap, ok := key.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		chain, _ := cert.MapToX509CertChain(keyAsMap)
+		for _, certificate := range chain {
+			if certificate.IsCA {
+				result = append(result, certificate)
+			}
+		}
+	}
+	return result
+}
+
+// findActiveVendorClaim returns the VendorClaimEvent (the last event of its path) of the vendor which claims the
+// organization at the given moment: its claim started and hasn't ended (by its end or an EndVendorClaimEvent) and the
+// vendor hasn't been retired at that moment. If multiple claims apply, the one which started last wins. If the
+// organization isn't claimed at that moment, nil is returned.
+func findActiveVendorClaim(organizationID core.PartyID, moment time.Time, lookup events.EventLookup) (events.Event, error) {
+	var result events.Event
+	var resultStart time.Time
+	checked := make(map[core.PartyID]bool)
+	for _, event := range lookup.FindEventsByKey(OrganizationEventKey(organizationID)) {
+		payload := VendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return nil, err
+		}
+		if payload.OrganizationID != organizationID || checked[payload.VendorID] {
+			continue
+		}
+		checked[payload.VendorID] = true
+		claim, err := lookup.FindLastEventByKey(OrganizationEventKey(organizationID), OrganizationEventMatcher(payload.VendorID, organizationID))
+		if err != nil {
+			return nil, err
+		}
+		if err := claim.Unmarshal(&payload); err != nil {
+			return nil, err
+		}
+		if moment.Before(payload.Start) || (result != nil && !payload.Start.After(resultStart)) {
+			continue
+		}
+		if active, err := isClaimActive(payload, moment, lookup); err != nil {
+			return nil, err
+		} else if active {
+			result = claim
+			resultStart = payload.Start
+		}
+	}
+	return result, nil
+}
+
+// isClaimActive returns whether the claim hasn't been ended (by its end or an EndVendorClaimEvent) at the given
+// moment, and its vendor hasn't been retired.
+func isClaimActive(claim VendorClaimEvent, moment time.Time, lookup events.EventLookup) (bool, error) {
+	if claim.End != nil && !moment.Before(*claim.End) {
+		return false, nil
+	}
+	for _, event := range lookup.FindEventsByKey(EndVendorClaimEventKey(claim.OrganizationID)) {
+		payload := EndVendorClaimEvent{}
+		if err := event.Unmarshal(&payload); err != nil {
+			return false, err
+		}
+		if payload.VendorID == claim.VendorID && payload.OrganizationID == claim.OrganizationID && !moment.Before(payload.End) {
+			return false, nil
+		}
+	}
+	for _, event := range lookup.FindEventsByKey(RetireVendorEventKey(claim.VendorID)) {
+		if !moment.Before(event.IssuedAt()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// requireUnsignedRegistration checks that an unsigned event doesn't alter what's registered by signed events: it may
+// not refer to a signed event, nor concern a vendor or organization of which the registration (the first event of its
+// path) is signed. Since the registration must be applied before the event itself, this doesn't depend on the order
+// in which events are received.
+func requireUnsignedRegistration(event events.Event, lookup events.EventLookup) error {
+	if !event.PreviousRef().IsZero() {
+		if previous := lookup.Get(event.PreviousRef()); previous != nil && len(previous.Signature()) > 0 {
+			return errors.New("previous event is signed")
+		}
+	}
+	var registration []events.Event
+	var err error
+	switch event.Type() {
+	case RegisterEndpoint:
+		payload := RegisterEndpointEvent{}
+		if err = event.Unmarshal(&payload); err == nil {
+			registration, err = findActiveVendorClaimPath(payload.Organization, event.IssuedAt(), lookup)
+		}
+	case DeregisterEndpoint:
+		payload := DeregisterEndpointEvent{}
+		if err = event.Unmarshal(&payload); err == nil {
+			registration, err = findActiveVendorClaimPath(payload.Organization, event.IssuedAt(), lookup)
+		}
+	case VendorClaim:
+		payload := VendorClaimEvent{}
+		if err = event.Unmarshal(&payload); err == nil {
+			registration, err = lookup.FindEventPathByKey(VendorEventKey(payload.VendorID), VendorEventMatcher(payload.VendorID))
+		}
+	case EndVendorClaim:
+		payload := EndVendorClaimEvent{}
+		if err = event.Unmarshal(&payload); err == nil {
+			registration, err = lookup.FindEventPathByKey(VendorEventKey(payload.VendorID), VendorEventMatcher(payload.VendorID))
+		}
+	case RetireVendor:
+		payload := RetireVendorEvent{}
+		if err = event.Unmarshal(&payload); err == nil {
+			registration, err = lookup.FindEventPathByKey(VendorEventKey(payload.Identifier), VendorEventMatcher(payload.Identifier))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(registration) > 0 && len(registration[0].Signature()) > 0 {
+		return fmt.Errorf("it concerns an entity registered by a signed %s", registration[0].Type())
+	}
+	return nil
+}
+
+// findActiveVendorClaimPath works like findActiveVendorClaim, but returns the complete event path of the claim.
+func findActiveVendorClaimPath(organizationID core.PartyID, moment time.Time, lookup events.EventLookup) ([]events.Event, error) {
+	claim, err := findActiveVendorClaim(organizationID, moment, lookup)
+	if err != nil || claim == nil {
+		return nil, err
+	}
+	payload := VendorClaimEvent{}
+	if err := claim.Unmarshal(&payload); err != nil {
+		return nil, err
+	}
+	return lookup.FindEventPathByKey(OrganizationEventKey(organizationID), OrganizationEventMatcher(payload.VendorID, organizationID))
+}
+
+// requireSigner checks that the party ID in the signer's certificate (as returned by signerIDFn) equals the expected party ID.
+func requireSigner(party string, expected core.PartyID, signerIDFn func() (core.PartyID, error)) error {
+	signerID, err := signerIDFn()
+	if err != nil {
+		return errors2.Wrapf(err, "unable to unmarshal %s ID from certificate", party)
+	}
+	if signerID.IsZero() {
+		return errors.New(party + " ID missing in certificate")
+	}
+	if signerID != expected {
+		return fmt.Errorf("%s ID in certificate (%s) doesn't match event (%s)", party, signerID, expected)
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package domain
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	test2 "github.com/nuts-foundation/nuts-crypto/test"
+	core "github.com/nuts-foundation/nuts-go-core"
+	cert2 "github.com/nuts-foundation/nuts-registry/pkg/cert"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerAuthorizer_RegisterEventHandlers(t *testing.T) {
+	var registered []events.EventType
+	NewSignerAuthorizer().RegisterEventHandlers(func(eventType events.EventType, _ events.EventHandler) {
+		registered = append(registered, eventType)
+	})
+	assert.ElementsMatch(t, GetEventTypes(), registered)
+}
+
+func TestSignerAuthorizer_Authorize(t *testing.T) {
+	vendorID := test.VendorID("vendor")
+	orgID := test.OrganizationID("org")
+	vendorCSR, _ := cert2.VendorCertificateRequest(vendorID, "Vendor", "", types.HealthcareDomain)
+	otherVendorCSR, _ := cert2.VendorCertificateRequest(test.VendorID("other"), "Other", "", types.HealthcareDomain)
+	orgCSR, _ := cert2.OrganisationCertificateRequest("Vendor", orgID, "Org", types.HealthcareDomain)
+	otherOrgCSR, _ := cert2.OrganisationCertificateRequest("Other", test.OrganizationID("other"), "Other", types.HealthcareDomain)
+	vendorCA := newTestVendorCA(t, vendorID)
+	otherVendorCA := newTestVendorCA(t, test.VendorID("other"))
+	registerVendor := signedEvent(t, RegisterVendor, RegisterVendorEvent{Identifier: vendorID, Name: "Vendor", Keys: []interface{}{vendorCA.jwk}}, vendorCSR)
+	registerOtherVendor := signedEvent(t, RegisterVendor, RegisterVendorEvent{Identifier: test.VendorID("other"), Name: "Other", Keys: []interface{}{otherVendorCA.jwk}}, otherVendorCSR)
+	claim := signedEvent(t, VendorClaim, VendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, OrgName: "Org", Start: time.Now().Add(-time.Hour)}, vendorCSR)
+	lookup := eventLookupStub{registerVendor, registerOtherVendor, claim}
+	authorizer := NewSignerAuthorizer()
+
+	t.Run("RegisterVendor", func(t *testing.T) {
+		payload := RegisterVendorEvent{Identifier: vendorID, Name: "Vendor"}
+		t.Run("ok - signed by vendor", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterVendor, payload, vendorCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("ok - registration, signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+			// The first registration is signed using a certificate issued by the (self-signed) CA it registers
+			err := authorizer.authorize(signedEvent(t, RegisterVendor, payload, vendorCSR), eventLookupStub{registerOtherVendor})
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RegisterVendor, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+			err = authorizer.authorize(newTestVendorCA(t, vendorID).signedEvent(t, RegisterVendor, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(otherVendorCA.signedEvent(t, RegisterVendor, payload, otherVendorCSR), lookup)
+			assert.Contains(t, err.Error(), "vendor ID in certificate (urn:oid:1.3.6.1.4.1.54851.4:other) doesn't match event")
+		})
+		t.Run("error - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RegisterVendor, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "vendor ID missing in certificate")
+		})
+	})
+	t.Run("VendorClaim", func(t *testing.T) {
+		payload := VendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, OrgName: "Org"}
+		t.Run("ok - signed by vendor", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, VendorClaim, payload, vendorCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, VendorClaim, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("ok - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, VendorClaim, payload, orgCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by organization, issued by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(otherVendorCA.signedEvent(t, VendorClaim, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, VendorClaim, payload, otherVendorCSR), lookup)
+			assert.Contains(t, err.Error(), "event should either be signed by vendor or organization")
+		})
+		t.Run("error - signed by other organization", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, VendorClaim, payload, otherOrgCSR), lookup)
+			assert.Contains(t, err.Error(), "event should either be signed by vendor or organization")
+		})
+	})
+	t.Run("RegisterEndpoint", func(t *testing.T) {
+		payload := RegisterEndpointEvent{Organization: orgID, Identifier: "endpoint"}
+		t.Run("ok - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("ok - signed by organization, issued through organization certificate", func(t *testing.T) {
+			// Organizations sign events using (ephemeral) certificates issued by their own certificate
+			orgCA := vendorCA.issue(t, orgCSR)
+			orgClaim := signedEvent(t, VendorClaim, VendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, OrgName: "Org", OrgKeys: []interface{}{orgCA.jwk}}, vendorCSR)
+			err := authorizer.authorize(orgCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), eventLookupStub{registerVendor, orgClaim})
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by organization, issued by other vendor", func(t *testing.T) {
+			// Any trusted vendor CA can issue a certificate for the organization, but only the claiming vendor's is authorized
+			err := authorizer.authorize(otherVendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by organization, self-signed", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RegisterEndpoint, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - organization not claimed", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), eventLookupStub{registerVendor})
+			assert.Contains(t, err.Error(), "organization isn't claimed by a vendor (id = urn:oid:2.16.840.1.113883.2.4.6.1:org)")
+		})
+		t.Run("error - claim ended", func(t *testing.T) {
+			endClaim := signedEvent(t, EndVendorClaim, EndVendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, End: time.Now()}, vendorCSR)
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), append(lookup, endClaim))
+			assert.Contains(t, err.Error(), "organization isn't claimed by a vendor")
+		})
+		t.Run("error - claimed by other vendor", func(t *testing.T) {
+			endClaim := signedEvent(t, EndVendorClaim, EndVendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, End: time.Now().Add(-time.Minute)}, vendorCSR)
+			otherClaim := signedEvent(t, VendorClaim, VendorClaimEvent{VendorID: test.VendorID("other"), OrganizationID: orgID, OrgName: "Org", Start: time.Now().Add(-time.Minute)}, otherVendorCSR)
+			currentLookup := eventLookupStub{registerVendor, registerOtherVendor, claim, endClaim, otherClaim}
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), currentLookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:other)")
+			err = authorizer.authorize(otherVendorCA.signedEvent(t, RegisterEndpoint, payload, orgCSR), currentLookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by other organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, RegisterEndpoint, payload, otherOrgCSR), lookup)
+			assert.Contains(t, err.Error(), "organization ID in certificate (urn:oid:2.16.840.1.113883.2.4.6.1:other) doesn't match event")
+		})
+		t.Run("error - signed by (other) vendor", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RegisterEndpoint, payload, otherVendorCSR), lookup)
+			assert.Contains(t, err.Error(), "organization ID missing in certificate")
+		})
+	})
+	t.Run("DeregisterEndpoint", func(t *testing.T) {
+		payload := DeregisterEndpointEvent{Organization: orgID, Identifier: "endpoint"}
+		t.Run("ok - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, DeregisterEndpoint, payload, orgCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by organization, issued by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(otherVendorCA.signedEvent(t, DeregisterEndpoint, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by other organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, DeregisterEndpoint, payload, otherOrgCSR), lookup)
+			assert.Contains(t, err.Error(), "organization ID in certificate (urn:oid:2.16.840.1.113883.2.4.6.1:other) doesn't match event")
+		})
+	})
+	t.Run("EndVendorClaim", func(t *testing.T) {
+		payload := EndVendorClaimEvent{VendorID: vendorID, OrganizationID: orgID, End: time.Now()}
+		t.Run("ok - signed by vendor", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, EndVendorClaim, payload, vendorCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("ok - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, EndVendorClaim, payload, orgCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by organization, issued by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(otherVendorCA.signedEvent(t, EndVendorClaim, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, EndVendorClaim, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by other vendor", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, EndVendorClaim, payload, otherVendorCSR), lookup)
+			assert.Contains(t, err.Error(), "event should either be signed by vendor or organization")
+		})
+	})
+	t.Run("RetireVendor", func(t *testing.T) {
+		payload := RetireVendorEvent{Identifier: vendorID}
+		t.Run("ok - signed by vendor", func(t *testing.T) {
+			err := authorizer.authorize(vendorCA.signedEvent(t, RetireVendor, payload, vendorCSR), lookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - signed by certificate not issued by registered vendor CA", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RetireVendor, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+			err = authorizer.authorize(otherVendorCA.signedEvent(t, RetireVendor, payload, vendorCSR), lookup)
+			assert.Contains(t, err.Error(), "certificate isn't issued by vendor (id = urn:oid:1.3.6.1.4.1.54851.4:vendor)")
+		})
+		t.Run("error - signed by organization", func(t *testing.T) {
+			err := authorizer.authorize(signedEvent(t, RetireVendor, payload, orgCSR), lookup)
+			assert.Contains(t, err.Error(), "vendor ID missing in certificate")
+		})
+	})
+	t.Run("not signed", func(t *testing.T) {
+		unsignedVendor := events.CreateEvent(RegisterVendor, RegisterVendorEvent{Identifier: test.VendorID("unsigned")}, nil)
+		unsignedClaim := events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: test.VendorID("unsigned"), OrganizationID: test.OrganizationID("unsigned")}, nil)
+		unsignedLookup := eventLookupStub{unsignedVendor, unsignedClaim, registerVendor, claim}
+		t.Run("ok - unsigned registration", func(t *testing.T) {
+			err := authorizer.authorize(events.CreateEvent(RegisterEndpoint, RegisterEndpointEvent{Organization: test.OrganizationID("unsigned")}, nil), unsignedLookup)
+			assert.NoError(t, err)
+			err = authorizer.authorize(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: test.VendorID("unsigned")}, unsignedClaim.Ref()), unsignedLookup)
+			assert.NoError(t, err)
+		})
+		t.Run("error - previous event is signed", func(t *testing.T) {
+			err := authorizer.authorize(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: test.VendorID("unsigned")}, claim.Ref()), unsignedLookup)
+			assert.Contains(t, err.Error(), "event not signed, it will not be processed")
+			assert.Contains(t, err.Error(), "previous event is signed")
+		})
+		t.Run("error - organization claim is signed", func(t *testing.T) {
+			err := authorizer.authorize(events.CreateEvent(RegisterEndpoint, RegisterEndpointEvent{Organization: orgID}, nil), unsignedLookup)
+			assert.Contains(t, err.Error(), "it concerns an entity registered by a signed VendorClaimEvent")
+			err = authorizer.authorize(events.CreateEvent(DeregisterEndpoint, DeregisterEndpointEvent{Organization: orgID}, nil), unsignedLookup)
+			assert.Contains(t, err.Error(), "it concerns an entity registered by a signed VendorClaimEvent")
+		})
+		t.Run("error - vendor registration is signed", func(t *testing.T) {
+			err := authorizer.authorize(events.CreateEvent(VendorClaim, VendorClaimEvent{VendorID: vendorID, OrganizationID: test.OrganizationID("other")}, nil), unsignedLookup)
+			assert.Contains(t, err.Error(), "it concerns an entity registered by a signed RegisterVendorEvent")
+			err = authorizer.authorize(events.CreateEvent(EndVendorClaim, EndVendorClaimEvent{VendorID: vendorID, OrganizationID: orgID}, nil), unsignedLookup)
+			assert.Contains(t, err.Error(), "it concerns an entity registered by a signed RegisterVendorEvent")
+			err = authorizer.authorize(events.CreateEvent(RetireVendor, RetireVendorEvent{Identifier: vendorID}, nil), unsignedLookup)
+			assert.Contains(t, err.Error(), "it concerns an entity registered by a signed RegisterVendorEvent")
+		})
+	})
+	t.Run("error - no rule for event type", func(t *testing.T) {
+		err := authorizer.authorize(signedEvent(t, "foo", struct{}{}, vendorCSR), lookup)
+		assert.EqualError(t, err, "no signer rule for event type: foo")
+	})
+	t.Run("error - signature without certificate", func(t *testing.T) {
+		event := events.CreateEvent(RegisterVendor, RegisterVendorEvent{Identifier: vendorID}, nil)
+		_ = event.Sign(func(data []byte) ([]byte, error) {
+			_, key := test.SelfSignCertificateFromCSR(vendorCSR, time.Now(), 1)
+			return jws.Sign(data, jwa.RS256, key)
+		})
+		verifySignature(t, event)
+		err := authorizer.authorize(event, lookup)
+		assert.Contains(t, err.Error(), "unable to determine event signer")
+	})
+	t.Run("error - signature not verified", func(t *testing.T) {
+		// Certificate of an unverified signature (e.g. an unsupported event version) can't be trusted to name the signer
+		event := events.CreateEvent(RegisterVendor, RegisterVendorEvent{Identifier: vendorID}, nil)
+		certificate, key := test.SelfSignCertificateFromCSR(vendorCSR, time.Now(), 1)
+		_ = event.Sign(func(data []byte) ([]byte, error) {
+			headers := jws.NewHeaders()
+			_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+			return jws.Sign(data, jwa.RS256, key, jws.WithHeaders(headers))
+		})
+		err := authorizer.authorize(event, lookup)
+		assert.Contains(t, err.Error(), "its signature hasn't been verified")
+	})
+}
+
+// testCA is a CA certificate (e.g. of a vendor) which issues the certificates events are signed with.
+type testCA struct {
+	certificate *x509.Certificate
+	key         *rsa.PrivateKey
+	jwk         map[string]interface{}
+}
+
+// newTestVendorCA creates a self-signed vendor CA certificate.
+func newTestVendorCA(t *testing.T, vendorID core.PartyID) testCA {
+	csr, _ := cert2.VendorCertificateRequest(vendorID, "Vendor", "CA", types.HealthcareDomain)
+	certificate, key := test.SelfSignCertificateFromCSR(csr, time.Now(), 1)
+	return newTestCA(t, certificate, key)
+}
+
+func newTestCA(t *testing.T, certificate *x509.Certificate, key *rsa.PrivateKey) testCA {
+	certificateAsJWK, _ := cert.CertificateToJWK(certificate)
+	jwkAsMap, err := cert.JwkToMap(certificateAsJWK)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return testCA{certificate: certificate, key: key, jwk: jwkAsMap}
+}
+
+// issue issues a (CA) certificate from the given CSR.
+func (c testCA) issue(t *testing.T, csr x509.CertificateRequest) testCA {
+	key := test2.GenerateRSAKey()
+	csr.PublicKey = &key.PublicKey
+	return newTestCA(t, test.SignCertificateFromCSRWithKey(csr, time.Now(), 1, c.certificate, c.key), key)
+}
+
+// signedEvent creates an event which is signed using a certificate issued by the CA from the given CSR.
+func (c testCA) signedEvent(t *testing.T, eventType events.EventType, payload interface{}, csr x509.CertificateRequest) events.Event {
+	signer := c.issue(t, csr)
+	return signEvent(t, events.CreateEvent(eventType, payload, nil), signer.certificate, signer.key)
+}
+
+// signedEvent creates an event which is signed using a (self-signed) certificate issued from the given CSR.
+func signedEvent(t *testing.T, eventType events.EventType, payload interface{}, csr x509.CertificateRequest) events.Event {
+	certificate, key := test.SelfSignCertificateFromCSR(csr, time.Now(), 1)
+	return signEvent(t, events.CreateEvent(eventType, payload, nil), certificate, key)
+}
+
+func signEvent(t *testing.T, event events.Event, certificate *x509.Certificate, key *rsa.PrivateKey) events.Event {
+	err := event.Sign(func(data []byte) ([]byte, error) {
+		headers := jws.NewHeaders()
+		_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+		return jws.Sign(data, jwa.RS256, key, jws.WithHeaders(headers))
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	verifySignature(t, event)
+	return event
+}
+
+// verifySignature runs the event through the events.SignatureValidator (without checking the certificate's trust),
+// which is required for the SignerAuthorizer to accept the signer.
+func verifySignature(t *testing.T, event events.Event) {
+	events.NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).RegisterEventHandlers(func(_ events.EventType, handler events.EventHandler) {
+		if !assert.NoError(t, handler(event, nil)) {
+			t.FailNow()
+		}
+	}, []events.EventType{event.Type()})
+}
@@ -27,5 +27,8 @@ func GetEventTypes() []events.EventType {
 		RegisterEndpoint,
 		RegisterVendor,
 		VendorClaim,
+		DeregisterEndpoint,
+		EndVendorClaim,
+		RetireVendor,
 	}
 }
@@ -0,0 +1,71 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+import (
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+)
+
+// freeFormEndpointStatusVersion is the last event version in which the status of an endpoint was free-form. Since v4
+// it must be one of the EndpointStatuses (see the RegisterEndpointEvent.v4.json schema).
+const freeFormEndpointStatusVersion events.Version = 3
+
+func init() {
+	// The formats below changed without the event version being increased, so they're applied to events of any version.
+	events.RegisterUpcaster(RegisterVendor, events.AllVersions, upcastVendorDomain)
+	events.RegisterUpcaster(RegisterVendor, events.AllVersions, upcastVendorKeyCertChain)
+	events.RegisterUpcaster(RegisterEndpoint, freeFormEndpointStatusVersion, upcastEndpointStatus)
+}
+
+// upcastVendorDomain sets the domain of the vendor to 'healthcare' when none is set, for handling legacy data when
+// 'domain' didn't exist.
+func upcastVendorDomain(payload map[string]interface{}) error {
+	if domain, ok := payload["domain"].(string); !ok || domain == "" {
+		payload["domain"] = types.FallbackDomain
+	}
+	return nil
+}
+
+// upcastVendorKeyCertChain converts the x5c field of the vendor's JWKs from a string to a []string, for handling registry
+// entries from when the certificate chain was stored as string.
+func upcastVendorKeyCertChain(payload map[string]interface{}) error {
+	keys, _ := payload["keys"].([]interface{})
+	for _, key := range keys {
+		keyAsMap, ok := key.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if x5c, ok := keyAsMap[jws.X509CertChainKey].(string); ok {
+			keyAsMap[jws.X509CertChainKey] = []string{x5c}
+		}
+	}
+	return nil
+}
+
+// upcastEndpointStatus sets the status of the endpoint to 'disabled' when it isn't one of the EndpointStatuses, for
+// handling registry entries from when the status was free-form and only 'active' had meaning (before v4).
+func upcastEndpointStatus(payload map[string]interface{}) error {
+	if status, _ := payload["status"].(string); !IsEndpointStatus(status) {
+		payload["status"] = EndpointStatusDisabled
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const legacyEventsDir = "../../../test_data/valid_files/events"
+
+func TestUpcasters_LegacyFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(legacyEventsDir, "*.json"))
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, files) {
+		return
+	}
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		event, err := events.EventFromJSON(data)
+		if !assert.NoError(t, err, file) {
+			continue
+		}
+		assert.Equal(t, events.Version(0), event.Version(), file)
+		assert.NoError(t, ValidatePayload(event), file)
+	}
+	t.Run("RegisterVendorEvent without domain", func(t *testing.T) {
+		data, _ := ioutil.ReadFile(filepath.Join(legacyEventsDir, "20200123091400001-RegisterVendorEvent.json"))
+		event, _ := events.EventFromJSON(data)
+		var payload RegisterVendorEvent
+		if !assert.NoError(t, event.Unmarshal(&payload)) {
+			return
+		}
+		assert.Equal(t, "healthcare", payload.Domain)
+		assert.Equal(t, "BecauseWeCare Software Inc.", payload.Name)
+	})
+	t.Run("RegisterEndpointEvent with removed version field", func(t *testing.T) {
+		data, _ := ioutil.ReadFile(filepath.Join(legacyEventsDir, "20200123091400003-RegisterEndpointEvent.json"))
+		event, _ := events.EventFromJSON(data)
+		var payload RegisterEndpointEvent
+		if !assert.NoError(t, event.Unmarshal(&payload)) {
+			return
+		}
+		assert.Equal(t, "tcp://127.0.0.1:1234", payload.URL)
+	})
+}
+
+func TestUpcastVendorDomain(t *testing.T) {
+	t.Run("domain absent", func(t *testing.T) {
+		payload := map[string]interface{}{}
+		_ = upcastVendorDomain(payload)
+		assert.Equal(t, "healthcare", payload["domain"])
+	})
+	t.Run("domain empty", func(t *testing.T) {
+		payload := map[string]interface{}{"domain": ""}
+		_ = upcastVendorDomain(payload)
+		assert.Equal(t, "healthcare", payload["domain"])
+	})
+	t.Run("domain set", func(t *testing.T) {
+		payload := map[string]interface{}{"domain": "personal"}
+		_ = upcastVendorDomain(payload)
+		assert.Equal(t, "personal", payload["domain"])
+	})
+}
+
+func TestUpcastVendorKeyCertChain(t *testing.T) {
+	t.Run("string x5c", func(t *testing.T) {
+		payload := map[string]interface{}{"keys": []interface{}{map[string]interface{}{"kty": "RSA", "x5c": "cert"}}}
+		_ = upcastVendorKeyCertChain(payload)
+		assert.Equal(t, []string{"cert"}, payload["keys"].([]interface{})[0].(map[string]interface{})["x5c"])
+	})
+	t.Run("array x5c", func(t *testing.T) {
+		payload := map[string]interface{}{"keys": []interface{}{map[string]interface{}{"kty": "RSA", "x5c": []interface{}{"cert"}}}}
+		_ = upcastVendorKeyCertChain(payload)
+		assert.Equal(t, []interface{}{"cert"}, payload["keys"].([]interface{})[0].(map[string]interface{})["x5c"])
+	})
+	t.Run("no keys", func(t *testing.T) {
+		payload := map[string]interface{}{}
+		assert.NoError(t, upcastVendorKeyCertChain(payload))
+		assert.Empty(t, payload)
+	})
+}
+
+func TestUpcastEndpointStatus(t *testing.T) {
+	t.Run("valid status", func(t *testing.T) {
+		payload := map[string]interface{}{"status": "maintenance"}
+		_ = upcastEndpointStatus(payload)
+		assert.Equal(t, "maintenance", payload["status"])
+	})
+	t.Run("legacy status", func(t *testing.T) {
+		payload := map[string]interface{}{"status": "inactive"}
+		_ = upcastEndpointStatus(payload)
+		assert.Equal(t, "disabled", payload["status"])
+	})
+	t.Run("status absent", func(t *testing.T) {
+		payload := map[string]interface{}{}
+		_ = upcastEndpointStatus(payload)
+		assert.Equal(t, "disabled", payload["status"])
+	})
+}
+
+func TestUpcastEndpointStatus_Versions(t *testing.T) {
+	payload := RegisterEndpointEvent{
+		Organization: test.OrganizationID("org"),
+		Identifier:   "endpoint",
+		EndpointType: "fhir",
+		URL:          "http://example.com",
+		Status:       "inactive",
+	}
+	t.Run("legacy status is read as disabled (v0)", func(t *testing.T) {
+		// Events without version (v0) don't have a ref, so the version can simply be removed
+		var asMap map[string]interface{}
+		_ = json.Unmarshal(events.CreateEvent(RegisterEndpoint, payload, nil).Marshal(), &asMap)
+		delete(asMap, "version")
+		delete(asMap, "ref")
+		data, _ := json.Marshal(asMap)
+		event, err := events.EventFromJSON(data)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, ValidatePayload(event))
+		var actual RegisterEndpointEvent
+		if !assert.NoError(t, event.Unmarshal(&actual)) {
+			return
+		}
+		assert.Equal(t, EndpointStatusDisabled, actual.Status)
+	})
+	t.Run("legacy status is read as disabled (v3)", func(t *testing.T) {
+		var asMap map[string]interface{}
+		_ = json.Unmarshal(events.CreateEvent(RegisterEndpoint, payload, nil).Marshal(), &asMap)
+		asMap["version"] = 3
+		delete(asMap, "ref")
+		// Since v3 the ref is the SHA-256 hash of the canonicalized event (without the ref itself)
+		data, _ := json.Marshal(asMap)
+		canonicalized, _ := jsoncanonicalizer.Transform(data)
+		ref := sha256.Sum256(canonicalized)
+		asMap["ref"] = hex.EncodeToString(ref[:])
+		data, _ = json.Marshal(asMap)
+		event, err := events.EventFromJSON(data)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, events.Version(3), event.Version())
+		assert.NoError(t, ValidatePayload(event))
+		var actual RegisterEndpointEvent
+		if !assert.NoError(t, event.Unmarshal(&actual)) {
+			return
+		}
+		assert.Equal(t, EndpointStatusDisabled, actual.Status)
+	})
+	t.Run("invalid status in current version is rejected", func(t *testing.T) {
+		event := events.CreateEvent(RegisterEndpoint, payload, nil)
+		err := ValidatePayload(event)
+		if !assert.Error(t, err) {
+			return
+		}
+		assert.Contains(t, err.Error(), "/status")
+		var actual RegisterEndpointEvent
+		if !assert.NoError(t, event.Unmarshal(&actual)) {
+			return
+		}
+		assert.Equal(t, "inactive", actual.Status)
+		assert.Error(t, actual.Validate())
+	})
+}
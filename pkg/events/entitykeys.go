@@ -0,0 +1,56 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package events
+
+import "sync"
+
+// EntityKey identifies the entity an event concerns (e.g. a vendor, or an endpoint of an organization). The lookup table
+// indexes the events by it, so the event path of an entity can be found without matching every event.
+type EntityKey struct {
+	// EventType is the type of the indexed events, since events of different types may concern the same entity.
+	EventType EventType
+	// ID identifies the entity, e.g. the ID of the vendor.
+	ID string
+}
+
+// EntityKeyFunc returns the key of the entity the given event concerns. If the key can't be determined (e.g. because
+// the payload is invalid) it should return false, in which case the event isn't indexed.
+type EntityKeyFunc func(event Event) (EntityKey, bool)
+
+var entityKeyFuncs = make(map[EventType]EntityKeyFunc)
+var entityKeyFuncsMutex sync.RWMutex
+
+// RegisterEntityKey registers the function which determines the entity key of events of the given type. Events are
+// indexed when they're registered in the lookup table, so it should be called before events are applied.
+func RegisterEntityKey(eventType EventType, fn EntityKeyFunc) {
+	entityKeyFuncsMutex.Lock()
+	defer entityKeyFuncsMutex.Unlock()
+	entityKeyFuncs[eventType] = fn
+}
+
+// entityKeyOf returns the entity key of the given event, or false if no key can be determined.
+func entityKeyOf(event Event) (EntityKey, bool) {
+	entityKeyFuncsMutex.RLock()
+	fn := entityKeyFuncs[event.Type()]
+	entityKeyFuncsMutex.RUnlock()
+	if fn == nil {
+		return EntityKey{}, false
+	}
+	return fn(event)
+}
@@ -22,6 +22,7 @@ package events
 import (
 	"bytes"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
@@ -30,13 +31,17 @@ import (
 	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/nuts-foundation/nuts-registry/logging"
 	"github.com/sirupsen/logrus"
-	"io"
 	"time"
 )
 
 // EventRef is a reference to an event
 type Ref []byte
 
+// ParseRef parses the string representation of a ref (as returned by Ref.String()).
+func ParseRef(input string) (Ref, error) {
+	return hex.DecodeString(input)
+}
+
 func (r *Ref) UnmarshalJSON(data []byte) error {
 	var str string
 	if err := json.Unmarshal(data, &str); err != nil {
@@ -69,7 +74,14 @@ func (r Ref) Equal(other Ref) bool {
 // Version
 type Version int
 
-const currentEventVersion Version = 1
+const currentEventVersion Version = 4
+
+// canonicalSignatureVersion is the first version in which the JWS covers the canonicalized envelope of the event
+// (type, version, issuedAt, prev and payload) rather than just the payload.
+const canonicalSignatureVersion Version = 2
+
+// sha256RefVersion is the first version in which the ref of the event is a SHA-256 hash rather than a SHA-1 hash.
+const sha256RefVersion Version = 3
 
 // Event defines an event which can be (un)marshalled.
 type Event interface {
@@ -107,6 +119,10 @@ type EventType string
 // ErrMissingEventType is given when the event being unmarshalled has no type attribute.
 var ErrMissingEventType = errors.New("unmarshalling error: missing event type")
 
+// ErrInvalidRef is given when the ref of the event being unmarshalled doesn't match its contents (e.g. because the
+// event has been tampered with) or when it's missing while the event's version requires one.
+var ErrInvalidRef = errors.New("event ref is invalid")
+
 type jsonEvent struct {
 	EventVersion     Version          `json:"version"`
 	EventType        string           `json:"type"`
@@ -116,11 +132,26 @@ type jsonEvent struct {
 	JWS              string           `json:"jws,omitempty"`
 	EventPayload     interface{}      `json:"payload,omitempty"`
 	signatureDetails SignatureDetails `json:"-"`
-	cachedData       []byte
+	// verifiedSigner holds the certificate of the event's signature once it has been verified (see VerifiedSigner).
+	verifiedSigner *x509.Certificate
+	// reapplied indicates the event is being re-applied to restore the canonical branch of a fork (see IsReapplied).
+	reapplied  bool
+	cachedData []byte
 }
 
+// SignatureDetails returns the details of the signature. When they haven't been set, the details are parsed from the JWS.
+// Parsing doesn't verify the signature, since that's done by the SignatureValidator when the event is processed. So the
+// certificate must not be used for authorization, use VerifiedSigner instead.
 func (j jsonEvent) SignatureDetails() SignatureDetails {
-	return j.signatureDetails
+	if j.signatureDetails.Certificate != nil || j.JWS == "" {
+		return j.signatureDetails
+	}
+	details, err := parseSignatureDetails([]byte(j.JWS))
+	if err != nil {
+		logging.Log().Debugf("Unable to parse signature details of event %s: %v", j.Ref(), err)
+		return j.signatureDetails
+	}
+	return *details
 }
 
 func (j jsonEvent) Version() Version {
@@ -163,6 +194,11 @@ func (j jsonEvent) Ref() Ref {
 		// This should never happen
 		panic(err)
 	}
+	if j.Version() >= sha256RefVersion {
+		sum := sha256.Sum256(canonicalizedJSON)
+		return sum[:]
+	}
+	// Legacy events
 	sum := sha1.Sum(canonicalizedJSON)
 	return sum[:]
 }
@@ -172,19 +208,48 @@ func (j jsonEvent) PreviousRef() Ref {
 }
 
 func (j *jsonEvent) Sign(signFn func([]byte) ([]byte, error)) error {
-	payload, err := json.Marshal(j.EventPayload)
+	data, err := j.signedData()
 	if err != nil {
 		return err
 	}
-	signature, err := signFn(payload)
+	signature, err := signFn(data)
 	if err != nil {
 		return err
 	}
 	j.JWS = string(signature)
+	j.verifiedSigner = nil
 	j.cachedData = nil // Reset cached data since we modified the event contents
 	return nil
 }
 
+// signedEnvelope contains the fields of an event which are covered by its signature (since v2).
+type signedEnvelope struct {
+	Type     string      `json:"type"`
+	Version  Version     `json:"version"`
+	IssuedAt time.Time   `json:"issuedAt"`
+	Prev     Ref         `json:"prev,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// signedData returns the data that should be covered by the event's signature. Before v2 that's just the payload,
+// since v2 it's the canonicalized (RFC 8785) envelope of the event.
+func (j jsonEvent) signedData() ([]byte, error) {
+	if j.Version() < canonicalSignatureVersion {
+		return json.Marshal(j.EventPayload)
+	}
+	data, err := json.Marshal(signedEnvelope{
+		Type:     j.EventType,
+		Version:  j.EventVersion,
+		IssuedAt: j.EventIssuedAt,
+		Prev:     j.PreviousEvent,
+		Payload:  j.EventPayload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(data)
+}
+
 func canonicalizeJSON(input []byte) ([]byte, error) {
 	return jsoncanonicalizer.Transform(input)
 }
@@ -233,11 +298,16 @@ func validateEvent(evt jsonEvent) error {
 	if evt.EventType == "" {
 		return ErrMissingEventType
 	}
-	if !evt.ThisEventRef.IsZero() {
-		actualRef := evt.Ref()
-		if !evt.ThisEventRef.Equal(actualRef) {
-			return fmt.Errorf("event ref is invalid (specified: %s, actual: %s)", evt.ThisEventRef, actualRef)
+	if evt.ThisEventRef.IsZero() {
+		// Events without version (v0) didn't have a ref
+		if evt.EventVersion >= 1 {
+			return fmt.Errorf("%w (missing)", ErrInvalidRef)
 		}
+		return nil
+	}
+	actualRef := evt.Ref()
+	if !evt.ThisEventRef.Equal(actualRef) {
+		return fmt.Errorf("%w (specified: %s, actual: %s)", ErrInvalidRef, evt.ThisEventRef, actualRef)
 	}
 	return nil
 }
@@ -262,33 +332,15 @@ func (j jsonEvent) Type() EventType {
 }
 
 func (j jsonEvent) Unmarshal(out interface{}) error {
-	if j.signatureDetails.Payload == nil {
-		// https://github.com/nuts-foundation/nuts-registry/issues/84
-		// Backwards compatibility for events that aren't signed
-		data := j.Marshal()
-		decoder := json.NewDecoder(bytes.NewReader(data))
-		// Look for "payload" field
-		for {
-			token, err := decoder.Token()
-			if err == io.EOF {
-				return errors.New("event has no payload")
-			}
-			if err != nil {
-				return err
-			}
-			str, ok := token.(string)
-			if ok && str == "payload" {
-				break
-			}
-		}
-		err := decoder.Decode(&out)
-		if err != nil {
-			return err
-		}
-	} else {
-		if err := json.Unmarshal(j.signatureDetails.Payload, &out); err != nil {
-			return err
-		}
+	payload, err := j.rawPayload()
+	if err != nil {
+		return err
+	}
+	if payload, err = upcast(j.Type(), j.Version(), payload); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		return err
 	}
 	postProc, ok := out.(UnmarshalPostProcessor)
 	if ok {
@@ -299,6 +351,19 @@ func (j jsonEvent) Unmarshal(out interface{}) error {
 	return nil
 }
 
+// rawPayload returns the payload of the event as JSON, as it was when the event was created (so before upcasting).
+// For unsigned events (https://github.com/nuts-foundation/nuts-registry/issues/84) it's taken from the parsed event
+// rather than the raw event data, so it's always the payload covered by the event's ref.
+func (j jsonEvent) rawPayload() ([]byte, error) {
+	if j.signatureDetails.Payload != nil {
+		return j.signatureDetails.Payload, nil
+	}
+	if j.EventPayload == nil {
+		return nil, errors.New("event has no payload")
+	}
+	return json.Marshal(j.EventPayload)
+}
+
 func (j jsonEvent) Marshal() []byte {
 	// Marshal a copy since Ref should be calculated
 	if j.cachedData == nil {
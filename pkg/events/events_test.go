@@ -19,12 +19,15 @@
 package events
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -160,6 +163,27 @@ func TestEventFromJSON(t *testing.T) {
 		event, err := EventFromJSON([]byte(input))
 		assert.Nil(t, event)
 		assert.EqualError(t, err, "event ref is invalid (specified: b1aa2fd05d040d20fe55152e14c336c0ab9d0d79, actual: b1aa2fd05d040d20fe55152e14c336c0ab9d0e79)")
+		assert.True(t, errors.Is(err, ErrInvalidRef))
+	})
+	t.Run("error - missing ref", func(t *testing.T) {
+		input := `{
+  "version": 1,
+  "type": "Test",
+  "issuedAt": "2020-04-27T10:25:22.861204915+02:00",
+  "payload": {
+    "Hello": "World"
+  }
+}`
+		event, err := EventFromJSON([]byte(input))
+		assert.Nil(t, event)
+		assert.EqualError(t, err, "event ref is invalid (missing)")
+	})
+	t.Run("error - tampered payload with original ref", func(t *testing.T) {
+		event := CreateEvent("Test", map[string]interface{}{"Hello": "World"}, nil)
+		data := strings.Replace(string(event.Marshal()), "World", "Mars", 1)
+		actual, err := EventFromJSON([]byte(data))
+		assert.Nil(t, actual)
+		assert.True(t, errors.Is(err, ErrInvalidRef))
 	})
 }
 
@@ -170,6 +194,20 @@ func TestRefCalculation(t *testing.T) {
 		assert.Equal(t, "eb0268837459c1cb5505fe9032caa50ab297de40", event.Ref().String())
 		assert.Equal(t, event.Ref(), event.Ref())
 	})
+	t.Run("ok - new events have a SHA-256 ref", func(t *testing.T) {
+		event := CreateEvent("Test", map[string]interface{}{"Hello": "World"}, nil)
+		assert.Len(t, event.Ref(), sha256.Size)
+		parsed, err := EventFromJSON(event.Marshal())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), parsed.Ref())
+	})
+	t.Run("ok - legacy events have a SHA-1 ref", func(t *testing.T) {
+		event := CreateEvent("Test", map[string]interface{}{"Hello": "World"}, nil)
+		(event.(*jsonEvent)).EventVersion = 2
+		assert.Len(t, event.Ref(), sha1.Size)
+	})
 	t.Run("ok - ref changes when included fields change", func(t *testing.T) {
 		// This test assumes the event is a flat JSON object (except payload ofc)
 
@@ -331,6 +369,7 @@ func TestMarshalEvent(t *testing.T) {
 		event := CreateEvent("v1", payload, []byte{1, 2, 3})
 		l, _ := time.LoadLocation("UTC")
 		(event.(*jsonEvent)).EventIssuedAt = time.Date(1970, 1, 1, 0, 0, 0, 0, l)
+		(event.(*jsonEvent)).EventVersion = 1
 
 		expected := `{
 	"issuedAt": ` + toJSON(event.IssuedAt()) + `,
@@ -436,6 +475,33 @@ func TestSignEvent(t *testing.T) {
 		}
 		assert.Equal(t, []byte("signature"), event.Signature())
 	})
+	t.Run("ok - v2 signs canonicalized envelope", func(t *testing.T) {
+		event := CreateEvent("Foobar", map[string]interface{}{"Hello": "World"}, []byte{1, 2, 3})
+		(event.(*jsonEvent)).EventVersion = 2
+		(event.(*jsonEvent)).EventIssuedAt = time.Unix(0, 0).UTC()
+		var signedData []byte
+		err := event.Sign(func(data []byte) ([]byte, error) {
+			signedData = data
+			return []byte("signature"), nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, `{"issuedAt":"1970-01-01T00:00:00Z","payload":{"Hello":"World"},"prev":"010203","type":"Foobar","version":2}`, string(signedData))
+	})
+	t.Run("ok - v1 signs payload", func(t *testing.T) {
+		event := CreateEvent("Foobar", map[string]interface{}{"Hello": "World"}, []byte{1, 2, 3})
+		(event.(*jsonEvent)).EventVersion = 1
+		var signedData []byte
+		err := event.Sign(func(data []byte) ([]byte, error) {
+			signedData = data
+			return []byte("signature"), nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, `{"Hello":"World"}`, string(signedData))
+	})
 	t.Run("ok - no signature", func(t *testing.T) {
 		event := CreateEvent("Foobar", struct{}{}, nil)
 		err := event.Sign(func(bytes2 []byte) (bytes []byte, err error) {
@@ -466,6 +532,18 @@ func TestRef_String(t *testing.T) {
 	assert.Equal(t, "c8c9ca", Ref([]byte{200, 201, 202}).String())
 }
 
+func TestParseRef(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		ref, err := ParseRef("c8c9ca")
+		assert.NoError(t, err)
+		assert.Equal(t, Ref([]byte{200, 201, 202}), ref)
+	})
+	t.Run("error - invalid hex", func(t *testing.T) {
+		_, err := ParseRef("xyz")
+		assert.Error(t, err)
+	})
+}
+
 func TestRef_Marshal(t *testing.T) {
 	t.Run("ok - roundtrip", func(t *testing.T) {
 		expected := Ref([]byte{1, 2, 3})
@@ -0,0 +1,106 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"sort"
+	"time"
+)
+
+// Fork describes an event path which forked: multiple events refer to the same previous event. This happens when an
+// entity is updated concurrently, e.g. by two nodes of the same vendor. The fork is resolved deterministically (see
+// ForkBranch.Canonical) so all nodes converge to the same state, but changes on the other branches are lost until the
+// fork is merged.
+type Fork struct {
+	// Parent is the ref of the event the branches refer to.
+	Parent Ref
+	// Branches holds the branches of the fork, the canonical branch first.
+	Branches []ForkBranch
+	// Merged indicates the canonical branch has been extended by an event issued after all events on the other branches,
+	// meaning the fork has been healed.
+	Merged bool
+}
+
+// ForkBranch is one of the branches of a forked event path.
+type ForkBranch struct {
+	// First is the ref of the first event on the branch, which refers to the fork's parent.
+	First Ref
+	// Last is the ref of the last event on the branch.
+	Last Ref
+	// Canonical indicates whether the branch wins the conflict resolution: the branch whose first event was issued
+	// earliest (or when issued at the same moment, has the lowest ref) is used.
+	Canonical bool
+}
+
+// forkList returns the forks in the lookup table, ordered by the moment their parent was issued.
+func (r *eventLookupTable) forkList() []Fork {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	var parents []Event
+	for parent := range r.forks {
+		parents = append(parents, parent)
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return precedes(parents[i], parents[j])
+	})
+	result := make([]Fork, 0, len(parents))
+	for _, parent := range parents {
+		fork := Fork{Parent: parent.Ref()}
+		var canonicalLast time.Time
+		var othersLast time.Time
+		for _, child := range r.forks[parent] {
+			branch := r.followBranch(child)
+			canonical := r.refs[parent] == child
+			entry := ForkBranch{First: child.Ref(), Last: branch[len(branch)-1].Ref(), Canonical: canonical}
+			if canonical {
+				canonicalLast = branch[len(branch)-1].IssuedAt()
+				fork.Branches = append([]ForkBranch{entry}, fork.Branches...)
+			} else {
+				for _, event := range branch {
+					if event.IssuedAt().After(othersLast) {
+						othersLast = event.IssuedAt()
+					}
+				}
+				fork.Branches = append(fork.Branches, entry)
+			}
+		}
+		fork.Merged = canonicalLast.After(othersLast)
+		result = append(result, fork)
+	}
+	return result
+}
+
+// followBranch returns the events on the branch starting with the given event, following the winning branches of nested forks.
+func (r *eventLookupTable) followBranch(event Event) []Event {
+	result := []Event{event}
+	for current := r.refs[event]; current != nil; current = r.refs[current] {
+		result = append(result, current)
+	}
+	return result
+}
+
+// IsReapplied returns whether the event is being re-applied by the event system to restore the state of the canonical
+// branch of a fork, after an event on another branch has been applied. Handlers which only maintain state should apply
+// it again, but handlers with side effects (e.g. sending the event to other systems) should skip it since they already
+// handled the event when it was applied the first time.
+func IsReapplied(event Event) bool {
+	jEvent, ok := event.(*jsonEvent)
+	return ok && jEvent.reapplied
+}
@@ -0,0 +1,158 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLookupTable_forkList(t *testing.T) {
+	root := CreateTestEvent(eventType, "root", nil, time.Unix(1000, 0))
+	branch1 := CreateTestEvent(eventType, "1", root.Ref(), time.Unix(3000, 0))
+	branch1Next := CreateTestEvent(eventType, "1-next", branch1.Ref(), time.Unix(4000, 0))
+	branch2 := CreateTestEvent(eventType, "2", root.Ref(), time.Unix(2000, 0))
+
+	t.Run("no forks", func(t *testing.T) {
+		lut := newEventLookupTable()
+		lut.register(root)
+		lut.register(branch1)
+		assert.Empty(t, lut.forkList())
+	})
+	t.Run("fork", func(t *testing.T) {
+		lut := newEventLookupTable()
+		lut.register(root)
+		lut.register(branch1)
+		lut.register(branch1Next)
+		lut.register(branch2)
+		forks := lut.forkList()
+		if !assert.Len(t, forks, 1) {
+			return
+		}
+		fork := forks[0]
+		assert.Equal(t, root.Ref(), fork.Parent)
+		assert.Equal(t, []ForkBranch{
+			{First: branch2.Ref(), Last: branch2.Ref(), Canonical: true},
+			{First: branch1.Ref(), Last: branch1Next.Ref()},
+		}, fork.Branches)
+		assert.False(t, fork.Merged)
+
+		t.Run("merged when canonical branch is extended", func(t *testing.T) {
+			lut.register(CreateTestEvent(eventType, "merge", branch2.Ref(), time.Unix(5000, 0)))
+			fork := lut.forkList()[0]
+			assert.True(t, fork.Merged)
+			assert.Equal(t, branch2.Ref(), fork.Branches[0].First)
+		})
+	})
+	t.Run("issued at the same moment, lowest ref wins", func(t *testing.T) {
+		a := CreateTestEvent(eventType, "a", root.Ref(), time.Unix(2000, 0))
+		b := CreateTestEvent(eventType, "b", root.Ref(), time.Unix(2000, 0))
+		expected := a
+		if b.Ref().String() < a.Ref().String() {
+			expected = b
+		}
+		for _, order := range [][]Event{{root, a, b}, {root, b, a}} {
+			lut := newEventLookupTable()
+			for _, event := range order {
+				lut.register(event)
+			}
+			assert.Equal(t, expected.Ref(), lut.forkList()[0].Branches[0].First)
+		}
+	})
+	t.Run("restored", func(t *testing.T) {
+		lut := newEventLookupTable()
+		assert.NoError(t, lut.restore([]Event{root, branch1, branch2}))
+		assert.Len(t, lut.forkList(), 1)
+	})
+}
+
+func TestEventSystem_Forks(t *testing.T) {
+	root := CreateTestEvent(eventType, "root", nil, time.Unix(1000, 0))
+	branch1 := CreateTestEvent(eventType, "1", root.Ref(), time.Unix(3000, 0))
+	branch1Next := CreateTestEvent(eventType, "1-next", branch1.Ref(), time.Unix(4000, 0))
+	branch2 := CreateTestEvent(eventType, "2", root.Ref(), time.Unix(2000, 0))
+	// setup creates an event system whose handler keeps the payload of the last applied event as state
+	setup := func(state *string) EventSystem {
+		system := NewEventSystem(eventType)
+		system.RegisterEventHandler(eventType, func(event Event, _ EventLookup) error {
+			return event.Unmarshal(state)
+		})
+		if err := system.Configure(io.TestDirectory(t)); err != nil {
+			panic(err)
+		}
+		return system
+	}
+
+	t.Run("state converges regardless of order", func(t *testing.T) {
+		orders := [][]Event{
+			{root, branch1, branch1Next, branch2},
+			{root, branch2, branch1, branch1Next},
+			{root, branch1, branch2, branch1Next},
+		}
+		for _, order := range orders {
+			var state string
+			system := setup(&state)
+			for _, event := range order {
+				if !assert.NoError(t, system.ProcessEvent(event)) {
+					return
+				}
+			}
+			assert.Equal(t, "2", state)
+			last, err := system.FindLastEvent(func(event Event) bool {
+				return true
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, branch2.Ref(), last.Ref())
+			assert.Len(t, system.Forks(), 1)
+			assert.Empty(t, system.ParkedEvents())
+			assert.Equal(t, "1", system.Diagnostics()[2].String())
+		}
+	})
+	t.Run("canonical event is marked as re-applied", func(t *testing.T) {
+		var state string
+		system := setup(&state)
+		var reapplied []Ref
+		system.RegisterEventHandler(eventType, func(event Event, _ EventLookup) error {
+			if IsReapplied(event) {
+				reapplied = append(reapplied, event.Ref())
+			}
+			return nil
+		})
+		for _, event := range []Event{root, branch2, branch1} {
+			_ = system.ProcessEvent(event)
+		}
+		assert.Equal(t, []Ref{branch2.Ref()}, reapplied)
+		assert.False(t, IsReapplied(system.Get(branch2.Ref())))
+	})
+	t.Run("merge", func(t *testing.T) {
+		var state string
+		system := setup(&state)
+		for _, event := range []Event{root, branch1, branch2} {
+			_ = system.ProcessEvent(event)
+		}
+		assert.NoError(t, system.ProcessEvent(CreateTestEvent(eventType, "1", branch2.Ref(), time.Unix(5000, 0))))
+		assert.Equal(t, "1", state)
+		assert.True(t, system.Forks()[0].Merged)
+		assert.Equal(t, "0", system.Diagnostics()[2].String())
+	})
+}
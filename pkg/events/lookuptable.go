@@ -19,8 +19,12 @@
 package events
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sync"
+
+	"github.com/nuts-foundation/nuts-registry/logging"
 )
 
 type EventLookup interface {
@@ -29,95 +33,254 @@ type EventLookup interface {
 	// FindLastEvent finds the last event in the event path which matches the specified matcher. If there are multiple
 	// event paths that match, an error is returned. If no events match, nil is returned.
 	FindLastEvent(matcher EventMatcher) (Event, error)
+	// FindEventPath finds the event path (ordered from first to last event) which matches the specified matcher. If there
+	// are multiple event paths that match, an error is returned. If no events match, nil is returned.
+	FindEventPath(matcher EventMatcher) ([]Event, error)
+	// FindLastEventByKey works like FindLastEvent, but only matches the events indexed under the given entity key (see
+	// RegisterEntityKey), so it doesn't need to match every event. If matcher is nil all indexed events match.
+	FindLastEventByKey(key EntityKey, matcher EventMatcher) (Event, error)
+	// FindEventPathByKey works like FindEventPath, but only matches the events indexed under the given entity key (see
+	// RegisterEntityKey), so it doesn't need to match every event. If matcher is nil all indexed events match.
+	FindEventPathByKey(key EntityKey, matcher EventMatcher) ([]Event, error)
+	// FindEventsByKey returns all events indexed under the given entity key (see RegisterEntityKey) in order of
+	// registration. Unlike FindEventPathByKey the events may be part of multiple event paths, e.g. when multiple vendors
+	// claimed the same organization.
+	FindEventsByKey(key EntityKey) []Event
 }
 
+// eventLookupTable holds the applied events. It's safe for concurrent use: it's altered by the event system's pipeline
+// while it's read by others (e.g. API calls).
 type eventLookupTable struct {
-	// refs contains all event references from parent to child (given that B refers to previous event A; {A -> B})
+	mux *sync.RWMutex
+	// refs contains all event references from parent to child (given that B refers to previous event A; {A -> B}). When
+	// multiple events refer to the same parent (a fork) it contains the child that wins the conflict resolution.
 	refs map[Event]Event
+	// forks contains all children of events referred to by more than one event, in order of registration {A -> [B, B']}
+	forks map[Event][]Event
 	// entries contains all events indexed by their ref {(ref(A) -> A, ref(B) -> B}
 	entries map[string]Event
+	// applied contains all events in order of registration [A, B]
+	applied []Event
+	// heads contains for every event the head (first event) of its path {A -> A, B -> A}
+	heads map[Event]Event
+	// tails contains for every head the last event of its path, following the branches that win the conflict resolution {A -> B}
+	tails map[Event]Event
+	// canonical contains the events which are on the winning branches of their path (see isCanonical)
+	canonical map[Event]bool
+	// index contains the events by the key of the entity they concern, in order of registration {key(A) -> [A, B]}
+	index map[EntityKey][]Event
 }
 
 func newEventLookupTable() *eventLookupTable {
-	return &eventLookupTable{refs: make(map[Event]Event, 0), entries: make(map[string]Event, 0)}
+	return &eventLookupTable{
+		mux:       &sync.RWMutex{},
+		refs:      make(map[Event]Event, 0),
+		forks:     make(map[Event][]Event, 0),
+		entries:   make(map[string]Event, 0),
+		heads:     make(map[Event]Event, 0),
+		tails:     make(map[Event]Event, 0),
+		canonical: make(map[Event]bool, 0),
+		index:     make(map[EntityKey][]Event, 0),
+	}
 }
 
-func (r eventLookupTable) Get(ref Ref) Event {
+func (r *eventLookupTable) Get(ref Ref) Event {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 	return r.entries[ref.String()]
 }
 
-func (r eventLookupTable) FindLastEvent(matcher EventMatcher) (Event, error) {
-	var matches = make(map[Event]bool)
-	for _, event := range r.entries {
-		if matcher(event) {
-			matches[event] = false
-		}
+func (r *eventLookupTable) FindLastEvent(matcher EventMatcher) (Event, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	head, err := r.findHead(r.applied, matcher)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	return r.tails[head], nil
+}
+
+func (r *eventLookupTable) FindEventPath(matcher EventMatcher) ([]Event, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	head, err := r.findHead(r.applied, matcher)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	return r.findPath(head), nil
+}
+
+func (r *eventLookupTable) FindLastEventByKey(key EntityKey, matcher EventMatcher) (Event, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	head, err := r.findHead(r.index[key], matcher)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	return r.tails[head], nil
+}
+
+func (r *eventLookupTable) FindEventPathByKey(key EntityKey, matcher EventMatcher) ([]Event, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	head, err := r.findHead(r.index[key], matcher)
+	if err != nil || head == nil {
+		return nil, err
 	}
-	// Find paths for matching events
-	var paths = make([][]Event, 0)
-	for event, matched := range matches {
-		if matched {
-			// Event already in a previously found path
+	return r.findPath(head), nil
+}
+
+func (r *eventLookupTable) FindEventsByKey(key EntityKey) []Event {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return append([]Event{}, r.index[key]...)
+}
+
+// findHead returns the head of the event path the matching candidates are part of. If the candidates which match are
+// part of multiple paths, an error is returned. If no candidates match, nil is returned. If matcher is nil all candidates match.
+func (r *eventLookupTable) findHead(candidates []Event, matcher EventMatcher) (Event, error) {
+	// Matching events belong to the same path when they have the same head, even when they're on a branch of a fork
+	var result Event
+	for _, event := range candidates {
+		if matcher != nil && !matcher(event) {
 			continue
 		}
-		path := r.findPath(event)
-		// Mark all events in path as matched
-		for _, eventInPath := range path {
-			matches[eventInPath] = true
+		head := r.heads[event]
+		if result != nil && result != head {
+			return nil, errors.New("multiple event paths match")
 		}
-		paths = append(paths, path)
-	}
-	if len(paths) == 0 {
-		return nil, nil
+		result = head
 	}
-	if len(paths) > 1 {
-		return nil, errors.New("multiple event paths match")
-	}
-	return paths[0][len(paths[0])-1], nil
+	return result, nil
 }
 
-func (r eventLookupTable) findPath(event Event) []Event {
+// findPath returns the path the given event is part of, ordered from first to last event. If the path forked, the
+// branches winning the conflict resolution are followed.
+func (r *eventLookupTable) findPath(event Event) []Event {
 	var path []Event
-	current := r.findHeadOfPath(event)
-	path = append(path, current)
-	for r.refs[current] != nil {
-		current = r.refs[current]
+	for current := r.heads[event]; current != nil; current = r.refs[current] {
 		path = append(path, current)
 	}
 	return path
 }
 
-// findHeadOfPath finds the head (first event) of the event path (events referring to previous events). Returns an error
-// if there the path is broken (missing events).
-func (r eventLookupTable) findHeadOfPath(event Event) Event {
-	if !event.PreviousRef().IsZero() {
-		// This event refers to another event
-		// Recursion should be safe for now, since we don't have paths with tens of thousands of events yet.
-		return r.findHeadOfPath(r.entries[event.PreviousRef().String()])
-	}
-	// This is the first event in its path
-	return event
+func (r *eventLookupTable) register(event Event) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.add(event)
 }
 
-func (r *eventLookupTable) register(event Event) error {
+func (r *eventLookupTable) add(event Event) error {
 	prevRef := event.PreviousRef()
-	if !prevRef.IsZero() {
+	if prevRef.IsZero() {
+		// This is the first event in its path
+		r.heads[event] = event
+		r.tails[event] = event
+		r.canonical[event] = true
+	} else {
 		// Event refers to a previous event, validate that:
 		// - referred event exists,
-		// - referred event isn't already referred to,
 		// - referred event is of same type
 		prevEvent := r.entries[prevRef.String()]
 		if prevEvent == nil {
 			return fmt.Errorf("previous event not found: %s", prevRef)
 		}
-		if r.refs[prevEvent] != nil {
-			return fmt.Errorf("previous event (%s) already referred to", prevRef)
-		}
 		if prevEvent.Type() != event.Type() {
 			return fmt.Errorf("previous event type differs (pref: %s=%s, this: %s=%s)", prevRef, prevEvent.Type(), event.Ref(), event.Type())
 		}
-		r.refs[prevEvent] = event
+		head := r.heads[prevEvent]
+		r.heads[event] = head
+		sibling := r.refs[prevEvent]
+		if sibling != nil {
+			// Referred event is already referred to by another event: the path forks
+			if r.forks[prevEvent] == nil {
+				r.forks[prevEvent] = []Event{sibling}
+			}
+			r.forks[prevEvent] = append(r.forks[prevEvent], event)
+			logging.Log().Warnf("Event path forks: events %s and %s both refer to previous event %s", sibling.Ref(), event.Ref(), prevRef)
+		}
+		if sibling == nil || precedes(event, sibling) {
+			r.refs[prevEvent] = event
+			if r.canonical[prevEvent] {
+				// The branch of the sibling (if any) no longer wins the conflict resolution
+				for current := sibling; current != nil; current = r.refs[current] {
+					delete(r.canonical, current)
+				}
+				r.canonical[event] = true
+				r.tails[head] = event
+			}
+		}
+	}
+	if key, ok := entityKeyOf(event); ok {
+		r.index[key] = append(r.index[key], event)
 	}
 	r.entries[event.Ref().String()] = event
+	r.applied = append(r.applied, event)
+	return nil
+}
+
+// precedes determines whether event a wins the conflict resolution over event b, when both refer to the same previous
+// event: the earliest issued event wins, or when issued at the same moment, the event with the lowest ref. Since this
+// only depends on the events themselves all nodes choose the same branch, regardless of the order they received them in.
+func precedes(a Event, b Event) bool {
+	if !a.IssuedAt().Equal(b.IssuedAt()) {
+		return a.IssuedAt().Before(b.IssuedAt())
+	}
+	return bytes.Compare(a.Ref(), b.Ref()) < 0
+}
+
+// isCanonical determines whether the event is on the winning branches of its path (in other words, whether it's part
+// of the path returned by findPath).
+func (r *eventLookupTable) isCanonical(event Event) bool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.canonical[event]
+}
+
+// lastOfPath returns the last event of the path the given event is part of.
+func (r *eventLookupTable) lastOfPath(event Event) Event {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.tails[r.heads[event]]
+}
+
+// eventsAfter returns the events registered after the event with the given ref, or all events if the ref is zero.
+func (r *eventLookupTable) eventsAfter(ref Ref) ([]Event, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	if ref.IsZero() {
+		return append([]Event{}, r.applied...), nil
+	}
+	if r.entries[ref.String()] == nil {
+		return nil, ErrUnknownEvent
+	}
+	// Most likely the requested event is one of the last events, so start searching at the end
+	for i := len(r.applied) - 1; i >= 0; i-- {
+		if r.applied[i].Ref().Equal(ref) {
+			return append([]Event{}, r.applied[i+1:]...), nil
+		}
+	}
+	return nil, ErrUnknownEvent
+}
+
+// restore replaces the contents of the lookup table with the given events, which must be in order of registration.
+// The event paths they form must be complete (all referred events must be present).
+func (r *eventLookupTable) restore(events []Event) error {
+	restored := newEventLookupTable()
+	for _, event := range events {
+		if err := restored.add(event); err != nil {
+			return err
+		}
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.entries = restored.entries
+	r.refs = restored.refs
+	r.forks = restored.forks
+	r.applied = restored.applied
+	r.heads = restored.heads
+	r.tails = restored.tails
+	r.canonical = restored.canonical
+	r.index = restored.index
 	return nil
 }
@@ -19,8 +19,10 @@
 package events
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 const eventType = "test"
@@ -43,19 +45,26 @@ func Test_EventLookup_register(t *testing.T) {
 		err := lut.register(CreateEvent(eventType+"2", eventPayload, event1.Ref()))
 		assert.Contains(t, err.Error(), "previous event type differs")
 	})
-	t.Run("error - event already referred to", func(t *testing.T) {
+	t.Run("ok - event already referred to (fork)", func(t *testing.T) {
 		event1 := CreateEvent(eventType, eventPayload, nil)
 		err := lut.register(event1)
 		if !assert.NoError(t, err) {
 			return
 		}
-		event2 := CreateEvent(eventType, eventPayload, event1.Ref())
+		event2 := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(2000, 0))
 		err = lut.register(event2)
 		if !assert.NoError(t, err) {
 			return
 		}
-		err = lut.register(CreateEvent(eventType, eventPayload, event1.Ref()))
-		assert.EqualError(t, err, "previous event ("+event1.Ref().String()+") already referred to")
+		event3 := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(1000, 0))
+		err = lut.register(event3)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Event{event2, event3}, lut.forks[event1])
+		// Earliest issued event wins
+		assert.Equal(t, event3, lut.refs[event1])
+		assert.NotNil(t, lut.Get(event2.Ref()))
 	})
 }
 
@@ -123,6 +132,30 @@ func Test_EventLookup_FindLastEvent(t *testing.T) {
 		}
 		assert.Nil(t, event)
 	})
+	t.Run("ok - forked path, last event of canonical branch", func(t *testing.T) {
+		lut := newEventLookupTable()
+		event1 := CreateTestEvent(eventType, eventPayload, nil, time.Unix(1000, 0))
+		event2 := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(3000, 0))
+		event3 := CreateTestEvent(eventType, eventPayload, event2.Ref(), time.Unix(4000, 0))
+		event2b := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(2000, 0))
+		lut.register(event1)
+		lut.register(event2)
+		lut.register(event3)
+		lut.register(event2b)
+		event, err := lut.FindLastEvent(func(event Event) bool {
+			return true
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event2b, event)
+		// Events on the other branch are part of the same path as well
+		event, err = lut.FindLastEvent(func(event Event) bool {
+			return event == event3
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, event2b, event)
+	})
 	t.Run("error - multiple paths match", func(t *testing.T) {
 		lut := newEventLookupTable()
 		lut.register(CreateEvent(eventType, eventPayload, nil))
@@ -134,3 +167,197 @@ func Test_EventLookup_FindLastEvent(t *testing.T) {
 		assert.EqualError(t, err, "multiple event paths match")
 	})
 }
+
+func Test_EventLookup_FindEventPath(t *testing.T) {
+	t.Run("ok - path ordered from first to last", func(t *testing.T) {
+		lut := newEventLookupTable()
+		event1 := CreateEvent(eventType, eventPayload, nil)
+		event2 := CreateEvent(eventType, eventPayload, event1.Ref())
+		event3 := CreateEvent(eventType, eventPayload, event2.Ref())
+		lut.register(event1)
+		lut.register(event2)
+		lut.register(event3)
+		path, err := lut.FindEventPath(func(event Event) bool {
+			return event == event2
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Event{event1, event2, event3}, path)
+	})
+	t.Run("ok - no matches", func(t *testing.T) {
+		lut := newEventLookupTable()
+		lut.register(CreateEvent(eventType, eventPayload, nil))
+		path, err := lut.FindEventPath(func(event Event) bool {
+			return false
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, path)
+	})
+	t.Run("error - multiple paths match", func(t *testing.T) {
+		lut := newEventLookupTable()
+		lut.register(CreateEvent(eventType, eventPayload, nil))
+		lut.register(CreateEvent(eventType, eventPayload, nil))
+		path, err := lut.FindEventPath(func(event Event) bool {
+			return true
+		})
+		assert.Nil(t, path)
+		assert.EqualError(t, err, "multiple event paths match")
+	})
+}
+
+// keyedEventType is an event type whose events are indexed by the ID in their payload (see keyedPayload).
+const keyedEventType = "keyed"
+
+type keyedPayload struct {
+	ID string `json:"id"`
+}
+
+func init() {
+	RegisterEntityKey(keyedEventType, func(event Event) (EntityKey, bool) {
+		payload := keyedPayload{}
+		if err := event.Unmarshal(&payload); err != nil || payload.ID == "" {
+			return EntityKey{}, false
+		}
+		return EntityKey{EventType: keyedEventType, ID: payload.ID}, true
+	})
+}
+
+func Test_EventLookup_FindByKey(t *testing.T) {
+	key := EntityKey{EventType: keyedEventType, ID: "a"}
+	event1 := CreateTestEvent(keyedEventType, keyedPayload{ID: "a"}, nil, time.Unix(1000, 0))
+	event2 := CreateTestEvent(keyedEventType, keyedPayload{ID: "a"}, event1.Ref(), time.Unix(2000, 0))
+	other := CreateTestEvent(keyedEventType, keyedPayload{ID: "b"}, nil, time.Unix(1000, 0))
+	lut := newEventLookupTable()
+	lut.register(event1)
+	lut.register(other)
+	lut.register(event2)
+	lut.register(CreateTestEvent(keyedEventType, keyedPayload{}, nil, time.Unix(1000, 0)))
+
+	t.Run("ok - last event", func(t *testing.T) {
+		event, err := lut.FindLastEventByKey(key, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event2, event)
+	})
+	t.Run("ok - path", func(t *testing.T) {
+		path, err := lut.FindEventPathByKey(key, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Event{event1, event2}, path)
+	})
+	t.Run("ok - matcher", func(t *testing.T) {
+		event, err := lut.FindLastEventByKey(key, func(event Event) bool {
+			return event == event1
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event2, event)
+		event, err = lut.FindLastEventByKey(key, func(event Event) bool {
+			return false
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	})
+	t.Run("ok - all events", func(t *testing.T) {
+		assert.Equal(t, []Event{event1, event2}, lut.FindEventsByKey(key))
+		assert.Empty(t, lut.FindEventsByKey(EntityKey{EventType: keyedEventType, ID: "c"}))
+	})
+	t.Run("ok - unknown key", func(t *testing.T) {
+		path, err := lut.FindEventPathByKey(EntityKey{EventType: keyedEventType, ID: "c"}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, path)
+	})
+	t.Run("ok - restored", func(t *testing.T) {
+		restored := newEventLookupTable()
+		if !assert.NoError(t, restored.restore(lut.applied)) {
+			return
+		}
+		event, err := restored.FindLastEventByKey(key, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, event2, event)
+	})
+	t.Run("error - multiple paths match", func(t *testing.T) {
+		lut := newEventLookupTable()
+		lut.register(CreateTestEvent(keyedEventType, keyedPayload{ID: "a"}, nil, time.Unix(1000, 0)))
+		lut.register(CreateTestEvent(keyedEventType, keyedPayload{ID: "a"}, nil, time.Unix(2000, 0)))
+		event, err := lut.FindLastEventByKey(key, nil)
+		assert.Nil(t, event)
+		assert.EqualError(t, err, "multiple event paths match")
+	})
+}
+
+func Test_EventLookup_HeadsAndTails(t *testing.T) {
+	lut := newEventLookupTable()
+	event1 := CreateTestEvent(eventType, eventPayload, nil, time.Unix(1000, 0))
+	event2 := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(3000, 0))
+	event3 := CreateTestEvent(eventType, eventPayload, event2.Ref(), time.Unix(4000, 0))
+	event3b := CreateTestEvent(eventType, eventPayload, event2.Ref(), time.Unix(5000, 0))
+	event2b := CreateTestEvent(eventType, eventPayload, event1.Ref(), time.Unix(2000, 0))
+	event4b := CreateTestEvent(eventType, eventPayload, event3b.Ref(), time.Unix(6000, 0))
+
+	lut.register(event1)
+	lut.register(event2)
+	lut.register(event3)
+	assert.Equal(t, event3, lut.lastOfPath(event1))
+	// Loses the conflict resolution, the canonical branch doesn't change
+	lut.register(event3b)
+	assert.Equal(t, event3, lut.lastOfPath(event3b))
+	assert.False(t, lut.isCanonical(event3b))
+	// Wins the conflict resolution, the branch of event2 is no longer canonical
+	lut.register(event2b)
+	assert.Equal(t, event2b, lut.lastOfPath(event3))
+	assert.True(t, lut.isCanonical(event2b))
+	assert.False(t, lut.isCanonical(event2))
+	assert.False(t, lut.isCanonical(event3))
+	// Extends a branch which isn't canonical
+	lut.register(event4b)
+	assert.Equal(t, event2b, lut.lastOfPath(event4b))
+	assert.False(t, lut.isCanonical(event4b))
+	assert.Equal(t, []Event{event1, event2b}, lut.findPath(event4b))
+	for _, event := range []Event{event1, event2, event3, event3b, event2b, event4b} {
+		assert.Equal(t, event1, lut.heads[event])
+	}
+}
+
+// BenchmarkEventLookupTable compares finding the last event of an entity's event path by matching every event against
+// looking it up by entity key, in a lookup table holding 50k events (10k entities with 5 events each).
+func BenchmarkEventLookupTable(b *testing.B) {
+	const entities = 10000
+	const pathLength = 5
+	lut := newEventLookupTable()
+	for i := 0; i < entities; i++ {
+		var prev Ref
+		for j := 0; j < pathLength; j++ {
+			event := CreateTestEvent(keyedEventType, keyedPayload{ID: fmt.Sprintf("%d", i)}, prev, time.Unix(int64(j), 0))
+			if err := lut.register(event); err != nil {
+				b.Fatal(err)
+			}
+			prev = event.Ref()
+		}
+	}
+	id := fmt.Sprintf("%d", entities/2)
+	matcher := func(event Event) bool {
+		payload := keyedPayload{}
+		_ = event.Unmarshal(&payload)
+		return payload.ID == id
+	}
+	b.Run("FindLastEvent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if event, _ := lut.FindLastEvent(matcher); event == nil {
+				b.Fatal("event not found")
+			}
+		}
+	})
+	b.Run("FindLastEventByKey", func(b *testing.B) {
+		key := EntityKey{EventType: keyedEventType, ID: id}
+		for i := 0; i < b.N; i++ {
+			if event, _ := lut.FindLastEventByKey(key, matcher); event == nil {
+				b.Fatal("event not found")
+			}
+		}
+	})
+}
@@ -0,0 +1,108 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/nuts-foundation/nuts-registry/logging"
+)
+
+// ErrEventSystemClosed is returned when work is submitted to the event system while it's being closed.
+var ErrEventSystemClosed = errors.New("the event system has been closed")
+
+// pipeline serializes the work on the event system's state: submitted jobs are executed one at a time by a single
+// goroutine, which owns the state. It's started on the first submitted job and stopped by stop().
+type pipeline struct {
+	jobs    chan pipelineJob
+	quit    chan struct{}
+	done    chan struct{}
+	running bool
+	mux     *sync.Mutex
+}
+
+type pipelineJob struct {
+	fn     func() error
+	result chan error
+}
+
+func newPipeline() *pipeline {
+	return &pipeline{jobs: make(chan pipelineJob), mux: &sync.Mutex{}}
+}
+
+// submit executes fn on the pipeline's goroutine and waits for it to complete. It must not be called from fn itself
+// (or any function called by fn, e.g. an event handler), since that would deadlock.
+func (p *pipeline) submit(fn func() error) error {
+	p.mux.Lock()
+	if !p.running {
+		p.quit = make(chan struct{})
+		p.done = make(chan struct{})
+		p.running = true
+		go p.run(p.quit, p.done)
+	}
+	quit := p.quit
+	p.mux.Unlock()
+
+	job := pipelineJob{fn: fn, result: make(chan error, 1)}
+	select {
+	case p.jobs <- job:
+		return <-job.result
+	case <-quit:
+		return ErrEventSystemClosed
+	}
+}
+
+// stop stops the pipeline's goroutine after the job it's executing (if any) completes. Jobs submitted afterwards start it again.
+func (p *pipeline) stop() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if !p.running {
+		return
+	}
+	close(p.quit)
+	<-p.done
+	p.running = false
+}
+
+func (p *pipeline) run(quit chan struct{}, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case job := <-p.jobs:
+			job.result <- execute(job.fn)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// execute calls fn, converting a panic into an error so it doesn't take down the pipeline (and the application with it).
+func execute(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Log().Errorf("Panic while processing events: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic while processing events: %v", r)
+		}
+	}()
+	return fn()
+}
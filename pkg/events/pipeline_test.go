@@ -0,0 +1,157 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Run("jobs are executed one at a time", func(t *testing.T) {
+		p := newPipeline()
+		defer p.stop()
+		// Deliberately not synchronized: the race detector complains if jobs are executed concurrently
+		counter := 0
+		wg := sync.WaitGroup{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = p.submit(func() error {
+					counter++
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 50, counter)
+	})
+	t.Run("error is returned", func(t *testing.T) {
+		p := newPipeline()
+		defer p.stop()
+		assert.EqualError(t, p.submit(func() error {
+			return errors.New("failed")
+		}), "failed")
+	})
+	t.Run("panic is returned as error", func(t *testing.T) {
+		p := newPipeline()
+		defer p.stop()
+		err := p.submit(func() error {
+			panic("oops")
+		})
+		assert.EqualError(t, err, "panic while processing events: oops")
+		// Pipeline still works
+		assert.NoError(t, p.submit(func() error { return nil }))
+	})
+	t.Run("restart after stop", func(t *testing.T) {
+		p := newPipeline()
+		assert.NoError(t, p.submit(func() error { return nil }))
+		p.stop()
+		p.stop()
+		assert.NoError(t, p.submit(func() error { return nil }))
+		p.stop()
+	})
+	t.Run("stop waits for running job", func(t *testing.T) {
+		p := newPipeline()
+		started := make(chan struct{})
+		finished := false
+		go func() {
+			_ = p.submit(func() error {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+				finished = true
+				return nil
+			})
+		}()
+		<-started
+		p.stop()
+		assert.True(t, finished)
+	})
+}
+
+func TestEventSystem_ConcurrentProcessing(t *testing.T) {
+	const eventsPerPath = 20
+	eventType := EventType("concurrent")
+	dir := io.TestDirectory(t)
+	system := NewEventSystem(eventType)
+	// Not synchronized, handlers are invoked by a single goroutine
+	handled := map[string]bool{}
+	system.RegisterEventHandler(eventType, func(event Event, _ EventLookup) error {
+		handled[event.Ref().String()] = true
+		return nil
+	})
+	if !assert.NoError(t, system.Configure(dir)) {
+		return
+	}
+	defer system.Close()
+
+	// Events are stored in files named after the moment they were issued (in milliseconds), so make those unique
+	start := time.Now()
+	issuedAt := func(path int, i int) time.Time {
+		return start.Add(time.Duration(3*i+path) * time.Millisecond)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			assert.NoError(t, system.ProcessEvent(CreateTestEvent(eventType, fmt.Sprintf("process-%d", i), nil, issuedAt(0, i))))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			assert.NoError(t, system.PublishEvent(CreateTestEvent(eventType, fmt.Sprintf("publish-%d", i), nil, issuedAt(1, i))))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			event := CreateTestEvent(eventType, fmt.Sprintf("file-%d", i), nil, issuedAt(2, i))
+			file := filepath.Join(dir, SuggestEventFileName(event))
+			if !assert.NoError(t, ioutil.WriteFile(file+".tmp", event.Marshal(), 0600)) {
+				return
+			}
+			if !assert.NoError(t, os.Rename(file+".tmp", file)) {
+				return
+			}
+			assert.NoError(t, system.LoadAndApplyEvents())
+		}
+	}()
+	wg.Wait()
+
+	var count int
+	_ = system.Exclusive(func() error {
+		count = len(handled)
+		return nil
+	})
+	assert.Equal(t, 3*eventsPerPath, count)
+	assert.Empty(t, system.ParkedEvents())
+}
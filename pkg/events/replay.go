@@ -0,0 +1,72 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package events
+
+import (
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/logging"
+)
+
+// replay applies the events in the lookup table which were issued at or before the given moment to the given handlers,
+// in order of registration. The handlers are passed a lookup table which only contains the replayed events, so the
+// event paths are followed as they were at that moment. Events that can't be applied at that moment (e.g. because
+// they refer to an event which was issued later, due to clock skew) are skipped.
+func (r *eventLookupTable) replay(moment time.Time, handlers map[EventType][]EventHandler) {
+	applied, _ := r.eventsAfter(nil)
+	replayed := newEventLookupTable()
+	apply := func(event Event) error {
+		for _, handler := range handlers[event.Type()] {
+			if err := handler(event, replayed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, event := range applied {
+		if event.IssuedAt().After(moment) {
+			continue
+		}
+		if !event.PreviousRef().IsZero() && replayed.Get(event.PreviousRef()) == nil {
+			logging.Log().Debugf("Skipping event in replay, previous event was issued later (ref = %s)", event.Ref())
+			continue
+		}
+		if err := apply(event); err != nil {
+			logging.Log().Debugf("Skipping event in replay, it can't be applied (ref = %s): %v", event.Ref(), err)
+			continue
+		}
+		if err := replayed.register(event); err != nil {
+			logging.Log().Debugf("Skipping event in replay (ref = %s): %v", event.Ref(), err)
+			continue
+		}
+		// Same as when processing events: when the event lost the conflict resolution of a fork, the last event of the
+		// canonical branch is applied again.
+		if !replayed.isCanonical(event) {
+			_ = apply(replayed.lastOfPath(event))
+		}
+	}
+}
+
+func (system *diskEventSystem) Replay(moment time.Time, registerHandlers func(EventRegistrar)) {
+	handlers := make(map[EventType][]EventHandler)
+	registerHandlers(func(eventType EventType, handler EventHandler) {
+		handlers[eventType] = append(handlers[eventType], handler)
+	})
+	system.lut.replay(moment, handlers)
+}
@@ -0,0 +1,82 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskEventSystem_Replay(t *testing.T) {
+	system := NewEventSystem(eventType)
+	_ = system.Configure(io.TestDirectory(t))
+	system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+		return nil
+	})
+	root := CreateTestEvent(eventType, "root", nil, time.Unix(1000, 0))
+	update := CreateTestEvent(eventType, "update", root.Ref(), time.Unix(2000, 0))
+	// Concurrent update, wins the conflict resolution since it was issued earlier
+	concurrentUpdate := CreateTestEvent(eventType, "concurrent update", root.Ref(), time.Unix(1500, 0))
+	// Issued before the event it refers to (clock skew)
+	skewed := CreateTestEvent(eventType, "skewed", update.Ref(), time.Unix(900, 0))
+	for _, event := range []Event{root, update, concurrentUpdate, skewed} {
+		if !assert.NoError(t, system.PublishEvent(event)) {
+			return
+		}
+	}
+	// replay returns the payload of the last event applied, and the refs of the events passed to the handler
+	replay := func(moment time.Time) (string, []Ref, EventLookup) {
+		var state string
+		var applied []Ref
+		var lookup EventLookup
+		system.Replay(moment, func(registrar EventRegistrar) {
+			registrar(eventType, func(event Event, l EventLookup) error {
+				_ = event.Unmarshal(&state)
+				applied = append(applied, event.Ref())
+				lookup = l
+				return nil
+			})
+		})
+		return state, applied, lookup
+	}
+
+	t.Run("ok - before first event", func(t *testing.T) {
+		state, applied, _ := replay(time.Unix(500, 0))
+		assert.Empty(t, state)
+		assert.Empty(t, applied)
+	})
+	t.Run("ok - only events issued at or before moment", func(t *testing.T) {
+		state, applied, lookup := replay(time.Unix(1000, 0))
+		assert.Equal(t, "root", state)
+		assert.Equal(t, []Ref{root.Ref()}, applied)
+		assert.Nil(t, lookup.Get(update.Ref()))
+	})
+	t.Run("ok - event referring to an event issued later is skipped", func(t *testing.T) {
+		state, applied, _ := replay(time.Unix(1800, 0))
+		assert.Equal(t, "concurrent update", state)
+		assert.Equal(t, []Ref{root.Ref(), concurrentUpdate.Ref()}, applied)
+	})
+	t.Run("ok - canonical branch of fork is re-applied", func(t *testing.T) {
+		state, _, _ := replay(time.Unix(3000, 0))
+		assert.Equal(t, "concurrent update", state)
+	})
+}
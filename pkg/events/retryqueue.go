@@ -0,0 +1,224 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/logging"
+	errors2 "github.com/pkg/errors"
+)
+
+// retryQueueFileName is the name of the file in the events directory the retry queue is persisted to. It deliberately
+// doesn't have a .json extension, since those files are considered to be events.
+const retryQueueFileName = "retry-queue.dat"
+
+// MaxEventAttempts is the number of times processing a parked event may fail before it's moved to the dead-letter state.
+const MaxEventAttempts = 10
+
+// eventRetryInterval is the minimum time between the first two failed attempts that count against an event's retry
+// budget; it doubles for every subsequent attempt. Parked events are retried whenever other events are applied, but
+// failures within the interval aren't counted, so events which are applied in quick succession (most of them unrelated
+// to the parked event) don't exhaust its budget. With 10 attempts an event is dead-lettered after failing for ~8.5 hours.
+const eventRetryInterval = time.Minute
+
+// ParkedEventState describes the state of an event in the retry queue.
+type ParkedEventState string
+
+const (
+	// ParkedEventPending indicates the event is retried when other events are processed.
+	ParkedEventPending ParkedEventState = "pending"
+	// ParkedEventDeadLetter indicates the retry budget of the event is exhausted; it's only retried on request.
+	ParkedEventDeadLetter ParkedEventState = "dead-letter"
+)
+
+// ParkedEvent is an event which couldn't be applied (yet), because the event it refers to hasn't been processed yet or
+// because one of the event handlers failed. It's set aside in the retry queue to be retried later.
+type ParkedEvent struct {
+	Event Event            `json:"-"`
+	State ParkedEventState `json:"state"`
+	// Attempts holds the number of times processing the event failed, counting at most one failure per retry interval.
+	Attempts int `json:"attempts"`
+	// LastError holds the reason why the event couldn't be applied the last time it was tried.
+	LastError   string    `json:"lastError,omitempty"`
+	ParkedAt    time.Time `json:"parkedAt"`
+	LastAttempt time.Time `json:"lastAttempt"`
+}
+
+// RetryQueue gives access to events which couldn't be applied (yet) and are parked to be retried later.
+type RetryQueue interface {
+	// ParkedEvents returns the events in the retry queue, ordered by the moment they were issued.
+	ParkedEvents() []ParkedEvent
+	// ParkedEvent returns the event in the retry queue with the given ref. If it's not in the queue ErrUnknownEvent is returned.
+	ParkedEvent(ref Ref) (*ParkedEvent, error)
+	// RetryParkedEvent tries to apply the parked event with the given ref, regardless of its state. Its retry budget is
+	// reset beforehand. If it still can't be applied, the reason is returned as error.
+	RetryParkedEvent(ref Ref) error
+	// DiscardParkedEvent removes the event with the given ref from the retry queue, so it won't be retried anymore.
+	DiscardParkedEvent(ref Ref) error
+}
+
+// persistedParkedEvent is the structure in which parked events are persisted.
+type persistedParkedEvent struct {
+	ParkedEvent
+	Data storedEvent `json:"event"`
+}
+
+// retryQueue holds the parked events, which are persisted to file (if configured) when flushed.
+type retryQueue struct {
+	file    string
+	entries map[string]*ParkedEvent
+	// dirty indicates the queue has been changed since it was last persisted.
+	dirty bool
+	now   func() time.Time
+}
+
+func newRetryQueue() *retryQueue {
+	return &retryQueue{entries: make(map[string]*ParkedEvent), now: time.Now}
+}
+
+// load reads the parked events from the given file, which is also used to persist subsequent changes.
+func (q *retryQueue) load(file string) error {
+	q.file = file
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors2.Wrap(err, "unable to read retry queue")
+	}
+	var persisted []persistedParkedEvent
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return errors2.Wrap(err, "unable to parse retry queue")
+	}
+	entries := make(map[string]*ParkedEvent, len(persisted))
+	for _, p := range persisted {
+		event, err := EventFromJSONWithIssuedAt(p.Data.Data, p.Data.IssuedAt)
+		if err != nil {
+			return errors2.Wrap(err, "unable to parse event in retry queue")
+		}
+		entry := p.ParkedEvent
+		entry.Event = event
+		entries[event.Ref().String()] = &entry
+	}
+	q.entries = entries
+	return nil
+}
+
+// flush persists the queue if it has been changed since it was last persisted. It's called once the work submitted to
+// the event system completes, rather than on every change, so (re)loading many events doesn't rewrite the file for
+// each one of them.
+func (q *retryQueue) flush() {
+	if q.file == "" || !q.dirty {
+		return
+	}
+	persisted := make([]persistedParkedEvent, 0, len(q.entries))
+	for _, entry := range q.list() {
+		persisted = append(persisted, persistedParkedEvent{
+			ParkedEvent: entry,
+			Data:        storedEvent{IssuedAt: entry.Event.IssuedAt(), Data: entry.Event.Marshal()},
+		})
+	}
+	data, _ := json.Marshal(persisted)
+	// Write to a temporary file first, so an interrupted write doesn't corrupt the queue
+	tmpFile := q.file + ".tmp"
+	err := ioutil.WriteFile(tmpFile, data, 0600)
+	if err == nil {
+		err = os.Rename(tmpFile, q.file)
+	}
+	if err != nil {
+		logging.Log().WithError(err).Error("Unable to persist retry queue")
+		return
+	}
+	q.dirty = false
+}
+
+// park adds the event to the queue or, if it's already there, updates it with the given cause. If attempted is true,
+// the cause is a failed attempt to process the event which counts against its retry budget, unless the previous
+// counted attempt was within the retry interval (see eventRetryInterval).
+func (q *retryQueue) park(event Event, cause error, attempted bool) {
+	now := q.now()
+	entry, ok := q.entries[event.Ref().String()]
+	if !ok {
+		entry = &ParkedEvent{Event: event, State: ParkedEventPending, ParkedAt: now}
+		q.entries[event.Ref().String()] = entry
+	}
+	entry.LastError = cause.Error()
+	q.dirty = true
+	if attempted && (entry.Attempts == 0 || !now.Before(entry.LastAttempt.Add(retryInterval(entry.Attempts)))) {
+		entry.Attempts++
+		entry.LastAttempt = now
+		if entry.State == ParkedEventPending && entry.Attempts >= MaxEventAttempts {
+			logging.Log().Warnf("Event %s couldn't be applied after %d attempts, it won't be retried anymore: %s", event.Ref(), entry.Attempts, entry.LastError)
+			entry.State = ParkedEventDeadLetter
+		}
+	}
+}
+
+// deadLetter adds the event to the queue in the dead-letter state or, if it's already there, moves it there, since
+// retrying it is pointless (e.g. because its payload is invalid). It's only retried on request.
+func (q *retryQueue) deadLetter(event Event, cause error) {
+	q.park(event, cause, true)
+	q.entries[event.Ref().String()].State = ParkedEventDeadLetter
+}
+
+// retryInterval returns the minimum time between the given number of counted attempts and the next one.
+func retryInterval(attempts int) time.Duration {
+	return eventRetryInterval << (attempts - 1)
+}
+
+func (q *retryQueue) remove(ref Ref) bool {
+	if _, ok := q.entries[ref.String()]; !ok {
+		return false
+	}
+	delete(q.entries, ref.String())
+	q.dirty = true
+	return true
+}
+
+func (q *retryQueue) get(ref Ref) *ParkedEvent {
+	return q.entries[ref.String()]
+}
+
+// list returns (copies of) the parked events, ordered by the moment they were issued.
+func (q *retryQueue) list() []ParkedEvent {
+	result := make([]ParkedEvent, 0, len(q.entries))
+	for _, entry := range q.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Event.IssuedAt().Before(result[j].Event.IssuedAt())
+	})
+	return result
+}
+
+// count returns the number of parked events in the given state.
+func (q *retryQueue) count(state ParkedEventState) int {
+	var result int
+	for _, entry := range q.entries {
+		if entry.State == state {
+			result++
+		}
+	}
+	return result
+}
@@ -0,0 +1,206 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryQueue(t *testing.T) {
+	eventType := EventType("retryable")
+	// setup creates an event system whose handler fails as long as *fail is true
+	setup := func(dir string, fail *bool, handled *int) EventSystem {
+		system := NewEventSystem(eventType)
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			if *fail {
+				return errors.New("failed")
+			}
+			*handled++
+			return nil
+		})
+		if err := system.Configure(dir); err != nil {
+			panic(err)
+		}
+		return system
+	}
+
+	t.Run("failed event is parked and persisted", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		fail := true
+		handled := 0
+		system := setup(dir, &fail, &handled)
+		event := CreateTestEvent(eventType, 1, nil, time.Now())
+		assert.Error(t, system.ProcessEvent(event))
+
+		parked, err := system.ParkedEvent(event.Ref())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, ParkedEventPending, parked.State)
+		assert.Equal(t, 1, parked.Attempts)
+		assert.Equal(t, "failed", parked.LastError)
+		assert.Equal(t, event.Ref(), parked.Event.Ref())
+
+		// Restart: event is still parked, and applied when its handler succeeds
+		fail = false
+		system = setup(dir, &fail, &handled)
+		if !assert.Len(t, system.ParkedEvents(), 1) {
+			return
+		}
+		restored := system.ParkedEvents()[0]
+		assert.Equal(t, 1, restored.Attempts)
+		assert.Equal(t, event.Ref(), restored.Event.Ref())
+		assert.Equal(t, event.IssuedAt().UTC(), restored.Event.IssuedAt().UTC())
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 1, handled)
+		assert.Empty(t, system.ParkedEvents())
+		assert.NotNil(t, system.Get(event.Ref()))
+	})
+	t.Run("out of order event is parked without counting as attempt", func(t *testing.T) {
+		fail := false
+		handled := 0
+		system := setup(io.TestDirectory(t), &fail, &handled)
+		event1 := CreateTestEvent(eventType, 1, nil, time.Now())
+		event2 := CreateTestEvent(eventType, 2, event1.Ref(), time.Now())
+		assert.NoError(t, system.ProcessEvent(event2))
+		parked, _ := system.ParkedEvent(event2.Ref())
+		if !assert.NotNil(t, parked) {
+			return
+		}
+		assert.Equal(t, 0, parked.Attempts)
+		assert.Contains(t, parked.LastError, "hasn't been processed yet")
+	})
+	t.Run("event is dead-lettered when retry budget is exhausted", func(t *testing.T) {
+		fail := true
+		handled := 0
+		system := setup(io.TestDirectory(t), &fail, &handled)
+		now := time.Now()
+		system.(*diskEventSystem).retryQueue.now = func() time.Time {
+			return now
+		}
+		event := CreateTestEvent(eventType, 1, nil, time.Unix(1000, 0))
+		_ = system.ProcessEvent(event)
+		for i := 1; i < MaxEventAttempts; i++ {
+			// Pending events are retried whenever events are (re)loaded, failures count once per retry interval
+			now = now.Add(retryInterval(i))
+			_ = system.LoadAndApplyEvents()
+		}
+		parked, _ := system.ParkedEvent(event.Ref())
+		assert.Equal(t, ParkedEventDeadLetter, parked.State)
+		assert.Equal(t, MaxEventAttempts, parked.Attempts)
+		assert.Equal(t, "1", system.Diagnostics()[1].String())
+
+		// Dead-lettered events aren't retried automatically anymore
+		fail = false
+		_ = system.LoadAndApplyEvents()
+		parked, _ = system.ParkedEvent(event.Ref())
+		assert.Equal(t, ParkedEventDeadLetter, parked.State)
+		assert.Nil(t, system.Get(event.Ref()))
+	})
+	t.Run("failures within the retry interval don't count as attempt", func(t *testing.T) {
+		fail := true
+		handled := 0
+		system := setup(io.TestDirectory(t), &fail, &handled)
+		now := time.Now()
+		system.(*diskEventSystem).retryQueue.now = func() time.Time {
+			return now
+		}
+		event := CreateTestEvent(eventType, 1, nil, time.Now())
+		_ = system.ProcessEvent(event)
+		for i := 0; i < MaxEventAttempts; i++ {
+			_ = system.LoadAndApplyEvents()
+		}
+		parked, _ := system.ParkedEvent(event.Ref())
+		assert.Equal(t, ParkedEventPending, parked.State)
+		assert.Equal(t, 1, parked.Attempts)
+		now = now.Add(eventRetryInterval)
+		_ = system.LoadAndApplyEvents()
+		parked, _ = system.ParkedEvent(event.Ref())
+		assert.Equal(t, 2, parked.Attempts)
+	})
+	t.Run("force retry", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			fail := true
+			handled := 0
+			system := setup(io.TestDirectory(t), &fail, &handled)
+			event := CreateTestEvent(eventType, 1, nil, time.Now())
+			_ = system.ProcessEvent(event)
+			fail = false
+			assert.NoError(t, system.RetryParkedEvent(event.Ref()))
+			assert.Empty(t, system.ParkedEvents())
+			assert.Equal(t, 1, handled)
+		})
+		t.Run("still failing", func(t *testing.T) {
+			fail := true
+			handled := 0
+			system := setup(io.TestDirectory(t), &fail, &handled)
+			event := CreateTestEvent(eventType, 1, nil, time.Now())
+			_ = system.ProcessEvent(event)
+			assert.EqualError(t, system.RetryParkedEvent(event.Ref()), "failed")
+			parked, _ := system.ParkedEvent(event.Ref())
+			assert.Equal(t, 1, parked.Attempts)
+		})
+		t.Run("previous event not processed", func(t *testing.T) {
+			fail := false
+			handled := 0
+			system := setup(io.TestDirectory(t), &fail, &handled)
+			event := CreateTestEvent(eventType, 1, []byte{1, 2, 3}, time.Now())
+			_ = system.ProcessEvent(event)
+			err := system.RetryParkedEvent(event.Ref())
+			assert.EqualError(t, err, "previous event 010203 hasn't been processed yet")
+		})
+		t.Run("unknown event", func(t *testing.T) {
+			fail := false
+			handled := 0
+			system := setup(io.TestDirectory(t), &fail, &handled)
+			assert.Equal(t, ErrUnknownEvent, system.RetryParkedEvent([]byte{1, 2, 3}))
+		})
+	})
+	t.Run("discard", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		fail := true
+		handled := 0
+		system := setup(dir, &fail, &handled)
+		event := CreateTestEvent(eventType, 1, nil, time.Now())
+		_ = system.ProcessEvent(event)
+		assert.NoError(t, system.DiscardParkedEvent(event.Ref()))
+		assert.Equal(t, ErrUnknownEvent, system.DiscardParkedEvent(event.Ref()))
+		_, err := system.ParkedEvent(event.Ref())
+		assert.Equal(t, ErrUnknownEvent, err)
+		// Discard is persisted
+		system = setup(dir, &fail, &handled)
+		assert.Empty(t, system.ParkedEvents())
+	})
+	t.Run("error - corrupt queue file", func(t *testing.T) {
+		dir := io.TestDirectory(t)
+		_ = ioutil.WriteFile(filepath.Join(dir, retryQueueFileName), []byte("not json"), 0600)
+		err := NewEventSystem(eventType).Configure(dir)
+		assert.Contains(t, err.Error(), "unable to parse retry queue")
+	})
+}
@@ -19,6 +19,14 @@
 package events
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jws"
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	"github.com/nuts-foundation/nuts-registry/logging"
 	errors2 "github.com/pkg/errors"
@@ -26,15 +34,32 @@ import (
 
 // SignatureValidator validates event signatures.
 type SignatureValidator struct {
-	verifier     JwsVerifier
-	certVerifier cert.Verifier
+	verifier          JwsVerifier
+	certVerifier      cert.Verifier
+	trustStore        cert.TrustStore
+	eventCertificates EventCertificates
 }
 
+// EventCertificates returns the CA certificates the given event registers. Roots are trusted for verifying the event's
+// signature (e.g. the self-signed vendor CA certificate of a vendor registering itself), intermediates must be issued
+// by a CA in the trust store (e.g. an organization CA certificate issued by its vendor).
+type EventCertificates func(event Event) (roots []*x509.Certificate, intermediates []*x509.Certificate)
+
 // NewSignatureValidator creates a new SignatureValidator for the given event types.
 func NewSignatureValidator(verifier JwsVerifier, certVerifier cert.Verifier) SignatureValidator {
 	return SignatureValidator{verifier: verifier, certVerifier: certVerifier}
 }
 
+// WithEventCertificates returns a copy of the validator which also accepts signatures of certificates issued by the CA
+// certificates the event registers itself (as returned by the given function), since these are only added to the
+// trust store after the event has been applied. Whether such a signer is authorized (e.g. whether the event registers
+// a new vendor rather than taking over an existing one) must be checked afterwards, e.g. by domain.SignerAuthorizer.
+func (v SignatureValidator) WithEventCertificates(trustStore cert.TrustStore, fn EventCertificates) SignatureValidator {
+	v.trustStore = trustStore
+	v.eventCertificates = fn
+	return v
+}
+
 // RegisterEventHandlers registers event handlers which will validate the event signatures.
 func (v SignatureValidator) RegisterEventHandlers(fn EventRegistrar, eventType []EventType) {
 	for _, eventType := range eventType {
@@ -46,17 +71,159 @@ func (v SignatureValidator) validate(event Event, _ EventLookup) error {
 	if len(event.Signature()) == 0 {
 		// https://github.com/nuts-foundation/nuts-registry/issues/84
 		logging.Log().Warnf("Event not signed, this is accepted for now but it will be rejected in future (event = %v).", event.IssuedAt())
-	} else {
-		// TODO: event.IssuedAt is not signed, what extra safety does it add checking it against the certificate validity?
-		// TODO: is the event signed by the expected entity (correct vendor/organization)?
-		if event.Version() <= currentEventVersion {
-			_, err := v.verifier(event.Signature(), event.IssuedAt(), v.certVerifier)
-			if err := err; err != nil {
-				return errors2.Wrapf(err, "event signature verification failed, it will not be processed (event = %v)", event.IssuedAt())
-			}
-		} else {
-			logging.Log().Warnf("Unsupported signature version (%d), unable to validate signature. This should be fixed in the future using canonicalization (event = %v).", event.Version(), event.IssuedAt())
+		return nil
+	}
+	// Whether the event is signed by the expected entity (correct vendor/organization) is checked by domain.SignerAuthorizer
+	if event.Version() > currentEventVersion {
+		return fmt.Errorf("unsupported event version (%d), unable to validate signature, it will not be processed (event = %v)", event.Version(), event.IssuedAt())
+	}
+	certVerifier := v.certVerifier
+	if v.eventCertificates != nil {
+		if roots, intermediates := v.eventCertificates(event); len(roots) > 0 || len(intermediates) > 0 {
+			certVerifier = eventCertificatesVerifier{Verifier: v.certVerifier, trustStore: v.trustStore, roots: roots, intermediates: intermediates}
 		}
 	}
+	signedData, err := v.verifier(event.Signature(), event.IssuedAt(), certVerifier)
+	if err != nil {
+		return errors2.Wrapf(err, "event signature verification failed, it will not be processed (event = %v)", event.IssuedAt())
+	}
+	if event.Version() >= canonicalSignatureVersion {
+		// Since v2 the signature covers the event's envelope, which must match the event itself
+		err = verifySignedEnvelope(event, signedData)
+	} else {
+		// Before v2 the signature only covers the payload, which must match the payload the event is applied with
+		err = verifySignedPayload(event, signedData)
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "event signature verification failed, it will not be processed (event = %v)", event.IssuedAt())
+	}
+	markVerified(event)
+	return nil
+}
+
+// eventCertificatesVerifier verifies certificates against the given verifier, or else against the trust store extended
+// with the CA certificates registered by the event being validated.
+type eventCertificatesVerifier struct {
+	cert.Verifier
+	trustStore    cert.TrustStore
+	roots         []*x509.Certificate
+	intermediates []*x509.Certificate
+}
+
+func (v eventCertificatesVerifier) Verify(certificate *x509.Certificate, moment time.Time, keyUsages []x509.ExtKeyUsage) error {
+	_, err := v.VerifiedChain(certificate, moment, keyUsages)
+	return err
+}
+
+func (v eventCertificatesVerifier) VerifiedChain(certificate *x509.Certificate, moment time.Time, keyUsages []x509.ExtKeyUsage) ([][]*x509.Certificate, error) {
+	chains, err := v.Verifier.VerifiedChain(certificate, moment, keyUsages)
+	if err == nil {
+		return chains, nil
+	}
+	roots := append([]*x509.Certificate{}, v.roots...)
+	intermediates := append([]*x509.Certificate{}, v.intermediates...)
+	if v.trustStore != nil {
+		trustedRoots, _ := v.trustStore.Roots()
+		trustedIntermediates, _ := v.trustStore.Intermediates()
+		roots = append(roots, trustedRoots...)
+		intermediates = append(intermediates, trustedIntermediates...)
+	}
+	return certificate.Verify(x509.VerifyOptions{Roots: certPool(roots), Intermediates: certPool(intermediates), CurrentTime: moment, KeyUsages: keyUsages})
+}
+
+func certPool(certificates []*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, certificate := range certificates {
+		pool.AddCert(certificate)
+	}
+	return pool
+}
+
+// markVerified records the certificate of the (verified) signature of the event as its signer (see VerifiedSigner).
+// The verifier checked the signature against the first certificate of the JWS' chain, so that's the signer.
+func markVerified(event Event) {
+	jEvent, ok := event.(*jsonEvent)
+	if !ok {
+		return
+	}
+	details, err := parseSignatureDetails(event.Signature())
+	if err != nil {
+		logging.Log().Debugf("Unable to determine signer of event %s: %v", event.Ref(), err)
+		return
+	}
+	jEvent.verifiedSigner = details.Certificate
+}
+
+// VerifiedSigner returns the certificate which signed the event, if its signature has been verified by the
+// SignatureValidator. If the event isn't signed or its signature hasn't been verified (yet), nil is returned.
+// Unlike SignatureDetails, it can be used to decide whether the signer is authorized to issue the event.
+func VerifiedSigner(event Event) *x509.Certificate {
+	jEvent, ok := event.(*jsonEvent)
+	if !ok {
+		return nil
+	}
+	return jEvent.verifiedSigner
+}
+
+// verifySignedPayload checks whether the given signed data matches the payload of the (pre-v2) event.
+func verifySignedPayload(event Event, signedData []byte) error {
+	jEvent, ok := event.(*jsonEvent)
+	if !ok {
+		return errors.New("unsupported event implementation")
+	}
+	payload, err := json.Marshal(jEvent.EventPayload)
+	if err != nil {
+		return errors2.Wrap(err, "unable to marshal payload")
+	}
+	expected, err := canonicalizeJSON(payload)
+	if err != nil {
+		return errors2.Wrap(err, "unable to canonicalize payload")
+	}
+	actual, err := canonicalizeJSON(signedData)
+	if err != nil {
+		return errors2.Wrap(err, "unable to canonicalize signed data")
+	}
+	if !bytes.Equal(expected, actual) {
+		return errors.New("signed payload doesn't match event")
+	}
 	return nil
 }
+
+// verifySignedEnvelope checks whether the given signed data matches the canonicalized envelope of the event.
+func verifySignedEnvelope(event Event, signedData []byte) error {
+	jEvent, ok := event.(*jsonEvent)
+	if !ok {
+		return errors.New("unsupported event implementation")
+	}
+	expected, err := jEvent.signedData()
+	if err != nil {
+		return errors2.Wrap(err, "unable to canonicalize event")
+	}
+	actual, err := canonicalizeJSON(signedData)
+	if err != nil {
+		return errors2.Wrap(err, "unable to canonicalize signed data")
+	}
+	if !bytes.Equal(expected, actual) {
+		return errors.New("signed data doesn't match event")
+	}
+	return nil
+}
+
+// parseSignatureDetails parses the signing certificate and payload from the given JWS, without verifying the signature.
+func parseSignatureDetails(signature []byte) (*SignatureDetails, error) {
+	message, err := jws.Parse(bytes.NewReader(signature))
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to parse signature")
+	}
+	if len(message.Signatures()) != 1 {
+		return nil, errors.New("JWS should contain exactly 1 signature")
+	}
+	chain, err := cert.GetX509ChainFromHeaders(message.Signatures()[0].ProtectedHeaders())
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to parse certificate chain")
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("JWS doesn't contain a certificate chain")
+	}
+	return &SignatureDetails{Certificate: chain[0], Payload: message.Payload()}, nil
+}
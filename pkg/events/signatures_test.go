@@ -19,8 +19,17 @@
 package events
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws"
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	test2 "github.com/nuts-foundation/nuts-crypto/test"
 	"github.com/nuts-foundation/nuts-registry/test"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -50,6 +59,65 @@ func TestSignatureValidator_verify(t *testing.T) {
 		err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(CreateEvent("foo", struct{}{}, nil), nil)
 		assert.NoError(t, err)
 	})
+	t.Run("ok - v2 signature survives marshalling", func(t *testing.T) {
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, []byte{1, 2, 3})
+		if !assert.NoError(t, event.Sign(jwsSigner(t))) {
+			return
+		}
+		parsed, _ := EventFromJSON(event.Marshal())
+		err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(parsed, nil)
+		assert.NoError(t, err)
+	})
+	t.Run("ok - v1 signature", func(t *testing.T) {
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		(event.(*jsonEvent)).EventVersion = 1
+		if !assert.NoError(t, event.Sign(jwsSigner(t))) {
+			return
+		}
+		err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(event, nil)
+		assert.NoError(t, err)
+	})
+	t.Run("error - unsupported version", func(t *testing.T) {
+		// Signature of an unsupported version can't be verified, so the event can't be trusted
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		(event.(*jsonEvent)).EventVersion = currentEventVersion + 1
+		if !assert.NoError(t, event.Sign(certificateSigner(t))) {
+			return
+		}
+		err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(event, nil)
+		assert.Contains(t, fmt.Sprintf("%v", err), fmt.Sprintf("unsupported event version (%d)", currentEventVersion+1))
+		assert.Nil(t, VerifiedSigner(event))
+	})
+	t.Run("error - v1 payload altered after signing", func(t *testing.T) {
+		// Before v2 only the payload is signed, so a valid JWS could otherwise be reused with another payload
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		(event.(*jsonEvent)).EventVersion = 1
+		if !assert.NoError(t, event.Sign(jwsSigner(t))) {
+			return
+		}
+		(event.(*jsonEvent)).EventPayload = map[string]interface{}{"Hello": "Mars"}
+		err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(event, nil)
+		assert.Contains(t, fmt.Sprintf("%v", err), "signed payload doesn't match event")
+	})
+	t.Run("error - v2 envelope altered after signing", func(t *testing.T) {
+		fields := map[string]func(event *jsonEvent){
+			"issuedAt": func(event *jsonEvent) { event.EventIssuedAt = event.EventIssuedAt.Add(time.Hour) },
+			"prev":     func(event *jsonEvent) { event.PreviousEvent = []byte{3, 2, 1} },
+			"type":     func(event *jsonEvent) { event.EventType = "bar" },
+			"payload":  func(event *jsonEvent) { event.EventPayload = map[string]interface{}{"Hello": "Mars"} },
+		}
+		for field, alter := range fields {
+			t.Run(field, func(t *testing.T) {
+				event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, []byte{1, 2, 3})
+				if !assert.NoError(t, event.Sign(jwsSigner(t))) {
+					return
+				}
+				alter(event.(*jsonEvent))
+				err := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier).validate(event, nil)
+				assert.Contains(t, fmt.Sprintf("%v", err), "signed data doesn't match event")
+			})
+		}
+	})
 	t.Run("error - verification failed", func(t *testing.T) {
 		event := CreateEvent("foo", struct{}{}, nil)
 		event.Sign(func(bytes2 []byte) (bytes []byte, err error) {
@@ -62,3 +130,143 @@ func TestSignatureValidator_verify(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestSignatureValidator_WithEventCertificates(t *testing.T) {
+	root, rootKey := test.SelfSignCertificateFromCSR(x509.CertificateRequest{Subject: pkix.Name{CommonName: "Root"}}, time.Now(), 1)
+	intermediateKey := test2.GenerateRSAKey()
+	intermediate := test.SignCertificateFromCSRWithKey(x509.CertificateRequest{Subject: pkix.Name{CommonName: "Intermediate"}, PublicKey: &intermediateKey.PublicKey}, time.Now(), 1, root, rootKey)
+	signerKey := test2.GenerateRSAKey()
+	signer := test.SignCertificateFromCSRWithKey(x509.CertificateRequest{Subject: pkix.Name{CommonName: "Signer"}, PublicKey: &signerKey.PublicKey}, time.Now(), 1, intermediate, intermediateKey)
+	repo, err := test.NewTestRepo(t)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer repo.Cleanup()
+	trustStore, err := cert.NewTrustStore(repo.Directory + "/truststore.pem")
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := CreateEvent("foo", struct{}{}, nil)
+	event.Sign(func(bytes2 []byte) (bytes []byte, err error) {
+		return bytes2, nil
+	})
+	validate := func(roots []*x509.Certificate, intermediates []*x509.Certificate) error {
+		verifier := func(signature []byte, signingTime time.Time, verifier cert.Verifier) (bytes []byte, err error) {
+			return signature, verifier.Verify(signer, signingTime, []x509.ExtKeyUsage{x509.ExtKeyUsageAny})
+		}
+		validator := NewSignatureValidator(verifier, trustStore).WithEventCertificates(trustStore, func(_ Event) ([]*x509.Certificate, []*x509.Certificate) {
+			return roots, intermediates
+		})
+		return validator.validate(event, nil)
+	}
+	t.Run("ok - issued by root registered by event", func(t *testing.T) {
+		assert.NoError(t, validate([]*x509.Certificate{root}, []*x509.Certificate{intermediate}))
+	})
+	t.Run("error - not issued by certificates registered by event", func(t *testing.T) {
+		assert.Contains(t, fmt.Sprintf("%v", validate(nil, nil)), "certificate signed by unknown authority")
+	})
+	t.Run("error - intermediate registered by event not issued by trusted root", func(t *testing.T) {
+		assert.Contains(t, fmt.Sprintf("%v", validate(nil, []*x509.Certificate{intermediate})), "certificate signed by unknown authority")
+	})
+	t.Run("ok - intermediate registered by event issued by trusted root", func(t *testing.T) {
+		if !assert.NoError(t, trustStore.AddCertificate(root)) {
+			return
+		}
+		assert.NoError(t, validate(nil, []*x509.Certificate{intermediate}))
+	})
+}
+
+func TestVerifiedSigner(t *testing.T) {
+	validator := NewSignatureValidator(test.NoopJwsVerifier, test.NoopCertificateVerifier)
+	t.Run("ok - set when signature is verified", func(t *testing.T) {
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		if !assert.NoError(t, event.Sign(certificateSigner(t))) {
+			return
+		}
+		assert.Nil(t, VerifiedSigner(event))
+		if !assert.NoError(t, validator.validate(event, nil)) {
+			return
+		}
+		assert.NotNil(t, VerifiedSigner(event))
+		assert.Equal(t, event.SignatureDetails().Certificate, VerifiedSigner(event))
+	})
+	t.Run("ok - not set when verification fails", func(t *testing.T) {
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		if !assert.NoError(t, event.Sign(certificateSigner(t))) {
+			return
+		}
+		failingVerifier := func(signature []byte, signingTime time.Time, verifier cert.Verifier) ([]byte, error) {
+			return nil, errors.New("failed")
+		}
+		assert.Error(t, NewSignatureValidator(failingVerifier, test.NoopCertificateVerifier).validate(event, nil))
+		assert.Nil(t, VerifiedSigner(event))
+	})
+	t.Run("ok - reset when signed again", func(t *testing.T) {
+		event := CreateEvent("foo", map[string]interface{}{"Hello": "World"}, nil)
+		_ = event.Sign(certificateSigner(t))
+		_ = validator.validate(event, nil)
+		_ = event.Sign(certificateSigner(t))
+		assert.Nil(t, VerifiedSigner(event))
+	})
+}
+
+func TestJsonEvent_SignatureDetails(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	certificate, _ := x509.ParseCertificate(test.GenerateCertificateEx(time.Now(), 1, privateKey))
+	signer := func(headers jws.Headers) func([]byte) ([]byte, error) {
+		return func(payload []byte) ([]byte, error) {
+			return jws.Sign(payload, jwa.RS256, privateKey, jws.WithHeaders(headers))
+		}
+	}
+	t.Run("ok - certificate and payload parsed from signature", func(t *testing.T) {
+		headers := jws.NewHeaders()
+		_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+		event := CreateEvent("foo", struct{}{}, nil)
+		if !assert.NoError(t, event.Sign(signer(headers))) {
+			return
+		}
+		details := event.SignatureDetails()
+		assert.Equal(t, certificate, details.Certificate)
+		assert.NotEmpty(t, details.Payload)
+	})
+	t.Run("ok - not signed", func(t *testing.T) {
+		event := CreateEvent("foo", struct{}{}, nil)
+		assert.Nil(t, event.SignatureDetails().Certificate)
+	})
+	t.Run("ok - signature without certificate", func(t *testing.T) {
+		event := CreateEvent("foo", struct{}{}, nil)
+		if !assert.NoError(t, event.Sign(signer(jws.NewHeaders()))) {
+			return
+		}
+		assert.Nil(t, event.SignatureDetails().Certificate)
+	})
+}
+
+// jwsSigner returns a signing function which signs the data as JWS using a newly generated key.
+func jwsSigner(t *testing.T) func([]byte) ([]byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return func(data []byte) ([]byte, error) {
+		return jws.Sign(data, jwa.RS256, privateKey)
+	}
+}
+
+// certificateSigner returns a signing function which signs the data as JWS using a newly generated key and a
+// self-signed certificate, which is included in the JWS' x5c header.
+func certificateSigner(t *testing.T) func([]byte) ([]byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	certificate, err := x509.ParseCertificate(test.GenerateCertificateEx(time.Now(), 1, privateKey))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	headers := jws.NewHeaders()
+	_ = headers.Set(jws.X509CertChainKey, []string{base64.StdEncoding.EncodeToString(certificate.Raw)})
+	return func(data []byte) ([]byte, error) {
+		return jws.Sign(data, jwa.RS256, privateKey, jws.WithHeaders(headers))
+	}
+}
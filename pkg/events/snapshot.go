@@ -0,0 +1,71 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	errors2 "github.com/pkg/errors"
+)
+
+// eventSystemSnapshot is the state of the event system as captured in a snapshot.
+type eventSystemSnapshot struct {
+	LastEvent Ref           `json:"lastEvent,omitempty"`
+	Events    []storedEvent `json:"events"`
+}
+
+func (system *diskEventSystem) Snapshot() ([]byte, Ref, error) {
+	applied, _ := system.lut.eventsAfter(nil)
+	snapshot := eventSystemSnapshot{Events: make([]storedEvent, 0, len(applied))}
+	for _, event := range applied {
+		snapshot.Events = append(snapshot.Events, storedEvent{IssuedAt: event.IssuedAt(), Data: event.Marshal()})
+	}
+	if len(applied) > 0 {
+		snapshot.LastEvent = applied[len(applied)-1].Ref()
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, snapshot.LastEvent, nil
+}
+
+func (system *diskEventSystem) Restore(data []byte) error {
+	snapshot := eventSystemSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors2.Wrap(err, "unable to parse snapshot")
+	}
+	evts := make([]Event, len(snapshot.Events))
+	for i, stored := range snapshot.Events {
+		event, err := EventFromJSONWithIssuedAt(stored.Data, stored.IssuedAt)
+		if err != nil {
+			return errors2.Wrap(err, "unable to parse event in snapshot")
+		}
+		evts[i] = event
+	}
+	if !snapshot.LastEvent.IsZero() && (len(evts) == 0 || !evts[len(evts)-1].Ref().Equal(snapshot.LastEvent)) {
+		return fmt.Errorf("last event is not in snapshot: %s", snapshot.LastEvent)
+	}
+	if err := system.lut.restore(evts); err != nil {
+		return errors2.Wrap(err, "invalid event path in snapshot")
+	}
+	return nil
+}
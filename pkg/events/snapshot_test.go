@@ -0,0 +1,105 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskEventSystem_Snapshot(t *testing.T) {
+	create := func(t *testing.T) (EventSystem, *int) {
+		system := NewEventSystem(eventType)
+		if !assert.NoError(t, system.Configure(io.TestDirectory(t))) {
+			t.FailNow()
+		}
+		handled := 0
+		system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+			handled++
+			return nil
+		})
+		return system, &handled
+	}
+	event1 := CreateTestEvent(eventType, 1, nil, time.Unix(10000, 0))
+	event2 := CreateTestEvent(eventType, 2, event1.Ref(), time.Unix(10001, 0))
+	event3 := CreateTestEvent(eventType, 3, event2.Ref(), time.Unix(10002, 0))
+
+	t.Run("ok - restored events are skipped", func(t *testing.T) {
+		system, _ := create(t)
+		_ = system.PublishEvent(event1)
+		_ = system.PublishEvent(event2)
+		data, lastEvent, err := system.Snapshot()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event2.Ref(), lastEvent)
+
+		restored, handled := create(t)
+		if !assert.NoError(t, restored.Restore(data)) {
+			return
+		}
+		assert.NotNil(t, restored.Get(event1.Ref()))
+		assert.Equal(t, 0, *handled)
+		for _, event := range []Event{event1, event2, event3} {
+			if !assert.NoError(t, restored.ProcessEvent(event)) {
+				return
+			}
+		}
+		assert.Equal(t, 1, *handled)
+		_, lastEvent, _ = restored.Snapshot()
+		assert.Equal(t, event3.Ref(), lastEvent)
+	})
+	t.Run("ok - empty", func(t *testing.T) {
+		system, _ := create(t)
+		data, lastEvent, err := system.Snapshot()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, lastEvent.IsZero())
+		assert.NoError(t, system.Restore(data))
+	})
+	t.Run("error - invalid JSON", func(t *testing.T) {
+		system, _ := create(t)
+		err := system.Restore([]byte("{"))
+		assert.EqualError(t, err, "unable to parse snapshot: unexpected end of JSON input")
+	})
+	t.Run("error - incomplete event path", func(t *testing.T) {
+		system, _ := create(t)
+		data, _ := json.Marshal(eventSystemSnapshot{
+			LastEvent: event2.Ref(),
+			Events:    []storedEvent{{IssuedAt: event2.IssuedAt(), Data: event2.Marshal()}},
+		})
+		err := system.Restore(data)
+		assert.EqualError(t, err, "invalid event path in snapshot: previous event not found: "+event1.Ref().String())
+	})
+	t.Run("error - last event not in snapshot", func(t *testing.T) {
+		system, _ := create(t)
+		data, _ := json.Marshal(eventSystemSnapshot{
+			LastEvent: event2.Ref(),
+			Events:    []storedEvent{{IssuedAt: event1.IssuedAt(), Data: event1.Marshal()}},
+		})
+		err := system.Restore(data)
+		assert.EqualError(t, err, "last event is not in snapshot: "+event2.Ref().String())
+	})
+}
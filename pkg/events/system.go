@@ -26,8 +26,8 @@ import (
 	"io/ioutil"
 	"os"
 	"regexp"
-	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
@@ -47,8 +47,14 @@ var ErrEventSystemNotConfigured = errors.New("the event system hasn't been confi
 // ErrEventNotSigned is returned when the event is not signed
 var ErrEventNotSigned = errors.New("the event is not signed")
 
+// ErrUnknownEvent is returned when an event is referred to which hasn't been applied
+var ErrUnknownEvent = errors.New("unknown event")
+
 const eventTimestampLayout = "20060102150405.000"
-const eventFileFormat = "(\\d{17})-([a-zA-Z]+)\\.json"
+
+// eventFileFormat is the format of event file names: the moment the event was issued and its type, optionally followed
+// by its ref (see EventFileNameWithRef).
+const eventFileFormat = "(\\d{17})-([a-zA-Z]+)(?:-[0-9a-f]+)?\\.json"
 
 func init() {
 	r, err := regexp.Compile(eventFileFormat)
@@ -58,17 +64,47 @@ func init() {
 	eventFileRegex = r
 }
 
-// EventSystem is meant for registering and handling events.
+// EventSystem is meant for registering and handling events. Events are applied one at a time by a single goroutine,
+// so methods which apply events (e.g. ProcessEvent) may be called concurrently: they wait for their turn.
 type EventSystem interface {
 	// RegisterEventHandler registers an event handler for the given type, which will be called when the an event of this
 	// type is received.
 	RegisterEventHandler(eventType EventType, handler EventHandler)
+	// RegisterPayloadValidator registers the validator which checks the payload of every event before its handlers are
+	// called. Events with an invalid payload aren't applied.
+	RegisterPayloadValidator(validator PayloadValidator)
 	ProcessEvent(event Event) error
 	PublishEvent(event Event) error
 	LoadAndApplyEvents() error
 	Configure(location string) error
 	Diagnostics() []core.DiagnosticResult
+	// Close releases the resources held by the event system (e.g. open files). It should be called on shutdown.
+	Close() error
+	// Snapshot captures the events that have been applied, so they can be restored later using Restore. Besides the
+	// captured state, it returns the ref of the last applied event.
+	Snapshot() ([]byte, Ref, error)
+	// Restore restores the applied events from a snapshot created by Snapshot, without invoking the event handlers.
+	// Events that are processed afterwards and have already been applied according to the snapshot are skipped.
+	Restore(snapshot []byte) error
+	// Exclusive calls fn on the goroutine that applies the events, so no events are applied while it runs. This can be
+	// used to capture (e.g. Snapshot) or restore state consistently. fn must not call methods which apply events
+	// (e.g. ProcessEvent), since they would wait for fn to complete.
+	Exclusive(fn func() error) error
+	// Subscribe registers a listener which is called for every event after it has been applied. The returned function
+	// removes the listener.
+	Subscribe(listener EventListener) func()
+	// EventsAfter returns the events that were applied after the event with the given ref, in order of application. If
+	// the ref is zero all applied events are returned. If no event with the given ref has been applied ErrUnknownEvent is returned.
+	EventsAfter(ref Ref) ([]Event, error)
+	// Replay rebuilds the state as it was at the given moment: the applied events which were issued at or before that
+	// moment are passed (in order of application) to the handlers registered through registerHandlers, instead of the
+	// event system's own handlers. Events which can't be applied at that moment are skipped.
+	Replay(moment time.Time, registerHandlers func(EventRegistrar))
+	// Forks returns the event paths which forked (multiple events refer to the same previous event), including the ones
+	// that have been merged.
+	Forks() []Fork
 	EventLookup
+	RetryQueue
 }
 
 // EventRegistrar is a function to register an event
@@ -77,6 +113,13 @@ type EventRegistrar func(EventType, EventHandler)
 // EventHandler handles an event of a specific type.
 type EventHandler func(Event, EventLookup) error
 
+// PayloadValidator validates the payload of an event, returning an error describing why it's invalid.
+type PayloadValidator func(Event) error
+
+// EventListener is notified of an event after it has been applied. It must not block, since it's called while the event
+// is being processed.
+type EventListener func(Event)
+
 // EventMatcher defines a matching function for events. The function should return true if the event matches, otherwise false.
 type EventMatcher func(Event) bool
 
@@ -84,44 +127,113 @@ type EventMatcher func(Event) bool
 type JwsVerifier func(signature []byte, signingTime time.Time, verifier cert.Verifier) ([]byte, error)
 
 type diskEventSystem struct {
-	eventHandlers map[EventType][]EventHandler
-	eventTypes    []EventType
-	location      string
-	lut           *eventLookupTable
-	// eventsToBeRetried holds events which should be retried since it failed previously.
-	eventsToBeRetried map[string]Event
+	eventHandlers    map[EventType][]EventHandler
+	payloadValidator PayloadValidator
+	eventTypes       []EventType
+	location         string
+	lut              *eventLookupTable
+	// pipeline applies the events; all work that alters the lookup table or retry queue is executed on it.
+	pipeline *pipeline
+	// retryQueue holds events which should be retried since they failed previously or were received out of order.
+	retryQueue   *retryQueue
+	listeners    map[int]EventListener
+	listenerSeq  int
+	listenersMux *sync.Mutex
+	// rejected holds the events (by source, e.g. file name) which were rejected on load because their ref didn't match their contents.
+	rejected map[string]error
 }
 
-// NewEventSystem creates and initializes a new event system.
+// NewEventSystem creates and initializes a new event system which stores events as separate files.
 func NewEventSystem(eventTypes ...EventType) EventSystem {
+	return newDiskEventSystem(eventTypes...)
+}
+
+func newDiskEventSystem(eventTypes ...EventType) *diskEventSystem {
 	return &diskEventSystem{
-		eventTypes:        eventTypes,
-		eventHandlers:     make(map[EventType][]EventHandler, 0),
-		lut:               newEventLookupTable(),
-		eventsToBeRetried: make(map[string]Event),
+		eventTypes:    eventTypes,
+		eventHandlers: make(map[EventType][]EventHandler, 0),
+		lut:           newEventLookupTable(),
+		pipeline:      newPipeline(),
+		retryQueue:    newRetryQueue(),
+		listeners:     make(map[int]EventListener),
+		listenersMux:  &sync.Mutex{},
+		rejected:      make(map[string]error),
 	}
 }
 
 func (system *diskEventSystem) Configure(location string) error {
 	system.location = location
-	return validateLocation(system.location)
+	if err := validateLocation(system.location); err != nil {
+		return err
+	}
+	return system.retryQueue.load(normalizeLocation(system.location, retryQueueFileName))
+}
+
+func (system *diskEventSystem) Close() error {
+	system.pipeline.stop()
+	return nil
+}
+
+// submit executes fn on the pipeline. The changes it made to the retry queue are persisted once it completes.
+func (system *diskEventSystem) submit(fn func() error) error {
+	return system.pipeline.submit(func() error {
+		defer system.retryQueue.flush()
+		return fn()
+	})
+}
+
+func (system *diskEventSystem) Exclusive(fn func() error) error {
+	return system.submit(fn)
 }
 
 func (system *diskEventSystem) RegisterEventHandler(eventType EventType, handler EventHandler) {
 	system.eventHandlers[eventType] = append(system.eventHandlers[eventType], handler)
 }
 
+func (system *diskEventSystem) RegisterPayloadValidator(validator PayloadValidator) {
+	system.payloadValidator = validator
+}
+
 func (system *diskEventSystem) Diagnostics() []core.DiagnosticResult {
+	var pending, deadLettered, rejected int
+	_ = system.submit(func() error {
+		pending = system.retryQueue.count(ParkedEventPending)
+		deadLettered = system.retryQueue.count(ParkedEventDeadLetter)
+		rejected = len(system.rejected)
+		return nil
+	})
+	var unmerged int
+	for _, fork := range system.lut.forkList() {
+		if !fork.Merged {
+			unmerged++
+		}
+	}
 	return []core.DiagnosticResult{
 		&core.GenericDiagnosticResult{
 			Title:   "Number of events to be retried",
-			Outcome: fmt.Sprintf("%d", len(system.eventsToBeRetried)),
+			Outcome: fmt.Sprintf("%d", pending),
+		},
+		&core.GenericDiagnosticResult{
+			Title:   "Number of dead-lettered events",
+			Outcome: fmt.Sprintf("%d", deadLettered),
+		},
+		&core.GenericDiagnosticResult{
+			Title:   "Number of unmerged forks",
+			Outcome: fmt.Sprintf("%d", unmerged),
+		},
+		&core.GenericDiagnosticResult{
+			Title:   "Number of events rejected because of an invalid ref",
+			Outcome: fmt.Sprintf("%d", rejected),
 		},
 	}
 }
 
+func (system *diskEventSystem) Forks() []Fork {
+	return system.lut.forkList()
+}
+
 // isEventType checks whether the given type is supported.
-func (system diskEventSystem) isEventType(eventType EventType) bool {
+func (system *diskEventSystem) isEventType(eventType EventType) bool {
 	for _, actual := range system.eventTypes {
 		if actual == eventType {
 			return true
@@ -134,6 +246,14 @@ func (system *diskEventSystem) ProcessEvent(event Event) error {
 	if err := system.assertConfigured(); err != nil {
 		return err
 	}
+	return system.submit(func() error {
+		return system.handleEvent(event)
+	})
+}
+
+// handleEvent applies the event unless it has already been applied or the event it refers to hasn't been applied yet,
+// in which case it's parked in the retry queue. It must be called on the pipeline.
+func (system *diskEventSystem) handleEvent(event Event) error {
 	if !system.isEventType(event.Type()) {
 		return fmt.Errorf("unknown event type: %s", event.Type())
 	}
@@ -144,7 +264,7 @@ func (system *diskEventSystem) ProcessEvent(event Event) error {
 	// If there is a previous event which hasn't been processed yet, we set it aside to be processed later.
 	if !system.isPreviousEventProcessed(event) {
 		logging.Log().Infof("Event %s refers to previous event %s which hasn't been processed yet, setting it aside.", event.Ref(), event.PreviousRef())
-		system.eventsToBeRetried[event.Ref().String()] = event
+		system.retryQueue.park(event, errPreviousEventNotProcessed(event), false)
 		return nil
 	}
 	err := system.processEvent(event)
@@ -153,26 +273,29 @@ func (system *diskEventSystem) ProcessEvent(event Event) error {
 	return err
 }
 
-func (system diskEventSystem) isPreviousEventProcessed(event Event) bool {
+func (system *diskEventSystem) isPreviousEventProcessed(event Event) bool {
 	if event.PreviousRef().IsZero() {
 		return true
 	}
 	return system.lut.Get(event.PreviousRef()) != nil
 }
 
+func errPreviousEventNotProcessed(event Event) error {
+	return fmt.Errorf("previous event %s hasn't been processed yet", event.PreviousRef())
+}
+
+// retryEvents retries the pending events in the retry queue. Dead-lettered events are skipped, since they're only
+// retried on request (see RetryParkedEvent).
 func (system *diskEventSystem) retryEvents() {
-	events := make([]Event, len(system.eventsToBeRetried))
-	i := 0
-	for _, event := range system.eventsToBeRetried {
-		events[i] = event
-		i++
-	}
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].IssuedAt().Before(events[j].IssuedAt())
-	})
-	for _, event := range events {
-		if !system.isPreviousEventProcessed(event) {
-			// Previous event not processed yet, skipping
+	for _, parked := range system.retryQueue.list() {
+		event := parked.Event
+		if system.lut.Get(event.Ref()) != nil {
+			// Applied in the meantime (e.g. when the queue was loaded from disk)
+			system.retryQueue.remove(event.Ref())
+			continue
+		}
+		if parked.State != ParkedEventPending || !system.isPreviousEventProcessed(event) {
+			// Dead-lettered or previous event not processed yet, skipping
 			continue
 		}
 		if err := system.processEvent(event); err != nil {
@@ -181,15 +304,82 @@ func (system *diskEventSystem) retryEvents() {
 	}
 }
 
+func (system *diskEventSystem) ParkedEvents() []ParkedEvent {
+	var result []ParkedEvent
+	_ = system.submit(func() error {
+		result = system.retryQueue.list()
+		return nil
+	})
+	return result
+}
+
+func (system *diskEventSystem) ParkedEvent(ref Ref) (*ParkedEvent, error) {
+	var result *ParkedEvent
+	err := system.submit(func() error {
+		entry := system.retryQueue.get(ref)
+		if entry == nil {
+			return ErrUnknownEvent
+		}
+		parked := *entry
+		result = &parked
+		return nil
+	})
+	return result, err
+}
+
+func (system *diskEventSystem) RetryParkedEvent(ref Ref) error {
+	return system.submit(func() error {
+		return system.retryParkedEvent(ref)
+	})
+}
+
+func (system *diskEventSystem) retryParkedEvent(ref Ref) error {
+	entry := system.retryQueue.get(ref)
+	if entry == nil {
+		return ErrUnknownEvent
+	}
+	// Retrying on request gives the event a fresh retry budget
+	entry.State = ParkedEventPending
+	entry.Attempts = 0
+	if !system.isPreviousEventProcessed(entry.Event) {
+		err := errPreviousEventNotProcessed(entry.Event)
+		system.retryQueue.park(entry.Event, err, false)
+		return err
+	}
+	if err := system.processEvent(entry.Event); err != nil {
+		return err
+	}
+	system.retryEvents()
+	return nil
+}
+
+func (system *diskEventSystem) DiscardParkedEvent(ref Ref) error {
+	return system.submit(func() error {
+		if !system.retryQueue.remove(ref) {
+			return ErrUnknownEvent
+		}
+		logging.Log().Infof("Discarded parked event %s", ref)
+		return nil
+	})
+}
+
 func (system *diskEventSystem) processEvent(event Event) error {
 	handlers := system.eventHandlers[event.Type()]
 	if handlers == nil {
 		return fmt.Errorf("no handlers registered for event (type = %s), handlers are: %v", event.Type(), system.eventHandlers)
 	}
+	if system.payloadValidator != nil {
+		if err := system.payloadValidator(event); err != nil {
+			// The payload won't become valid by retrying, so the event is dead-lettered right away
+			logging.Log().Warnf("Event %s has an invalid payload, it won't be retried: %v", event.Ref(), err)
+			system.retryQueue.deadLetter(event, err)
+			return err
+		}
+	}
 	for _, handler := range handlers {
 		if err := handler(event, system.lut); err != nil {
 			logging.Log().Warnf("Error while processing event %s, event will set aside to be processed later: %v", event.Ref(), err)
-			system.eventsToBeRetried[event.Ref().String()] = event
+			system.retryQueue.park(event, err, true)
 			return err
 		}
 	}
@@ -202,39 +392,110 @@ func (system *diskEventSystem) processEvent(event Event) error {
 		"type":     event.Type(),
 		"issuedAt": event.IssuedAt(),
 	}).Info("Event processed")
-	delete(system.eventsToBeRetried, event.Ref().String())
+	if !system.lut.isCanonical(event) {
+		system.reapplyCanonicalEvent(event)
+	}
+	system.retryQueue.remove(event.Ref())
+	system.notifyListeners(event)
 	return nil
 }
 
-func (system *diskEventSystem) PublishEvent(event Event) error {
-	if err := system.assertConfigured(); err != nil {
-		return err
+// reapplyCanonicalEvent is called when the given event lost the conflict resolution of a fork. Since the event handlers
+// applied it nonetheless, the last event on the canonical branch is applied again to make sure the handlers' state
+// reflects the canonical branch. The event is marked as re-applied (see IsReapplied), so handlers with side effects
+// can skip it.
+func (system *diskEventSystem) reapplyCanonicalEvent(event Event) {
+	canonicalEvent := system.lut.lastOfPath(event)
+	logging.Log().Warnf("Event %s is on a branch of a fork which isn't used, re-applying event %s", event.Ref(), canonicalEvent.Ref())
+	if jEvent, ok := canonicalEvent.(*jsonEvent); ok {
+		jEvent.reapplied = true
+		defer func() {
+			jEvent.reapplied = false
+		}()
 	}
-	if err := system.ProcessEvent(event); err != nil {
-		return err
+	for _, handler := range system.eventHandlers[canonicalEvent.Type()] {
+		if err := handler(canonicalEvent, system.lut); err != nil {
+			logging.Log().Errorf("Error while re-applying event %s: %v", canonicalEvent.Ref(), err)
+			return
+		}
 	}
+}
 
-	eventFileName := SuggestEventFileName(event)
-	err := ioutil.WriteFile(normalizeLocation(system.location, eventFileName), event.Marshal(), os.ModePerm)
-	if err != nil {
-		return errors2.Wrap(err, "event processed, but enable to save it to disk")
+func (system *diskEventSystem) Subscribe(listener EventListener) func() {
+	system.listenersMux.Lock()
+	defer system.listenersMux.Unlock()
+	id := system.listenerSeq
+	system.listenerSeq++
+	system.listeners[id] = listener
+	return func() {
+		system.listenersMux.Lock()
+		defer system.listenersMux.Unlock()
+		delete(system.listeners, id)
 	}
-	return nil
 }
 
-func (system diskEventSystem) Get(ref Ref) Event {
+func (system *diskEventSystem) notifyListeners(event Event) {
+	system.listenersMux.Lock()
+	defer system.listenersMux.Unlock()
+	for _, listener := range system.listeners {
+		listener(event)
+	}
+}
+
+func (system *diskEventSystem) EventsAfter(ref Ref) ([]Event, error) {
+	return system.lut.eventsAfter(ref)
+}
+
+func (system *diskEventSystem) PublishEvent(event Event) error {
+	if err := system.assertConfigured(); err != nil {
+		return err
+	}
+	return system.submit(func() error {
+		if err := system.handleEvent(event); err != nil {
+			return err
+		}
+		eventFileName := SuggestEventFileName(event)
+		err := ioutil.WriteFile(normalizeLocation(system.location, eventFileName), event.Marshal(), os.ModePerm)
+		if err != nil {
+			return errors2.Wrap(err, "event processed, but enable to save it to disk")
+		}
+		return nil
+	})
+}
+
+func (system *diskEventSystem) Get(ref Ref) Event {
 	return system.lut.Get(ref)
 }
 
-func (system diskEventSystem) FindLastEvent(matcher EventMatcher) (Event, error) {
+func (system *diskEventSystem) FindLastEvent(matcher EventMatcher) (Event, error) {
 	return system.lut.FindLastEvent(matcher)
 }
 
+func (system *diskEventSystem) FindEventPath(matcher EventMatcher) ([]Event, error) {
+	return system.lut.FindEventPath(matcher)
+}
+
+func (system *diskEventSystem) FindLastEventByKey(key EntityKey, matcher EventMatcher) (Event, error) {
+	return system.lut.FindLastEventByKey(key, matcher)
+}
+
+func (system *diskEventSystem) FindEventPathByKey(key EntityKey, matcher EventMatcher) ([]Event, error) {
+	return system.lut.FindEventPathByKey(key, matcher)
+}
+
+func (system *diskEventSystem) FindEventsByKey(key EntityKey) []Event {
+	return system.lut.FindEventsByKey(key)
+}
+
 // Load the db files from the datadir
 func (system *diskEventSystem) LoadAndApplyEvents() error {
 	if err := system.assertConfigured(); err != nil {
 		return err
 	}
+	return system.submit(system.loadAndApplyEvents)
+}
+
+func (system *diskEventSystem) loadAndApplyEvents() error {
 	entries, err := ioutil.ReadDir(system.location)
 	if err != nil {
 		return err
@@ -251,22 +512,43 @@ func (system *diskEventSystem) LoadAndApplyEvents() error {
 			return fmt.Errorf("file does not match event file name format (file = %s, expected format = %s)", entry.Name(), eventFileFormat)
 		}
 		event, err := readEvent(normalizeLocation(system.location, entry.Name()), matches[1])
-		if err != nil {
+		if errors.Is(err, ErrInvalidRef) {
+			system.reject(entry.Name(), err)
+			continue
+		} else if err != nil {
 			return errors2.Wrapf(err, "error reading event: %s", entry.Name())
 		}
-		if err := system.ProcessEvent(event); err != nil {
+		if err := system.handleEvent(event); err != nil {
 			return errors2.Wrap(err, fmt.Sprintf("error while applying event (event = %s)", entry.Name()))
 		}
 	}
+	// Parked events which aren't stored in the events directory (e.g. received from other nodes) are retried as well
+	system.retryEvents()
 	return nil
 }
 
+// reject records an event which couldn't be loaded because its ref doesn't match its contents (e.g. because it was
+// tampered with). The event is skipped rather than failing the load, so the node keeps running on the intact events.
+func (system *diskEventSystem) reject(source string, err error) {
+	if _, exists := system.rejected[source]; !exists {
+		logging.Log().Errorf("Rejected event, it will be skipped (source = %s): %v", source, err)
+	}
+	system.rejected[source] = err
+}
+
 // SuggestEventFileName suggests a file name for a event, when writing that event to disk.
 func SuggestEventFileName(event Event) string {
 	return strings.Replace(event.IssuedAt().UTC().Format(eventTimestampLayout), ".", "", 1) + "-" + string(event.Type()) + ".json"
 }
 
-func (system diskEventSystem) assertConfigured() error {
+// EventFileNameWithRef returns a file name for the event like SuggestEventFileName, which includes the event's ref.
+// It's unique even when multiple events of the same type were issued within the same millisecond.
+func EventFileNameWithRef(event Event) string {
+	name := SuggestEventFileName(event)
+	return strings.TrimSuffix(name, ".json") + "-" + event.Ref().String() + ".json"
+}
+
+func (system *diskEventSystem) assertConfigured() error {
 	if system.location == "" {
 		return ErrEventSystemNotConfigured
 	}
@@ -30,6 +30,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -68,6 +69,53 @@ func TestNoEventHandler(t *testing.T) {
 	assert.EqualError(t, err, "no handlers registered for event (type = some-type), handlers are: map[]")
 }
 
+func TestPayloadValidator(t *testing.T) {
+	repo, err := test.NewTestRepo(t)
+	if !assert.NoError(t, err) {
+		return
+	}
+	system := NewEventSystem("some-type")
+	system.Configure(repo.Directory + "/events")
+	handlerCalled := false
+	system.RegisterEventHandler("some-type", func(_ Event, _ EventLookup) error {
+		handlerCalled = true
+		return nil
+	})
+	system.RegisterPayloadValidator(func(event Event) error {
+		var payload map[string]interface{}
+		_ = event.Unmarshal(&payload)
+		if payload["valid"] != true {
+			return errors.New("invalid payload")
+		}
+		return nil
+	})
+	t.Run("invalid payload", func(t *testing.T) {
+		event := CreateEvent("some-type", map[string]interface{}{"valid": false}, nil)
+		err := system.ProcessEvent(event)
+		assert.EqualError(t, err, "invalid payload")
+		assert.False(t, handlerCalled)
+		assert.Nil(t, system.Get(event.Ref()))
+		// Retrying won't make the payload valid, so the event is dead-lettered right away
+		parked, _ := system.ParkedEvent(event.Ref())
+		if assert.NotNil(t, parked) {
+			assert.Equal(t, "invalid payload", parked.LastError)
+			assert.Equal(t, ParkedEventDeadLetter, parked.State)
+			assert.Equal(t, 1, parked.Attempts)
+		}
+	})
+	t.Run("valid payload", func(t *testing.T) {
+		event := CreateEvent("some-type", map[string]interface{}{"valid": true}, nil)
+		err := system.ProcessEvent(event)
+		assert.NoError(t, err)
+		assert.True(t, handlerCalled)
+		// The event with the invalid payload hasn't been retried
+		parkedEvents := system.ParkedEvents()
+		if assert.Len(t, parkedEvents, 1) {
+			assert.Equal(t, 1, parkedEvents[0].Attempts)
+		}
+	})
+}
+
 func TestDiagnostics(t *testing.T) {
 	repo, err := test.NewTestRepo(t)
 	if !assert.NoError(t, err) {
@@ -76,9 +124,11 @@ func TestDiagnostics(t *testing.T) {
 	system := NewEventSystem()
 	system.Configure(repo.Directory + "/events")
 	diagnostics := system.Diagnostics()
-	assert.Len(t, diagnostics, 1)
-	assert.Equal(t, "0", diagnostics[0].String())
-	assert.NotEmpty(t, diagnostics[0].Name())
+	assert.Len(t, diagnostics, 4)
+	for _, diagnostic := range diagnostics {
+		assert.Equal(t, "0", diagnostic.String())
+		assert.NotEmpty(t, diagnostic.Name())
+	}
 }
 
 func TestProcessEventsOutOfOrder(t *testing.T) {
@@ -122,7 +172,7 @@ func TestProcessEventsOutOfOrder(t *testing.T) {
 			}
 		}
 		assert.Equal(t, len(events), eventsHandled)
-		assert.Empty(t, (system.(*diskEventSystem)).eventsToBeRetried)
+		assert.Empty(t, system.ParkedEvents())
 	})
 	t.Run("missing event halfway, shouldn't retry", func(t *testing.T) {
 		event1 := CreateEvent(eventType, "1", nil)
@@ -263,6 +313,68 @@ func TestLoadEventsInvalidJson(t *testing.T) {
 	assert.EqualError(t, err, "error reading event: 20200123091400001-InvalidJson.json: unable to parse event JSON: invalid character '{' looking for beginning of object key string")
 }
 
+func TestLoadEventsInvalidRef(t *testing.T) {
+	repo, err := test.NewTestRepo(t)
+	if !assert.NoError(t, err) {
+		return
+	}
+	system := NewEventSystem("Test")
+	handled := 0
+	system.RegisterEventHandler("Test", func(_ Event, _ EventLookup) error {
+		handled++
+		return nil
+	})
+	system.Configure(repo.Directory)
+	valid := CreateTestEvent("Test", map[string]interface{}{"Hello": "World"}, nil, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	_ = ioutil.WriteFile(normalizeLocation(repo.Directory, SuggestEventFileName(valid)), valid.Marshal(), os.ModePerm)
+	// Tampered event: payload altered while the original ref is kept
+	tampered := CreateTestEvent("Test", map[string]interface{}{"Hello": "World"}, valid.Ref(), time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC))
+	data := strings.Replace(string(tampered.Marshal()), "World", "Mars", 1)
+	_ = ioutil.WriteFile(normalizeLocation(repo.Directory, SuggestEventFileName(tampered)), []byte(data), os.ModePerm)
+
+	t.Run("tampered event is skipped and reported", func(t *testing.T) {
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, 1, handled)
+		assert.NotNil(t, system.Get(valid.Ref()))
+		assert.Nil(t, system.Get(tampered.Ref()))
+		assert.Equal(t, "1", system.Diagnostics()[3].String())
+	})
+	t.Run("reloading doesn't report it twice", func(t *testing.T) {
+		if !assert.NoError(t, system.LoadAndApplyEvents()) {
+			return
+		}
+		assert.Equal(t, "1", system.Diagnostics()[3].String())
+	})
+}
+
+func TestLoadEventsWithRefInFileName(t *testing.T) {
+	repo, err := test.NewTestRepo(t)
+	if !assert.NoError(t, err) {
+		return
+	}
+	system := NewEventSystem("Test")
+	system.RegisterEventHandler("Test", func(_ Event, _ EventLookup) error {
+		return nil
+	})
+	system.Configure(repo.Directory)
+	issuedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	event1 := CreateTestEvent("Test", "1", nil, issuedAt)
+	event2 := CreateTestEvent("Test", "2", nil, issuedAt)
+	// Events of the same type issued at the same moment get the same name, unless the ref is included
+	assert.Equal(t, SuggestEventFileName(event1), SuggestEventFileName(event2))
+	assert.NotEqual(t, EventFileNameWithRef(event1), EventFileNameWithRef(event2))
+	for _, event := range []Event{event1, event2} {
+		_ = ioutil.WriteFile(normalizeLocation(repo.Directory, EventFileNameWithRef(event)), event.Marshal(), os.ModePerm)
+	}
+	if !assert.NoError(t, system.LoadAndApplyEvents()) {
+		return
+	}
+	assert.NotNil(t, system.Get(event1.Ref()))
+	assert.NotNil(t, system.Get(event2.Ref()))
+}
+
 func TestLoadEventsEmptyFile(t *testing.T) {
 	repo, err := test.NewTestRepoFrom(t, "../../test_data/empty_files")
 	if !assert.NoError(t, err) {
@@ -370,6 +482,7 @@ func Test_readEvent(t *testing.T) {
 		}
 		event := CreateEvent(eventType, eventPayload, nil)
 		(event.(*jsonEvent)).EventIssuedAt = time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+		(event.(*jsonEvent)).EventVersion = 1
 		eventFilePath := normalizeLocation(repo.Directory, SuggestEventFileName(event))
 		ioutil.WriteFile(eventFilePath, event.Marshal(), os.ModePerm)
 		// Random timestamp = random string, makes sure it isn't parsable, which is only performed when overriding event.IssuedAt (because it's not set in source JSON).
@@ -392,3 +505,66 @@ func Test_readEvent(t *testing.T) {
 		assert.Nil(t, event)
 	})
 }
+
+func TestDiskEventSystem_Subscribe(t *testing.T) {
+	system := NewEventSystem(eventType)
+	_ = system.Configure(io.TestDirectory(t))
+	system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+		return nil
+	})
+	var notified []Event
+	unsubscribe := system.Subscribe(func(event Event) {
+		notified = append(notified, event)
+	})
+	event1 := CreateTestEvent(eventType, 1, nil, time.Unix(10000, 0))
+	event2 := CreateTestEvent(eventType, 2, event1.Ref(), time.Unix(10001, 0))
+	if !assert.NoError(t, system.PublishEvent(event1)) {
+		return
+	}
+	// Already applied events shouldn't lead to notifications
+	if !assert.NoError(t, system.ProcessEvent(event1)) {
+		return
+	}
+	assert.Equal(t, []Event{event1}, notified)
+	unsubscribe()
+	if !assert.NoError(t, system.PublishEvent(event2)) {
+		return
+	}
+	assert.Len(t, notified, 1)
+}
+
+func TestDiskEventSystem_EventsAfter(t *testing.T) {
+	system := NewEventSystem(eventType)
+	_ = system.Configure(io.TestDirectory(t))
+	system.RegisterEventHandler(eventType, func(_ Event, _ EventLookup) error {
+		return nil
+	})
+	event1 := CreateTestEvent(eventType, 1, nil, time.Unix(10000, 0))
+	event2 := CreateTestEvent(eventType, 2, event1.Ref(), time.Unix(10001, 0))
+	event3 := CreateTestEvent(eventType, 3, nil, time.Unix(10002, 0))
+	for _, event := range []Event{event1, event2, event3} {
+		if !assert.NoError(t, system.PublishEvent(event)) {
+			return
+		}
+	}
+	t.Run("ok - all events", func(t *testing.T) {
+		evts, err := system.EventsAfter(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []Event{event1, event2, event3}, evts)
+	})
+	t.Run("ok - events after ref", func(t *testing.T) {
+		evts, err := system.EventsAfter(event1.Ref())
+		assert.NoError(t, err)
+		assert.Equal(t, []Event{event2, event3}, evts)
+	})
+	t.Run("ok - no events after last event", func(t *testing.T) {
+		evts, err := system.EventsAfter(event3.Ref())
+		assert.NoError(t, err)
+		assert.Empty(t, evts)
+	})
+	t.Run("error - unknown event", func(t *testing.T) {
+		evts, err := system.EventsAfter(Ref{1, 2, 3})
+		assert.Equal(t, ErrUnknownEvent, err)
+		assert.Nil(t, evts)
+	})
+}
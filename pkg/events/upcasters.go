@@ -0,0 +1,102 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+
+	errors2 "github.com/pkg/errors"
+)
+
+// Upcaster converts the payload of an event from the shape it has in a particular event version to the shape of the next
+// version. The payload is passed as generic JSON object and should be modified in place.
+type Upcaster func(payload map[string]interface{}) error
+
+// AllVersions can be specified as version when registering an upcaster which should be applied to events of any
+// version, e.g. for normalizing payloads whose format changed without the event version being increased.
+const AllVersions Version = math.MaxInt32
+
+type upcasterKey struct {
+	eventType EventType
+	version   Version
+}
+
+var upcasters = make(map[upcasterKey][]Upcaster)
+var upcastersMutex sync.RWMutex
+
+// RegisterUpcaster registers an upcaster for events of the given type and version. When an event is unmarshalled the
+// upcasters registered for its version and all later versions are applied (in order of version, then registration)
+// so the payload has the current shape before it's unmarshalled. This way handlers don't need to know about older
+// payload formats.
+func RegisterUpcaster(eventType EventType, version Version, upcaster Upcaster) {
+	upcastersMutex.Lock()
+	defer upcastersMutex.Unlock()
+	key := upcasterKey{eventType: eventType, version: version}
+	upcasters[key] = append(upcasters[key], upcaster)
+}
+
+// getUpcasters returns the upcasters which apply to events of the given type and version, in the order they should be applied.
+func getUpcasters(eventType EventType, version Version) []Upcaster {
+	upcastersMutex.RLock()
+	defer upcastersMutex.RUnlock()
+	var keys []upcasterKey
+	for key := range upcasters {
+		if key.eventType == eventType && key.version >= version {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].version < keys[j].version
+	})
+	var result []Upcaster
+	for _, key := range keys {
+		result = append(result, upcasters[key]...)
+	}
+	return result
+}
+
+// upcast applies the upcasters for the given event type and version to the payload. If there are no upcasters the payload
+// is returned as-is.
+func upcast(eventType EventType, version Version, payload []byte) ([]byte, error) {
+	applicable := getUpcasters(eventType, version)
+	if len(applicable) == 0 {
+		return payload, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// Numbers are kept as-is to prevent loss of precision when the payload is marshalled again
+	decoder.UseNumber()
+	var payloadAsMap map[string]interface{}
+	if err := decoder.Decode(&payloadAsMap); err != nil {
+		return nil, errors2.Wrap(err, "payload is not a JSON object")
+	}
+	if payloadAsMap == nil {
+		payloadAsMap = make(map[string]interface{})
+	}
+	for _, upcaster := range applicable {
+		if err := upcaster(payloadAsMap); err != nil {
+			return nil, errors2.Wrapf(err, "unable to upcast %s (version %d)", eventType, version)
+		}
+	}
+	return json.Marshal(payloadAsMap)
+}
@@ -0,0 +1,117 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterUpcaster(t *testing.T) {
+	const eventType EventType = "UpcasterTestEvent"
+	var applied []string
+	RegisterUpcaster(eventType, 2, func(payload map[string]interface{}) error {
+		applied = append(applied, "v2")
+		payload["name"] = payload["name"].(string) + "-v3"
+		return nil
+	})
+	RegisterUpcaster(eventType, 0, func(payload map[string]interface{}) error {
+		applied = append(applied, "v0")
+		payload["name"] = payload["name"].(string) + "-v1"
+		return nil
+	})
+	RegisterUpcaster(eventType, 1, func(payload map[string]interface{}) error {
+		applied = append(applied, "v1")
+		payload["name"] = payload["name"].(string) + "-v2"
+		return nil
+	})
+	type testPayload struct {
+		Name   string `json:"name"`
+		Number int64  `json:"number"`
+	}
+
+	t.Run("upcasters for version and later are applied in order", func(t *testing.T) {
+		applied = nil
+		event := jsonEvent{EventType: string(eventType), EventVersion: 1, EventPayload: testPayload{Name: "name", Number: 9007199254740993}}
+		var payload testPayload
+		err := event.Unmarshal(&payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"v1", "v2"}, applied)
+		assert.Equal(t, "name-v2-v3", payload.Name)
+		// Numbers shouldn't lose precision
+		assert.Equal(t, int64(9007199254740993), payload.Number)
+	})
+	t.Run("no upcasters for later version", func(t *testing.T) {
+		applied = nil
+		event := jsonEvent{EventType: string(eventType), EventVersion: 3, EventPayload: testPayload{Name: "name"}}
+		var payload testPayload
+		err := event.Unmarshal(&payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, applied)
+		assert.Equal(t, "name", payload.Name)
+	})
+	t.Run("other event type", func(t *testing.T) {
+		applied = nil
+		event := jsonEvent{EventType: "other", EventVersion: 0, EventPayload: testPayload{Name: "name"}}
+		var payload testPayload
+		_ = event.Unmarshal(&payload)
+		assert.Empty(t, applied)
+	})
+}
+
+func TestUpcast(t *testing.T) {
+	const eventType EventType = "UpcastTestEvent"
+	RegisterUpcaster(eventType, 0, func(payload map[string]interface{}) error {
+		if payload["fail"] != nil {
+			return errors.New("failed")
+		}
+		payload["upcasted"] = true
+		return nil
+	})
+	t.Run("ok - no upcasters", func(t *testing.T) {
+		payload := []byte(`{"foo": "bar"}`)
+		result, err := upcast("other", 0, payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, payload, result)
+	})
+	t.Run("ok - null payload", func(t *testing.T) {
+		result, err := upcast(eventType, 0, []byte("null"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.JSONEq(t, `{"upcasted": true}`, string(result))
+	})
+	t.Run("error - payload is not an object", func(t *testing.T) {
+		_, err := upcast(eventType, 0, []byte("[]"))
+		assert.Contains(t, err.Error(), "payload is not a JSON object")
+	})
+	t.Run("error - upcaster fails", func(t *testing.T) {
+		_, err := upcast(eventType, 0, []byte(`{"fail": true}`))
+		assert.EqualError(t, err, "unable to upcast UpcastTestEvent (version 0): failed")
+	})
+}
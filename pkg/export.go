@@ -0,0 +1,90 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	crypto "github.com/nuts-foundation/nuts-crypto/pkg"
+	"github.com/nuts-foundation/nuts-crypto/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	errors2 "github.com/pkg/errors"
+)
+
+// Export writes all applied events in chain order as tar.gz bundle to the given writer. The bundle contains a
+// manifest listing the ref and SHA-256 hash of each event file, signed with the vendor's signing certificate. The
+// events are laid out like the events directory, so the bundle can be ingested like the tar.gz from sync mode 'github'.
+func (r *Registry) Export(writer io.Writer) error {
+	vendor, err := r.getVendor()
+	if err != nil {
+		return err
+	}
+	evts, err := r.EventSystem.EventsAfter(nil)
+	if err != nil {
+		return errors2.Wrap(err, "unable to list events")
+	}
+	manifest := bundle.Manifest{CreatedAt: time.Now(), Vendor: vendor.Identifier, Events: make([]bundle.ManifestEntry, len(evts))}
+	for i, event := range evts {
+		hash := sha256.Sum256(event.Marshal())
+		manifest.Events[i] = bundle.ManifestEntry{
+			Ref:    event.Ref(),
+			File:   path.Join(bundle.EventsDir, events.EventFileNameWithRef(event)),
+			SHA256: hex.EncodeToString(hash[:]),
+		}
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	key := types.KeyForEntity(types.LegalEntity{URI: vendor.Identifier.String()}).WithQualifier(crypto.SigningCertificateQualifier)
+	signedManifest, err := r.crypto.SignJWS(manifestData, key)
+	if err != nil {
+		return errors2.Wrap(err, "unable to sign export manifest")
+	}
+
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	// The manifest comes first, so it can be verified before the events are read
+	if err := bundle.WriteEntry(tarWriter, bundle.ManifestFile, signedManifest, manifest.CreatedAt); err != nil {
+		return err
+	}
+	for i, event := range evts {
+		if err := bundle.WriteEntry(tarWriter, manifest.Events[i].File, event.Marshal(), manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	logging.Log().Infof("Exported %d events", len(evts))
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Export(t *testing.T) {
+	orgID := test.OrganizationID("org")
+
+	t.Run("ok", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+			return
+		}
+		if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+			return
+		}
+		if _, err := cxt.registry.RegisterEndpoint(orgID, "endpoint", "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+			return
+		}
+		buf := new(bytes.Buffer)
+		err := cxt.registry.Export(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+		files := readBundle(t, buf.Bytes())
+		if !assert.NotEmpty(t, files) {
+			return
+		}
+		// Manifest
+		signedManifest := files[0]
+		assert.Equal(t, bundle.RootDir+"/"+bundle.ManifestFile, signedManifest.name)
+		manifestData, err := cxt.registry.crypto.VerifyJWS(signedManifest.data, time.Now(), cxt.registry.crypto.TrustStore())
+		if !assert.NoError(t, err) {
+			return
+		}
+		manifest := bundle.Manifest{}
+		if !assert.NoError(t, json.Unmarshal(manifestData, &manifest)) {
+			return
+		}
+		assert.Equal(t, vendorId, manifest.Vendor)
+		// Events, in chain order
+		applied, _ := cxt.registry.EventSystem.EventsAfter(nil)
+		if !assert.Len(t, manifest.Events, 3) || !assert.Len(t, files, 4) {
+			return
+		}
+		for i, entry := range manifest.Events {
+			file := files[i+1]
+			assert.Equal(t, applied[i].Ref(), entry.Ref)
+			assert.Equal(t, bundle.RootDir+"/"+entry.File, file.name)
+			// The ref makes the file name unique, even for events of the same type issued in the same millisecond
+			assert.Contains(t, entry.File, entry.Ref.String())
+			hash := sha256.Sum256(file.data)
+			assert.Equal(t, hex.EncodeToString(hash[:]), entry.SHA256)
+			event, err := events.EventFromJSON(file.data)
+			if assert.NoError(t, err) {
+				assert.Equal(t, entry.Ref, event.Ref())
+			}
+		}
+		t.Run("bundle can be ingested", func(t *testing.T) {
+			target := createTestContext(t)
+			defer target.close()
+			datadir := target.registry.Config.Datadir
+			_ = os.MkdirAll(filepath.Join(datadir, "tmp"), os.ModePerm)
+			_ = os.MkdirAll(target.registry.getEventsDir(), os.ModePerm)
+			_ = ioutil.WriteFile(filepath.Join(datadir, "tmp", "registry.tar.gz"), buf.Bytes(), 0644)
+			if !assert.NoError(t, target.registry.unzip()) {
+				return
+			}
+			entries, _ := ioutil.ReadDir(target.registry.getEventsDir())
+			assert.Len(t, entries, 3)
+			assert.NoFileExists(t, filepath.Join(datadir, bundle.ManifestFile))
+		})
+	})
+	t.Run("error - vendor not registered", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		err := cxt.registry.Export(new(bytes.Buffer))
+		assert.Contains(t, err.Error(), "vendor not found")
+	})
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+func readBundle(t *testing.T, bundle []byte) []bundleFile {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(bundle))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	tarReader := tar.NewReader(gzipReader)
+	var result []bundleFile
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return result
+		}
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		data, _ := ioutil.ReadAll(tarReader)
+		result = append(result, bundleFile{name: header.Name, data: data})
+	}
+}
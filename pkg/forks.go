@@ -0,0 +1,117 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	crypto "github.com/nuts-foundation/nuts-crypto/pkg"
+	"github.com/nuts-foundation/nuts-crypto/pkg/types"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	dom "github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+)
+
+// ErrForkNotFound is returned when the specified fork was not found
+var ErrForkNotFound = errors.New("fork not found")
+
+// ErrForkNotMergeable is returned when the specified fork can't be merged as requested
+var ErrForkNotMergeable = errors.New("fork can't be merged")
+
+// Forks returns the event paths which forked, because multiple events refer to the same previous event.
+func (r *Registry) Forks() ([]events.Fork, error) {
+	return r.EventSystem.Forks(), nil
+}
+
+// MergeFork heals the fork whose branches refer to the event with the given (parent) ref, by publishing a merge event:
+// an event which extends the canonical branch with the data of the last event of the branch specified by keep. If keep
+// is zero the data of the canonical branch is kept. Since the merge event is issued after all events on the fork's
+// branches, the fork is considered merged afterwards. The fork must concern data managed by this node.
+func (r *Registry) MergeFork(parent events.Ref, keep events.Ref) (events.Event, error) {
+	var fork *events.Fork
+	for _, f := range r.EventSystem.Forks() {
+		if f.Parent.Equal(parent) {
+			fork = &f
+			break
+		}
+	}
+	if fork == nil {
+		return nil, ErrForkNotFound
+	}
+	canonicalLast := fork.Branches[0].Last
+	if keep.IsZero() {
+		keep = canonicalLast
+	}
+	var keepBranch bool
+	for _, branch := range fork.Branches {
+		keepBranch = keepBranch || branch.Last.Equal(keep)
+	}
+	if !keepBranch {
+		return nil, fmt.Errorf("%w: event %s is not the last event of one of the fork's branches", ErrForkNotMergeable, keep)
+	}
+	previousEvent := r.EventSystem.Get(canonicalLast)
+	keepEvent := r.EventSystem.Get(keep)
+	logging.Log().Infof("Merging fork (parent=%s), keeping data of event %s", parent, keep)
+	vendorID := core.NutsConfig().VendorID()
+	switch keepEvent.Type() {
+	case dom.RegisterVendor:
+		payload := dom.RegisterVendorEvent{}
+		if err := keepEvent.Unmarshal(&payload); err != nil {
+			return nil, err
+		}
+		if payload.Identifier != vendorID {
+			return nil, fmt.Errorf("%w: it concerns a vendor which isn't managed by this node (id=%s)", ErrForkNotMergeable, payload.Identifier)
+		}
+		return r.signAndPublishEvent(dom.RegisterVendor, payload, previousEvent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+			entity := types.LegalEntity{URI: vendorID.String()}
+			return r.crypto.SignJWS(dataToBeSigned, types.KeyForEntity(entity).WithQualifier(crypto.SigningCertificateQualifier))
+		})
+	case dom.VendorClaim:
+		payload := dom.VendorClaimEvent{}
+		if err := keepEvent.Unmarshal(&payload); err != nil {
+			return nil, err
+		}
+		return r.signAndPublishEventAsOrganization(dom.VendorClaim, payload, payload.OrganizationID, previousEvent)
+	case dom.RegisterEndpoint:
+		payload := dom.RegisterEndpointEvent{}
+		if err := keepEvent.Unmarshal(&payload); err != nil {
+			return nil, err
+		}
+		return r.signAndPublishEventAsOrganization(dom.RegisterEndpoint, payload, payload.Organization, previousEvent)
+	default:
+		return nil, fmt.Errorf("%w: merging forks of %s events is not supported", ErrForkNotMergeable, keepEvent.Type())
+	}
+}
+
+func (r *Registry) signAndPublishEventAsOrganization(eventType events.EventType, payload interface{}, organizationID core.PartyID, previousEvent events.Event) (events.Event, error) {
+	org, err := r.Db.OrganizationById(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if org.Vendor != core.NutsConfig().VendorID() {
+		return nil, fmt.Errorf("%w: it concerns an organization which isn't managed by this node (id=%s)", ErrForkNotMergeable, organizationID)
+	}
+	return r.signAndPublishEvent(eventType, payload, previousEvent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+		return r.signAsOrganization(org.Identifier, org.Name, dataToBeSigned, instant, len(org.GetActiveCertificates()) > 0)
+	})
+}
@@ -0,0 +1,102 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_MergeFork(t *testing.T) {
+	cxt := createTestContext(t)
+	defer cxt.close()
+	orgID := test.OrganizationID("org")
+	if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+		return
+	}
+	parent, err := cxt.registry.RegisterEndpoint(orgID, "endpoint", "url", "type", db.StatusActive, nil, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	org, _ := cxt.registry.Db.OrganizationById(orgID)
+	// Simulate concurrent updates (e.g. by two nodes of the same vendor) of the endpoint
+	update := func(url string) events.Event {
+		event, err := cxt.registry.signAndPublishEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: orgID,
+			URL:          url,
+			EndpointType: "type",
+			Identifier:   "endpoint",
+			Status:       db.StatusActive,
+		}, parent, func(dataToBeSigned []byte, instant time.Time) ([]byte, error) {
+			return cxt.registry.signAsOrganization(org.Identifier, org.Name, dataToBeSigned, instant, len(org.GetActiveCertificates()) > 0)
+		})
+		if err != nil {
+			panic(err)
+		}
+		return event
+	}
+	canonical := update("url-1")
+	other := update("url-2")
+	endpointURL := func() string {
+		endpoints, _ := cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+		return endpoints[0].URL
+	}
+
+	forks, err := cxt.registry.Forks()
+	if !assert.NoError(t, err) || !assert.Len(t, forks, 1) {
+		return
+	}
+	assert.Equal(t, parent.Ref(), forks[0].Parent)
+	assert.Equal(t, canonical.Ref(), forks[0].Branches[0].First)
+	assert.False(t, forks[0].Merged)
+	assert.Equal(t, "url-1", endpointURL())
+
+	t.Run("error - unknown fork", func(t *testing.T) {
+		_, err := cxt.registry.MergeFork(canonical.Ref(), nil)
+		assert.Equal(t, ErrForkNotFound, err)
+	})
+	t.Run("error - keep is not the last event of a branch", func(t *testing.T) {
+		_, err := cxt.registry.MergeFork(parent.Ref(), parent.Ref())
+		assert.True(t, errors.Is(err, ErrForkNotMergeable))
+	})
+	t.Run("ok - keep other branch", func(t *testing.T) {
+		event, err := cxt.registry.MergeFork(parent.Ref(), other.Ref())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, canonical.Ref(), event.PreviousRef())
+		assert.Equal(t, "url-2", endpointURL())
+		forks, _ := cxt.registry.Forks()
+		assert.True(t, forks[0].Merged)
+		history, err := cxt.registry.EndpointHistory(orgID, "endpoint")
+		assert.NoError(t, err)
+		assert.Equal(t, []events.Event{parent, canonical, event}, history)
+	})
+}
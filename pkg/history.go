@@ -0,0 +1,68 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"errors"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	dom "github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+)
+
+// ErrEndpointNotFound is returned when the specified endpoint was not found
+var ErrEndpointNotFound = errors.New("endpoint not found")
+
+// VendorHistory returns the events registering (and updating) the specified vendor, ordered from first to last.
+// When the vendor isn't found ErrVendorNotFound is returned.
+func (r *Registry) VendorHistory(vendorID core.PartyID) ([]events.Event, error) {
+	return r.history(dom.VendorEventKey(vendorID), dom.VendorEventMatcher(vendorID), ErrVendorNotFound)
+}
+
+// OrganizationHistory returns the events claiming (and updating) the specified organization, ordered from first to last.
+// When the organization isn't found ErrOrganizationNotFound is returned.
+func (r *Registry) OrganizationHistory(organizationID core.PartyID) ([]events.Event, error) {
+	org, err := r.Db.OrganizationById(organizationID)
+	if errors.Is(err, db.ErrOrganizationNotFound) {
+		return nil, ErrOrganizationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return r.history(dom.OrganizationEventKey(organizationID), dom.OrganizationEventMatcher(org.Vendor, organizationID), ErrOrganizationNotFound)
+}
+
+// EndpointHistory returns the events registering (and updating) the specified endpoint of an organization, ordered from
+// first to last. When the endpoint isn't found ErrEndpointNotFound is returned.
+func (r *Registry) EndpointHistory(organizationID core.PartyID, endpointID types.EndpointID) ([]events.Event, error) {
+	return r.history(dom.EndpointEventKey(organizationID, endpointID), dom.EndpointEventMatcher(organizationID, endpointID), ErrEndpointNotFound)
+}
+
+func (r *Registry) history(key events.EntityKey, matcher events.EventMatcher, notFoundErr error) ([]events.Event, error) {
+	path, err := r.EventSystem.FindEventPathByKey(key, matcher)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, notFoundErr
+	}
+	return path, nil
+}
@@ -0,0 +1,121 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_History(t *testing.T) {
+	orgID := test.OrganizationID("orgId")
+	// setup registers a vendor, an organization and an endpoint, which is updated once
+	setup := func(t *testing.T, cxt testContext) []events.Event {
+		var result []events.Event
+		for _, fn := range []func() (events.Event, error){
+			func() (events.Event, error) { return cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()) },
+			func() (events.Event, error) { return cxt.registry.VendorClaim(orgID, "org", nil) },
+			func() (events.Event, error) {
+				return cxt.registry.RegisterEndpoint(orgID, "endpointId", "url", "type", domain.EndpointStatusActive, nil, nil, nil)
+			},
+			func() (events.Event, error) {
+				return cxt.registry.RegisterEndpoint(orgID, "endpointId", "url-updated", "type", domain.EndpointStatusActive, nil, nil, nil)
+			},
+		} {
+			event, err := fn()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			result = append(result, event)
+		}
+		return result
+	}
+
+	t.Run("ok - vendor", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		published := setup(t, cxt)
+		history, err := cxt.registry.VendorHistory(vendorId)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Len(t, history, 1) {
+			return
+		}
+		assert.Equal(t, published[0].Ref(), history[0].Ref())
+		assert.Equal(t, domain.RegisterVendor, history[0].Type())
+		assert.NotNil(t, history[0].SignatureDetails().Certificate)
+	})
+	t.Run("ok - organization", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		published := setup(t, cxt)
+		history, err := cxt.registry.OrganizationHistory(orgID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Len(t, history, 1) {
+			return
+		}
+		assert.Equal(t, published[1].Ref(), history[0].Ref())
+	})
+	t.Run("ok - endpoint (ordered first to last)", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		published := setup(t, cxt)
+		history, err := cxt.registry.EndpointHistory(orgID, "endpointId")
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Len(t, history, 2) {
+			return
+		}
+		assert.Equal(t, published[2].Ref(), history[0].Ref())
+		assert.Equal(t, published[3].Ref(), history[1].Ref())
+		assert.NotNil(t, history[1].SignatureDetails().Certificate)
+	})
+	t.Run("error - vendor not found", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		history, err := cxt.registry.VendorHistory(test.VendorID("unknown"))
+		assert.Nil(t, history)
+		assert.Equal(t, ErrVendorNotFound, err)
+	})
+	t.Run("error - organization not found", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		history, err := cxt.registry.OrganizationHistory(test.OrganizationID("unknown"))
+		assert.Nil(t, history)
+		assert.Equal(t, ErrOrganizationNotFound, err)
+	})
+	t.Run("error - endpoint not found", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		setup(t, cxt)
+		history, err := cxt.registry.EndpointHistory(orgID, types.EndpointID("unknown"))
+		assert.Nil(t, history)
+		assert.Equal(t, ErrEndpointNotFound, err)
+	})
+}
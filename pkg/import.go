@@ -0,0 +1,340 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	certutil "github.com/nuts-foundation/nuts-registry/pkg/cert"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	errors2 "github.com/pkg/errors"
+)
+
+// ErrInvalidBundle is returned when an import bundle can't be read or its manifest is invalid.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// Import reads a tar.gz bundle of events (e.g. created by Export) from the given reader. Every event is validated by
+// running it through the event handlers against a scratch copy of the registry state, which yields the returned report.
+// Only when apply is true the events that are applied or parked according to the report are actually imported.
+// If the bundle contains a manifest, the hashes of the event files and its signature are verified before validating
+// the events. If the bundle can't be read or its manifest is invalid, an error wrapping ErrInvalidBundle is returned.
+// Importing isn't atomic: the events are imported one by one, and when importing an event fails the import stops. The
+// report then holds the error and marks the events which have been imported.
+func (r *Registry) Import(reader io.Reader, apply bool) (*bundle.ImportReport, error) {
+	files, signedManifest, err := bundle.Read(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	report := &bundle.ImportReport{}
+	var order []string
+	if signedManifest != nil {
+		if report.Manifest, err = parseManifest(signedManifest, files); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		// The certificate of the vendor that signed the manifest might be registered by the bundle itself, so the
+		// vendor's CA certificates in the bundle are used to verify it as well.
+		verifier := manifestVerifier{
+			trustStore:    r.crypto.TrustStore(),
+			intermediates: vendorCACertificates(files, report.Manifest.Vendor),
+		}
+		if err := verifyManifestSignature(r.crypto.VerifyJWS, signedManifest, report.Manifest, verifier); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		for _, entry := range report.Manifest.Events {
+			order = append(order, entry.File)
+		}
+	} else {
+		// Without manifest, events are imported in the same order as when loading them from the events directory
+		for name := range files {
+			order = append(order, name)
+		}
+		sort.Slice(order, func(i, j int) bool {
+			return path.Base(order[i]) < path.Base(order[j])
+		})
+	}
+
+	scratchDir, err := ioutil.TempDir("", "registry-import")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratchDir)
+	scratch, err := r.createScratchState(scratchDir)
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to create scratch copy of registry state")
+	}
+	defer scratch.Close()
+	evts := dryRunImport(scratch, order, files, report)
+	if !apply {
+		return report, nil
+	}
+	imported := 0
+	for i := range report.Events {
+		entry := &report.Events[i]
+		if entry.Outcome != bundle.ImportOutcomeApplied && entry.Outcome != bundle.ImportOutcomeParked {
+			continue
+		}
+		if err := r.EventSystem.PublishEvent(evts[i]); err != nil {
+			report.Error = errors2.Wrapf(err, "unable to import event (file = %s)", entry.File).Error()
+			logging.Log().Errorf("Import stopped after %d events: %s", imported, report.Error)
+			return report, nil
+		}
+		entry.Imported = true
+		imported++
+	}
+	report.Applied = true
+	logging.Log().Infof("Imported %d events", imported)
+	return report, nil
+}
+
+// dryRunImport processes the events in the given files (in the given order) using the scratch event system and adds
+// the outcome per event to the report. It returns the parsed events (nil when unparseable or duplicate), in the same order.
+func dryRunImport(scratch events.EventSystem, order []string, files map[string][]byte, report *bundle.ImportReport) []events.Event {
+	evts := make([]events.Event, len(order))
+	errs := make([]error, len(order))
+	seen := make(map[string]bool, len(order))
+	report.Events = make([]bundle.ImportReportEntry, len(order))
+	for i, file := range order {
+		entry := &report.Events[i]
+		entry.File = file
+		event, err := events.EventFromJSON(files[file])
+		if err != nil {
+			entry.Outcome = bundle.ImportOutcomeRejected
+			entry.Reason = err.Error()
+			continue
+		}
+		entry.Ref = event.Ref()
+		entry.Type = event.Type()
+		if seen[event.Ref().String()] || scratch.Get(event.Ref()) != nil {
+			entry.Outcome = bundle.ImportOutcomeDuplicate
+			continue
+		}
+		seen[event.Ref().String()] = true
+		evts[i] = event
+		errs[i] = scratch.ProcessEvent(event)
+	}
+	// Events can be applied after events later in the bundle (e.g. when out of order), so the outcome is determined afterwards
+	for i, event := range evts {
+		if event == nil {
+			continue
+		}
+		entry := &report.Events[i]
+		if scratch.Get(event.Ref()) != nil {
+			entry.Outcome = bundle.ImportOutcomeApplied
+		} else if !event.PreviousRef().IsZero() && scratch.Get(event.PreviousRef()) == nil {
+			entry.Outcome = bundle.ImportOutcomeParked
+			entry.Reason = fmt.Sprintf("previous event %s is missing", event.PreviousRef())
+		} else {
+			entry.Outcome = bundle.ImportOutcomeRejected
+			if parked, err := scratch.ParkedEvent(event.Ref()); err == nil {
+				entry.Reason = parked.LastError
+			} else if errs[i] != nil {
+				entry.Reason = errs[i].Error()
+			}
+		}
+	}
+	return evts
+}
+
+// scratchState is a copy of the registry state, which can be used to process events without affecting the registry.
+type scratchState struct {
+	events.EventSystem
+	db db.Db
+}
+
+// Close closes the event system and database of the scratch state.
+func (s scratchState) Close() error {
+	err := s.EventSystem.Close()
+	if closer, ok := s.db.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// createScratchState creates an event system and database (stored in the given directory) with the same event
+// handlers (except for the network ambassador) and state as the registry. The database is of the configured type, so
+// events are validated by the same implementation. It uses a copy of the registry's trust store. The scratch state must
+// be closed after use.
+func (r *Registry) createScratchState(dir string) (*scratchState, error) {
+	trustStore, err := cert.NewTrustStore(filepath.Join(dir, "truststore.pem"))
+	if err != nil {
+		return nil, err
+	}
+	roots, _ := r.crypto.TrustStore().Roots()
+	intermediates, _ := r.crypto.TrustStore().Intermediates()
+	for _, certificate := range append(roots, intermediates...) {
+		if err := trustStore.AddCertificate(certificate); err != nil {
+			return nil, err
+		}
+	}
+	var eventsData, dbData []byte
+	err = r.EventSystem.Exclusive(func() error {
+		var err error
+		if eventsData, _, err = r.EventSystem.Snapshot(); err != nil {
+			return err
+		}
+		dbData, err = r.Db.Snapshot()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	database, err := r.openDb(filepath.Join(dir, "db"))
+	if err != nil {
+		return nil, err
+	}
+	scratch := &scratchState{EventSystem: events.NewEventSystem(domain.GetEventTypes()...), db: database}
+	r.registerStateHandlers(scratch.EventSystem, trustStore, database)
+	if err := scratch.Configure(filepath.Join(dir, "events")); err != nil {
+		_ = scratch.Close()
+		return nil, err
+	}
+	if err := scratch.Restore(eventsData); err != nil {
+		_ = scratch.Close()
+		return nil, err
+	}
+	if err := database.Restore(dbData); err != nil {
+		_ = scratch.Close()
+		return nil, err
+	}
+	return scratch, nil
+}
+
+// parseManifest parses the signed manifest (without verifying its signature) and checks whether it matches the given files.
+func parseManifest(signedManifest []byte, files map[string][]byte) (*bundle.Manifest, error) {
+	message, err := jws.Parse(bytes.NewReader(signedManifest))
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to parse manifest")
+	}
+	manifest := bundle.Manifest{}
+	if err := json.Unmarshal(message.Payload(), &manifest); err != nil {
+		return nil, errors2.Wrap(err, "unable to parse manifest")
+	}
+	listed := make(map[string]bool, len(manifest.Events))
+	for _, entry := range manifest.Events {
+		data, ok := files[entry.File]
+		if !ok {
+			return nil, fmt.Errorf("file listed in manifest is missing: %s", entry.File)
+		}
+		hash := sha256.Sum256(data)
+		if hex.EncodeToString(hash[:]) != entry.SHA256 {
+			return nil, fmt.Errorf("SHA-256 hash of file doesn't match manifest: %s", entry.File)
+		}
+		listed[entry.File] = true
+	}
+	for file := range files {
+		if !listed[file] {
+			return nil, fmt.Errorf("file not listed in manifest: %s", file)
+		}
+	}
+	return &manifest, nil
+}
+
+// manifestVerifier verifies certificates against the given trust store, using the given (untrusted) intermediate
+// certificates to build the chain. Since these can't be used as trust anchor, the chain must still end at a root
+// certificate of the trust store.
+type manifestVerifier struct {
+	trustStore    cert.TrustStore
+	intermediates []*x509.Certificate
+}
+
+func (v manifestVerifier) Verify(certificate *x509.Certificate, moment time.Time, keyUsages []x509.ExtKeyUsage) error {
+	_, err := v.VerifiedChain(certificate, moment, keyUsages)
+	return err
+}
+
+func (v manifestVerifier) VerifiedChain(certificate *x509.Certificate, moment time.Time, keyUsages []x509.ExtKeyUsage) ([][]*x509.Certificate, error) {
+	_, roots := v.trustStore.Roots()
+	intermediates, _ := v.trustStore.Intermediates()
+	pool := x509.NewCertPool()
+	for _, intermediate := range append(intermediates, v.intermediates...) {
+		pool.AddCert(intermediate)
+	}
+	return certificate.Verify(x509.VerifyOptions{Roots: roots, Intermediates: pool, CurrentTime: moment, KeyUsages: keyUsages})
+}
+
+// vendorCACertificates returns the CA certificates of the given vendor, registered by the RegisterVendor events in the
+// given files. Files that can't be parsed are skipped, since they're reported by the dry run.
+func vendorCACertificates(files map[string][]byte, vendorID core.PartyID) []*x509.Certificate {
+	var result []*x509.Certificate
+	for _, data := range files {
+		event, err := events.EventFromJSON(data)
+		if err != nil || event.Type() != domain.RegisterVendor {
+			continue
+		}
+		payload := domain.RegisterVendorEvent{}
+		if err := event.Unmarshal(&payload); err != nil || payload.Identifier != vendorID {
+			continue
+		}
+		for _, key := range payload.Keys {
+			keyAsMap, ok := key.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			chain, _ := cert.MapToX509CertChain(keyAsMap)
+			for _, certificate := range chain {
+				if certificate.IsCA {
+					result = append(result, certificate)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// verifyManifestSignature verifies the manifest is signed by the vendor it specifies, using a certificate trusted by
+// the given verifier.
+func verifyManifestSignature(verifier events.JwsVerifier, signedManifest []byte, manifest *bundle.Manifest, trustStore cert.Verifier) error {
+	// The signature is verified at the moment the manifest was created, so the bundle remains valid when the
+	// certificate expires.
+	if _, err := verifier(signedManifest, manifest.CreatedAt, trustStore); err != nil {
+		return errors2.Wrap(err, "invalid manifest signature")
+	}
+	message, _ := jws.Parse(bytes.NewReader(signedManifest))
+	chain, err := cert.GetX509ChainFromHeaders(message.Signatures()[0].ProtectedHeaders())
+	if err != nil || len(chain) == 0 {
+		return errors.New("unable to determine manifest signer")
+	}
+	if signer, err := certutil.NewNutsCertificate(chain[0]).GetVendorID(); err != nil || signer != manifest.Vendor {
+		return fmt.Errorf("manifest not signed by vendor %s", manifest.Vendor)
+	}
+	return nil
+}
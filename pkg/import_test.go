@@ -0,0 +1,283 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Import(t *testing.T) {
+	orgID := test.OrganizationID("org")
+	// createExport registers the vendor, an organization and an endpoint and exports them
+	createExport := func(t *testing.T) []byte {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+			return nil
+		}
+		if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+			return nil
+		}
+		if _, err := cxt.registry.RegisterEndpoint(orgID, "endpoint", "url", "type", db.StatusActive, nil, nil, nil); !assert.NoError(t, err) {
+			return nil
+		}
+		buf := new(bytes.Buffer)
+		if err := cxt.registry.Export(buf); !assert.NoError(t, err) {
+			return nil
+		}
+		return buf.Bytes()
+	}
+	exported := createExport(t)
+	if exported == nil {
+		return
+	}
+	// createTarget creates an empty registry which trusts the CA that issued the exporting vendor's certificate
+	createTarget := func(t *testing.T) testContext {
+		cxt := createTestContext(t)
+		_ = cxt.registry.crypto.TrustStore().AddCertificate(nutsCACertificate)
+		return cxt
+	}
+
+	t.Run("ok - dry run, then apply", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+
+		report, err := cxt.registry.Import(bytes.NewReader(exported), false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, report.Applied)
+		assert.NotNil(t, report.Manifest)
+		if !assert.Len(t, report.Events, 3) {
+			return
+		}
+		for _, entry := range report.Events {
+			assert.Equal(t, bundle.ImportOutcomeApplied, entry.Outcome, entry.Reason)
+		}
+		assert.Equal(t, domain.RegisterVendor, report.Events[0].Type)
+		// Dry run shouldn't alter the registry
+		_, err = cxt.registry.VendorById(vendorId)
+		assert.Equal(t, ErrVendorNotFound, err)
+		evts, _ := cxt.registry.EventSystem.EventsAfter(nil)
+		assert.Empty(t, evts)
+
+		report, err = cxt.registry.Import(bytes.NewReader(exported), true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, report.Applied)
+		endpoints, _ := cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+		assert.Len(t, endpoints, 1)
+		for _, entry := range report.Events {
+			event := cxt.registry.EventSystem.Get(entry.Ref)
+			if assert.NotNil(t, event) {
+				assert.FileExists(t, filepath.Join(cxt.registry.getEventsDir(), events.SuggestEventFileName(event)))
+			}
+		}
+
+		t.Run("import again", func(t *testing.T) {
+			report, err := cxt.registry.Import(bytes.NewReader(exported), true)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, entry := range report.Events {
+				assert.Equal(t, bundle.ImportOutcomeDuplicate, entry.Outcome)
+			}
+		})
+	})
+	t.Run("ok - dry run with SQLite database", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+		cxt.registry.Config.Database = "sqlite"
+
+		scratch, err := cxt.registry.createScratchState(t.TempDir())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.IsType(t, &db.SQLiteDb{}, scratch.db)
+		assert.NoError(t, scratch.Close())
+		report, err := cxt.registry.Import(bytes.NewReader(exported), false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, entry := range report.Events {
+			assert.Equal(t, bundle.ImportOutcomeApplied, entry.Outcome, entry.Reason)
+		}
+	})
+	t.Run("error - import stops at first failure", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+		eventSystem := cxt.registry.EventSystem
+		defer func() {
+			cxt.registry.EventSystem = eventSystem
+		}()
+		cxt.registry.EventSystem = &failingEventSystem{EventSystem: eventSystem, publishable: 1}
+
+		report, err := cxt.registry.Import(bytes.NewReader(exported), true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, report.Applied)
+		assert.Contains(t, report.Error, "unable to import event (file = "+report.Events[1].File+"): disk full")
+		assert.True(t, report.Events[0].Imported)
+		assert.False(t, report.Events[1].Imported)
+		assert.False(t, report.Events[2].Imported)
+		_, err = cxt.registry.VendorById(vendorId)
+		assert.NoError(t, err)
+		evts, _ := eventSystem.EventsAfter(nil)
+		assert.Len(t, evts, 1)
+	})
+	t.Run("ok - outcome per event", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+		missingParent := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: orgID}, []byte{1, 2, 3})
+		unknownOrg := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: orgID, Identifier: "endpoint", EndpointType: "type", URL: "url", Status: "active"}, nil)
+		vendor := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: vendorId, Name: "vendor"}, nil)
+
+		report, err := cxt.registry.Import(bytes.NewReader(createBundle(t, map[string][]byte{
+			"events/1.json": vendor.Marshal(),
+			"events/2.json": vendor.Marshal(),
+			"events/3.json": missingParent.Marshal(),
+			"events/4.json": unknownOrg.Marshal(),
+			"events/5.json": []byte("invalid"),
+			"events/6.json": bytes.Replace(vendor.Marshal(), []byte(`"vendor"`), []byte(`"other"`), 1),
+		})), false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Nil(t, report.Manifest)
+		if !assert.Len(t, report.Events, 6) {
+			return
+		}
+		assert.Equal(t, bundle.ImportOutcomeApplied, report.Events[0].Outcome)
+		assert.Equal(t, bundle.ImportOutcomeDuplicate, report.Events[1].Outcome)
+		assert.Equal(t, bundle.ImportOutcomeParked, report.Events[2].Outcome)
+		assert.Contains(t, report.Events[2].Reason, "is missing")
+		assert.Equal(t, bundle.ImportOutcomeRejected, report.Events[3].Outcome)
+		assert.Contains(t, report.Events[3].Reason, "organization not registered")
+		assert.Equal(t, "events/5.json", report.Events[4].File)
+		assert.Equal(t, bundle.ImportOutcomeRejected, report.Events[4].Outcome)
+		assert.Nil(t, report.Events[4].Ref)
+		assert.Equal(t, bundle.ImportOutcomeRejected, report.Events[5].Outcome)
+		assert.Contains(t, report.Events[5].Reason, "event ref is invalid")
+
+		t.Run("apply", func(t *testing.T) {
+			_, err := cxt.registry.Import(bytes.NewReader(createBundle(t, map[string][]byte{
+				"events/1.json": vendor.Marshal(),
+				"events/2.json": missingParent.Marshal(),
+				"events/3.json": unknownOrg.Marshal(),
+			})), true)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = cxt.registry.VendorById(vendorId)
+			assert.NoError(t, err)
+			parked, err := cxt.registry.ParkedEvent(missingParent.Ref())
+			if assert.NoError(t, err) {
+				assert.Equal(t, events.ParkedEventPending, parked.State)
+			}
+			_, err = cxt.registry.ParkedEvent(unknownOrg.Ref())
+			assert.True(t, errors.Is(err, events.ErrUnknownEvent))
+		})
+	})
+	t.Run("error - tampered event", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+		files := readBundle(t, exported)
+		tampered := make(map[string][]byte, len(files))
+		for _, file := range files {
+			tampered[file.name[len(bundle.RootDir)+1:]] = file.data
+		}
+		lastEvent := files[len(files)-1].name[len(bundle.RootDir)+1:]
+		tampered[lastEvent] = append(tampered[lastEvent], ' ')
+
+		_, err := cxt.registry.Import(bytes.NewReader(createBundle(t, tampered)), true)
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+		assert.Contains(t, err.Error(), "SHA-256 hash of file doesn't match manifest: "+lastEvent)
+	})
+	t.Run("error - file not in manifest", func(t *testing.T) {
+		cxt := createTarget(t)
+		defer cxt.close()
+		files := readBundle(t, exported)
+		extended := map[string][]byte{"events/extra.json": []byte("{}")}
+		for _, file := range files {
+			extended[file.name[len(bundle.RootDir)+1:]] = file.data
+		}
+
+		_, err := cxt.registry.Import(bytes.NewReader(createBundle(t, extended)), true)
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+		assert.Contains(t, err.Error(), "file not listed in manifest: events/extra.json")
+	})
+	t.Run("error - untrusted manifest signer", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		_, err := cxt.registry.Import(bytes.NewReader(exported), false)
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+		assert.Contains(t, err.Error(), "invalid manifest signature")
+	})
+	t.Run("error - not a tar.gz", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		_, err := cxt.registry.Import(bytes.NewReader([]byte("foo")), false)
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+	})
+}
+
+// failingEventSystem fails publishing events once the given number of events has been published.
+type failingEventSystem struct {
+	events.EventSystem
+	publishable int
+}
+
+func (f *failingEventSystem) PublishEvent(event events.Event) error {
+	if f.publishable == 0 {
+		return errors.New("disk full")
+	}
+	f.publishable--
+	return f.EventSystem.PublishEvent(event)
+}
+
+// createBundle creates a tar.gz bundle containing the given files (relative to the bundle's top-level directory).
+func createBundle(t *testing.T, files map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, data := range files {
+		if !assert.NoError(t, bundle.WriteEntry(tarWriter, name, data, time.Now())) {
+			t.FailNow()
+		}
+	}
+	_ = tarWriter.Close()
+	_ = gzipWriter.Close()
+	return buf.Bytes()
+}
@@ -103,12 +103,25 @@ func TestRegistry_verify(t *testing.T) {
 		t.Run("org has no certificates", func(t *testing.T) {
 			cxt := createTestContext(t)
 			defer cxt.close()
-			cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
-			cxt.registry.EventSystem.PublishEvent(events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{
+			// Vendor registered before it had certificates, so it could claim the organization without signing the event
+			err := cxt.registry.EventSystem.PublishEvent(events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{
+				Name:       vendorName,
+				Identifier: vendorId,
+			}, nil))
+			if !assert.NoError(t, err) {
+				return
+			}
+			if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+				return
+			}
+			err = cxt.registry.EventSystem.PublishEvent(events.CreateEvent(domain.VendorClaim, domain.VendorClaimEvent{
 				VendorID:       vendorId,
 				OrganizationID: orgId,
 				OrgName:        orgName,
 			}, nil))
+			if !assert.NoError(t, err) {
+				return
+			}
 			// Assert that the org has no keys
 			org, _ := cxt.registry.Db.OrganizationById(orgId)
 			assert.Len(t, org.Keys, 0)
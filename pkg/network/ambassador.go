@@ -57,6 +57,10 @@ func NewAmbassador(networkClient network.NetworkClient, cryptoClient crypto.Clie
 func (n *ambassador) RegisterEventHandlers(fn events.EventRegistrar, eventType []events.EventType) {
 	for _, eventType := range eventType {
 		fn(eventType, func(event events.Event, lookup events.EventLookup) error {
+			if events.IsReapplied(event) {
+				// Already sent when it was applied the first time
+				return nil
+			}
 			go n.sendEventToNetwork(event)
 			return nil
 		})
@@ -23,6 +23,7 @@ import (
 	core "github.com/nuts-foundation/nuts-go-core"
 	"github.com/nuts-foundation/nuts-go-test/io"
 	pkg2 "github.com/nuts-foundation/nuts-network/pkg"
+	"github.com/nuts-foundation/nuts-network/pkg/model"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/test"
 	"github.com/spf13/cobra"
@@ -51,6 +52,39 @@ func Test_ambassador_Send(t *testing.T) {
 	assert.Len(t, documents, 1)
 }
 
+func Test_ambassador_SendFork(t *testing.T) {
+	os.Setenv("NUTS_IDENTITY", test.VendorID("4").String())
+	core.NutsConfig().Load(&cobra.Command{})
+	eventSystem := events.NewEventSystem(eventType)
+	eventSystem.Configure(io.TestDirectory(t))
+	networkClient := &countingNetworkClient{NetworkClient: pkg2.NewTestNetworkInstance(io.TestDirectory(t))}
+	NewAmbassador(networkClient, nil, eventSystem).RegisterEventHandlers(eventSystem.RegisterEventHandler, []events.EventType{eventType})
+	root := events.CreateEvent(eventType, "root", nil)
+	time.Sleep(time.Millisecond)
+	canonical := events.CreateEvent(eventType, "canonical", root.Ref())
+	time.Sleep(time.Millisecond)
+	other := events.CreateEvent(eventType, "other", root.Ref())
+	// Applying the other branch of the fork re-applies the canonical event, which must not be sent again
+	for _, event := range []events.Event{root, canonical, other} {
+		if !assert.NoError(t, eventSystem.ProcessEvent(event)) {
+			return
+		}
+	}
+	time.Sleep(500 * time.Millisecond) // Async process, wait for events to be sent
+	assert.Equal(t, int32(3), atomic.LoadInt32(&networkClient.added))
+}
+
+// countingNetworkClient counts the documents added to the network.
+type countingNetworkClient struct {
+	pkg2.NetworkClient
+	added int32
+}
+
+func (c *countingNetworkClient) AddDocumentWithContents(timestamp time.Time, docType string, contents []byte) (*model.Document, error) {
+	atomic.AddInt32(&c.added, 1)
+	return c.NetworkClient.AddDocumentWithContents(timestamp, docType, contents)
+}
+
 func Test_ambassador_Receive(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		eventSystem, networkInstance := createInstance(t)
@@ -22,6 +22,7 @@ package pkg
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -42,18 +43,35 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/nuts-foundation/nuts-crypto/client"
 	crypto "github.com/nuts-foundation/nuts-crypto/pkg"
+	"github.com/nuts-foundation/nuts-crypto/pkg/cert"
 	core "github.com/nuts-foundation/nuts-go-core"
 	networkClient "github.com/nuts-foundation/nuts-network/client"
 	networkPkg "github.com/nuts-foundation/nuts-network/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/bundle"
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/digest"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/pkg/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 // ConfDataDir is the config name for specifiying the data location of the requiredFiles
 const ConfDataDir = "datadir"
 
+// ConfEventStore is the config name for the storage used for events: 'fs' (file per event) or 'bbolt'
+const ConfEventStore = "eventStore"
+
+// ConfDatabase is the config name for the database holding the registry's state: 'memory' or 'sqlite'
+const ConfDatabase = "database"
+
+// sqliteFileName is the name of the SQLite database file (in the data directory) when the 'sqlite' database is used
+const sqliteFileName = "registry.sqlite"
+
+// ConfSnapshotInterval is the config name for the interval in minutes at which snapshots of the registry state are created
+const ConfSnapshotInterval = "snapshotInterval"
+
 // ConfMode is the config name for the engine mode, server or client
 const ConfMode = "mode"
 
@@ -78,9 +96,15 @@ const ConfVendorCACertificateValidity = "vendorCACertificateValidity"
 // ConfClientTimeout is the time-out for the client in seconds (e.g. when using the CLI).
 const ConfClientTimeout = "clientTimeout"
 
+// ConfWebhooksFile is the config name for the file containing the webhooks which are notified of applied events
+const ConfWebhooksFile = "webhooksFile"
+
 // ModuleName == Registry
 const ModuleName = "Registry"
 
+// eventStreamBufferSize specifies how many applied events are buffered for a subscriber before the subscription is ended.
+const eventStreamBufferSize = 100
+
 // ReloadRegistryIdleTimeout defines the cooling down period after receiving a file watcher notification, before
 // the registry is reloaded (from disk).
 var ReloadRegistryIdleTimeout time.Duration
@@ -90,8 +114,13 @@ type RegistryClient interface {
 	// EndpointsByOrganization returns all registered endpoints for an organization
 	EndpointsByOrganizationAndType(organizationIdentifier core.PartyID, endpointType *string) ([]db.Endpoint, error)
 
-	// SearchOrganizations searches the registry for any Organization matching the given query
-	SearchOrganizations(query string) ([]db.Organization, error)
+	// SearchOrganizations searches the registry for any Organization matching the given query. It returns the page of
+	// the matching organizations (ordered by relevance) specified by the options, and the total number of matches.
+	SearchOrganizations(query string, options db.SearchOptions) (*db.SearchResult, error)
+
+	// SearchEndpoints finds the endpoints matching the query across all organizations. It returns the page of the
+	// matching endpoints (each with its organization) specified by the options, and the total number of matches.
+	SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) (*db.EndpointSearchResult, error)
 
 	// OrganizationById returns an Organization given the Id or an error if it doesn't exist
 	OrganizationById(id core.PartyID) (*db.Organization, error)
@@ -99,12 +128,24 @@ type RegistryClient interface {
 	// ReverseLookup finds an exact match on name or returns an error if not found
 	ReverseLookup(name string) (*db.Organization, error)
 
-	// RegisterEndpoint registers an endpoint for an organization
-	RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, properties map[string]string) (events.Event, error)
+	// RegisterEndpoint registers an endpoint for an organization. The endpoint is available from notBefore until notAfter,
+	// both being optional. When no status is given, the endpoint is registered as active.
+	RegisterEndpoint(organizationID core.PartyID, id string, url string, endpointType string, status string, notBefore *time.Time, notAfter *time.Time, properties map[string]string) (events.Event, error)
 
 	// VendorClaim registers an organization under a vendor. orgKeys are the organization's keys in JWK format
 	VendorClaim(orgID core.PartyID, orgName string, orgKeys []interface{}) (events.Event, error)
 
+	// DeregisterEndpoint deregisters the endpoint of an organization claimed by the current vendor. Deregistration is
+	// final: registering or updating the endpoint afterwards fails. When not found it returns an ErrOrganizationNotFound or ErrEndpointNotFound error.
+	DeregisterEndpoint(organizationID core.PartyID, endpointID types.EndpointID) (events.Event, error)
+
+	// EndVendorClaim ends the current vendor's claim on the organization. When not found it returns an ErrOrganizationNotFound error.
+	EndVendorClaim(organizationID core.PartyID) (events.Event, error)
+
+	// RetireVendor retires the vendor, which must be the current vendor. Retirement is final. When not found it returns
+	// an ErrVendorNotFound error.
+	RetireVendor(vendorID core.PartyID) (events.Event, error)
+
 	// RegisterVendor registers a vendor with the given id, name for the specified domain. If the vendor with this ID
 	// already exists, it functions as an update.
 	RegisterVendor(certificate *x509.Certificate) (events.Event, error)
@@ -123,6 +164,72 @@ type RegistryClient interface {
 
 	// VendorById finds a vendor by its ID. When not found it returns an ErrVendorNotFound error and a nil result.
 	VendorById(vID core.PartyID) (*db.Vendor, error)
+
+	// OrganizationByIdAsOf returns the Organization as it was registered at the given moment, which is rebuilt from the
+	// events issued at or before that moment. When it wasn't registered at that moment an error is returned.
+	OrganizationByIdAsOf(id core.PartyID, moment time.Time) (*db.Organization, error)
+
+	// VendorByIdAsOf returns the vendor as it was registered at the given moment, which is rebuilt from the events issued
+	// at or before that moment. When it wasn't registered at that moment it returns an ErrVendorNotFound error.
+	VendorByIdAsOf(vID core.PartyID, moment time.Time) (*db.Vendor, error)
+
+	// EndpointsByOrganizationAndTypeAsOf returns the endpoints of an organization as they were registered at the given
+	// moment, which are rebuilt from the events issued at or before that moment.
+	EndpointsByOrganizationAndTypeAsOf(organizationIdentifier core.PartyID, endpointType *string, moment time.Time) ([]db.Endpoint, error)
+
+	// Subscribe streams the events applied by the registry until the given context is cancelled. When from is non-zero,
+	// the events applied after the event with that ref are streamed first, so an interrupted stream can be resumed.
+	// The returned channel is closed when the stream ends, e.g. when the subscriber can't keep up with the applied events.
+	Subscribe(ctx context.Context, from events.Ref) (<-chan events.Event, error)
+
+	// VendorHistory returns the events registering (and updating) the vendor, ordered from first to last.
+	// When not found it returns an ErrVendorNotFound error.
+	VendorHistory(vendorID core.PartyID) ([]events.Event, error)
+
+	// OrganizationHistory returns the events claiming (and updating) the organization, ordered from first to last.
+	// When not found it returns an ErrOrganizationNotFound error.
+	OrganizationHistory(organizationID core.PartyID) ([]events.Event, error)
+
+	// EndpointHistory returns the events registering (and updating) the endpoint of the organization, ordered from first
+	// to last. When not found it returns an ErrEndpointNotFound error.
+	EndpointHistory(organizationID core.PartyID, endpointID types.EndpointID) ([]events.Event, error)
+
+	// ParkedEvents returns the events which couldn't be applied (yet) and are parked in the retry queue, ordered by the
+	// moment they were issued.
+	ParkedEvents() ([]events.ParkedEvent, error)
+
+	// ParkedEvent returns the parked event with the given ref. When not found it returns an events.ErrUnknownEvent error.
+	ParkedEvent(ref events.Ref) (*events.ParkedEvent, error)
+
+	// RetryParkedEvent tries to apply the parked event with the given ref, even when it has been dead-lettered. If it
+	// still can't be applied the reason is returned as error. When not found it returns an events.ErrUnknownEvent error.
+	RetryParkedEvent(ref events.Ref) error
+
+	// DiscardParkedEvent removes the parked event with the given ref from the retry queue, so it won't be retried anymore.
+	// When not found it returns an events.ErrUnknownEvent error.
+	DiscardParkedEvent(ref events.Ref) error
+
+	// Forks returns the event paths which forked, because multiple events refer to the same previous event.
+	Forks() ([]events.Fork, error)
+
+	// MergeFork heals the fork whose branches refer to the event with the given (parent) ref, by publishing an event which
+	// extends the canonical branch with the data of the last event of the branch specified by keep (the canonical
+	// branch when zero). When not found it returns an ErrForkNotFound error.
+	MergeFork(parent events.Ref, keep events.Ref) (events.Event, error)
+
+	// Export writes a tar.gz bundle of all events in chain order to the given writer, including a manifest (listing each
+	// event's ref and SHA-256 hash) which is signed with the vendor's signing certificate.
+	Export(writer io.Writer) error
+
+	// Digest calculates the Merkle-style state digest over the refs of all applied events, including a digest per vendor.
+	// Nodes holding the same events have the same digest. The refs of the events are only included when includeRefs is true.
+	Digest(includeRefs bool) (*digest.Digest, error)
+
+	// Import validates the events in the tar.gz bundle read from the given reader against a scratch copy of the registry
+	// state and reports the outcome per event. The events are only imported when apply is true. If the bundle can't be
+	// read or its manifest is invalid, an error wrapping ErrInvalidBundle is returned. Importing isn't atomic: when
+	// importing an event fails, the import stops and the report holds the error.
+	Import(reader io.Reader, apply bool) (*bundle.ImportReport, error)
 }
 
 // RegistryConfig holds the config
@@ -132,10 +239,14 @@ type RegistryConfig struct {
 	SyncAddress                     string
 	SyncInterval                    int
 	Datadir                         string
+	EventStore                      string
+	Database                        string
+	SnapshotInterval                int
 	Address                         string
 	VendorCACertificateValidity     int
 	OrganisationCertificateValidity int
 	ClientTimeout                   int
+	WebhooksFile                    string
 }
 
 func DefaultRegistryConfig() RegistryConfig {
@@ -144,6 +255,9 @@ func DefaultRegistryConfig() RegistryConfig {
 		SyncAddress:                     "https://codeload.github.com/nuts-foundation/nuts-registry-development/tar.gz/master",
 		SyncInterval:                    30,
 		Datadir:                         "./data",
+		EventStore:                      "fs",
+		Database:                        "memory",
+		SnapshotInterval:                60,
 		Address:                         "localhost:1323",
 		VendorCACertificateValidity:     1095,
 		OrganisationCertificateValidity: 365,
@@ -160,9 +274,12 @@ type Registry struct {
 	crypto            crypto.Client
 	OnChange          func(registry *Registry)
 	networkAmbassador network.Ambassador
+	webhooks          webhook.Dispatcher
 	configOnce        sync.Once
 	_logger           *logrus.Entry
 	closers           []chan struct{}
+	digestCache       digestCache
+	replayCache       replayCache
 }
 
 var instance *Registry
@@ -201,7 +318,15 @@ func (r *Registry) Configure() error {
 		cfg := core.NutsConfig()
 		r.Config.Mode = cfg.GetEngineMode(r.Config.Mode)
 		if r.Config.Mode == core.ServerEngineMode {
-			r.EventSystem = events.NewEventSystem(domain.GetEventTypes()...)
+			switch es := r.Config.EventStore; es {
+			case "fs":
+				r.EventSystem = events.NewEventSystem(domain.GetEventTypes()...)
+			case "bbolt":
+				r.EventSystem = events.NewBBoltEventSystem(domain.GetEventTypes()...)
+			default:
+				err = fmt.Errorf("invalid eventStore: %s", es)
+				return
+			}
 			if r.Config.VendorCACertificateValidity < 1 {
 				err = errors.New("vendor CA certificate validity must be at least 1 day")
 				return
@@ -211,16 +336,22 @@ func (r *Registry) Configure() error {
 				return
 			}
 			// Order of event processors:
-			// -  TrustStore; must be first since certificates might be self-signed, and thus be added to the truststore
-			//    before signature validation takes place.
-			// -  Signature validator
-			// -  Database, (in memory) queryable view of the registry
+			// -  TrustStore, signature validator, signer authorizer and database (see registerStateHandlers)
 			// -  Network Ambassador, when all other processors succeeded the event is probably valid and can be broadcast.
-			domain.NewCertificateEventHandler(r.crypto.TrustStore()).RegisterEventHandlers(r.EventSystem.RegisterEventHandler)
-			signatureValidator := events.NewSignatureValidator(r.crypto.VerifyJWS, r.crypto.TrustStore())
-			signatureValidator.RegisterEventHandlers(r.EventSystem.RegisterEventHandler, domain.GetEventTypes())
-			r.Db = db.New()
-			r.Db.RegisterEventHandlers(r.EventSystem.RegisterEventHandler)
+			// Webhooks (when configured) subscribe to the event system instead, so they're only notified of events after all
+			// processors succeeded and the event has been registered as applied.
+			if r.Db, err = r.openDb(r.Config.Datadir); err != nil {
+				return
+			}
+			r.registerStateHandlers(r.EventSystem, r.crypto.TrustStore(), r.Db)
+			if r.Config.WebhooksFile != "" {
+				var webhookConfig *webhook.Config
+				if webhookConfig, err = webhook.LoadConfig(r.Config.WebhooksFile); err != nil {
+					return
+				}
+				r.webhooks = webhook.NewDispatcher(*webhookConfig, r.Db)
+				r.EventSystem.Subscribe(r.webhooks.Notify)
+			}
 			if r.networkAmbassador == nil {
 				r.networkAmbassador = network.NewAmbassador(r.network, r.crypto, r.EventSystem)
 			}
@@ -229,6 +360,10 @@ func (r *Registry) Configure() error {
 				logging.Log().WithError(err).Warn("Unable to configure event system")
 				return
 			}
+			// Restore the latest snapshot (if any), so only events applied after the snapshot have to be replayed
+			if err := r.restoreSnapshot(); err != nil && err != ErrNoSnapshotRestored {
+				logging.Log().WithError(err).Warn("Unable to restore snapshot, all events will be replayed")
+			}
 			// Apply stored events
 			if err = r.EventSystem.LoadAndApplyEvents(); err != nil {
 				logging.Log().WithError(err).Warn("Unable to load registry files")
@@ -238,6 +373,22 @@ func (r *Registry) Configure() error {
 	return err
 }
 
+// registerStateHandlers registers the event handlers which validate the events and apply them to the database. Before
+// any handler is called the payload of the event is validated against the JSON schema of its type. Order of event processors:
+//   - Signature validator; since vendor CA certificates might be self-signed, signatures may also be verified against
+//     the CA certificates the event registers itself.
+//   - Signer authorizer, verifies the event is signed by the entity it concerns (e.g. vendor or organization)
+//   - TrustStore; certificates are only added to the truststore after the event's signer has been authorized.
+//   - Database, (in memory) queryable view of the registry
+func (r *Registry) registerStateHandlers(eventSystem events.EventSystem, trustStore cert.TrustStore, database db.Db) {
+	eventSystem.RegisterPayloadValidator(domain.ValidatePayload)
+	signatureValidator := events.NewSignatureValidator(r.crypto.VerifyJWS, trustStore).WithEventCertificates(trustStore, domain.RegisteredCACertificates)
+	signatureValidator.RegisterEventHandlers(eventSystem.RegisterEventHandler, domain.GetEventTypes())
+	domain.NewSignerAuthorizer().RegisterEventHandlers(eventSystem.RegisterEventHandler)
+	domain.NewCertificateEventHandler(trustStore).RegisterEventHandlers(eventSystem.RegisterEventHandler)
+	database.RegisterEventHandlers(eventSystem.RegisterEventHandler)
+}
+
 func (r *Registry) Verify(fix bool) ([]events.Event, bool, error) {
 	return r.verify(core.NutsConfig(), fix)
 }
@@ -248,8 +399,15 @@ func (r *Registry) EndpointsByOrganizationAndType(organizationIdentifier core.Pa
 }
 
 // SearchOrganizations is a wrapper for sam func on DB
-func (r *Registry) SearchOrganizations(query string) ([]db.Organization, error) {
-	return r.Db.SearchOrganizations(query), nil
+func (r *Registry) SearchOrganizations(query string, options db.SearchOptions) (*db.SearchResult, error) {
+	result := r.Db.SearchOrganizations(query, options)
+	return &result, nil
+}
+
+// SearchEndpoints is a wrapper for sam func on DB
+func (r *Registry) SearchEndpoints(query db.EndpointQuery, options db.SearchOptions) (*db.EndpointSearchResult, error) {
+	result := r.Db.SearchEndpoints(query, options)
+	return &result, nil
 }
 
 // OrganizationById is a wrapper for sam func on DB
@@ -294,6 +452,12 @@ func (r *Registry) Start() error {
 			logging.Log().Error("Error occurred during registry data verification: ", err)
 		}
 		r.networkAmbassador.Start()
+		if r.webhooks != nil {
+			r.webhooks.Start()
+		}
+		if r.Config.SnapshotInterval > 0 {
+			r.startSnapshotRoutine()
+		}
 		switch cm := r.Config.SyncMode; cm {
 		case "fs":
 			return r.startFileSystemWatcher()
@@ -314,10 +478,43 @@ func (r *Registry) Shutdown() error {
 			ch <- struct{}{}
 		}
 		logging.Log().Info("All routines closed")
+		if r.webhooks != nil {
+			r.webhooks.Stop()
+		}
+		var err error
+		if r.EventSystem != nil {
+			err = r.EventSystem.Close()
+		}
+		// Close the database after the event system, so no events are being applied
+		if closer, ok := r.Db.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
 	}
 	return nil
 }
 
+// openDb opens the database configured by Config.Database. Persistent databases (SQLite) are stored in the given directory.
+func (r *Registry) openDb(dir string) (db.Db, error) {
+	switch database := r.Config.Database; database {
+	case "memory":
+		return db.New(), nil
+	case "sqlite":
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, err
+		}
+		sqliteDb, err := db.NewSQLiteDb(filepath.Join(dir, sqliteFileName))
+		if err != nil {
+			return nil, err
+		}
+		return sqliteDb, nil
+	default:
+		return nil, fmt.Errorf("invalid database: %s", database)
+	}
+}
+
 // Load signals the Db to (re)load sources. On success the OnChange func is called
 func (r *Registry) Load() error {
 	if err := r.EventSystem.LoadAndApplyEvents(); err != nil {
@@ -331,8 +528,74 @@ func (r *Registry) Load() error {
 	return nil
 }
 
+func (r *Registry) Subscribe(ctx context.Context, from events.Ref) (<-chan events.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	applied := make(chan events.Event, eventStreamBufferSize)
+	// Subscribe before retrieving the already applied events, so no events are missed in between
+	unsubscribe := r.EventSystem.Subscribe(func(event events.Event) {
+		select {
+		case applied <- event:
+		default:
+			logging.Log().Warn("Event stream subscriber can't keep up with applied events, ending subscription.")
+			cancel()
+		}
+	})
+	history, err := r.EventSystem.EventsAfter(from)
+	if err != nil {
+		unsubscribe()
+		cancel()
+		return nil, err
+	}
+	result := make(chan events.Event)
+	go func() {
+		defer close(result)
+		defer cancel()
+		defer unsubscribe()
+		sent := make(map[string]bool, len(history))
+		for _, event := range history {
+			select {
+			case result <- event:
+				sent[event.Ref().String()] = true
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case event := <-applied:
+				if sent[event.Ref().String()] {
+					// Applied while retrieving the history, already sent
+					continue
+				}
+				select {
+				case result <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
 func (r *Registry) Diagnostics() []core.DiagnosticResult {
-	return r.EventSystem.Diagnostics()
+	results := r.EventSystem.Diagnostics()
+	if stateDigest, err := r.Digest(false); err == nil {
+		results = append(results, &core.GenericDiagnosticResult{
+			Title:   "State digest",
+			Outcome: fmt.Sprintf("%s (events = %d, vendors = %d)", stateDigest.Root, stateDigest.EventCount, len(stateDigest.Vendors)),
+		})
+	}
+	results = append(results, &core.GenericDiagnosticResult{
+		Title:   "Last snapshot",
+		Outcome: r.lastSnapshot(),
+	})
+	if r.webhooks != nil {
+		results = append(results, r.webhooks.Diagnostics()...)
+	}
+	return results
 }
 
 func (r *Registry) getEventsDir() string {
@@ -0,0 +1,136 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/types"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegistry_ConcurrentEventProcessing drives the paths through which events enter the registry (REST API, Nuts
+// Network and reloading the events directory) concurrently, while the database is being queried. Run with -race.
+func TestRegistry_ConcurrentEventProcessing(t *testing.T) {
+	const eventsPerPath = 20
+	cxt := createTestContext(t)
+	defer cxt.close()
+	orgID := test.OrganizationID("org")
+	if _, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate()); !assert.NoError(t, err) {
+		return
+	}
+	if _, err := cxt.registry.VendorClaim(orgID, "org", nil); !assert.NoError(t, err) {
+		return
+	}
+	endpointEvent := func(id string) events.Event {
+		event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{
+			Organization: orgID,
+			Identifier:   types.EndpointID(id),
+			URL:          "url",
+			EndpointType: "type",
+			Status:       db.StatusActive,
+		}, nil)
+		// The organization's claim is signed, so events concerning it must be signed as well
+		err := event.Sign(func(data []byte) ([]byte, error) {
+			return cxt.registry.signAsOrganization(orgID, "org", data, event.IssuedAt(), true)
+		})
+		assert.NoError(t, err)
+		return event
+	}
+
+	fileEventsIssuedAt := time.Now().Add(-time.Hour)
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	// REST API
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			_, err := cxt.registry.RegisterEndpoint(orgID, fmt.Sprintf("rest-%d", i), "url", "type", db.StatusActive, nil, nil, nil)
+			assert.NoError(t, err)
+		}
+	}()
+	// Nuts Network (the ambassador processes received events)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			err := cxt.registry.EventSystem.ProcessEvent(endpointEvent(fmt.Sprintf("network-%d", i)))
+			assert.NoError(t, err)
+		}
+	}()
+	// Reloading the events directory (e.g. triggered by the file system watcher)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < eventsPerPath; i++ {
+			// Events are stored in files named after the moment they were issued, so make sure they don't collide with the
+			// files written by the REST API path.
+			event, _ := events.EventFromJSONWithIssuedAt(endpointEvent(fmt.Sprintf("file-%d", i)).Marshal(), fileEventsIssuedAt.Add(time.Duration(i)*time.Millisecond))
+			file := filepath.Join(cxt.registry.getEventsDir(), events.SuggestEventFileName(event))
+			// Write the file atomically, so it isn't read while being written
+			if !assert.NoError(t, ioutil.WriteFile(file+".tmp", event.Marshal(), 0600)) {
+				return
+			}
+			if !assert.NoError(t, os.Rename(file+".tmp", file)) {
+				return
+			}
+			assert.NoError(t, cxt.registry.Load())
+		}
+	}()
+	// Query while events are being applied
+	done := make(chan struct{})
+	readersDone := make(chan struct{})
+	go func() {
+		defer close(readersDone)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_, _ = cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+				_, _ = cxt.registry.SearchOrganizations("org", db.SearchOptions{})
+				_, _ = cxt.registry.EndpointHistory(orgID, "rest-0")
+				_, _ = cxt.registry.ParkedEvents()
+				_ = cxt.registry.Diagnostics()
+				// Yield, so the readers don't starve the writers on machines with few cores
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	<-readersDone
+
+	endpoints, err := cxt.registry.EndpointsByOrganizationAndType(orgID, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, endpoints, 3*eventsPerPath)
+	parkedEvents, _ := cxt.registry.ParkedEvents()
+	assert.Empty(t, parkedEvents)
+}
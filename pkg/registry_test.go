@@ -22,6 +22,7 @@
 package pkg
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -49,6 +50,7 @@ import (
 	"github.com/nuts-foundation/nuts-registry/pkg/db"
 	"github.com/nuts-foundation/nuts-registry/pkg/events"
 	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/pkg/webhook"
 	"github.com/nuts-foundation/nuts-registry/test"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -93,7 +95,7 @@ func TestRegistry_Start(t *testing.T) {
 		if !assert.NoError(t, err) {
 			return
 		}
-		assert.Len(t, registry.closers, 1)
+		assert.Len(t, registry.closers, 2)
 	})
 
 	t.Run("Invalid datadir gives error on Start", func(t *testing.T) {
@@ -135,10 +137,41 @@ func TestRegistry_Configure(t *testing.T) {
 		if err := registry.Configure(); err != nil {
 			t.Errorf("Expected no error, got [%v]", err)
 		}
-		if len(registry.Db.SearchOrganizations("")) == 0 {
+		if registry.Db.SearchOrganizations("", db.SearchOptions{}).Total == 0 {
 			t.Error("Expected loaded organizations, got 0")
 		}
 	})
+	t.Run("ok - bbolt event store", func(t *testing.T) {
+		registry := create(t)
+		repo, err := test.NewTestRepoFrom(t, "../test_data/valid_files")
+		if !assert.NoError(t, err) {
+			return
+		}
+		registry.Config.Datadir = repo.Directory
+		registry.Config.EventStore = "bbolt"
+		if !assert.NoError(t, registry.Configure()) {
+			return
+		}
+		defer registry.EventSystem.Close()
+		assert.NotEmpty(t, registry.Db.SearchOrganizations("", db.SearchOptions{}).Organizations)
+		assert.FileExists(t, filepath.Join(repo.Directory, "events", "events.db"))
+	})
+	t.Run("ok - SQLite database", func(t *testing.T) {
+		registry := create(t)
+		repo, err := test.NewTestRepoFrom(t, "../test_data/valid_files")
+		if !assert.NoError(t, err) {
+			return
+		}
+		registry.Config.Datadir = repo.Directory
+		registry.Config.Database = "sqlite"
+		if !assert.NoError(t, registry.Configure()) {
+			return
+		}
+		defer registry.Shutdown()
+		assert.IsType(t, &db.SQLiteDb{}, registry.Db)
+		assert.NotEmpty(t, registry.Db.SearchOrganizations("", db.SearchOptions{}).Organizations)
+		assert.FileExists(t, filepath.Join(repo.Directory, "registry.sqlite"))
+	})
 	t.Run("ok - client mode", func(t *testing.T) {
 		os.Setenv("NUTS_MODE", "cli")
 		defer os.Unsetenv("NUTS_MODE")
@@ -168,6 +201,18 @@ func TestRegistry_Configure(t *testing.T) {
 		err = registry.Configure()
 		assert.Error(t, err)
 	})
+	t.Run("error - invalid event store", func(t *testing.T) {
+		registry := create(t)
+		registry.Config.EventStore = "foo"
+		err := registry.Configure()
+		assert.EqualError(t, err, "invalid eventStore: foo")
+	})
+	t.Run("error - invalid database", func(t *testing.T) {
+		registry := create(t)
+		registry.Config.Database = "foo"
+		err := registry.Configure()
+		assert.EqualError(t, err, "invalid database: foo")
+	})
 	t.Run("error - vendor CA certificate validity invalid", func(t *testing.T) {
 		registry := create(t)
 		registry.Config.VendorCACertificateValidity = 0
@@ -180,6 +225,45 @@ func TestRegistry_Configure(t *testing.T) {
 		err := registry.Configure()
 		assert.EqualError(t, err, "organisation certificate validity must be at least 1 day")
 	})
+	t.Run("ok - webhooks", func(t *testing.T) {
+		registry := create(t)
+		received := make(chan string, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			received <- request.Header.Get(webhook.EventTypeHeader)
+		}))
+		defer server.Close()
+		registry.Config.WebhooksFile = filepath.Join(io.TestDirectory(t), "webhooks.yaml")
+		_ = ioutil.WriteFile(registry.Config.WebhooksFile, []byte(fmt.Sprintf("webhooks:\n  - url: %s\n    secret: secret\n", server.URL)), os.ModePerm)
+		if !assert.NoError(t, registry.Configure()) {
+			return
+		}
+		defer registry.EventSystem.Close()
+		registry.webhooks.Start()
+		defer registry.webhooks.Stop()
+		err := registry.EventSystem.ProcessEvent(events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: test.VendorID("webhook"), Name: "Vendor"}, nil))
+		if !assert.NoError(t, err) {
+			return
+		}
+		select {
+		case eventType := <-received:
+			assert.Equal(t, string(domain.RegisterVendor), eventType)
+		case <-time.After(5 * time.Second):
+			t.Error("webhook wasn't notified")
+		}
+		// Stopping waits for the delivery to be completed
+		registry.webhooks.Stop()
+		var outcomes []string
+		for _, diagnostic := range registry.Diagnostics() {
+			outcomes = append(outcomes, diagnostic.String())
+		}
+		assert.Contains(t, outcomes, "delivered=1, pending=0, failed=0")
+	})
+	t.Run("error - invalid webhooks file", func(t *testing.T) {
+		registry := create(t)
+		registry.Config.WebhooksFile = "non-existing.yaml"
+		err := registry.Configure()
+		assert.Contains(t, err.Error(), "unable to read webhooks file")
+	})
 }
 
 func TestRegistry_FileUpdate(t *testing.T) {
@@ -204,7 +288,7 @@ func TestRegistry_FileUpdate(t *testing.T) {
 			t.Errorf("Expected no error, got [%v]", err)
 		}
 
-		if len(cxt.registry.Db.SearchOrganizations("")) != 0 {
+		if cxt.registry.Db.SearchOrganizations("", db.SearchOptions{}).Total != 0 {
 			t.Error("Expected empty db")
 		}
 
@@ -293,8 +377,29 @@ func TestRegistry_SearchOrganizations(t *testing.T) {
 	defer mockCtrl.Finish()
 	t.Run("ok", func(t *testing.T) {
 		mockDb := mock.NewMockDb(mockCtrl)
-		mockDb.EXPECT().SearchOrganizations("query")
-		(&Registry{Db: mockDb}).SearchOrganizations("query")
+		options := db.SearchOptions{Offset: 1, Limit: 2}
+		mockDb.EXPECT().SearchOrganizations("query", options).Return(db.SearchResult{Total: 3})
+		result, err := (&Registry{Db: mockDb}).SearchOrganizations("query", options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 3, result.Total)
+	})
+}
+
+func TestRegistry_SearchEndpoints(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	t.Run("ok", func(t *testing.T) {
+		mockDb := mock.NewMockDb(mockCtrl)
+		query := db.EndpointQuery{EndpointType: "fhir", Properties: map[string]string{"version": "r4"}}
+		options := db.SearchOptions{Offset: 1, Limit: 2}
+		mockDb.EXPECT().SearchEndpoints(query, options).Return(db.EndpointSearchResult{Total: 3})
+		result, err := (&Registry{Db: mockDb}).SearchEndpoints(query, options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 3, result.Total)
 	})
 }
 
@@ -446,3 +551,59 @@ func configureIdentity() {
 func configureIdleTimeout() {
 	ReloadRegistryIdleTimeout = 100 * time.Millisecond
 }
+
+func TestRegistry_Subscribe(t *testing.T) {
+	receive := func(t *testing.T, stream <-chan events.Event) events.Event {
+		select {
+		case event := <-stream:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout while waiting for event")
+		}
+		return nil
+	}
+	t.Run("ok - applied and new events are streamed", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		vendorEvent, err := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
+		if !assert.NoError(t, err) {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := cxt.registry.Subscribe(ctx, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, vendorEvent.Ref(), receive(t, stream).Ref())
+		claimEvent, err := cxt.registry.VendorClaim(test.OrganizationID("org"), "org", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, claimEvent.Ref(), receive(t, stream).Ref())
+		cancel()
+		// Stream should be closed after cancelling
+		for range stream {
+		}
+	})
+	t.Run("ok - resume after ref", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		vendorEvent, _ := cxt.registry.RegisterVendor(cxt.issueVendorCACertificate())
+		claimEvent, _ := cxt.registry.VendorClaim(test.OrganizationID("org"), "org", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := cxt.registry.Subscribe(ctx, vendorEvent.Ref())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, claimEvent.Ref(), receive(t, stream).Ref())
+	})
+	t.Run("error - unknown ref", func(t *testing.T) {
+		cxt := createTestContext(t)
+		defer cxt.close()
+		stream, err := cxt.registry.Subscribe(context.Background(), events.Ref{1, 2, 3})
+		assert.Equal(t, events.ErrUnknownEvent, err)
+		assert.Nil(t, stream)
+	})
+}
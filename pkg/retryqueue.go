@@ -0,0 +1,46 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+)
+
+// ParkedEvents returns the events which couldn't be applied (yet) and are parked in the retry queue.
+func (r *Registry) ParkedEvents() ([]events.ParkedEvent, error) {
+	return r.EventSystem.ParkedEvents(), nil
+}
+
+// ParkedEvent returns the parked event with the given ref. When not found it returns an events.ErrUnknownEvent error.
+func (r *Registry) ParkedEvent(ref events.Ref) (*events.ParkedEvent, error) {
+	return r.EventSystem.ParkedEvent(ref)
+}
+
+// RetryParkedEvent tries to apply the parked event with the given ref, even when it has been dead-lettered.
+func (r *Registry) RetryParkedEvent(ref events.Ref) error {
+	logging.Log().Infof("Retrying parked event %s on request", ref)
+	return r.EventSystem.RetryParkedEvent(ref)
+}
+
+// DiscardParkedEvent removes the parked event with the given ref from the retry queue.
+func (r *Registry) DiscardParkedEvent(ref events.Ref) error {
+	return r.EventSystem.DiscardParkedEvent(ref)
+}
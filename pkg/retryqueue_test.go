@@ -0,0 +1,52 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_RetryQueue(t *testing.T) {
+	cxt := createTestContext(t)
+	defer cxt.close()
+	// Endpoint of an organization which hasn't been claimed yet, so it can't be applied
+	event := events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: test.OrganizationID("unknown")}, nil)
+	assert.Error(t, cxt.registry.EventSystem.ProcessEvent(event))
+
+	parkedEvents, err := cxt.registry.ParkedEvents()
+	if !assert.NoError(t, err) || !assert.Len(t, parkedEvents, 1) {
+		return
+	}
+	assert.Equal(t, event.Ref(), parkedEvents[0].Event.Ref())
+	parked, err := cxt.registry.ParkedEvent(event.Ref())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, parked.Attempts)
+	assert.Error(t, cxt.registry.RetryParkedEvent(event.Ref()))
+	assert.NoError(t, cxt.registry.DiscardParkedEvent(event.Ref()))
+	_, err = cxt.registry.ParkedEvent(event.Ref())
+	assert.Equal(t, events.ErrUnknownEvent, err)
+}
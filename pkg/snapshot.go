@@ -0,0 +1,286 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuts-foundation/nuts-crypto/pkg/types"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	errors2 "github.com/pkg/errors"
+)
+
+// snapshotKeyQualifier is the qualifier of the vendor key which is used to sign snapshots.
+const snapshotKeyQualifier = "snapshot"
+
+// snapshotFileSuffix is the suffix of snapshot file names, which are prefixed with the creation timestamp.
+const snapshotFileSuffix = "-snapshot.json"
+
+// snapshotTimestampLayout is the layout of the timestamp in snapshot file names, so they can be sorted lexicographically.
+const snapshotTimestampLayout = "20060102150405.000"
+
+// snapshotsToKeep specifies how many snapshots are kept, older snapshots are removed when a new snapshot is created.
+const snapshotsToKeep = 3
+
+// ErrNoSnapshotRestored is returned when there's no (valid) snapshot to restore the registry from.
+var ErrNoSnapshotRestored = errors.New("no snapshot restored")
+
+// signedSnapshot is the structure of a snapshot file: the snapshot and the signature over it.
+type signedSnapshot struct {
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Signature []byte          `json:"signature"`
+}
+
+// snapshot is the state of the registry at the moment the event identified by LastEvent was applied.
+type snapshot struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	LastEvent events.Ref      `json:"lastEvent"`
+	Events    json.RawMessage `json:"events"`
+	Db        json.RawMessage `json:"db"`
+}
+
+// createSnapshot captures the state of the registry (database and applied events), signs it using the vendor's
+// snapshot key and writes it to the snapshots directory. No snapshot is created when no events have been applied or
+// when the last applied event equals the given (previously snapshotted) event. It returns the path of the snapshot
+// file (empty when no snapshot was created) and the ref of the last applied event.
+func (r *Registry) createSnapshot(previous events.Ref) (string, events.Ref, error) {
+	key, err := r.snapshotKey()
+	if err != nil {
+		return "", nil, err
+	}
+	var eventsData, dbData []byte
+	var lastEvent events.Ref
+	// The event system and database must be captured without events being applied in between, so they're consistent
+	err = r.EventSystem.Exclusive(func() error {
+		var err error
+		if eventsData, lastEvent, err = r.EventSystem.Snapshot(); err != nil {
+			return errors2.Wrap(err, "unable to snapshot event system")
+		}
+		if lastEvent.IsZero() || lastEvent.Equal(previous) {
+			return nil
+		}
+		if dbData, err = r.Db.Snapshot(); err != nil {
+			return errors2.Wrap(err, "unable to snapshot database")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if lastEvent.IsZero() || lastEvent.Equal(previous) {
+		return "", lastEvent, nil
+	}
+	if !r.crypto.PrivateKeyExists(key) {
+		logging.Log().Info("No snapshot key found, will generate a new key pair.")
+		if _, err := r.crypto.GenerateKeyPair(key, false); err != nil {
+			return "", nil, errors2.Wrap(err, "unable to generate snapshot key")
+		}
+	}
+	s := snapshot{CreatedAt: time.Now(), LastEvent: lastEvent, Events: eventsData, Db: dbData}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", nil, err
+	}
+	signature, err := r.crypto.Sign(data, key)
+	if err != nil {
+		return "", nil, errors2.Wrap(err, "unable to sign snapshot")
+	}
+	fileData, err := json.Marshal(signedSnapshot{Snapshot: data, Signature: signature})
+	if err != nil {
+		return "", nil, err
+	}
+	dir := r.getSnapshotsDir()
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", nil, err
+	}
+	fileName := strings.Replace(s.CreatedAt.UTC().Format(snapshotTimestampLayout), ".", "", 1) + snapshotFileSuffix
+	target := filepath.Join(dir, fileName)
+	// Write to a temporary file first, so an interrupted write doesn't leave a partial snapshot behind
+	tmpFile := target + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, fileData, 0600); err != nil {
+		return "", nil, errors2.Wrap(err, "unable to write snapshot")
+	}
+	if err := os.Rename(tmpFile, target); err != nil {
+		return "", nil, errors2.Wrap(err, "unable to write snapshot")
+	}
+	logging.Log().Infof("Snapshot created (file = %s, last event = %s)", target, lastEvent)
+	r.pruneSnapshots()
+	return target, lastEvent, nil
+}
+
+// restoreSnapshot restores the registry state from the newest valid snapshot. Snapshots that can't be restored
+// (e.g. because they're corrupt or have an invalid signature) are skipped. If no snapshot could be restored,
+// ErrNoSnapshotRestored is returned and the registry state is left untouched.
+func (r *Registry) restoreSnapshot() error {
+	files, err := r.listSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return ErrNoSnapshotRestored
+	}
+	pristine, err := r.Db.Snapshot()
+	if err != nil {
+		return err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		s, err := r.readSnapshot(files[i])
+		if err == nil {
+			err = r.applySnapshot(*s, pristine)
+		}
+		if err != nil {
+			logging.Log().WithError(err).Warnf("Unable to restore snapshot, skipping it (file = %s)", files[i])
+			continue
+		}
+		logging.Log().Infof("Restored snapshot (file = %s, last event = %s)", files[i], s.LastEvent)
+		return nil
+	}
+	return ErrNoSnapshotRestored
+}
+
+func (r *Registry) applySnapshot(s snapshot, pristine []byte) error {
+	return r.EventSystem.Exclusive(func() error {
+		if err := r.Db.Restore(s.Db); err != nil {
+			return errors2.Wrap(err, "unable to restore database")
+		}
+		if err := r.EventSystem.Restore(s.Events); err != nil {
+			// Database has been altered, so restore it to its original state
+			if restoreErr := r.Db.Restore(pristine); restoreErr != nil {
+				logging.Log().WithError(restoreErr).Error("Unable to reset database after failed snapshot restore")
+			}
+			return errors2.Wrap(err, "unable to restore event system")
+		}
+		return nil
+	})
+}
+
+// readSnapshot reads the snapshot from the given file and verifies its signature.
+func (r *Registry) readSnapshot(file string) (*snapshot, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	signed := signedSnapshot{}
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, errors2.Wrap(err, "unable to parse snapshot")
+	}
+	key, err := r.snapshotKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := r.crypto.GetPublicKeyAsJWK(key)
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to load snapshot key")
+	}
+	if valid, err := r.crypto.VerifyWith(signed.Snapshot, signed.Signature, publicKey); err != nil {
+		return nil, errors2.Wrap(err, "unable to verify snapshot signature")
+	} else if !valid {
+		return nil, errors.New("invalid snapshot signature")
+	}
+	s := snapshot{}
+	if err := json.Unmarshal(signed.Snapshot, &s); err != nil {
+		return nil, errors2.Wrap(err, "unable to parse snapshot")
+	}
+	return &s, nil
+}
+
+// listSnapshots returns the snapshot files in the snapshots directory, ordered from oldest to newest.
+func (r *Registry) listSnapshots() ([]string, error) {
+	entries, err := ioutil.ReadDir(r.getSnapshotsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), snapshotFileSuffix) {
+			files = append(files, filepath.Join(r.getSnapshotsDir(), entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// pruneSnapshots removes all but the newest snapshots.
+func (r *Registry) pruneSnapshots() {
+	files, err := r.listSnapshots()
+	if err != nil {
+		logging.Log().WithError(err).Warn("Unable to list snapshots for pruning")
+		return
+	}
+	for i := 0; i < len(files)-snapshotsToKeep; i++ {
+		if err := os.Remove(files[i]); err != nil {
+			logging.Log().WithError(err).Warnf("Unable to remove old snapshot: %s", files[i])
+		}
+	}
+}
+
+func (r *Registry) lastSnapshot() string {
+	files, _ := r.listSnapshots()
+	if len(files) == 0 {
+		return "none"
+	}
+	return filepath.Base(files[len(files)-1])
+}
+
+func (r *Registry) snapshotKey() (types.KeyIdentifier, error) {
+	vendorID := core.NutsConfig().VendorID()
+	if vendorID.IsZero() {
+		return nil, errors.New("vendor ID not configured, it is required for signing snapshots")
+	}
+	return types.KeyForEntity(types.LegalEntity{URI: vendorID.String()}).WithQualifier(snapshotKeyQualifier), nil
+}
+
+func (r *Registry) getSnapshotsDir() string {
+	return r.Config.Datadir + "/snapshots"
+}
+
+// startSnapshotRoutine periodically creates a snapshot of the registry state, but only when events have been applied
+// since the last snapshot.
+func (r *Registry) startSnapshotRoutine() {
+	closer := make(chan struct{})
+	go func() {
+		var snapshotted events.Ref
+		for {
+			select {
+			case <-closer:
+				logging.Log().Debug("Stopping snapshot routine")
+				return
+			case <-time.After(time.Duration(r.Config.SnapshotInterval) * time.Minute):
+				if _, lastEvent, err := r.createSnapshot(snapshotted); err != nil {
+					logging.Log().WithError(err).Error("Unable to create snapshot")
+				} else {
+					snapshotted = lastEvent
+				}
+			}
+		}
+	}()
+	r.closers = append(r.closers, closer)
+}
@@ -0,0 +1,146 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-crypto/pkg"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-go-test/io"
+	pkg2 "github.com/nuts-foundation/nuts-network/pkg"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Snapshots(t *testing.T) {
+	os.Setenv("NUTS_IDENTITY", vendorId.String())
+	core.NutsConfig().Load(&cobra.Command{})
+	// create configures a registry on the given directory, which holds both the registry data and the crypto storage
+	create := func(t *testing.T, dir string) *Registry {
+		registry := &Registry{
+			Config:  DefaultRegistryConfig(),
+			crypto:  pkg.NewTestCryptoInstance(dir),
+			network: pkg2.NewTestNetworkInstance(dir),
+		}
+		registry.Config.Datadir = dir
+		if !assert.NoError(t, registry.Configure()) {
+			t.FailNow()
+		}
+		return registry
+	}
+	removeEventFiles := func(dir string) {
+		entries, _ := ioutil.ReadDir(filepath.Join(dir, "events"))
+		for _, entry := range entries {
+			_ = os.Remove(filepath.Join(dir, "events", entry.Name()))
+		}
+	}
+	newRepo := func(t *testing.T) *test.TestRepo {
+		repo, err := test.NewTestRepoFrom(t, "../test_data/valid_files")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	t.Run("ok - state is restored from snapshot", func(t *testing.T) {
+		repo := newRepo(t)
+		registry := create(t, repo.Directory)
+		file, lastEvent, err := registry.createSnapshot(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.FileExists(t, file)
+		assert.Equal(t, "991d2f0fa517d27ba2701d3aa1ff34301fd1a569", lastEvent.String())
+		// Without event files the state can only be restored from the snapshot
+		removeEventFiles(repo.Directory)
+		registry = create(t, repo.Directory)
+		assert.Equal(t, 2, registry.Db.SearchOrganizations("", db.SearchOptions{}).Total)
+		assert.NotNil(t, registry.EventSystem.Get(lastEvent))
+		assert.Equal(t, filepath.Base(file), registry.Diagnostics()[len(registry.Diagnostics())-1].String())
+	})
+	t.Run("ok - no snapshot when nothing changed", func(t *testing.T) {
+		repo := newRepo(t)
+		registry := create(t, repo.Directory)
+		_, lastEvent, _ := registry.createSnapshot(nil)
+		file, _, err := registry.createSnapshot(lastEvent)
+		assert.NoError(t, err)
+		assert.Empty(t, file)
+	})
+	t.Run("ok - no snapshot when there are no events", func(t *testing.T) {
+		registry := create(t, io.TestDirectory(t))
+		file, lastEvent, err := registry.createSnapshot(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, file)
+		assert.True(t, lastEvent.IsZero())
+		assert.Equal(t, "none", registry.lastSnapshot())
+	})
+	t.Run("ok - corrupt snapshot is skipped", func(t *testing.T) {
+		repo := newRepo(t)
+		registry := create(t, repo.Directory)
+		if _, _, err := registry.createSnapshot(nil); !assert.NoError(t, err) {
+			return
+		}
+		removeEventFiles(repo.Directory)
+		_ = ioutil.WriteFile(filepath.Join(registry.getSnapshotsDir(), "99990101000000000"+snapshotFileSuffix), []byte("{"), os.ModePerm)
+		registry = create(t, repo.Directory)
+		assert.Equal(t, 2, registry.Db.SearchOrganizations("", db.SearchOptions{}).Total)
+	})
+	t.Run("ok - tampered snapshot falls back to full replay", func(t *testing.T) {
+		repo := newRepo(t)
+		registry := create(t, repo.Directory)
+		file, _, err := registry.createSnapshot(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		data, _ := ioutil.ReadFile(file)
+		signed := signedSnapshot{}
+		_ = json.Unmarshal(data, &signed)
+		signed.Signature[0] ^= 0xFF
+		data, _ = json.Marshal(signed)
+		_ = ioutil.WriteFile(file, data, os.ModePerm)
+
+		registry = create(t, repo.Directory)
+		assert.Equal(t, 2, registry.Db.SearchOrganizations("", db.SearchOptions{}).Total)
+		assert.Equal(t, ErrNoSnapshotRestored, registry.restoreSnapshot())
+	})
+	t.Run("ok - old snapshots are pruned", func(t *testing.T) {
+		repo := newRepo(t)
+		registry := create(t, repo.Directory)
+		_ = os.MkdirAll(registry.getSnapshotsDir(), os.ModePerm)
+		for _, name := range []string{"20200101000000001", "20200101000000002", "20200101000000003"} {
+			_ = ioutil.WriteFile(filepath.Join(registry.getSnapshotsDir(), name+snapshotFileSuffix), []byte("{}"), os.ModePerm)
+		}
+		file, _, err := registry.createSnapshot(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		files, _ := registry.listSnapshots()
+		assert.Len(t, files, snapshotsToKeep)
+		assert.Equal(t, file, files[len(files)-1])
+		assert.NotContains(t, files, filepath.Join(registry.getSnapshotsDir(), "20200101000000001"+snapshotFileSuffix))
+	})
+}
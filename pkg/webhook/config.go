@@ -0,0 +1,106 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	core "github.com/nuts-foundation/nuts-go-core"
+	errors2 "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultMaxAttempts = 5
+const defaultInitialBackoff = time.Second
+const defaultTimeout = 10 * time.Second
+
+// Config holds the configuration of the webhooks, as read from the webhooks file.
+type Config struct {
+	// MaxAttempts specifies how many times delivering a notification is tried before it's considered failed.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialBackoff specifies how long to wait before the first retry, it's doubled for every next retry.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	// Timeout specifies the time-out of a single delivery attempt.
+	Timeout  time.Duration `yaml:"timeout"`
+	Webhooks []Webhook     `yaml:"webhooks"`
+}
+
+// Webhook specifies an URL to which notifications are delivered and which events it's notified of. When multiple filters
+// are specified an event must match all of them, when a filter has multiple values the event must match one of them.
+type Webhook struct {
+	URL string `yaml:"url"`
+	// Secret is the key used to sign the notifications using HMAC-SHA256.
+	Secret string `yaml:"secret"`
+	// EventTypes filters the events by type.
+	EventTypes []string `yaml:"eventTypes"`
+	// Vendors filters the events by vendor, for organization and endpoint events that's the vendor of the organization.
+	Vendors []string `yaml:"vendors"`
+	// Organizations filters the events by organization, vendor events don't match when specified.
+	Organizations []string `yaml:"organizations"`
+}
+
+// LoadConfig reads the webhook configuration (YAML or JSON) from the given file, applying defaults where needed.
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors2.Wrap(err, "unable to read webhooks file")
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, errors2.Wrap(err, "unable to parse webhooks file")
+	}
+	if err := config.validate(); err != nil {
+		return nil, errors2.Wrap(err, "invalid webhooks file")
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	if c.InitialBackoff == 0 {
+		c.InitialBackoff = defaultInitialBackoff
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxAttempts < 0 || c.InitialBackoff < 0 || c.Timeout < 0 {
+		return errors.New("maxAttempts, initialBackoff and timeout can't be negative")
+	}
+	for i, webhook := range c.Webhooks {
+		if parsed, err := url.Parse(webhook.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("webhook %d: invalid URL: %s", i, webhook.URL)
+		}
+		if webhook.Secret == "" {
+			return fmt.Errorf("webhook %d: secret is required", i)
+		}
+		for _, id := range append(append([]string{}, webhook.Vendors...), webhook.Organizations...) {
+			if _, err := core.ParsePartyID(id); err != nil {
+				return fmt.Errorf("webhook %d: invalid vendor or organization ID: %s", i, id)
+			}
+		}
+	}
+	return nil
+}
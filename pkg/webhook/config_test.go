@@ -0,0 +1,101 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	write := func(t *testing.T, contents string) string {
+		file := filepath.Join(io.TestDirectory(t), "webhooks.yaml")
+		if err := ioutil.WriteFile(file, []byte(contents), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	t.Run("ok", func(t *testing.T) {
+		config, err := LoadConfig(write(t, `
+maxAttempts: 3
+initialBackoff: 100ms
+timeout: 2s
+webhooks:
+  - url: https://addressbook.local/registry
+    secret: secret
+    eventTypes: [RegisterEndpointEvent]
+    vendors: ["urn:oid:1.3.6.1.4.1.54851.4:1"]
+    organizations: ["urn:oid:2.16.840.1.113883.2.4.6.1:1"]
+`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 3, config.MaxAttempts)
+		assert.Equal(t, 100*time.Millisecond, config.InitialBackoff)
+		assert.Equal(t, 2*time.Second, config.Timeout)
+		if !assert.Len(t, config.Webhooks, 1) {
+			return
+		}
+		assert.Equal(t, "https://addressbook.local/registry", config.Webhooks[0].URL)
+		assert.Equal(t, "secret", config.Webhooks[0].Secret)
+		assert.Equal(t, []string{"RegisterEndpointEvent"}, config.Webhooks[0].EventTypes)
+		assert.Len(t, config.Webhooks[0].Vendors, 1)
+		assert.Len(t, config.Webhooks[0].Organizations, 1)
+	})
+	t.Run("ok - defaults", func(t *testing.T) {
+		config, err := LoadConfig(write(t, `{"webhooks": [{"url": "http://localhost", "secret": "secret"}]}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, defaultMaxAttempts, config.MaxAttempts)
+		assert.Equal(t, defaultInitialBackoff, config.InitialBackoff)
+		assert.Equal(t, defaultTimeout, config.Timeout)
+	})
+	t.Run("error - file doesn't exist", func(t *testing.T) {
+		_, err := LoadConfig("non-existing.yaml")
+		assert.Contains(t, err.Error(), "unable to read webhooks file")
+	})
+	t.Run("error - unknown field", func(t *testing.T) {
+		_, err := LoadConfig(write(t, "hooks: []"))
+		assert.Contains(t, err.Error(), "unable to parse webhooks file")
+	})
+	t.Run("error - invalid URL", func(t *testing.T) {
+		_, err := LoadConfig(write(t, "webhooks:\n  - url: ftp://localhost\n    secret: secret"))
+		assert.EqualError(t, err, "invalid webhooks file: webhook 0: invalid URL: ftp://localhost")
+	})
+	t.Run("error - missing secret", func(t *testing.T) {
+		_, err := LoadConfig(write(t, "webhooks:\n  - url: http://localhost"))
+		assert.EqualError(t, err, "invalid webhooks file: webhook 0: secret is required")
+	})
+	t.Run("error - invalid vendor ID", func(t *testing.T) {
+		_, err := LoadConfig(write(t, "webhooks:\n  - url: http://localhost\n    secret: secret\n    vendors: [foo]"))
+		assert.EqualError(t, err, "invalid webhooks file: webhook 0: invalid vendor or organization ID: foo")
+	})
+	t.Run("error - negative max attempts", func(t *testing.T) {
+		_, err := LoadConfig(write(t, "maxAttempts: -1"))
+		assert.EqualError(t, err, "invalid webhooks file: maxAttempts, initialBackoff and timeout can't be negative")
+	})
+}
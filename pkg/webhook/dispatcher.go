@@ -0,0 +1,429 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	core "github.com/nuts-foundation/nuts-go-core"
+	"github.com/nuts-foundation/nuts-registry/logging"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+)
+
+// SignatureHeader is the HTTP header containing the signature of the notification: "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the request body, keyed with the webhook's secret.
+const SignatureHeader = "X-Registry-Signature"
+
+// DeliveryHeader is the HTTP header containing the ID of the delivery, which stays the same when delivery is retried.
+const DeliveryHeader = "X-Registry-Delivery"
+
+// EventTypeHeader is the HTTP header containing the type of the event the notification is about.
+const EventTypeHeader = "X-Registry-Event"
+
+// deliveryLogSize specifies how many deliveries are kept in the delivery log.
+const deliveryLogSize = 100
+
+// diagnosticsLogSize specifies how many of the most recent deliveries are reported in the diagnostics.
+const diagnosticsLogSize = 10
+
+// queueSize specifies how many notifications can be queued for a webhook before new notifications are dropped.
+const queueSize = 1000
+
+// Notification is the JSON document which is POSTed to the webhooks when an event is applied.
+type Notification struct {
+	// ID holds the ID of the delivery
+	ID           string           `json:"id"`
+	Ref          string           `json:"ref"`
+	Type         events.EventType `json:"type"`
+	IssuedAt     time.Time        `json:"issuedAt"`
+	Vendor       string           `json:"vendor,omitempty"`
+	Organization string           `json:"organization,omitempty"`
+	// Event holds the event as JSON
+	Event json.RawMessage `json:"event"`
+}
+
+// DeliveryStatus describes the status of a delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is the status of a delivery which hasn't succeeded yet, but will be (re)tried.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered is the status of a delivery which succeeded.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed is the status of a delivery which failed too often (or couldn't be queued) and won't be retried.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery describes the delivery of a notification to a webhook.
+type Delivery struct {
+	ID          string
+	URL         string
+	EventRef    events.Ref
+	EventType   events.EventType
+	Status      DeliveryStatus
+	Attempts    int
+	LastError   string
+	LastAttempt time.Time
+	body        []byte
+}
+
+func (d Delivery) String() string {
+	result := fmt.Sprintf("%s %s %s: %s (attempts=%d", d.URL, d.EventType, d.EventRef, d.Status, d.Attempts)
+	if !d.LastAttempt.IsZero() {
+		result += ", last attempt=" + d.LastAttempt.Format(time.RFC3339)
+	}
+	if d.LastError != "" {
+		result += ", error=" + d.LastError
+	}
+	return result + ")"
+}
+
+// Dispatcher notifies the configured webhooks of the events applied by the registry.
+type Dispatcher interface {
+	// Notify queues notifications of the event for the webhooks it matches. It's an events.EventListener which should be
+	// subscribed to the event system (see events.EventSystem.Subscribe), so it's only called for events that have been applied.
+	Notify(event events.Event)
+	// Start starts delivering notifications. Events handled before the dispatcher is started (e.g. when replaying the
+	// stored events at startup) don't result in notifications.
+	Start()
+	// Stop stops delivering notifications, undelivered notifications are abandoned.
+	Stop()
+	// Deliveries returns the most recent deliveries, oldest first.
+	Deliveries() []Delivery
+	// Diagnostics returns the number of deliveries per webhook and the most recent deliveries.
+	Diagnostics() []core.DiagnosticResult
+}
+
+type target struct {
+	webhook   Webhook
+	queue     chan *Delivery
+	delivered int
+	failed    int
+	pending   int
+}
+
+type dispatcher struct {
+	config   Config
+	database db.Db
+	client   *http.Client
+	targets  []*target
+	// mutex guards started, the delivery log and the deliveries and counters of the targets
+	mutex   sync.Mutex
+	started bool
+	log     []*Delivery
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a Dispatcher for the given configuration. The database is used to look up the vendor of
+// organizations, for filtering organization and endpoint events by vendor.
+func NewDispatcher(config Config, database db.Db) Dispatcher {
+	result := &dispatcher{
+		config:   config,
+		database: database,
+		client:   &http.Client{Timeout: config.Timeout},
+		stop:     make(chan struct{}),
+	}
+	for _, webhook := range config.Webhooks {
+		result.targets = append(result.targets, &target{webhook: webhook, queue: make(chan *Delivery, queueSize)})
+	}
+	return result
+}
+
+func (d *dispatcher) Start() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.started {
+		return
+	}
+	d.started = true
+	for _, t := range d.targets {
+		d.wg.Add(1)
+		go d.deliverAll(t)
+	}
+}
+
+func (d *dispatcher) Stop() {
+	d.mutex.Lock()
+	if !d.started {
+		d.mutex.Unlock()
+		return
+	}
+	d.started = false
+	d.mutex.Unlock()
+	close(d.stop)
+	d.wg.Wait()
+}
+
+func (d *dispatcher) Deliveries() []Delivery {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	result := make([]Delivery, len(d.log))
+	for i, delivery := range d.log {
+		result[i] = *delivery
+	}
+	return result
+}
+
+func (d *dispatcher) Diagnostics() []core.DiagnosticResult {
+	var results []core.DiagnosticResult
+	d.mutex.Lock()
+	for _, t := range d.targets {
+		results = append(results, &core.GenericDiagnosticResult{
+			Title:   fmt.Sprintf("Webhook deliveries (%s)", t.webhook.URL),
+			Outcome: fmt.Sprintf("delivered=%d, pending=%d, failed=%d", t.delivered, t.pending, t.failed),
+		})
+	}
+	d.mutex.Unlock()
+	deliveries := d.Deliveries()
+	if len(deliveries) > diagnosticsLogSize {
+		deliveries = deliveries[len(deliveries)-diagnosticsLogSize:]
+	}
+	var log []string
+	for _, delivery := range deliveries {
+		log = append(log, delivery.String())
+	}
+	return append(results, &core.GenericDiagnosticResult{
+		Title:   "Recent webhook deliveries",
+		Outcome: strings.Join(log, "; "),
+	})
+}
+
+func (d *dispatcher) Notify(event events.Event) {
+	d.mutex.Lock()
+	started := d.started
+	d.mutex.Unlock()
+	if !started {
+		return
+	}
+	vendorID, organizationID := d.subjects(event)
+	for _, t := range d.targets {
+		if !t.webhook.matches(event.Type(), vendorID, organizationID) {
+			continue
+		}
+		delivery := &Delivery{
+			ID:        uuid.New().String(),
+			URL:       t.webhook.URL,
+			EventRef:  event.Ref(),
+			EventType: event.Type(),
+			Status:    DeliveryPending,
+		}
+		notification := Notification{
+			ID:       delivery.ID,
+			Ref:      event.Ref().String(),
+			Type:     event.Type(),
+			IssuedAt: event.IssuedAt(),
+			Event:    event.Marshal(),
+		}
+		if !vendorID.IsZero() {
+			notification.Vendor = vendorID.String()
+		}
+		if !organizationID.IsZero() {
+			notification.Organization = organizationID.String()
+		}
+		body, err := json.Marshal(notification)
+		if err != nil {
+			// Should never happen
+			logging.Log().Errorf("Unable to create webhook notification (url=%s,event=%s): %v", t.webhook.URL, event.Ref(), err)
+			continue
+		}
+		delivery.body = body
+		d.mutex.Lock()
+		d.appendToLog(delivery)
+		select {
+		case t.queue <- delivery:
+			t.pending++
+		default:
+			logging.Log().Warnf("Webhook queue is full, dropping notification (url=%s,event=%s)", t.webhook.URL, event.Ref())
+			delivery.Status = DeliveryFailed
+			delivery.LastError = "queue is full"
+			t.failed++
+		}
+		d.mutex.Unlock()
+	}
+}
+
+// appendToLog adds the delivery to the delivery log, removing the oldest delivery when it's full. The mutex must be held.
+func (d *dispatcher) appendToLog(delivery *Delivery) {
+	if len(d.log) == deliveryLogSize {
+		d.log = d.log[1:]
+	}
+	d.log = append(d.log, delivery)
+}
+
+// deliverAll delivers the notifications queued for the target (in order) until the dispatcher is stopped.
+func (d *dispatcher) deliverAll(t *target) {
+	defer d.wg.Done()
+	for {
+		select {
+		case delivery := <-t.queue:
+			if !d.deliver(t, delivery) {
+				return
+			}
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// deliver tries to deliver the notification to the target, retrying with exponential backoff when it fails. It returns
+// false when the dispatcher was stopped while waiting for a retry.
+func (d *dispatcher) deliver(t *target, delivery *Delivery) bool {
+	backoff := d.config.InitialBackoff
+	for {
+		err := d.post(t.webhook, delivery)
+		d.mutex.Lock()
+		delivery.Attempts++
+		delivery.LastAttempt = time.Now()
+		if err == nil {
+			delivery.Status = DeliveryDelivered
+			delivery.LastError = ""
+			t.pending--
+			t.delivered++
+		} else {
+			delivery.LastError = err.Error()
+			if delivery.Attempts >= d.config.MaxAttempts {
+				delivery.Status = DeliveryFailed
+				t.pending--
+				t.failed++
+			}
+		}
+		status := delivery.Status
+		d.mutex.Unlock()
+		if status != DeliveryPending {
+			if status == DeliveryFailed {
+				logging.Log().Warnf("Unable to deliver webhook notification, giving up (url=%s,event=%s): %v", t.webhook.URL, delivery.EventRef, err)
+			}
+			return true
+		}
+		logging.Log().Infof("Unable to deliver webhook notification, retrying in %s (url=%s,event=%s): %v", backoff, t.webhook.URL, delivery.EventRef, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+			backoff *= 2
+		case <-d.stop:
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+func (d *dispatcher) post(webhook Webhook, delivery *Delivery) error {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.body))
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(EventTypeHeader, string(delivery.EventType))
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+	return nil
+}
+
+// subjects returns the vendor and organization the event concerns (zero when not applicable). For organization and
+// endpoint events the vendor is looked up in the database.
+func (d *dispatcher) subjects(event events.Event) (vendorID core.PartyID, organizationID core.PartyID) {
+	switch event.Type() {
+	case domain.RegisterVendor:
+		payload := domain.RegisterVendorEvent{}
+		_ = event.Unmarshal(&payload)
+		vendorID = payload.Identifier
+	case domain.RetireVendor:
+		payload := domain.RetireVendorEvent{}
+		_ = event.Unmarshal(&payload)
+		vendorID = payload.Identifier
+	case domain.VendorClaim:
+		payload := domain.VendorClaimEvent{}
+		_ = event.Unmarshal(&payload)
+		vendorID, organizationID = payload.VendorID, payload.OrganizationID
+	case domain.EndVendorClaim:
+		payload := domain.EndVendorClaimEvent{}
+		_ = event.Unmarshal(&payload)
+		vendorID, organizationID = payload.VendorID, payload.OrganizationID
+	case domain.RegisterEndpoint:
+		payload := domain.RegisterEndpointEvent{}
+		_ = event.Unmarshal(&payload)
+		organizationID = payload.Organization
+	case domain.DeregisterEndpoint:
+		payload := domain.DeregisterEndpointEvent{}
+		_ = event.Unmarshal(&payload)
+		organizationID = payload.Organization
+	}
+	if vendorID.IsZero() && !organizationID.IsZero() && d.database != nil {
+		if organization, err := d.database.OrganizationById(organizationID); err == nil {
+			vendorID = organization.Vendor
+		}
+	}
+	return
+}
+
+// matches checks whether the webhook should be notified of an event with the given type, vendor and organization.
+func (w Webhook) matches(eventType events.EventType, vendorID core.PartyID, organizationID core.PartyID) bool {
+	if len(w.EventTypes) > 0 && !contains(w.EventTypes, string(eventType)) {
+		return false
+	}
+	if len(w.Vendors) > 0 && (vendorID.IsZero() || !contains(w.Vendors, vendorID.String())) {
+		return false
+	}
+	if len(w.Organizations) > 0 && (organizationID.IsZero() || !contains(w.Organizations, organizationID.String())) {
+		return false
+	}
+	return true
+}
+
+// Sign calculates the value of the signature header for the given body: "sha256=" followed by the hex-encoded HMAC-SHA256
+// of the body, keyed with the secret. Receivers can use it to verify notifications.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
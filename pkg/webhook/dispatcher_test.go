@@ -0,0 +1,361 @@
+/*
+ * Nuts registry
+ * Copyright (C) 2020. Nuts community
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nuts-foundation/nuts-go-test/io"
+	"github.com/nuts-foundation/nuts-registry/mock"
+	"github.com/nuts-foundation/nuts-registry/pkg/db"
+	"github.com/nuts-foundation/nuts-registry/pkg/events"
+	"github.com/nuts-foundation/nuts-registry/pkg/events/domain"
+	"github.com/nuts-foundation/nuts-registry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a local HTTP server playing the webhook receiver, responding with the given status codes (in order, the
+// last one is repeated).
+type receiver struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	requests chan receivedRequest
+}
+
+func newReceiver(statuses ...int) *receiver {
+	r := &receiver{statuses: statuses, requests: make(chan receivedRequest, 100)}
+	r.server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		r.mutex.Lock()
+		status := r.statuses[0]
+		if len(r.statuses) > 1 {
+			r.statuses = r.statuses[1:]
+		}
+		r.mutex.Unlock()
+		writer.WriteHeader(status)
+		r.requests <- receivedRequest{header: request.Header, body: body}
+	}))
+	return r
+}
+
+func (r *receiver) next(t *testing.T) *receivedRequest {
+	select {
+	case request := <-r.requests:
+		return &request
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook wasn't notified")
+		return nil
+	}
+}
+
+func (r *receiver) assertNoRequest(t *testing.T) {
+	select {
+	case <-r.requests:
+		t.Error("webhook shouldn't have been notified")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func testConfig(webhooks ...Webhook) Config {
+	return Config{MaxAttempts: 3, InitialBackoff: time.Millisecond, Timeout: time.Second, Webhooks: webhooks}
+}
+
+// deliver notifies the dispatcher of the events, like the event system does after applying them.
+func deliver(dispatcher Dispatcher, evts ...events.Event) {
+	for _, event := range evts {
+		dispatcher.Notify(event)
+	}
+}
+
+// subscribedEventSystem creates an event system with a no-op handler for every event type, to which the dispatcher is subscribed.
+func subscribedEventSystem(t *testing.T, dispatcher Dispatcher) events.EventSystem {
+	eventSystem := events.NewEventSystem(domain.GetEventTypes()...)
+	if !assert.NoError(t, eventSystem.Configure(io.TestDirectory(t))) {
+		t.FailNow()
+	}
+	for _, eventType := range domain.GetEventTypes() {
+		eventSystem.RegisterEventHandler(eventType, func(events.Event, events.EventLookup) error {
+			return nil
+		})
+	}
+	eventSystem.Subscribe(dispatcher.Notify)
+	return eventSystem
+}
+
+var vendorID = test.VendorID("vendor")
+var orgID = test.OrganizationID("org")
+
+func registerVendorEvent() events.Event {
+	return events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: vendorID, Name: "Vendor"}, nil)
+}
+
+func registerEndpointEvent() events.Event {
+	return events.CreateEvent(domain.RegisterEndpoint, domain.RegisterEndpointEvent{Organization: orgID, Identifier: "endpoint", EndpointType: "type", URL: "url", Status: "active"}, nil)
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	t.Run("ok - signed notification", func(t *testing.T) {
+		r := newReceiver(http.StatusNoContent)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+		dispatcher.Start()
+		defer dispatcher.Stop()
+		event := registerVendorEvent()
+
+		deliver(dispatcher, event)
+
+		request := r.next(t)
+		assert.Equal(t, Sign("secret", request.body), request.header.Get(SignatureHeader))
+		assert.Equal(t, "application/json", request.header.Get("Content-Type"))
+		assert.Equal(t, string(domain.RegisterVendor), request.header.Get(EventTypeHeader))
+		notification := Notification{}
+		if !assert.NoError(t, json.Unmarshal(request.body, &notification)) {
+			return
+		}
+		assert.Equal(t, request.header.Get(DeliveryHeader), notification.ID)
+		assert.Equal(t, event.Ref().String(), notification.Ref)
+		assert.Equal(t, domain.RegisterVendor, notification.Type)
+		assert.Equal(t, vendorID.String(), notification.Vendor)
+		assert.Empty(t, notification.Organization)
+		eventFromNotification, err := events.EventFromJSON(notification.Event)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, event.Ref(), eventFromNotification.Ref())
+	})
+	t.Run("ok - not notified before started", func(t *testing.T) {
+		r := newReceiver(http.StatusOK)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+
+		deliver(dispatcher, registerVendorEvent())
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		r.assertNoRequest(t)
+		assert.Empty(t, dispatcher.Deliveries())
+	})
+	t.Run("ok - retried after failure", func(t *testing.T) {
+		r := newReceiver(http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+		dispatcher.Start()
+
+		deliver(dispatcher, registerVendorEvent())
+
+		first := r.next(t)
+		r.next(t)
+		third := r.next(t)
+		assert.Equal(t, first.header.Get(DeliveryHeader), third.header.Get(DeliveryHeader))
+		dispatcher.Stop()
+		deliveries := dispatcher.Deliveries()
+		if !assert.Len(t, deliveries, 1) {
+			return
+		}
+		assert.Equal(t, DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Empty(t, deliveries[0].LastError)
+	})
+	t.Run("error - gives up after max attempts", func(t *testing.T) {
+		r := newReceiver(http.StatusInternalServerError)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+		dispatcher.Start()
+
+		deliver(dispatcher, registerVendorEvent())
+
+		r.next(t)
+		r.next(t)
+		r.next(t)
+		r.assertNoRequest(t)
+		dispatcher.Stop()
+		deliveries := dispatcher.Deliveries()
+		if !assert.Len(t, deliveries, 1) {
+			return
+		}
+		assert.Equal(t, DeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Equal(t, "unexpected status code: 500", deliveries[0].LastError)
+	})
+	t.Run("ok - stop while waiting for retry", func(t *testing.T) {
+		r := newReceiver(http.StatusInternalServerError)
+		defer r.server.Close()
+		config := testConfig(Webhook{URL: r.server.URL, Secret: "secret"})
+		config.InitialBackoff = time.Hour
+		dispatcher := NewDispatcher(config, nil)
+		dispatcher.Start()
+
+		deliver(dispatcher, registerVendorEvent())
+
+		r.next(t)
+		stopped := make(chan struct{})
+		go func() {
+			dispatcher.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Error("dispatcher didn't stop")
+		}
+		assert.Equal(t, DeliveryPending, dispatcher.Deliveries()[0].Status)
+	})
+	t.Run("ok - re-applied event isn't notified again", func(t *testing.T) {
+		r := newReceiver(http.StatusOK)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+		dispatcher.Start()
+		defer dispatcher.Stop()
+		eventSystem := subscribedEventSystem(t, dispatcher)
+		root := registerVendorEvent()
+		time.Sleep(time.Millisecond)
+		canonical := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: vendorID, Name: "Canonical"}, root.Ref())
+		time.Sleep(time.Millisecond)
+		other := events.CreateEvent(domain.RegisterVendor, domain.RegisterVendorEvent{Identifier: vendorID, Name: "Other"}, root.Ref())
+
+		// Applying the other branch of the fork re-applies the canonical event
+		for _, event := range []events.Event{root, canonical, other} {
+			if !assert.NoError(t, eventSystem.ProcessEvent(event)) {
+				return
+			}
+		}
+
+		assert.Len(t, dispatcher.Deliveries(), 3)
+	})
+	t.Run("ok - event which isn't applied isn't notified", func(t *testing.T) {
+		r := newReceiver(http.StatusOK)
+		defer r.server.Close()
+		dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+		dispatcher.Start()
+		defer dispatcher.Stop()
+		eventSystem := subscribedEventSystem(t, dispatcher)
+		eventSystem.RegisterEventHandler(domain.RegisterEndpoint, func(events.Event, events.EventLookup) error {
+			return errors.New("failed")
+		})
+
+		assert.Error(t, eventSystem.ProcessEvent(registerEndpointEvent()))
+		assert.NoError(t, eventSystem.ProcessEvent(registerVendorEvent()))
+
+		deliveries := dispatcher.Deliveries()
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, domain.RegisterVendor, deliveries[0].EventType)
+		}
+	})
+}
+
+func TestDispatcher_Filters(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	database := mock.NewMockDb(mockCtrl)
+	database.EXPECT().OrganizationById(orgID).Return(&db.Organization{Identifier: orgID, Vendor: vendorID}, nil).AnyTimes()
+
+	run := func(t *testing.T, webhook Webhook, event events.Event, expected bool) {
+		r := newReceiver(http.StatusOK)
+		defer r.server.Close()
+		webhook.URL = r.server.URL
+		webhook.Secret = "secret"
+		dispatcher := NewDispatcher(testConfig(webhook), database)
+		dispatcher.Start()
+		defer dispatcher.Stop()
+		deliver(dispatcher, event)
+		if expected {
+			r.next(t)
+		} else {
+			r.assertNoRequest(t)
+		}
+	}
+	t.Run("event type", func(t *testing.T) {
+		run(t, Webhook{EventTypes: []string{string(domain.RegisterEndpoint)}}, registerEndpointEvent(), true)
+		run(t, Webhook{EventTypes: []string{string(domain.RegisterEndpoint)}}, registerVendorEvent(), false)
+	})
+	t.Run("vendor", func(t *testing.T) {
+		run(t, Webhook{Vendors: []string{vendorID.String()}}, registerVendorEvent(), true)
+		run(t, Webhook{Vendors: []string{test.VendorID("other").String()}}, registerVendorEvent(), false)
+	})
+	t.Run("vendor of organization", func(t *testing.T) {
+		run(t, Webhook{Vendors: []string{vendorID.String()}}, registerEndpointEvent(), true)
+		run(t, Webhook{Vendors: []string{test.VendorID("other").String()}}, registerEndpointEvent(), false)
+	})
+	t.Run("organization", func(t *testing.T) {
+		run(t, Webhook{Organizations: []string{orgID.String()}}, registerEndpointEvent(), true)
+		run(t, Webhook{Organizations: []string{test.OrganizationID("other").String()}}, registerEndpointEvent(), false)
+		run(t, Webhook{Organizations: []string{orgID.String()}}, registerVendorEvent(), false)
+	})
+	t.Run("all filters must match", func(t *testing.T) {
+		run(t, Webhook{EventTypes: []string{string(domain.RegisterEndpoint)}, Organizations: []string{test.OrganizationID("other").String()}}, registerEndpointEvent(), false)
+	})
+	t.Run("organization not found", func(t *testing.T) {
+		otherDatabase := mock.NewMockDb(mockCtrl)
+		otherDatabase.EXPECT().OrganizationById(orgID).Return(nil, errors.New("not found"))
+		dispatcher := NewDispatcher(testConfig(), otherDatabase).(*dispatcher)
+		vendor, organization := dispatcher.subjects(registerEndpointEvent())
+		assert.True(t, vendor.IsZero())
+		assert.Equal(t, orgID, organization)
+	})
+}
+
+func TestDispatcher_Diagnostics(t *testing.T) {
+	r := newReceiver(http.StatusOK)
+	defer r.server.Close()
+	dispatcher := NewDispatcher(testConfig(Webhook{URL: r.server.URL, Secret: "secret"}), nil)
+	dispatcher.Start()
+	deliver(dispatcher, registerVendorEvent())
+	r.next(t)
+	dispatcher.Stop()
+
+	diagnostics := dispatcher.Diagnostics()
+
+	if !assert.Len(t, diagnostics, 2) {
+		return
+	}
+	assert.Equal(t, "Webhook deliveries ("+r.server.URL+")", diagnostics[0].Name())
+	assert.Equal(t, "delivered=1, pending=0, failed=0", diagnostics[0].String())
+	assert.Equal(t, "Recent webhook deliveries", diagnostics[1].Name())
+	assert.Contains(t, diagnostics[1].String(), r.server.URL+" RegisterVendorEvent")
+	assert.Contains(t, diagnostics[1].String(), ": delivered (attempts=1, last attempt=")
+}
+
+func TestDispatcher_DeliveryLog(t *testing.T) {
+	dispatcher := NewDispatcher(testConfig(Webhook{URL: "http://localhost", Secret: "secret"}), nil).(*dispatcher)
+	for i := 0; i < deliveryLogSize+10; i++ {
+		dispatcher.appendToLog(&Delivery{Attempts: i})
+	}
+	deliveries := dispatcher.Deliveries()
+	assert.Len(t, deliveries, deliveryLogSize)
+	assert.Equal(t, 10, deliveries[0].Attempts)
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", Sign("key", []byte("The quick brown fox jumps over the lazy dog")))
+}
@@ -22,7 +22,7 @@ func GenerateCertificateEx(notBefore time.Time, validityInDays int, privKey *rsa
 		Subject: pkix.Name{
 			CommonName: "Unit Test",
 		},
-		PublicKey:             privKey.PublicKey,
+		PublicKey:             &privKey.PublicKey,
 		NotBefore:             notBefore,
 		NotAfter:              notBefore.AddDate(0, 0, validityInDays),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
@@ -42,7 +42,7 @@ func GenerateCertificateCA(name string, signer *x509.Certificate, privKey *rsa.P
 		Subject: pkix.Name{
 			CommonName: name,
 		},
-		PublicKey:             privKey.PublicKey,
+		PublicKey:             &privKey.PublicKey,
 		NotBefore:             time.Now().AddDate(0, 0, -1),
 		NotAfter:              time.Now().AddDate(0, 0, 1),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,